@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdherenceHandler 计划完成度API处理器
+type AdherenceHandler struct {
+	adherenceService *services.AdherenceService
+}
+
+// NewAdherenceHandler 创建计划完成度API处理器
+func NewAdherenceHandler(adherenceService *services.AdherenceService) *AdherenceHandler {
+	return &AdherenceHandler{
+		adherenceService: adherenceService,
+	}
+}
+
+// GetAdherence 获取最近若干周每次训练和每周的计划完成度
+func (h *AdherenceHandler) GetAdherence(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	weeks, _ := strconv.Atoi(c.DefaultQuery("weeks", "4"))
+
+	report, err := h.adherenceService.Report(userID, weeks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取计划完成度成功",
+		"data":    report,
+	})
+}
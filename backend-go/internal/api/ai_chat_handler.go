@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AIChatHandler AI 教练对话API处理器
+type AIChatHandler struct {
+	chatService *services.AIChatService
+}
+
+// NewAIChatHandler 创建 AI 教练对话API处理器
+func NewAIChatHandler(chatService *services.AIChatService) *AIChatHandler {
+	return &AIChatHandler{
+		chatService: chatService,
+	}
+}
+
+// Chat 发送消息，以 SSE 流式返回回复
+// 事件依次为：conversation（对话 id 和标题）、若干 delta（内容片段）和 tool（工具调用记录）、done（保存后的回复）；
+// 失败时为 error，此时消息没有保存，客户端可以在同一对话中重发
+// tool 事件的 status 为 pending 时，客户端应请用户确认后调用 ConfirmToolCall
+func (h *AIChatHandler) Chat(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.AIChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.chatService.OpenConversation(userID, req)
+	if err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	h.event(c, "conversation", gin.H{"id": conversation.ID, "title": conversation.Title})
+	message, err := h.chatService.Reply(ctx, conversation, req.Message, req.Context, func(delta string) error {
+		h.event(c, "delta", gin.H{"content": delta})
+		return ctx.Err()
+	}, func(invocation *models.AIToolInvocation) error {
+		h.event(c, "tool", invocation)
+		return ctx.Err()
+	})
+	if err != nil {
+		h.event(c, "error", gin.H{"error": err.Error()})
+		return
+	}
+	h.event(c, "done", message)
+}
+
+// event 发送一个 SSE 事件并立即刷新
+func (h *AIChatHandler) event(c *gin.Context, name string, data interface{}) {
+	c.SSEvent(name, data)
+	c.Writer.Flush()
+}
+
+// GetConversations 获取对话列表
+func (h *AIChatHandler) GetConversations(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	conversations, err := h.chatService.ListConversations(userID, skip, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    conversations,
+	})
+}
+
+// GetConversation 获取对话及全部消息
+func (h *AIChatHandler) GetConversation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	conversation, err := h.chatService.GetConversation(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    conversation,
+	})
+}
+
+// UpdateConversation 修改对话标题
+func (h *AIChatHandler) UpdateConversation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.UpdateAIConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.chatService.RenameConversation(userID, c.Param("id"), req.Title)
+	if err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "修改成功",
+		"data":    conversation,
+	})
+}
+
+// DeleteConversation 删除对话
+func (h *AIChatHandler) DeleteConversation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	if err := h.chatService.DeleteConversation(userID, c.Param("id")); err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+// ConfirmToolCall 确认或拒绝 AI 教练请求的修改操作（如调整训练计划）
+func (h *AIChatHandler) ConfirmToolCall(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.ConfirmAIToolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.chatService.ConfirmToolCall(c.Request.Context(), userID, c.Param("id"), req.Approve)
+	if err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "处理成功",
+		"data":    result,
+	})
+}
+
+// GetToolCalls 获取 AI 教练的工具调用记录，可按对话筛选
+func (h *AIChatHandler) GetToolCalls(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	invocations, err := h.chatService.ListToolInvocations(userID, c.Query("conversation_id"), skip, limit)
+	if err != nil {
+		c.JSON(aiChatErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    invocations,
+	})
+}
+
+// aiChatErrorStatus 对话错误对应的 HTTP 状态码
+func aiChatErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrConversationNotFound), errors.Is(err, services.ErrToolInvocationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrToolInvocationNotPending):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrAIChatUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AIHandler AI服务API处理器
+type AIHandler struct {
+	aiService *services.AIService
+}
+
+// NewAIHandler 创建AI服务API处理器
+func NewAIHandler(aiService *services.AIService) *AIHandler {
+	return &AIHandler{
+		aiService: aiService,
+	}
+}
+
+// GetProviders 获取AI服务提供商的健康状态
+func (h *AIHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    h.aiService.ProviderHealth(),
+	})
+}
+
+// GetPlanCacheStats 获取AI训练计划缓存的命中统计
+func (h *AIHandler) GetPlanCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    h.aiService.PlanCacheStats(),
+	})
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExerciseHandler 标准动作库API处理器
+type ExerciseHandler struct {
+	catalogService *services.ExerciseCatalogService
+}
+
+// NewExerciseHandler 创建标准动作库API处理器
+func NewExerciseHandler(catalogService *services.ExerciseCatalogService) *ExerciseHandler {
+	return &ExerciseHandler{
+		catalogService: catalogService,
+	}
+}
+
+// GetExercises 获取标准动作列表
+func (h *ExerciseHandler) GetExercises(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	exercises, err := h.catalogService.List(c.Query("category"), c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取动作库成功",
+		"data":    exercises,
+	})
+}
+
+// GetExercise 获取单个标准动作
+func (h *ExerciseHandler) GetExercise(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的动作ID"})
+		return
+	}
+
+	exercise, err := h.catalogService.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取动作成功",
+		"data":    exercise,
+	})
+}
+
+// CreateExercise 创建标准动作（仅管理员）
+func (h *ExerciseHandler) CreateExercise(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.CreateCatalogExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exercise, err := h.catalogService.Create(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "创建动作成功",
+		"data":    exercise,
+	})
+}
+
+// MatchExercise 按名称模糊匹配标准动作
+func (h *ExerciseHandler) MatchExercise(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少动作名称"})
+		return
+	}
+
+	match, err := h.catalogService.Match(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if match == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到匹配的标准动作"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "匹配动作成功",
+		"data":    match,
+	})
+}
+
+// BackfillExercises 为历史训练动作回填标准动作关联（仅管理员）
+func (h *ExerciseHandler) BackfillExercises(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.BackfillExercisesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.catalogService.Backfill(req.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "回填动作关联完成",
+		"data":    report,
+	})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+	"gymates/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportContentTypes 各导出格式的 Content-Type
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV: "text/csv; charset=utf-8",
+	models.ExportFormatTCX: "application/vnd.garmin.tcx+xml",
+	models.ExportFormatGPX: "application/gpx+xml",
+}
+
+// flushWriter 每次写入后立即发送给客户端，导出内容以 chunked 方式逐批返回
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.w.Flush()
+	return n, err
+}
+
+// ExportHandler 训练数据导出API处理器
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler 创建训练数据导出API处理器
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportWorkouts 导出训练数据
+// 查询参数: format（csv/tcx/gpx，默认 csv）, from/to（YYYY-MM-DD，可选，to 当天包含在内）
+func (h *ExportHandler) ExportWorkouts(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	format := c.DefaultQuery("format", models.ExportFormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式"})
+		return
+	}
+
+	var from, to time.Time
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from 日期格式错误"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to 日期格式错误"})
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	// 按批次直接写入响应，不缓存整个文件；响应头发出后无法再返回错误状态，
+	// 中途出错时由 ExportService 在文件末尾写入错误标记
+	filename := fmt.Sprintf("gymates-workouts-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.Export(userID, format, from, to, flushWriter{w: c.Writer}); err != nil {
+		logger.Error.Printf("导出训练数据失败: user_id=%v, format=%v, error=%v", userID, format, err.Error())
+	}
+}
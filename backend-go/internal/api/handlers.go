@@ -15,6 +15,22 @@ type Handlers struct {
 	messageHandler   *MessageHandler
 	communityHandler *CommunityHandler
 	buddyHandler     *BuddyHandler
+	programHandler   *ProgramHandler
+	exerciseHandler  *ExerciseHandler
+	templateHandler  *TemplateHandler
+	importHandler    *ImportHandler
+	exportHandler    *ExportHandler
+	restHandler      *RestTimerHandler
+	riskHandler      *RiskHandler
+	adherenceHandler *AdherenceHandler
+	syncHandler      *SyncHandler
+	unitHandler      *UnitHandler
+	heartRateHandler *HeartRateHandler
+	routeHandler     *RouteHandler
+	aiHandler        *AIHandler
+	aiChatHandler    *AIChatHandler
+	healthHandler    *HealthHandler
+	webSocketService *services.WebSocketService
 }
 
 // NewHandlers 创建主API处理器
@@ -27,13 +43,45 @@ func NewHandlers(
 	buddyService *services.BuddyService,
 	communityService *services.CommunityService,
 	userProfileService *services.UserProfileService,
+	programService *services.ProgramService,
+	catalogService *services.ExerciseCatalogService,
+	templateService *services.TemplateService,
+	importService *services.ImportService,
+	exportService *services.ExportService,
+	restTimerService *services.RestTimerService,
+	riskService *services.RiskService,
+	adherenceService *services.AdherenceService,
+	syncService *services.SyncService,
+	unitService *services.UnitService,
+	heartRateService *services.HeartRateService,
+	routeService *services.RouteService,
+	aiChatService *services.AIChatService,
+	healthService *services.HealthService,
+	nutritionService *services.NutritionService,
+	webSocketService *services.WebSocketService,
 ) *Handlers {
 	return &Handlers{
-		userHandler:      NewUserHandler(userService, authService, userProfileService),
-		trainingHandler:  NewTrainingHandler(trainingService, aiService),
+		userHandler:      NewUserHandler(userService, authService, userProfileService, unitService),
+		trainingHandler:  NewTrainingHandler(trainingService, aiService, unitService),
 		messageHandler:   NewMessageHandler(messageService),
 		communityHandler: NewCommunityHandler(communityService),
 		buddyHandler:     NewBuddyHandler(buddyService),
+		programHandler:   NewProgramHandler(programService, unitService),
+		exerciseHandler:  NewExerciseHandler(catalogService),
+		templateHandler:  NewTemplateHandler(templateService, unitService),
+		importHandler:    NewImportHandler(importService),
+		exportHandler:    NewExportHandler(exportService),
+		restHandler:      NewRestTimerHandler(restTimerService),
+		riskHandler:      NewRiskHandler(riskService),
+		adherenceHandler: NewAdherenceHandler(adherenceService),
+		syncHandler:      NewSyncHandler(syncService),
+		unitHandler:      NewUnitHandler(unitService),
+		heartRateHandler: NewHeartRateHandler(heartRateService),
+		routeHandler:     NewRouteHandler(routeService, unitService),
+		aiHandler:        NewAIHandler(aiService),
+		aiChatHandler:    NewAIChatHandler(aiChatService),
+		healthHandler:    NewHealthHandler(healthService, nutritionService, unitService),
+		webSocketService: webSocketService,
 	}
 }
 
@@ -41,10 +89,15 @@ func NewHandlers(
 func (h *Handlers) Register(r *gin.Engine) {
 	api := r.Group("/api/v1")
 
+	// 实时推送（组间休息计时器等），浏览器无法设置请求头时通过 token 参数认证
+	api.GET("/ws", h.authMiddleware(), h.webSocketService.HandleWebSocket)
+
 	// 用户相关路由
 	user := api.Group("/user")
 	user.Use(h.authMiddleware())
 	{
+		user.GET("/profile", h.userHandler.GetProfile)
+		user.PUT("/profile", h.userHandler.UpdateProfile)
 		user.GET("/settings", h.userHandler.GetSettings)
 		user.PUT("/settings", h.userHandler.UpdateSettings)
 		user.POST("/change-password", h.userHandler.ChangePassword)
@@ -66,9 +119,92 @@ func (h *Handlers) Register(r *gin.Engine) {
 		training.POST("/ai-generate", h.trainingHandler.GenerateAIPlan)
 		training.POST("/start", h.trainingHandler.StartWorkout)
 		training.POST("/end", h.trainingHandler.EndWorkout)
+		training.POST("/pause", h.trainingHandler.PauseWorkout)
+		training.POST("/resume", h.trainingHandler.ResumeWorkout)
+		training.GET("/active", h.trainingHandler.GetActiveWorkout)
 		training.POST("/complete-exercise", h.trainingHandler.CompleteExercise)
 		training.POST("/feedback", h.trainingHandler.SubmitFeedback)
 		training.GET("/stats", h.trainingHandler.GetTrainingStats)
+		training.GET("/analytics/volume", h.trainingHandler.GetVolumeAnalytics)
+		training.GET("/streak", h.trainingHandler.GetStreak)
+		training.GET("/progression", h.trainingHandler.GetProgression)
+		training.PUT("/progression/rules", h.trainingHandler.UpdateProgressionRule)
+		training.GET("/records", h.trainingHandler.GetPersonalRecords)
+		training.GET("/records/timeline", h.trainingHandler.GetPersonalRecordTimeline)
+		training.POST("/programs", h.programHandler.CreateProgram)
+		training.GET("/programs", h.programHandler.GetPrograms)
+		training.GET("/programs/:id/progress", h.programHandler.GetProgramProgress)
+		training.POST("/plans/:id/skip", h.programHandler.SkipDay)
+		training.POST("/templates", h.templateHandler.SaveTemplate)
+		training.GET("/templates", h.templateHandler.GetTemplates)
+		training.GET("/templates/gallery", h.templateHandler.GetGallery)
+		training.POST("/templates/clone", h.templateHandler.CloneTemplate)
+		training.POST("/templates/fork", h.templateHandler.ForkTemplate)
+		training.POST("/templates/share", h.templateHandler.ShareTemplate)
+		training.POST("/templates/publish", h.templateHandler.PublishTemplate)
+		training.POST("/templates/rate", h.templateHandler.RateTemplate)
+		training.POST("/import", h.importHandler.ImportWorkouts)
+		training.GET("/export", h.exportHandler.ExportWorkouts)
+		training.POST("/rest", h.restHandler.StartRest)
+		training.GET("/rest", h.restHandler.GetRest)
+		training.POST("/rest/extend", h.restHandler.ExtendRest)
+		training.POST("/rest/skip", h.restHandler.SkipRest)
+		training.POST("/rest/end", h.restHandler.EndRest)
+		training.GET("/risk-report", h.riskHandler.GetRiskReport)
+		training.GET("/adherence", h.adherenceHandler.GetAdherence)
+		training.POST("/sync", h.syncHandler.Sync)
+		training.GET("/plates", h.unitHandler.CalculatePlates)
+		training.POST("/heart-rate", h.heartRateHandler.UploadHeartRate)
+		training.GET("/heart-rate", h.heartRateHandler.GetHeartRate)
+		training.POST("/routes/points", h.routeHandler.AppendRoutePoints)
+		training.POST("/routes/gpx", h.routeHandler.ImportRouteGPX)
+		training.GET("/routes", h.routeHandler.GetRoutes)
+	}
+
+	// AI服务路由
+	ai := api.Group("/ai")
+	ai.Use(h.authMiddleware())
+	{
+		ai.GET("/providers", h.adminMiddleware(), h.aiHandler.GetProviders)
+		ai.GET("/plan-cache", h.adminMiddleware(), h.aiHandler.GetPlanCacheStats)
+		ai.POST("/chat", h.aiChatHandler.Chat)
+		ai.GET("/conversations", h.aiChatHandler.GetConversations)
+		ai.GET("/conversations/:id", h.aiChatHandler.GetConversation)
+		ai.PUT("/conversations/:id", h.aiChatHandler.UpdateConversation)
+		ai.DELETE("/conversations/:id", h.aiChatHandler.DeleteConversation)
+		ai.GET("/tool-calls", h.aiChatHandler.GetToolCalls)
+		ai.POST("/tool-calls/:id/confirm", h.aiChatHandler.ConfirmToolCall)
+	}
+
+	// BMI 路由
+	bmi := api.Group("/bmi")
+	bmi.Use(h.authMiddleware())
+	{
+		bmi.POST("/calculate", h.healthHandler.CalculateBMI)
+		bmi.POST("/records", h.healthHandler.CreateBMIRecord)
+		bmi.GET("/records", h.healthHandler.GetBMIRecords)
+	}
+
+	// 饮食记录路由
+	nutrition := api.Group("/nutrition")
+	nutrition.Use(h.authMiddleware())
+	{
+		nutrition.POST("/calculate", h.healthHandler.CalculateNutrition)
+		nutrition.GET("/foods", h.healthHandler.SearchFoods)
+		nutrition.GET("/daily", h.healthHandler.GetDailyIntake)
+		nutrition.POST("/records", h.healthHandler.CreateNutritionRecord)
+		nutrition.GET("/records", h.healthHandler.GetNutritionRecords)
+	}
+
+	// 标准动作库路由
+	exercises := api.Group("/exercises")
+	exercises.Use(h.authMiddleware())
+	{
+		exercises.GET("", h.exerciseHandler.GetExercises)
+		exercises.GET("/match", h.exerciseHandler.MatchExercise)
+		exercises.GET("/:id", h.exerciseHandler.GetExercise)
+		exercises.POST("", h.adminMiddleware(), h.exerciseHandler.CreateExercise)
+		exercises.POST("/backfill", h.adminMiddleware(), h.exerciseHandler.BackfillExercises)
 	}
 
 	// 消息相关路由
@@ -122,6 +258,9 @@ func (h *Handlers) Register(r *gin.Engine) {
 func (h *Handlers) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
+		if token == "" && c.IsWebsocket() {
+			token = c.Query("token")
+		}
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证token"})
 			c.Abort()
@@ -147,3 +286,15 @@ func (h *Handlers) authMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// adminMiddleware 只允许管理员访问，需要放在 authMiddleware 之后
+func (h *Handlers) adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.userHandler.authService.IsAdmin(c.GetString("user_id")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -34,8 +34,9 @@ type Handlers struct {
 func New(db *gorm.DB, redis *redis.Client, cacheService *cache.CacheService, cfg *config.Config) *Handlers {
 	// 初始化服务层
 	userService := services.NewUserService(db, redis)
-	userProfileService := services.NewUserProfileService(db)
-	workoutService := services.NewWorkoutService(db, redis)
+	streakService := services.NewStreakService(db)
+	userProfileService := services.NewUserProfileService(db, streakService)
+	workoutService := services.NewWorkoutService(db, redis, streakService, services.NewCalorieService(db))
 	communityService := services.NewCommunityService(db)
 	messageService := services.NewMessageService(db)
 	webSocketService := services.NewWebSocketService()
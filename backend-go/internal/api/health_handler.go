@@ -0,0 +1,249 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler BMI 和饮食记录API处理器
+// 请求和响应按用户的单位制，服务中统一使用公制（cm、kg、g）
+type HealthHandler struct {
+	healthService    *services.HealthService
+	nutritionService *services.NutritionService
+	unitService      *services.UnitService
+}
+
+// NewHealthHandler 创建BMI和饮食记录API处理器
+func NewHealthHandler(healthService *services.HealthService, nutritionService *services.NutritionService, unitService *services.UnitService) *HealthHandler {
+	return &HealthHandler{
+		healthService:    healthService,
+		nutritionService: nutritionService,
+		unitService:      unitService,
+	}
+}
+
+// CalculateBMI 计算BMI，身高体重按请求指定或用户设置的单位制
+func (h *HealthHandler) CalculateBMI(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.BMIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	units := h.unitService.ConverterFor(userID, req.UnitSystem)
+	units.BMIRequest(&req)
+
+	result, err := h.healthService.CalculateBMI(req)
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	units.BMI(result)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "计算成功",
+		"data":    result,
+	})
+}
+
+// CreateBMIRecord 计算BMI并保存记录
+func (h *HealthHandler) CreateBMIRecord(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.BMIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.ConverterFor(userID, req.UnitSystem).BMIRequest(&req)
+
+	record, err := h.healthService.CreateBMIRecord(userID, req)
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "BMI记录创建成功",
+		"data":    record,
+	})
+}
+
+// GetBMIRecords 获取BMI记录
+func (h *HealthHandler) GetBMIRecords(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	records, err := h.healthService.BMIRecords(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    records,
+	})
+}
+
+// CalculateNutrition 计算食物的营养成分，数量为空单位时按用户的单位制（g 或 oz）
+func (h *HealthHandler) CalculateNutrition(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.NutritionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	grams := h.unitService.Converter(userID).NutritionRequest(&req)
+
+	facts, err := h.nutritionService.Calculate(req.FoodName, grams)
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "计算成功",
+		"data": models.NutritionResponse{
+			FoodName:  req.FoodName,
+			Quantity:  req.Quantity,
+			Unit:      req.Unit,
+			Nutrition: *facts,
+		},
+	})
+}
+
+// SearchFoods 搜索食物
+func (h *HealthHandler) SearchFoods(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "搜索关键词不能为空"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    h.nutritionService.SearchFoods(query),
+	})
+}
+
+// GetDailyIntake 获取一天的摄入汇总，默认为今天
+func (h *HealthHandler) GetDailyIntake(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	intake, err := h.nutritionService.DailyIntake(userID, c.DefaultQuery("date", time.Now().Format("2006-01-02")))
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    intake,
+	})
+}
+
+// CreateNutritionRecord 创建饮食记录，数量统一保存为克
+func (h *HealthHandler) CreateNutritionRecord(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.NutritionRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	units := h.unitService.Converter(userID)
+	units.NutritionRecordRequest(&req)
+
+	record, err := h.nutritionService.CreateRecord(userID, req)
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	records := []models.NutritionRecord{*record}
+	units.NutritionRecords(records)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "饮食记录创建成功",
+		"data":    records[0],
+	})
+}
+
+// GetNutritionRecords 获取饮食记录，可按日期过滤
+func (h *HealthHandler) GetNutritionRecords(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	records, total, err := h.nutritionService.Records(userID, c.Query("date"), skip, limit)
+	if err != nil {
+		c.JSON(healthErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).NutritionRecords(records)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"records": records,
+			"total":   total,
+		},
+	})
+}
+
+// healthErrorStatus 食物不存在返回 404，参数超出范围或日期格式错误返回 400
+func healthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrFoodNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBodyMetricsOutOfRange), errors.Is(err, services.ErrInvalidNutritionDate):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
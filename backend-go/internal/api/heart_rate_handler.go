@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeartRateHandler 训练心率API处理器
+type HeartRateHandler struct {
+	heartRateService *services.HeartRateService
+}
+
+// NewHeartRateHandler 创建训练心率API处理器
+func NewHeartRateHandler(heartRateService *services.HeartRateService) *HeartRateHandler {
+	return &HeartRateHandler{
+		heartRateService: heartRateService,
+	}
+}
+
+// UploadHeartRate 上传训练的心率采样点
+func (h *HeartRateHandler) UploadHeartRate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.UploadHeartRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.heartRateService.Upload(userID, req)
+	if err != nil {
+		c.JSON(heartRateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "上传成功",
+		"data":    resp,
+	})
+}
+
+// GetHeartRate 获取训练的心率分析和心率曲线
+func (h *HeartRateHandler) GetHeartRate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	recordID := c.Query("record_id")
+	if recordID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 record_id"})
+		return
+	}
+
+	resp, err := h.heartRateService.Get(userID, recordID)
+	if err != nil {
+		c.JSON(heartRateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    resp,
+	})
+}
+
+// heartRateErrorStatus 训练记录不存在返回 404，没有有效采样点返回 400
+func heartRateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWorkoutRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNoHeartRateSamples):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
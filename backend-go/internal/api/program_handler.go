@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProgramHandler 训练周期API处理器
+type ProgramHandler struct {
+	programService *services.ProgramService
+	unitService    *services.UnitService
+}
+
+// NewProgramHandler 创建训练周期API处理器
+func NewProgramHandler(programService *services.ProgramService, unitService *services.UnitService) *ProgramHandler {
+	return &ProgramHandler{
+		programService: programService,
+		unitService:    unitService,
+	}
+}
+
+// CreateProgram 创建训练周期
+func (h *ProgramHandler) CreateProgram(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.CreateProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	units := h.unitService.Converter(userID)
+	units.ProgramRequest(&req)
+
+	program, err := h.programService.CreateProgram(userID, req)
+	if err != nil {
+		c.JSON(planErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	units.Program(program)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "创建训练周期成功",
+		"data":    program,
+	})
+}
+
+// GetPrograms 获取训练周期列表
+func (h *ProgramHandler) GetPrograms(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	programs, err := h.programService.GetPrograms(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Programs(programs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取训练周期成功",
+		"data":    programs,
+	})
+}
+
+// GetProgramProgress 获取训练周期进度
+func (h *ProgramHandler) GetProgramProgress(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	progress, err := h.programService.GetProgress(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取训练周期进度成功",
+		"data":    progress,
+	})
+}
+
+// SkipDay 跳过训练日并顺延后续训练
+func (h *ProgramHandler) SkipDay(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	plans, err := h.programService.SkipDay(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "已跳过训练并重新排期",
+		"data":    plans,
+	})
+}
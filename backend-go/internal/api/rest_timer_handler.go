@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestTimerHandler 组间休息计时器API处理器
+// 计时器状态同时通过 WebSocket 推送到用户的所有设备，这里的接口供不方便保持长连接的设备使用
+type RestTimerHandler struct {
+	restTimerService *services.RestTimerService
+}
+
+// NewRestTimerHandler 创建组间休息计时器API处理器
+func NewRestTimerHandler(restTimerService *services.RestTimerService) *RestTimerHandler {
+	return &RestTimerHandler{
+		restTimerService: restTimerService,
+	}
+}
+
+// StartRest 手动开始休息
+func (h *RestTimerHandler) StartRest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.StartRestTimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.restTimerService.Start(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "开始休息",
+		"data":    state,
+	})
+}
+
+// GetRest 获取进行中的休息，没有时 data 为空
+func (h *RestTimerHandler) GetRest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取休息状态成功",
+		"data":    h.restTimerService.Current(userID),
+	})
+}
+
+// ExtendRest 延长休息，seconds 为负数时缩短
+func (h *RestTimerHandler) ExtendRest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.ExtendRestTimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.restTimerService.Extend(userID, req.Seconds)
+	if err != nil {
+		c.JSON(restErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "延长休息成功",
+		"data":    state,
+	})
+}
+
+// SkipRest 跳过休息
+func (h *RestTimerHandler) SkipRest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	state, err := h.restTimerService.Skip(userID)
+	if err != nil {
+		c.JSON(restErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "跳过休息成功",
+		"data":    state,
+	})
+}
+
+// EndRest 结束休息
+func (h *RestTimerHandler) EndRest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	state, err := h.restTimerService.End(userID)
+	if err != nil {
+		c.JSON(restErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "结束休息成功",
+		"data":    state,
+	})
+}
+
+// restErrorStatus 没有进行中的休息返回 404
+func restErrorStatus(err error) int {
+	if errors.Is(err, services.ErrNoRestTimer) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
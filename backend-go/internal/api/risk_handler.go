@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RiskHandler 疲劳与受伤风险API处理器
+type RiskHandler struct {
+	riskService *services.RiskService
+}
+
+// NewRiskHandler 创建疲劳与受伤风险API处理器
+func NewRiskHandler(riskService *services.RiskService) *RiskHandler {
+	return &RiskHandler{
+		riskService: riskService,
+	}
+}
+
+// GetRiskReport 获取当前的风险报告
+func (h *RiskHandler) GetRiskReport(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	report, err := h.riskService.Report(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    report,
+	})
+}
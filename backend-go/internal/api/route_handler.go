@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteHandler GPS 轨迹API处理器
+type RouteHandler struct {
+	routeService *services.RouteService
+	unitService  *services.UnitService
+}
+
+// NewRouteHandler 创建 GPS 轨迹API处理器
+func NewRouteHandler(routeService *services.RouteService, unitService *services.UnitService) *RouteHandler {
+	return &RouteHandler{
+		routeService: routeService,
+		unitService:  unitService,
+	}
+}
+
+// AppendRoutePoints 训练中分批上传 GPS 轨迹点
+func (h *RouteHandler) AppendRoutePoints(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.AppendRoutePointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route, err := h.routeService.AppendPoints(userID, req)
+	if err != nil {
+		c.JSON(routeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, userID, route, "上传成功")
+}
+
+// ImportRouteGPX 导入 GPX 轨迹
+// 表单字段: file（GPX 文件）, record_id, set_id（可选）
+func (h *RouteHandler) ImportRouteGPX(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	recordID := c.PostForm("record_id")
+	if recordID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 record_id"})
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传 GPX 文件"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件不能超过 10MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	route, err := h.routeService.ImportGPX(userID, recordID, c.PostForm("set_id"), file)
+	if err != nil {
+		c.JSON(routeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, userID, route, "导入成功")
+}
+
+// GetRoutes 获取训练的 GPS 轨迹
+func (h *RouteHandler) GetRoutes(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	recordID := c.Query("record_id")
+	if recordID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 record_id"})
+		return
+	}
+
+	routes, err := h.routeService.Routes(userID, recordID)
+	if err != nil {
+		c.JSON(routeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Routes(routes)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    routes,
+	})
+}
+
+// respond 按用户单位制返回上传后的轨迹
+func (h *RouteHandler) respond(c *gin.Context, userID string, route *models.RouteResponse, message string) {
+	routes := []models.RouteResponse{*route}
+	h.unitService.Converter(userID).Routes(routes)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": message,
+		"data":    routes[0],
+	})
+}
+
+// routeErrorStatus 训练记录或组不存在返回 404，轨迹点无效返回 400
+func routeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWorkoutRecordNotFound), errors.Is(err, services.ErrRouteSetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNoRoutePoints), errors.Is(err, services.ErrRouteTooLarge), errors.Is(err, services.ErrInvalidGPX):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TemplateHandler 训练计划模板API处理器
+type TemplateHandler struct {
+	templateService *services.TemplateService
+	unitService     *services.UnitService
+}
+
+// NewTemplateHandler 创建训练计划模板API处理器
+func NewTemplateHandler(templateService *services.TemplateService, unitService *services.UnitService) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+		unitService:     unitService,
+	}
+}
+
+// SaveTemplate 将训练计划保存为模板
+func (h *TemplateHandler) SaveTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.SaveTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.SaveAsTemplate(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).TrainingPlan(template)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "保存模板成功",
+		"data":    template,
+	})
+}
+
+// GetTemplates 获取我的模板或搭子分享给我的模板（scope=mine|shared）
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	templates, err := h.templateService.GetTemplates(userID, c.DefaultQuery("scope", "mine"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).TrainingPlans(templates)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取模板成功",
+		"data":    templates,
+	})
+}
+
+// GetGallery 模板广场
+func (h *TemplateHandler) GetGallery(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	gallery, err := h.templateService.Gallery(c.Query("keyword"), c.DefaultQuery("sort", models.GallerySortPopular), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(c.GetString("user_id")).TrainingPlans(gallery.Plans)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取模板广场成功",
+		"data":    gallery,
+	})
+}
+
+// CloneTemplate 将模板克隆到指定日期
+func (h *TemplateHandler) CloneTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.CreatePlanFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.templateService.Clone(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).TrainingPlan(plan)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "克隆模板成功",
+		"data":    plan,
+	})
+}
+
+// ForkTemplate 派生他人的模板到自己的模板库
+func (h *TemplateHandler) ForkTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.ForkTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.Fork(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).TrainingPlan(template)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "派生模板成功",
+		"data":    template,
+	})
+}
+
+// ShareTemplate 分享模板给搭子
+func (h *TemplateHandler) ShareTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.ShareTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := h.templateService.Share(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "分享模板成功",
+		"data":    share,
+	})
+}
+
+// PublishTemplate 发布模板到模板广场或撤回
+func (h *TemplateHandler) PublishTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.PublishTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.Publish(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).TrainingPlan(template)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新模板发布状态成功",
+		"data":    template,
+	})
+}
+
+// RateTemplate 评价模板
+func (h *TemplateHandler) RateTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.RateTrainingPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.templateService.Rate(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "评价模板成功",
+		"data":    result,
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,16 +15,19 @@ import (
 type TrainingHandler struct {
 	trainingService *services.TrainingService
 	aiService       *services.AIService
+	unitService     *services.UnitService
 }
 
 // NewTrainingHandler 创建训练API处理器
 func NewTrainingHandler(
 	trainingService *services.TrainingService,
 	aiService *services.AIService,
+	unitService *services.UnitService,
 ) *TrainingHandler {
 	return &TrainingHandler{
 		trainingService: trainingService,
 		aiService:       aiService,
+		unitService:     unitService,
 	}
 }
 
@@ -46,6 +50,7 @@ func (h *TrainingHandler) GetTodayPlan(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.unitService.Converter(userID).Plan(plan)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -70,6 +75,7 @@ func (h *TrainingHandler) GetHistoryPlans(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.unitService.Converter(userID).Plans(plans)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -91,12 +97,15 @@ func (h *TrainingHandler) CreatePlan(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	units := h.unitService.Converter(userID)
+	units.PlanRequest(&req)
 
 	plan, err := h.trainingService.CreatePlan(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(planErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	units.Plan(plan)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"code":    201,
@@ -124,18 +133,15 @@ func (h *TrainingHandler) UpdatePlan(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	units := h.unitService.Converter(userID)
+	units.ExercisesRequest(req.Exercises)
 
-	planIDUint, err := strconv.ParseUint(planID, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
-		return
-	}
-
-	plan, err := h.trainingService.UpdatePlan(userID, uint(planIDUint), req)
+	plan, err := h.trainingService.UpdatePlan(userID, planID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	units.Plan(plan)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -158,13 +164,7 @@ func (h *TrainingHandler) DeletePlan(c *gin.Context) {
 		return
 	}
 
-	planIDUint, err := strconv.ParseUint(planID, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的计划ID"})
-		return
-	}
-
-	err = h.trainingService.DeletePlan(userID, uint(planIDUint))
+	err := h.trainingService.DeletePlan(userID, planID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -196,7 +196,7 @@ func (h *TrainingHandler) GenerateAIPlan(c *gin.Context) {
 		return
 	}
 
-	plan, err := h.trainingService.GenerateAIPlan(strconv.FormatUint(userIDUint, 10), models.GenerateAIPlanRequest{
+	plan, err := h.trainingService.GenerateAIPlan(c.Request.Context(), strconv.FormatUint(userIDUint, 10), models.GenerateAIPlanRequest{
 		Goal:       req.Goal,
 		Duration:   req.Duration,
 		Difficulty: req.Difficulty,
@@ -204,9 +204,14 @@ func (h *TrainingHandler) GenerateAIPlan(c *gin.Context) {
 		FocusAreas: req.FocusAreas,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNoLLMProvider) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
+	h.unitService.Converter(userID).Plan(plan)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -230,6 +235,14 @@ func (h *TrainingHandler) StartWorkout(c *gin.Context) {
 	}
 
 	record, err := h.trainingService.StartWorkout(userID, req)
+	h.unitService.Converter(userID).Record(record)
+	if errors.Is(err, services.ErrWorkoutInProgress) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"data":  record,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -258,9 +271,10 @@ func (h *TrainingHandler) EndWorkout(c *gin.Context) {
 
 	record, err := h.trainingService.EndWorkout(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(workoutErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	h.unitService.Converter(userID).Record(record)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -269,6 +283,124 @@ func (h *TrainingHandler) EndWorkout(c *gin.Context) {
 	})
 }
 
+// PauseWorkout 暂停训练
+func (h *TrainingHandler) PauseWorkout(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.WorkoutActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.trainingService.PauseWorkout(userID, req)
+	if err != nil {
+		c.JSON(workoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Record(record)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "暂停训练成功",
+		"data":    record,
+	})
+}
+
+// ResumeWorkout 恢复训练
+func (h *TrainingHandler) ResumeWorkout(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.WorkoutActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.trainingService.ResumeWorkout(userID, req)
+	if err != nil {
+		c.JSON(workoutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Record(record)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "恢复训练成功",
+		"data":    record,
+	})
+}
+
+// GetActiveWorkout 获取进行中的训练
+func (h *TrainingHandler) GetActiveWorkout(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	record, err := h.trainingService.GetActiveWorkout(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Record(record)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取进行中的训练成功",
+		"data":    record,
+	})
+}
+
+// GetVolumeAnalytics 获取按周、肌群和动作聚合的训练量
+func (h *TrainingHandler) GetVolumeAnalytics(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	weeks, _ := strconv.Atoi(c.DefaultQuery("weeks", "8"))
+
+	volume, err := h.trainingService.GetVolumeAnalytics(userID, weeks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Volume(volume)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取训练量分析成功",
+		"data":    volume,
+	})
+}
+
+// workoutErrorStatus 非法的状态转换返回 409，其余返回 500
+func workoutErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidWorkoutTransition) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// planErrorStatus 计划结构、组数据或日期无效返回 400
+func planErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidPlanStructure) || errors.Is(err, services.ErrInvalidSetData) || errors.Is(err, services.ErrInvalidPlanDate) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // CompleteExercise 完成动作
 func (h *TrainingHandler) CompleteExercise(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -282,16 +414,20 @@ func (h *TrainingHandler) CompleteExercise(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	units := h.unitService.Converter(userID)
+	units.CompleteRequest(&req)
 
-	err := h.trainingService.CompleteExercise(userID, req)
+	result, err := h.trainingService.CompleteExercise(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(planErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	units.PersonalRecords(result.PersonalRecords)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "完成动作成功",
+		"data":    result,
 	})
 }
 
@@ -342,3 +478,126 @@ func (h *TrainingHandler) GetTrainingStats(c *gin.Context) {
 		"data":    stats,
 	})
 }
+
+// GetStreak 获取连续训练天数
+func (h *TrainingHandler) GetStreak(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	streak, err := h.trainingService.GetStreaks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取连续训练天数成功",
+		"data":    streak,
+	})
+}
+
+// GetProgression 获取渐进超负荷建议
+func (h *TrainingHandler) GetProgression(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	suggestions, err := h.trainingService.GetProgressionSuggestions(userID, c.QueryArray("exercise"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).Progression(suggestions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取渐进超负荷建议成功",
+		"data":    suggestions,
+	})
+}
+
+// UpdateProgressionRule 更新渐进超负荷规则
+func (h *TrainingHandler) UpdateProgressionRule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.UpdateProgressionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	units := h.unitService.Converter(userID)
+	units.ProgressionRuleRequest(&req)
+
+	rule, err := h.trainingService.UpdateProgressionRule(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	units.ProgressionRule(rule)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新渐进规则成功",
+		"data":    rule,
+	})
+}
+
+// GetPersonalRecords 获取个人记录
+func (h *TrainingHandler) GetPersonalRecords(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	records, err := h.trainingService.GetPersonalRecords(userID, c.Query("formula"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).PersonalRecords(records)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取个人记录成功",
+		"data":    records,
+	})
+}
+
+// GetPersonalRecordTimeline 获取动作的个人记录时间线
+func (h *TrainingHandler) GetPersonalRecordTimeline(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	exerciseName := c.Query("exercise")
+	if exerciseName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少动作名称"})
+		return
+	}
+
+	records, err := h.trainingService.GetPersonalRecordTimeline(userID, exerciseName, c.Query("formula"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).PersonalRecords(records)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取个人记录时间线成功",
+		"data":    records,
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gymates/internal/models"
+	"gymates/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnitHandler 单位制相关API处理器
+type UnitHandler struct {
+	unitService *services.UnitService
+}
+
+// NewUnitHandler 创建单位制API处理器
+func NewUnitHandler(unitService *services.UnitService) *UnitHandler {
+	return &UnitHandler{
+		unitService: unitService,
+	}
+}
+
+// CalculatePlates 按用户的单位制计算杠铃每侧需要装载的杠铃片
+func (h *UnitHandler) CalculatePlates(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.PlateLoadingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.unitService.PlateLoading(userID, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPlateTargetBelowBar) || errors.Is(err, services.ErrPlateTargetTooHeavy) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "计算配片成功",
+		"data":    result,
+	})
+}
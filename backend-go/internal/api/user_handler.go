@@ -15,6 +15,7 @@ type UserHandler struct {
 	userService        *services.UserService
 	authService        *services.AuthService
 	userProfileService *services.UserProfileService
+	unitService        *services.UnitService
 }
 
 // NewUserHandler 创建用户API处理器
@@ -22,14 +23,68 @@ func NewUserHandler(
 	userService *services.UserService,
 	authService *services.AuthService,
 	userProfileService *services.UserProfileService,
+	unitService *services.UnitService,
 ) *UserHandler {
 	return &UserHandler{
 		userService:        userService,
 		authService:        authService,
 		userProfileService: userProfileService,
+		unitService:        unitService,
 	}
 }
 
+// GetProfile 获取用户资料，身高体重按用户的单位制返回
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	profile, err := h.userProfileService.GetProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.unitService.Converter(userID).User(profile)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取用户资料成功",
+		"data":    profile,
+	})
+}
+
+// UpdateProfile 更新用户资料，身高体重按用户的单位制填写
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权"})
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	units := h.unitService.Converter(userID)
+	units.ProfileRequest(&req)
+
+	profile, err := h.userProfileService.UpdateProfile(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	units.User(profile)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新用户资料成功",
+		"data":    profile,
+	})
+}
+
 // GetSettings 获取用户设置
 func (h *UserHandler) GetSettings(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -12,6 +13,7 @@ type Config struct {
 	JWT         JWTConfig
 	AI          AIConfig
 	Server      ServerConfig
+	Admin       AdminConfig
 }
 
 type DatabaseConfig struct {
@@ -38,8 +40,26 @@ type JWTConfig struct {
 type AIConfig struct {
 	TencentSecretID  string
 	TencentSecretKey string
+	HunyuanAPIKey    string // 混元 OpenAI 兼容接口的 API Key，与腾讯云 SecretId/SecretKey 不同
 	DeepSeekAPIKey   string
 	GroqAPIKey       string
+
+	// 兼容 OpenAI 接口的自定义服务（本地模拟服务、自建模型等），配置后优先使用
+	BaseURL       string
+	APIKey        string
+	Model         string
+	ContextWindow int // 自定义服务模型的上下文窗口（token），对话历史超出时裁剪最早的消息
+
+	Timeout    int  // 每个提供商单次调用的超时秒数
+	MaxRetries int  // 每个提供商失败后的重试次数
+	UseStub    bool // 只使用本地模拟提供商，不访问网络
+
+	PlanCacheTTL int // 相同请求生成的训练计划在 Redis 中缓存的秒数，0 表示不缓存
+}
+
+// AdminConfig 管理员配置，管理员可以维护标准动作库、查看 AI 服务运行状态
+type AdminConfig struct {
+	UserIDs []string
 }
 
 type ServerConfig struct {
@@ -71,13 +91,25 @@ func Load() *Config {
 		AI: AIConfig{
 			TencentSecretID:  getEnv("TENCENT_SECRET_ID", ""),
 			TencentSecretKey: getEnv("TENCENT_SECRET_KEY", ""),
+			HunyuanAPIKey:    getEnv("HUNYUAN_API_KEY", ""),
 			DeepSeekAPIKey:   getEnv("DEEPSEEK_API_KEY", ""),
 			GroqAPIKey:       getEnv("GROQ_API_KEY", ""),
+			BaseURL:          getEnv("AI_BASE_URL", ""),
+			APIKey:           getEnv("AI_API_KEY", ""),
+			Model:            getEnv("AI_MODEL", ""),
+			ContextWindow:    getEnvAsInt("AI_CONTEXT_WINDOW", 8192),
+			Timeout:          getEnvAsInt("AI_TIMEOUT", 20),
+			MaxRetries:       getEnvAsInt("AI_MAX_RETRIES", 1),
+			UseStub:          getEnv("AI_STUB", "") == "true",
+			PlanCacheTTL:     getEnvAsInt("AI_PLAN_CACHE_TTL", 21600),
 		},
 		Server: ServerConfig{
 			Port: getEnv("PORT", "8080"),
 			Host: getEnv("HOST", "0.0.0.0"),
 		},
+		Admin: AdminConfig{
+			UserIDs: getEnvAsList("ADMIN_USER_IDS"),
+		},
 	}
 }
 
@@ -96,3 +128,14 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsList 读取逗号分隔的列表，忽略空项
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -31,8 +31,10 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 		logLevel = logger.Info
 	}
 
+	// TranslateError 把唯一约束冲突等数据库错误转换为 gorm.ErrDuplicatedKey 等通用错误
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -196,6 +196,48 @@ func (r *exerciseRepository) GetByCategory(category string, limit, offset int) (
 	return exercises, err
 }
 
+func (r *exerciseRepository) GetByName(name string) (*models.Exercise, error) {
+	var exercise models.Exercise
+	err := r.db.Where("name = ?", name).First(&exercise).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+func (r *exerciseRepository) GetAll() ([]*models.Exercise, error) {
+	var exercises []*models.Exercise
+	err := r.db.Order("name ASC").Find(&exercises).Error
+	return exercises, err
+}
+
+// UnlinkedNames 尚未关联标准动作的训练动作名称（去重）
+func (r *exerciseRepository) UnlinkedNames() ([]string, error) {
+	var names []string
+	err := r.db.Model(&models.TrainingExercise{}).
+		Where("catalog_id IS NULL OR catalog_id = ?", 0).
+		Distinct("name").
+		Pluck("name", &names).Error
+	return names, err
+}
+
+// CountUnlinked 该名称尚未关联标准动作的训练动作数
+func (r *exerciseRepository) CountUnlinked(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TrainingExercise{}).
+		Where("name = ? AND (catalog_id IS NULL OR catalog_id = ?)", name, 0).
+		Count(&count).Error
+	return count, err
+}
+
+// SetCatalogID 将同名且尚未关联的训练动作关联到标准动作
+func (r *exerciseRepository) SetCatalogID(name string, catalogID uint) (int64, error) {
+	result := r.db.Model(&models.TrainingExercise{}).
+		Where("name = ? AND (catalog_id IS NULL OR catalog_id = ?)", name, 0).
+		Update("catalog_id", catalogID)
+	return result.RowsAffected, result.Error
+}
+
 func (r *exerciseRepository) Update(exercise *models.Exercise) error {
 	return r.db.Save(exercise).Error
 }
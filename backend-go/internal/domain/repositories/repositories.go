@@ -38,6 +38,11 @@ type ExerciseRepository interface {
 	Create(exercise *models.Exercise) error
 	GetByID(id uint) (*models.Exercise, error)
 	GetByCategory(category string, limit, offset int) ([]*models.Exercise, error)
+	GetByName(name string) (*models.Exercise, error)
+	GetAll() ([]*models.Exercise, error)
+	UnlinkedNames() ([]string, error)
+	CountUnlinked(name string) (int64, error)
+	SetCatalogID(name string, catalogID uint) (int64, error)
 	Update(exercise *models.Exercise) error
 	Delete(id uint) error
 }
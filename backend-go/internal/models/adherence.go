@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// SessionAdherence 一次训练对计划的完成度
+// 次数完成度对计时组按时长、对有氧按距离计算；重量完成度只统计有目标重量的组
+type SessionAdherence struct {
+	Score                float64  `json:"score"` // 0-100
+	SetsPlanned          int      `json:"sets_planned"`
+	SetsCompleted        int      `json:"sets_completed"`
+	ExtraSets            int      `json:"extra_sets"` // 计划外追加的组
+	RepsHit              float64  `json:"reps_hit"`   // 0-1，按计划组平均，未完成的组计 0
+	LoadHit              float64  `json:"load_hit"`   // 0-1，按有目标重量的计划组平均
+	ExercisesPlanned     int      `json:"exercises_planned"`
+	ExercisesCompleted   int      `json:"exercises_completed"`
+	ExercisesSkipped     []string `json:"exercises_skipped"`
+	ExercisesSubstituted []string `json:"exercises_substituted"`
+}
+
+// WeeklyAdherence 一周的计划完成度
+// 已过期但没有训练的计划按 0 分计入，未到日期的计划不计入
+type WeeklyAdherence struct {
+	Week              string  `json:"week"` // 周一日期（用户时区，YYYY-MM-DD）
+	Score             float64 `json:"score"`
+	SessionsPlanned   int     `json:"sessions_planned"`
+	SessionsCompleted int     `json:"sessions_completed"`
+	SessionsSkipped   int     `json:"sessions_skipped"`
+	SetsPlanned       int     `json:"sets_planned"`
+	SetsCompleted     int     `json:"sets_completed"`
+}
+
+// SessionAdherenceItem 一次训练的完成度明细
+type SessionAdherenceItem struct {
+	RecordID  string           `json:"record_id"`
+	PlanID    string           `json:"plan_id"`
+	PlanName  string           `json:"plan_name"`
+	StartTime time.Time        `json:"start_time"`
+	Adherence SessionAdherence `json:"adherence"`
+}
+
+// AdherenceReport 最近若干周的计划完成度
+type AdherenceReport struct {
+	Score            float64                `json:"score"` // 统计周期内的整体完成度
+	Weeks            []WeeklyAdherence      `json:"weeks"`
+	Sessions         []SessionAdherenceItem `json:"sessions"`
+	SkippedExercises []string               `json:"skipped_exercises"` // 统计周期内跳过两次及以上的动作
+}
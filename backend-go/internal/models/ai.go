@@ -0,0 +1,156 @@
+package models
+
+import "time"
+
+// AI 训练计划来源
+const (
+	AIPlanSourceModel    = "model"    // 模型输出直接通过校验
+	AIPlanSourceRepaired = "repaired" // 把校验错误反馈给模型后修复通过
+	AIPlanSourceTemplate = "template" // 模型输出始终未通过校验或模型不可用，使用模板计划
+)
+
+// AIProviderHealth AI 服务提供商的健康状态
+type AIProviderHealth struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"` // 连续失败达到阈值后暂停使用一段时间
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Calls               int64      `json:"calls"` // 调用次数（含重试）
+	Failures            int64      `json:"failures"`
+	AvgLatencyMs        int64      `json:"avg_latency_ms"` // 成功调用的平均耗时
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	CooldownUntil       *time.Time `json:"cooldown_until,omitempty"`
+}
+
+// AIPlanCacheStats AI 训练计划缓存的命中统计，服务启动后累计
+type AIPlanCacheStats struct {
+	Enabled    bool    `json:"enabled"` // 未配置 Redis 或缓存时间为 0 时不缓存，仍合并同时发起的相同请求
+	TTLSeconds int     `json:"ttl_seconds"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	Shared     int64   `json:"shared"`   // 与同时发起的相同请求共用一次生成的次数
+	Stored     int64   `json:"stored"`   // 写入缓存的计划数，模板计划不缓存
+	Errors     int64   `json:"errors"`   // 读写 Redis 失败的次数，失败时直接调用模型
+	HitRate    float64 `json:"hit_rate"` // 命中和共用占全部请求的比例
+}
+
+// AIPlanContext 生成训练计划时参考的用户资料和训练历史，由服务端填充
+type AIPlanContext struct {
+	Gender           string
+	Age              int     // 未填写生日时为 0
+	Height           float64 // cm
+	Weight           float64 // kg
+	BMI              float64
+	TotalWorkouts    int // 累计完成的训练次数，用于判断训练经验
+	AvgMinutes       int // 最近 4 周平均每次训练时长
+	LastWeekWorkouts int // 最近 7 天完成的训练次数
+	LastWeek         []AIExerciseSummary
+	Records          []AIRecordSummary
+	PainfulExercises []AIPainSummary // 最近出现疼痛的动作，生成的计划中不应包含
+	Equipment        []string        // 最近训练用过的器械，请求没有指定器械时参考
+}
+
+// AIExerciseSummary 上周某个动作的训练情况
+type AIExerciseSummary struct {
+	Name   string
+	Sets   int
+	Weight float64 // 最大重量（kg）
+	Reps   int     // 最大重量下的次数
+}
+
+// AIRecordSummary 某个动作的个人记录
+type AIRecordSummary struct {
+	Name  string
+	Type  string // max_weight, estimated_1rm 等
+	Value float64
+}
+
+// AIPainSummary 最近出现疼痛的动作
+type AIPainSummary struct {
+	Name  string
+	Level int // 最近一次疼痛等级 1-10
+}
+
+// AIConversation AI 教练对话
+type AIConversation struct {
+	ID            string          `json:"id" gorm:"primaryKey"`
+	UserID        string          `json:"user_id" gorm:"not null;index"`
+	Title         string          `json:"title"` // 默认取第一条消息的开头
+	MessageCount  int             `json:"message_count"`
+	LastMessageAt time.Time       `json:"last_message_at"`
+	Messages      []AIChatMessage `json:"messages,omitempty" gorm:"foreignKey:ConversationID"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AIConversation) TableName() string {
+	return "ai_conversations"
+}
+
+// AIChatMessage 对话中的一条消息
+type AIChatMessage struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ConversationID string    `json:"conversation_id" gorm:"not null;index"`
+	UserID         string    `json:"user_id" gorm:"not null"`
+	Role           string    `json:"role"` // user, assistant
+	Content        string    `json:"content"`
+	Provider       string    `json:"provider,omitempty"` // 回复的提供商和模型，用户消息为空
+	Model          string    `json:"model,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (AIChatMessage) TableName() string {
+	return "ai_chat_messages"
+}
+
+// UpdateAIConversationRequest 修改对话标题
+type UpdateAIConversationRequest struct {
+	Title string `json:"title" binding:"required,max=100"`
+}
+
+// AI 工具调用状态
+const (
+	AIToolStatusSucceeded = "succeeded"
+	AIToolStatusFailed    = "failed"
+	AIToolStatusPending   = "pending"  // 修改数据的工具等待用户确认
+	AIToolStatusRunning   = "running"  // 用户已确认，正在执行
+	AIToolStatusRejected  = "rejected" // 用户拒绝执行
+	AIToolStatusExpired   = "expired"  // 超时未确认
+)
+
+// AIToolInvocation AI 教练的一次工具调用，所有调用都会记录用于审计
+type AIToolInvocation struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	UserID         string     `json:"user_id" gorm:"not null;index"`
+	ConversationID string     `json:"conversation_id" gorm:"index"`
+	ToolCallID     string     `json:"tool_call_id"` // 模型生成的调用 id
+	Tool           string     `json:"tool"`
+	Arguments      string     `json:"arguments"` // 模型传入的 JSON 参数
+	Mutating       bool       `json:"mutating"`  // 是否修改用户数据
+	Status         string     `json:"status"`
+	Summary        string     `json:"summary,omitempty"` // 需要确认的操作说明
+	Result         string     `json:"result,omitempty"`  // 返回给模型的结果（JSON），过长时截断
+	Error          string     `json:"error,omitempty"`
+	ConfirmedAt    *time.Time `json:"confirmed_at,omitempty"` // 用户确认或拒绝的时间
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AIToolInvocation) TableName() string {
+	return "ai_tool_invocations"
+}
+
+// ConfirmAIToolRequest 确认或拒绝修改数据的工具调用
+type ConfirmAIToolRequest struct {
+	Approve bool `json:"approve"`
+}
+
+// ConfirmAIToolResponse 确认结果，以及追加到对话中的回复
+type ConfirmAIToolResponse struct {
+	Invocation *AIToolInvocation `json:"invocation"`
+	Message    *AIChatMessage    `json:"message,omitempty"`
+}
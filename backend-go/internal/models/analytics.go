@@ -0,0 +1,30 @@
+package models
+
+// VolumeBucket 某个肌群或动作在一段时间内的训练量
+// 训练量（Volume）= 重量(kg) × 次数，只统计已完成的组；
+// 肌群组数中次要肌群按 0.5 组计入，因此 Sets 可能是小数
+type VolumeBucket struct {
+	Name      string  `json:"name"`
+	CatalogID uint    `json:"catalog_id,omitempty"` // 仅动作维度，关联的标准动作
+	Sets      float64 `json:"sets"`
+	Reps      int     `json:"reps"`
+	Volume    float64 `json:"volume"` // kg
+}
+
+// VolumeSeries 按周排列的训练量时间序列，与 VolumeAnalyticsResponse.Weeks 一一对应
+type VolumeSeries struct {
+	Name   string    `json:"name"`
+	Sets   []float64 `json:"sets"`
+	Volume []float64 `json:"volume"`
+}
+
+// VolumeAnalyticsResponse 训练量分析
+type VolumeAnalyticsResponse struct {
+	Weeks             []string       `json:"weeks"` // 每周周一日期（用户时区，YYYY-MM-DD），按时间升序
+	TotalSets         []float64      `json:"total_sets"`
+	TotalVolume       []float64      `json:"total_volume"`
+	MuscleGroups      []VolumeSeries `json:"muscle_groups"`       // 按区间内总组数降序
+	Exercises         []VolumeSeries `json:"exercises"`           // 按区间内总训练量降序
+	ThisWeek          []VolumeBucket `json:"this_week"`           // 本周各肌群训练量，按组数降序
+	ThisWeekExercises []VolumeBucket `json:"this_week_exercises"` // 本周各动作训练量，按训练量降序
+}
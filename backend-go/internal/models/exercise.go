@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Exercise 标准动作库条目
+// 训练计划中的 TrainingExercise 通过 CatalogID 关联到这里，统计时以标准动作为准，
+// 避免"卧推"、"Bench Press"、"杠铃卧推"被当作三个不同的动作
+type Exercise struct {
+	ID                    uint              `json:"id" gorm:"primaryKey"`
+	Name                  string            `json:"name" gorm:"not null;index"` // 标准中文名称
+	NameEn                string            `json:"name_en"`
+	Names                 map[string]string `json:"names" gorm:"serializer:json"` // 多语言名称，如 {"zh": "卧推", "en": "Bench Press"}
+	Aliases               []string          `json:"aliases" gorm:"serializer:json"`
+	Description           string            `json:"description"`
+	Category              string            `json:"category"`   // 胸、背、腿、肩、臂、有氧等
+	Difficulty            string            `json:"difficulty"` // 初级、中级、高级
+	PrimaryMuscleGroups   []string          `json:"primary_muscle_groups" gorm:"column:muscle_groups;serializer:json"`
+	SecondaryMuscleGroups []string          `json:"secondary_muscle_groups" gorm:"serializer:json"`
+	Equipment             []string          `json:"equipment" gorm:"serializer:json"`
+	Instructions          string            `json:"instructions"`
+	VideoURL              string            `json:"video_url"`
+	ImageURL              string            `json:"image_url"`
+	CreatedAt             time.Time         `json:"created_at"`
+	UpdatedAt             time.Time         `json:"updated_at"`
+}
+
+// CreateCatalogExerciseRequest 创建标准动作请求
+type CreateCatalogExerciseRequest struct {
+	Name                  string            `json:"name" binding:"required"`
+	NameEn                string            `json:"name_en"`
+	Names                 map[string]string `json:"names"`
+	Aliases               []string          `json:"aliases"`
+	Description           string            `json:"description"`
+	Category              string            `json:"category"`
+	Difficulty            string            `json:"difficulty"`
+	PrimaryMuscleGroups   []string          `json:"primary_muscle_groups" binding:"required,min=1"`
+	SecondaryMuscleGroups []string          `json:"secondary_muscle_groups"`
+	Equipment             []string          `json:"equipment"`
+	Instructions          string            `json:"instructions"`
+	VideoURL              string            `json:"video_url"`
+	ImageURL              string            `json:"image_url"`
+}
+
+// ExerciseMatch 自由文本动作名称与标准动作的匹配结果
+type ExerciseMatch struct {
+	Input       string    `json:"input"`
+	Exercise    *Exercise `json:"exercise"`
+	MatchedName string    `json:"matched_name"` // 命中的名称或别名
+	Score       float64   `json:"score"`        // 0-1，1 表示规范化后完全一致
+}
+
+// BackfillExercisesRequest 回填动作关联请求
+type BackfillExercisesRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// ExerciseBackfillReport 回填动作关联报告
+type ExerciseBackfillReport struct {
+	DryRun         bool            `json:"dry_run"`
+	DistinctNames  int             `json:"distinct_names"`
+	LinkedRows     int64           `json:"linked_rows"`
+	Matches        []ExerciseMatch `json:"matches"`
+	UnmatchedNames []string        `json:"unmatched_names"`
+}
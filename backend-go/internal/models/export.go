@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// 导出格式
+const (
+	ExportFormatCSV = "csv"
+	ExportFormatTCX = "tcx"
+	ExportFormatGPX = "gpx"
+)
+
+// TrackPoint GPS 轨迹点
+type TrackPoint struct {
+	Latitude  float64   `json:"lat"`
+	Longitude float64   `json:"lon"`
+	Elevation float64   `json:"ele"` // 米
+	Time      time.Time `json:"time"`
+}
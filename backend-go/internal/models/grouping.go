@@ -0,0 +1,43 @@
+package models
+
+// 组类型
+const (
+	SetTypeWarmup  = "warmup"  // 热身组，不计入训练量和个人记录
+	SetTypeWorking = "working" // 正式组
+	SetTypeDrop    = "drop"    // 递减组，紧接在上一组之后降低重量继续
+	SetTypeAMRAP   = "amrap"   // 力竭组，次数为目标下限，记录实际完成次数
+	SetTypeTimed   = "timed"   // 计时组，以时长为目标
+)
+
+// 动作分组类型：同一分组内的动作依次交替完成
+const (
+	GroupTypeSuperset = "superset"  // 超级组，两个动作
+	GroupTypeGiantSet = "giant_set" // 巨型组，三个及以上动作
+	GroupTypeCircuit  = "circuit"   // 循环训练，按轮次完成
+)
+
+// ExerciseGroupResponse 训练计划中的动作分组
+type ExerciseGroupResponse struct {
+	Key         string   `json:"key"`
+	Type        string   `json:"type"`
+	Rounds      int      `json:"rounds"`
+	ExerciseIDs []string `json:"exercise_ids"` // 按完成顺序排列
+}
+
+// GroupProgress 完成动作后分组内的进度，Done 为 true 时分组全部轮次已完成
+type GroupProgress struct {
+	Key              string `json:"key"`
+	Type             string `json:"type"`
+	Round            int    `json:"round"` // 当前进行到第几轮（从 1 开始）
+	Rounds           int    `json:"rounds"`
+	NextExerciseID   string `json:"next_exercise_id,omitempty"`
+	NextExerciseName string `json:"next_exercise_name,omitempty"`
+	RestTime         int    `json:"rest_time"` // 秒，同一轮内的动作之间不休息
+	Done             bool   `json:"done"`
+}
+
+// CompleteExerciseResult 完成动作的结果
+type CompleteExerciseResult struct {
+	PersonalRecords []PersonalRecord `json:"personal_records"`
+	Group           *GroupProgress   `json:"group,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BMI 分类
+const (
+	BMICategoryUnderweight = "偏瘦"
+	BMICategoryNormal      = "正常"
+	BMICategoryOverweight  = "偏胖"
+	BMICategoryObese       = "肥胖"
+)
+
+// HealthRecordTypeBMI BMI 健康记录
+const HealthRecordTypeBMI = "bmi"
+
+// BMIRequest BMI计算请求，身高体重按单位制填写（公制 cm/kg，英制 in/lb）
+type BMIRequest struct {
+	Height     float64 `json:"height" binding:"required,gt=0"`                        // 身高
+	Weight     float64 `json:"weight" binding:"required,gt=0"`                        // 体重
+	Age        int     `json:"age" binding:"required,gt=0"`                           // 年龄
+	Gender     string  `json:"gender" binding:"required"`                             // 性别: male/female
+	UnitSystem string  `json:"unit_system" binding:"omitempty,oneof=metric imperial"` // 为空时按用户设置
+	Notes      string  `json:"notes"`                                                 // 保存记录时的备注
+}
+
+// WeightRange 体重范围
+type WeightRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// BMIResponse BMI计算结果
+type BMIResponse struct {
+	BMI         float64     `json:"bmi"`
+	Category    string      `json:"category"`
+	IdealWeight WeightRange `json:"ideal_weight"`
+	BodyFat     float64     `json:"body_fat"`
+	BMR         float64     `json:"bmr"`
+	TDEE        float64     `json:"tdee"`
+	Units       UnitLabels  `json:"units"` // 理想体重的单位
+}
+
+// HealthRecord 健康记录（BMI 等），数值与单位无关
+type HealthRecord struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	UserID    string         `json:"user_id" gorm:"not null;index"`
+	Date      time.Time      `json:"date"`
+	Type      string         `json:"type"`
+	Value     float64        `json:"value"`
+	Unit      string         `json:"unit"`
+	Notes     string         `json:"notes"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// 最大心率来源
+const (
+	MaxHeartRateEntered   = "entered"   // 用户在设置中填写
+	MaxHeartRateEstimated = "estimated" // 按年龄估算（208 - 0.7 × 年龄）
+	MaxHeartRateDefault   = "default"   // 没有生日时使用默认值
+)
+
+// HeartRateSample 一个心率采样点
+type HeartRateSample struct {
+	Time time.Time `json:"time" binding:"required"`
+	BPM  int       `json:"bpm" binding:"required"`
+}
+
+// UploadHeartRateRequest 上传训练的心率数据，可以分多次上传，重复时间的采样点以后上传的为准
+type UploadHeartRateRequest struct {
+	RecordID string            `json:"record_id" binding:"required"`
+	Samples  []HeartRateSample `json:"samples" binding:"required,min=1,max=20000,dive"`
+}
+
+// HeartRateZone 心率区间内的时间
+type HeartRateZone struct {
+	Zone    int     `json:"zone"` // 1-5
+	MinBPM  int     `json:"min_bpm"`
+	MaxBPM  int     `json:"max_bpm"`
+	Seconds int     `json:"seconds"`
+	Percent float64 `json:"percent"` // 占有效心率时长的百分比
+}
+
+// HeartRateSummary 一次训练的心率分析
+type HeartRateSummary struct {
+	Samples            int             `json:"samples"`
+	Seconds            int             `json:"seconds"` // 有效心率时长，采样间隔过大的部分视为断连不计入
+	AvgBPM             int             `json:"avg_bpm"`
+	PeakBPM            int             `json:"peak_bpm"`
+	MaxHeartRate       int             `json:"max_heart_rate"`
+	MaxHeartRateSource string          `json:"max_heart_rate_source"` // entered, estimated, default
+	Zones              []HeartRateZone `json:"zones"`
+	TrainingLoad       float64         `json:"training_load"` // Edwards TRIMP：各区间分钟数 × 区间序号之和
+	Calories           int             `json:"calories"`      // 按心率估算的卡路里
+}
+
+// HeartRateChunk 一次上传的心率数据
+// 采样点按时间排序后编码为 [与上一点的毫秒差 uvarint][bpm 1 字节]，每个点约 2-3 字节
+type HeartRateChunk struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	RecordID    string    `json:"record_id" gorm:"not null;index"`
+	UserID      string    `json:"user_id" gorm:"not null"`
+	StartAt     time.Time `json:"start_at"`
+	EndAt       time.Time `json:"end_at"`
+	SampleCount int       `json:"sample_count"`
+	Data        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (HeartRateChunk) TableName() string {
+	return "workout_heart_rate_chunks"
+}
+
+// HeartRateResponse 训练的心率数据和分析
+type HeartRateResponse struct {
+	RecordID       string            `json:"record_id"`
+	Accepted       int               `json:"accepted,omitempty"` // 本次上传接受的采样点数
+	Dropped        int               `json:"dropped,omitempty"`  // 心率超出范围或不在训练时间内而丢弃的采样点数
+	Calories       int               `json:"calories"`
+	CaloriesSource string            `json:"calories_source"`
+	Summary        *HeartRateSummary `json:"summary"`
+	Samples        []HeartRateSample `json:"samples,omitempty"` // 降采样后的曲线，用于绘图
+}
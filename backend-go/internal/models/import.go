@@ -0,0 +1,36 @@
+package models
+
+// 导入时的重量单位
+const (
+	WeightUnitKg = "kg"
+	WeightUnitLb = "lb"
+)
+
+// ImportSkippedRow 导入时跳过的行
+type ImportSkippedRow struct {
+	Line   int    `json:"line"` // CSV 中的行号（含表头，从 1 开始）
+	Reason string `json:"reason"`
+}
+
+// ImportedWorkoutSummary 导入（或预览）的一次训练
+type ImportedWorkoutSummary struct {
+	Name      string `json:"name"`
+	StartTime string `json:"start_time"`
+	Exercises int    `json:"exercises"`
+	Sets      int    `json:"sets"`
+	Duplicate bool   `json:"duplicate"` // 已导入过，本次跳过
+}
+
+// ImportReport 训练历史导入报告
+type ImportReport struct {
+	DryRun             bool                     `json:"dry_run"`
+	WeightUnit         string                   `json:"weight_unit"` // 源文件的重量单位，导入后统一为 kg
+	TotalRows          int                      `json:"total_rows"`
+	ImportedWorkouts   int                      `json:"imported_workouts"`
+	ImportedExercises  int                      `json:"imported_exercises"`
+	ImportedSets       int                      `json:"imported_sets"`
+	DuplicateWorkouts  int                      `json:"duplicate_workouts"`
+	SkippedRows        []ImportSkippedRow       `json:"skipped_rows"`
+	UnmatchedExercises []string                 `json:"unmatched_exercises"` // 未能匹配标准动作的名称（仍会导入）
+	Workouts           []ImportedWorkoutSummary `json:"workouts"`
+}
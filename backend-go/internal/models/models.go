@@ -62,8 +62,27 @@ type TrainingPlan struct {
 	Status        string             `json:"status"` // pending, in_progress, completed, skipped
 	IsAIGenerated bool               `json:"is_ai_generated"`
 	AIReason      string             `json:"ai_reason"`
-	CreatedAt     time.Time          `json:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at"`
+	ProgramID     string             `json:"program_id" gorm:"index"` // 所属训练周期，为空表示独立计划
+	ProgramWeek   int                `json:"program_week"`            // 在训练周期中的第几周（从 1 开始）
+
+	// AI 生成来源：模型直接输出、按校验错误修复后的输出，或回退到模板
+	AISource           string   `json:"ai_source,omitempty"` // model, repaired, template
+	AIProvider         string   `json:"ai_provider,omitempty"`
+	AIModel            string   `json:"ai_model,omitempty"`
+	AIAttempts         int      `json:"ai_attempts,omitempty"`                                 // 调用模型的次数（含修复）
+	AIValidationErrors []string `json:"ai_validation_errors,omitempty" gorm:"serializer:json"` // 最后一次未通过的校验错误
+
+	// 模板：模板不会出现在每日计划中，只用于克隆到具体日期
+	IsTemplate       bool       `json:"is_template" gorm:"index"`
+	Visibility       string     `json:"visibility,omitempty"`                      // 模板可见性：private, public
+	SourceTemplateID string     `json:"source_template_id,omitempty" gorm:"index"` // 克隆或派生自哪个模板
+	ForkCount        int        `json:"fork_count"`                                // 被其他用户克隆/派生的次数
+	RatingAverage    float64    `json:"rating_average"`
+	RatingCount      int        `json:"rating_count"`
+	PublishedAt      *time.Time `json:"published_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (TrainingPlan) TableName() string {
@@ -73,6 +92,7 @@ func (TrainingPlan) TableName() string {
 type TrainingExercise struct {
 	ID           string        `json:"id" gorm:"primaryKey"`
 	PlanID       string        `json:"plan_id" gorm:"not null"`
+	CatalogID    uint          `json:"catalog_id,omitempty" gorm:"index"` // 关联的标准动作，0 表示未关联
 	Name         string        `json:"name" gorm:"not null"`
 	Description  string        `json:"description"`
 	Category     string        `json:"category"`   // 胸、背、腿、肩、臂等
@@ -84,25 +104,46 @@ type TrainingExercise struct {
 	ImageURL     string        `json:"image_url"`
 	Instructions string        `json:"instructions"`
 	Order        int           `json:"order"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+
+	// 分组：相同 GroupKey 的动作组成超级组/巨型组/循环，按 Order 依次交替完成
+	GroupKey    string `json:"group_key,omitempty"`
+	GroupType   string `json:"group_type,omitempty"`   // superset, giant_set, circuit
+	GroupRounds int    `json:"group_rounds,omitempty"` // 分组轮数
+
+	SubstitutedWith string `json:"substituted_with,omitempty"` // 训练时实际做的替换动作
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type ExerciseSet struct {
-	ID         string    `json:"id" gorm:"primaryKey"`
-	ExerciseID string    `json:"exercise_id" gorm:"not null"`
-	Reps       int       `json:"reps"`
-	Weight     float64   `json:"weight"`    // kg
-	Duration   int       `json:"duration"`  // 秒，用于有氧运动
-	Distance   float64   `json:"distance"`  // 公里，用于跑步等
-	RestTime   int       `json:"rest_time"` // 秒
-	Completed  bool      `json:"completed"`
-	Order      int       `json:"order"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string  `json:"id" gorm:"primaryKey"`
+	ExerciseID string  `json:"exercise_id" gorm:"not null"`
+	Reps       int     `json:"reps"`
+	Weight     float64 `json:"weight"`    // kg
+	Duration   int     `json:"duration"`  // 秒，用于有氧运动
+	Distance   float64 `json:"distance"`  // 公里，用于跑步等
+	RestTime   int     `json:"rest_time"` // 秒
+	Completed  bool    `json:"completed"`
+	Order      int     `json:"order"`
+	SetType    string  `json:"set_type" gorm:"default:working"` // warmup, working, drop, amrap, timed
+
+	// 计划目标：首次完成时记录原计划值，之后 Reps 等字段保存实际完成值
+	TargetReps     int     `json:"target_reps"`
+	TargetWeight   float64 `json:"target_weight"`
+	TargetDuration int     `json:"target_duration"`
+	TargetDistance float64 `json:"target_distance"`
+	Extra          bool    `json:"extra"` // 训练时计划外追加的组
+
+	CompletedAt *time.Time `json:"completed_at"` // 完成时间
+	RecordID    string     `json:"record_id"`    // 完成该组时所在的训练记录
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type CreatePlanRequest struct {
+	ID          string                  `json:"id"` // 可选，离线创建时由客户端生成的 UUID，仅同步时沿用
 	Name        string                  `json:"name" binding:"required"`
 	Description string                  `json:"description"`
 	Date        string                  `json:"date" binding:"required"`
@@ -110,6 +151,8 @@ type CreatePlanRequest struct {
 }
 
 type CreateExerciseRequest struct {
+	ID           string             `json:"id"`         // 可选，离线创建时由客户端生成的 UUID，仅同步时沿用
+	CatalogID    uint               `json:"catalog_id"` // 可选，未指定时按名称自动匹配标准动作
 	Name         string             `json:"name" binding:"required"`
 	Description  string             `json:"description"`
 	Category     string             `json:"category"`
@@ -121,20 +164,27 @@ type CreateExerciseRequest struct {
 	ImageURL     string             `json:"image_url"`
 	Instructions string             `json:"instructions"`
 	Order        int                `json:"order"`
+	GroupKey     string             `json:"group_key"`    // 可选，相同分组标识的动作组成超级组/巨型组/循环
+	GroupType    string             `json:"group_type"`   // superset, giant_set, circuit，为空时按分组内动作数推断
+	GroupRounds  int                `json:"group_rounds"` // 分组轮数，为空时取分组内动作的最大组数
 }
 
 type CreateSetRequest struct {
-	Reps     int     `json:"reps" binding:"required"`
+	ID       string  `json:"id"`   // 可选，离线创建时由客户端生成的 UUID，仅同步时沿用
+	Reps     int     `json:"reps"` // 力竭组为目标下限，计时组可为空
 	Weight   float64 `json:"weight"`
 	Duration int     `json:"duration"`
 	Distance float64 `json:"distance"`
 	RestTime int     `json:"rest_time"`
 	Order    int     `json:"order"`
+	SetType  string  `json:"set_type"` // warmup, working, drop, amrap, timed，默认 working
 }
 
 type UpdatePlanRequest struct {
 	Name        string                  `json:"name"`
 	Description string                  `json:"description"`
+	Date        string                  `json:"date"`                                     // 调整训练日期，格式 YYYY-MM-DD，为空时不修改
+	Status      string                  `json:"status" binding:"omitempty,oneof=skipped"` // 只支持标记为跳过，训练周期中的计划会顺延排期
 	Exercises   []CreateExerciseRequest `json:"exercises"`
 }
 
@@ -147,17 +197,20 @@ type GenerateAIPlanRequest struct {
 }
 
 type CompleteExerciseRequest struct {
-	ExerciseID string               `json:"exercise_id" binding:"required"`
-	Sets       []CompleteSetRequest `json:"sets" binding:"required"`
+	ExerciseID  string               `json:"exercise_id" binding:"required"`
+	Sets        []CompleteSetRequest `json:"sets" binding:"required"`
+	Substitute  string               `json:"substitute"`   // 用其他动作替换计划动作时填写实际动作名称，为空时保持不变
+	CompletedAt *time.Time           `json:"completed_at"` // 离线记录时的完成时间，为空时使用服务端当前时间
 }
 
 type CompleteSetRequest struct {
-	SetID     string  `json:"set_id" binding:"required"`
+	SetID     string  `json:"set_id"` // 为空时追加一组计划外的组（如临时加的递减组）
 	Reps      int     `json:"reps"`
 	Weight    float64 `json:"weight"`
 	Duration  int     `json:"duration"`
 	Distance  float64 `json:"distance"`
 	Completed bool    `json:"completed"`
+	SetType   string  `json:"set_type"` // 仅追加组时使用，默认 working
 }
 
 type CompleteWorkoutRequest struct {
@@ -182,6 +235,13 @@ type GenerateTrainingPlanRequest struct {
 	Difficulty string   `json:"difficulty" binding:"required"`
 	Equipment  []string `json:"equipment"`
 	FocusAreas []string `json:"focus_areas"`
+
+	// 最近的计划完成度，由服务端填充，用于调整训练量
+	Adherence        float64  `json:"-"`
+	SkippedExercises []string `json:"-"`
+
+	// 用户资料和训练历史，由服务端填充
+	Context *AIPlanContext `json:"-"`
 }
 
 type GenerateNutritionPlanRequest struct {
@@ -193,8 +253,9 @@ type GenerateNutritionPlanRequest struct {
 }
 
 type AIChatRequest struct {
-	Message string `json:"message" binding:"required"`
-	Context string `json:"context"`
+	ConversationID string `json:"conversation_id"` // 为空时创建新对话
+	Message        string `json:"message" binding:"required,max=4000"`
+	Context        string `json:"context"` // 附加的背景信息（如当前页面），只用于本次回复
 }
 
 // 签到相关模型
@@ -259,6 +320,22 @@ type WorkoutRecord struct {
 	Calories  int       `json:"calories"`
 	Notes     string    `json:"notes"`
 	Status    string    `json:"status"` // in_progress, completed, paused
+
+	CaloriesSource string `json:"calories_source"` // measured: 设备实测, heart_rate: 按心率估算, estimated: 按 MET 估算
+	ImportKey      string `json:"-" gorm:"index"`  // 从其他应用导入的训练的去重键，手动记录为空
+
+	PausedAt       *time.Time     `json:"paused_at"`                         // 当前暂停开始时间，未暂停时为空
+	PausedSeconds  int            `json:"paused_seconds"`                    // 已结束的暂停区间累计秒数
+	LastActivityAt time.Time      `json:"last_activity_at"`                  // 最近一次操作（开始/暂停/恢复/完成组）时间
+	AutoClosed     bool           `json:"auto_closed"`                       // 是否因长时间未活动被自动结束
+	Pauses         []WorkoutPause `json:"pauses" gorm:"foreignKey:RecordID"` // 暂停区间
+
+	AdherenceScore float64           `json:"adherence_score"`                            // 计划完成度 0-100
+	Adherence      *SessionAdherence `json:"adherence,omitempty" gorm:"serializer:json"` // 完成度明细，没有关联计划时为空
+
+	HeartRate    *HeartRateSummary `json:"heart_rate,omitempty" gorm:"serializer:json"` // 心率分析，没有上传心率时为空
+	TrainingLoad float64           `json:"training_load"`                               // 按心率计算的训练负荷
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -268,30 +345,44 @@ type WorkoutRecord struct {
 }
 
 type StartWorkoutRequest struct {
-	PlanID string `json:"plan_id"`
-	Notes  string `json:"notes"`
+	ID        string     `json:"id"` // 可选，离线开始训练时由客户端生成的 UUID，仅同步时沿用
+	PlanID    string     `json:"plan_id"`
+	Notes     string     `json:"notes"`
+	StartedAt *time.Time `json:"started_at"` // 离线记录时的开始时间，为空时使用服务端当前时间
 }
 
 type EndWorkoutRequest struct {
-	RecordID string `json:"record_id" binding:"required"`
-	Notes    string `json:"notes"`
-	Calories int    `json:"calories"`
+	RecordID         string     `json:"record_id" binding:"required"`
+	Notes            string     `json:"notes"`
+	Calories         int        `json:"calories"`
+	CaloriesMeasured bool       `json:"calories_measured"` // 为 true 时信任客户端上报的卡路里，否则由服务端估算
+	EndedAt          *time.Time `json:"ended_at"`          // 离线记录时的结束时间，为空时使用服务端当前时间
 }
 
 type WorkoutRecordResponse struct {
-	ID        string       `json:"id"`
-	UserID    string       `json:"user_id"`
-	PlanID    string       `json:"plan_id"`
-	StartTime time.Time    `json:"start_time"`
-	EndTime   time.Time    `json:"end_time"`
-	Duration  int          `json:"duration"`
-	Calories  int          `json:"calories"`
-	Notes     string       `json:"notes"`
-	Status    string       `json:"status"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	User      User         `json:"user"`
-	Plan      TrainingPlan `json:"plan"`
+	ID             string            `json:"id"`
+	UserID         string            `json:"user_id"`
+	PlanID         string            `json:"plan_id"`
+	StartTime      time.Time         `json:"start_time"`
+	EndTime        time.Time         `json:"end_time"`
+	Duration       int               `json:"duration"`
+	Calories       int               `json:"calories"`
+	CaloriesSource string            `json:"calories_source"`
+	Notes          string            `json:"notes"`
+	Status         string            `json:"status"`
+	PausedAt       *time.Time        `json:"paused_at"`
+	PausedSeconds  int               `json:"paused_seconds"`
+	LastActivityAt time.Time         `json:"last_activity_at"`
+	AutoClosed     bool              `json:"auto_closed"`
+	Pauses         []WorkoutPause    `json:"pauses"`
+	AdherenceScore float64           `json:"adherence_score"`
+	Adherence      *SessionAdherence `json:"adherence,omitempty"`
+	HeartRate      *HeartRateSummary `json:"heart_rate,omitempty"`
+	TrainingLoad   float64           `json:"training_load"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	User           User              `json:"user"`
+	Plan           TrainingPlan      `json:"plan"`
 }
 
 // 统计相关模型
@@ -311,11 +402,14 @@ type UserStats struct {
 	TotalCalories      int       `json:"total_calories"`
 	CurrentStreak      int       `json:"current_streak"`
 	LongestStreak      int       `json:"longest_streak"`
+	LastActiveDate     string    `json:"last_active_date"` // 最近活跃日（用户时区，YYYY-MM-DD）
 	FavoriteExercise   string    `json:"favorite_exercise"`
 	WorkoutDays        int       `json:"workout_days"`
 	RestDays           int       `json:"rest_days"`
 	AverageWorkoutTime int       `json:"average_workout_time"`
 	LastWorkoutAt      time.Time `json:"last_workout_at"`
+	WeeklyAdherence    float64   `json:"weekly_adherence"` // 本周计划完成度 0-100
+	AdherenceScore     float64   `json:"adherence_score"`  // 最近 4 周计划完成度 0-100
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 
@@ -393,17 +487,22 @@ type ExerciseFeedbackResponse struct {
 
 // 训练统计响应
 type TrainingStatsResponse struct {
-	TotalWorkouts    int     `json:"total_workouts"`
-	TotalDuration    int     `json:"total_duration"`
-	TotalCalories    int     `json:"total_calories"`
-	AverageDuration  float64 `json:"average_duration"`
-	AverageCalories  float64 `json:"average_calories"`
-	StreakDays       int     `json:"streak_days"`
-	LongestStreak    int     `json:"longest_streak"`
-	FavoriteExercise string  `json:"favorite_exercise"`
-	LastWorkoutDate  string  `json:"last_workout_date"`
-	WeeklyWorkouts   int     `json:"weekly_workouts"`
-	MonthlyWorkouts  int     `json:"monthly_workouts"`
+	TotalWorkouts     int          `json:"total_workouts"`
+	TotalDuration     int          `json:"total_duration"`
+	TotalCalories     int          `json:"total_calories"`
+	MeasuredCalories  int          `json:"measured_calories"`  // 设备实测部分
+	EstimatedCalories int          `json:"estimated_calories"` // 按心率或 MET 估算部分
+	AverageDuration   float64      `json:"average_duration"`
+	AverageCalories   float64      `json:"average_calories"`
+	StreakDays        int          `json:"streak_days"`
+	LongestStreak     int          `json:"longest_streak"`
+	FavoriteExercise  string       `json:"favorite_exercise"`
+	LastWorkoutDate   string       `json:"last_workout_date"`
+	WeeklyWorkouts    int          `json:"weekly_workouts"`
+	MonthlyWorkouts   int          `json:"monthly_workouts"`
+	WeeklyStats       []WeeklyStat `json:"weekly_stats"`
+	AdherenceScore    float64      `json:"adherence_score"`  // 最近 4 周计划完成度 0-100
+	WeeklyAdherence   float64      `json:"weekly_adherence"` // 本周计划完成度 0-100
 }
 
 // 用户设置相关模型
@@ -415,24 +514,28 @@ type UpdateSettingsRequest struct {
 	Language          string `json:"language"`
 	Timezone          string `json:"timezone"`
 	Theme             string `json:"theme"`
+	UnitSystem        string `json:"unit_system" binding:"omitempty,oneof=metric imperial"`
+	MaxHeartRate      *int   `json:"max_heart_rate" binding:"omitempty,eq=0|min=100,max=230"` // 为空时保持不变，0 表示清除后按年龄估算
 }
 
 type UserStatsResponse struct {
-	ID                 string `json:"id"`
-	UserID             string `json:"user_id"`
-	TotalWorkouts      int    `json:"total_workouts"`
-	TotalDuration      int    `json:"total_duration"`
-	TotalCalories      int    `json:"total_calories"`
-	CurrentStreak      int    `json:"current_streak"`
-	LongestStreak      int    `json:"longest_streak"`
-	FavoriteExercise   string `json:"favorite_exercise"`
-	WorkoutDays        int    `json:"workout_days"`
-	RestDays           int    `json:"rest_days"`
-	AverageWorkoutTime int    `json:"average_workout_time"`
-	LastWorkoutAt      string `json:"last_workout_at"`
-	CreatedAt          string `json:"created_at"`
-	UpdatedAt          string `json:"updated_at"`
-	Period             string `json:"period"`
+	ID                 string  `json:"id"`
+	UserID             string  `json:"user_id"`
+	TotalWorkouts      int     `json:"total_workouts"`
+	TotalDuration      int     `json:"total_duration"`
+	TotalCalories      int     `json:"total_calories"`
+	CurrentStreak      int     `json:"current_streak"`
+	LongestStreak      int     `json:"longest_streak"`
+	FavoriteExercise   string  `json:"favorite_exercise"`
+	WorkoutDays        int     `json:"workout_days"`
+	RestDays           int     `json:"rest_days"`
+	AverageWorkoutTime int     `json:"average_workout_time"`
+	LastWorkoutAt      string  `json:"last_workout_at"`
+	WeeklyAdherence    float64 `json:"weekly_adherence"`
+	AdherenceScore     float64 `json:"adherence_score"`
+	CreatedAt          string  `json:"created_at"`
+	UpdatedAt          string  `json:"updated_at"`
+	Period             string  `json:"period"`
 }
 
 type UserAchievementResponse struct {
@@ -467,6 +570,8 @@ type UserSettingsResponse struct {
 	Language          string `json:"language"`
 	Timezone          string `json:"timezone"`
 	Theme             string `json:"theme"`
+	UnitSystem        string `json:"unit_system"`
+	MaxHeartRate      int    `json:"max_heart_rate"`
 	CreatedAt         string `json:"created_at"`
 	UpdatedAt         string `json:"updated_at"`
 }
@@ -482,8 +587,10 @@ type WorkoutSession struct {
 	Calories  int       `json:"calories"`
 	Notes     string    `json:"notes"`
 	Status    string    `json:"status"` // in_progress, completed, paused
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+
+	CaloriesSource string    `json:"calories_source"` // measured: 设备实测, estimated: 按 MET 估算；创建时传入 measured 表示信任客户端上报的卡路里
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	// 关联数据
 	User User         `json:"user" gorm:"foreignKey:UserID"`
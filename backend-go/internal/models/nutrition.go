@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NutritionRecord 饮食记录，每条为一餐中的一种食物，营养数据按记录的数量计算
+type NutritionRecord struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	UserID    string         `json:"user_id" gorm:"not null;index"`
+	Date      time.Time      `json:"date"`
+	MealType  string         `json:"meal_type"` // 早餐、午餐、晚餐、加餐
+	FoodName  string         `json:"food_name"`
+	Quantity  float64        `json:"quantity"` // 克
+	Unit      string         `json:"unit"`
+	Calories  float64        `json:"calories"`
+	Protein   float64        `json:"protein"` // 克
+	Carbs     float64        `json:"carbs"`   // 克
+	Fat       float64        `json:"fat"`     // 克
+	Fiber     float64        `json:"fiber"`   // 克
+	Sugar     float64        `json:"sugar"`   // 克
+	Sodium    float64        `json:"sodium"`  // 毫克
+	Notes     string         `json:"notes"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// NutritionDaySummary 一天的饮食汇总
+type NutritionDaySummary struct {
+	Date     string  `json:"date"`
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Meals    int     `json:"meals"` // 记录的餐数
+	Records  int     `json:"records"`
+}
+
+// NutritionSummary 一段时间的饮食汇总
+type NutritionSummary struct {
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	Days        []NutritionDaySummary `json:"days"`         // 只包含有记录的日期
+	AvgCalories float64               `json:"avg_calories"` // 有记录日期的平均值
+	AvgProtein  float64               `json:"avg_protein"`
+}
+
+// NutritionFacts 营养成分，食物库中为每 100 克的含量
+type NutritionFacts struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"` // 克
+	Carbs    float64 `json:"carbs"`   // 克
+	Fat      float64 `json:"fat"`     // 克
+	Fiber    float64 `json:"fiber"`   // 克
+	Sugar    float64 `json:"sugar"`   // 克
+	Sodium   float64 `json:"sodium"`  // 毫克
+}
+
+// NutritionRequest 营养计算请求
+type NutritionRequest struct {
+	FoodName string  `json:"food_name" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	Unit     string  `json:"unit" binding:"omitempty,oneof=g kg oz lb lbs"` // 为空时按用户的单位制（g 或 oz）
+}
+
+// NutritionResponse 营养计算结果，数量和单位与请求一致
+type NutritionResponse struct {
+	FoodName  string         `json:"food_name"`
+	Quantity  float64        `json:"quantity"`
+	Unit      string         `json:"unit"`
+	Nutrition NutritionFacts `json:"nutrition"`
+}
+
+// NutritionRecordRequest 饮食记录请求
+type NutritionRecordRequest struct {
+	Date     string  `json:"date" binding:"required"` // YYYY-MM-DD
+	MealType string  `json:"meal_type" binding:"required"`
+	FoodName string  `json:"food_name" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	Unit     string  `json:"unit" binding:"omitempty,oneof=g kg oz lb lbs"` // 为空时按用户的单位制，记录中统一保存为克
+	Notes    string  `json:"notes"`
+}
+
+// Food 食物搜索结果
+type Food struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// MealSummary 一餐的摄入汇总
+type MealSummary struct {
+	MealType string  `json:"meal_type"`
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Count    int     `json:"count"`
+}
+
+// DailyIntakeResponse 一天的摄入汇总
+type DailyIntakeResponse struct {
+	Date string `json:"date"`
+	NutritionFacts
+	Meals []MealSummary `json:"meals"`
+}
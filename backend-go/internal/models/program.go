@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+)
+
+// TrainingProgram 多周期训练计划（如 12 周增肌计划）
+// 由若干阶段（中周期）组成，每个阶段按周模板重复，并物化为每日的 TrainingPlan
+type TrainingProgram struct {
+	ID          string         `json:"id" gorm:"primaryKey"`
+	UserID      string         `json:"user_id" gorm:"not null;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Goal        string         `json:"goal"`
+	StartDate   time.Time      `json:"start_date"`
+	TotalWeeks  int            `json:"total_weeks"`
+	Status      string         `json:"status"` // active, completed, archived
+	Phases      []ProgramPhase `json:"phases" gorm:"foreignKey:ProgramID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ProgramPhase 训练阶段（中周期），减载周也是一个阶段
+type ProgramPhase struct {
+	ID              string               `json:"id" gorm:"primaryKey"`
+	ProgramID       string               `json:"program_id" gorm:"not null;index"`
+	Name            string               `json:"name"`
+	Order           int                  `json:"order"`
+	Weeks           int                  `json:"weeks"`
+	IsDeload        bool                 `json:"is_deload"`
+	IntensityFactor float64              `json:"intensity_factor"` // 重量系数，1.0 表示按模板
+	VolumeFactor    float64              `json:"volume_factor"`    // 组数系数，1.0 表示按模板
+	Days            []ProgramDayTemplate `json:"days" gorm:"foreignKey:PhaseID"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// ProgramDayTemplate 周模板中的一个训练日
+type ProgramDayTemplate struct {
+	ID        string                  `json:"id" gorm:"primaryKey"`
+	PhaseID   string                  `json:"phase_id" gorm:"not null;index"`
+	DayOffset int                     `json:"day_offset"` // 相对每周第一天的偏移，0-6
+	Name      string                  `json:"name"`
+	Exercises []CreateExerciseRequest `json:"exercises" gorm:"serializer:json"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
+
+// CreateProgramRequest 创建训练计划周期请求
+type CreateProgramRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description string               `json:"description"`
+	Goal        string               `json:"goal"`
+	StartDate   string               `json:"start_date" binding:"required"`
+	Phases      []CreatePhaseRequest `json:"phases" binding:"required,min=1,max=12,dive"`
+}
+
+// CreatePhaseRequest 创建训练阶段请求
+type CreatePhaseRequest struct {
+	Name            string                     `json:"name" binding:"required"`
+	Weeks           int                        `json:"weeks" binding:"required,min=1,max=52"`
+	IsDeload        bool                       `json:"is_deload"`
+	IntensityFactor float64                    `json:"intensity_factor"`
+	VolumeFactor    float64                    `json:"volume_factor"`
+	Days            []CreateDayTemplateRequest `json:"days" binding:"required,min=1,dive"`
+}
+
+// CreateDayTemplateRequest 创建周模板训练日请求
+type CreateDayTemplateRequest struct {
+	DayOffset int                     `json:"day_offset" binding:"min=0,max=6"`
+	Name      string                  `json:"name" binding:"required"`
+	Exercises []CreateExerciseRequest `json:"exercises" binding:"required"`
+}
+
+// ProgramProgressResponse 训练周期进度
+type ProgramProgressResponse struct {
+	ProgramID       string     `json:"program_id"`
+	Name            string     `json:"name"`
+	Status          string     `json:"status"`
+	TotalWeeks      int        `json:"total_weeks"`
+	CurrentWeek     int        `json:"current_week"`
+	CurrentPhase    string     `json:"current_phase"`
+	IsDeloadWeek    bool       `json:"is_deload_week"`
+	TotalWorkouts   int        `json:"total_workouts"`
+	CompletedCount  int        `json:"completed_count"`
+	SkippedCount    int        `json:"skipped_count"`
+	PendingCount    int        `json:"pending_count"`
+	ProgressPercent float64    `json:"progress_percent"`
+	NextWorkoutDate *time.Time `json:"next_workout_date"`
+	EndDate         time.Time  `json:"end_date"`
+}
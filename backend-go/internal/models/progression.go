@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// 渐进超负荷策略
+const (
+	ProgressionLinear = "linear" // 线性递增：完成全部目标次数后加重
+	ProgressionDouble = "double" // 双重递进：先在次数区间内加次数，达到上限后加重
+	ProgressionRPE    = "rpe"    // 基于主观用力程度（RPE）调整重量
+)
+
+// ProgressionRule 渐进超负荷规则
+// ExerciseName 为空时表示该用户的默认规则
+type ProgressionRule struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	UserID          string    `json:"user_id" gorm:"not null;index"`
+	ExerciseName    string    `json:"exercise_name"`
+	Strategy        string    `json:"strategy"`         // linear, double, rpe
+	WeightIncrement float64   `json:"weight_increment"` // kg
+	MinReps         int       `json:"min_reps"`
+	MaxReps         int       `json:"max_reps"`
+	TargetRPE       float64   `json:"target_rpe"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// UpdateProgressionRuleRequest 更新渐进规则请求
+type UpdateProgressionRuleRequest struct {
+	ExerciseName    string  `json:"exercise_name"`
+	Strategy        string  `json:"strategy" binding:"required,oneof=linear double rpe"`
+	WeightIncrement float64 `json:"weight_increment" binding:"min=0"`
+	MinReps         int     `json:"min_reps" binding:"min=0"`
+	MaxReps         int     `json:"max_reps" binding:"min=0"`
+	TargetRPE       float64 `json:"target_rpe" binding:"min=0,max=10"`
+}
+
+// ProgressionSuggestion 下一次训练的重量/次数建议
+type ProgressionSuggestion struct {
+	ExerciseName      string  `json:"exercise_name"`
+	Strategy          string  `json:"strategy"`
+	LastWeight        float64 `json:"last_weight"`
+	LastReps          int     `json:"last_reps"`
+	SuggestedWeight   float64 `json:"suggested_weight"`
+	SuggestedReps     int     `json:"suggested_reps"`
+	LastDuration      int     `json:"last_duration,omitempty"`      // 计时组上次最长时长（秒）
+	SuggestedDuration int     `json:"suggested_duration,omitempty"` // 计时组建议时长（秒）
+	Reason            string  `json:"reason"`
+	HasHistory        bool    `json:"has_history"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// 个人记录类型
+const (
+	RecordMaxWeight    = "max_weight"    // 最大重量
+	RecordMaxReps      = "max_reps"      // 某一重量下的最多次数
+	RecordMaxVolume    = "max_volume"    // 单次训练该动作的最大容量（重量×次数）
+	RecordEstimated1RM = "estimated_1rm" // 估算最大重复重量
+	RecordFastestPace  = "fastest_pace"  // 有氧最快配速（秒/公里）
+)
+
+// 1RM 估算公式
+const (
+	FormulaEpley   = "epley"
+	FormulaBrzycki = "brzycki"
+)
+
+// PersonalRecord 个人记录（PR）
+// 每次刷新记录都会新增一行，因此同一动作、同一类型的所有行按时间排列即为 PR 时间线
+type PersonalRecord struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	UserID        string    `json:"user_id" gorm:"not null;index"`
+	ExerciseName  string    `json:"exercise_name" gorm:"not null;index"`
+	RecordType    string    `json:"record_type" gorm:"not null"`
+	Formula       string    `json:"formula,omitempty"` // 仅 estimated_1rm 使用
+	Value         float64   `json:"value"`
+	PreviousValue float64   `json:"previous_value"`
+	Weight        float64   `json:"weight"`   // kg
+	Reps          int       `json:"reps"`     // 次
+	Duration      int       `json:"duration"` // 秒
+	Distance      float64   `json:"distance"` // 公里
+	ExerciseID    string    `json:"exercise_id"`
+	SetID         string    `json:"set_id"`
+	AchievedAt    time.Time `json:"achieved_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PersonalRecordEvent 刷新个人记录事件
+type PersonalRecordEvent struct {
+	UserID string         `json:"user_id"`
+	Record PersonalRecord `json:"record"`
+}
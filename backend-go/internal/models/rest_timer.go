@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// 组间休息计时器状态
+const (
+	RestTimerRunning  = "running"
+	RestTimerFinished = "finished" // 倒计时自然结束
+	RestTimerSkipped  = "skipped"  // 提前跳过休息
+	RestTimerEnded    = "ended"    // 被新的计时器替换或手动结束
+)
+
+// 组间休息计时器推送的 WebSocket 消息类型
+const (
+	RestTimerEventStarted  = "rest_timer_started"
+	RestTimerEventTick     = "rest_timer_tick"
+	RestTimerEventUpdated  = "rest_timer_updated"
+	RestTimerEventFinished = "rest_timer_finished"
+)
+
+// RestTimer 组间休息计时器，由服务端倒计时并推送到用户的所有设备
+type RestTimer struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"user_id" gorm:"not null;index"`
+	RecordID   string     `json:"record_id,omitempty"`
+	ExerciseID string     `json:"exercise_id,omitempty"`
+	SetID      string     `json:"set_id,omitempty"`
+	Duration   int        `json:"duration"` // 秒，包含延长的时间
+	Extended   int        `json:"extended"` // 秒，累计延长的时间
+	StartedAt  time.Time  `json:"started_at"`
+	EndsAt     time.Time  `json:"ends_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// RestTimerState 推送给设备的计时器状态，设备按服务端时间校准后显示相同的倒计时
+type RestTimerState struct {
+	Timer      RestTimer `json:"timer"`
+	Remaining  int       `json:"remaining"` // 秒
+	ServerTime time.Time `json:"server_time"`
+}
+
+// StartRestTimerRequest 手动开始休息请求，指定组时默认使用该组的休息时间
+type StartRestTimerRequest struct {
+	SetID    string `json:"set_id"`
+	Duration int    `json:"duration"` // 秒
+}
+
+// ExtendRestTimerRequest 延长休息请求
+type ExtendRestTimerRequest struct {
+	Seconds int `json:"seconds" binding:"required"`
+}
+
+// SetCompletedEvent 完成一组事件
+type SetCompletedEvent struct {
+	UserID     string `json:"user_id"`
+	RecordID   string `json:"record_id"`
+	ExerciseID string `json:"exercise_id"`
+	SetID      string `json:"set_id"`
+	RestTime   int    `json:"rest_time"` // 秒，分组内同一轮的动作之间为 0
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// 风险信号类型
+const (
+	RiskPainTrend       = "pain_trend"       // 同一动作或肌群的疼痛等级持续上升
+	RiskPerformanceDrop = "performance_drop" // 同一动作的估算 1RM 明显下降
+	RiskWorkloadSpike   = "workload_spike"   // 急慢性负荷比过高
+	RiskFatigue         = "fatigue"          // 近期反馈多为"过难"
+)
+
+// 风险等级
+const (
+	RiskLevelLow      = "low"
+	RiskLevelModerate = "moderate"
+	RiskLevelHigh     = "high"
+)
+
+// NotificationTypeInjuryRisk 疲劳与受伤风险提醒的通知类型
+const NotificationTypeInjuryRisk = "injury_risk"
+
+// RiskFlag 一条风险信号及建议
+type RiskFlag struct {
+	Key         string   `json:"key"` // 信号类型 + 对象，用于去重提醒
+	Type        string   `json:"type"`
+	Severity    string   `json:"severity"` // moderate, high
+	Exercise    string   `json:"exercise,omitempty"`
+	MuscleGroup string   `json:"muscle_group,omitempty"`
+	Message     string   `json:"message"`
+	Suggestion  string   `json:"suggestion"`
+	Substitutes []string `json:"substitutes,omitempty"` // 可替换的标准动作
+}
+
+// RiskReport 用户的疲劳与受伤风险报告
+type RiskReport struct {
+	UserID      string     `json:"user_id"`
+	Level       string     `json:"level"`
+	AcuteLoad   float64    `json:"acute_load"`   // 最近 7 天训练量（kg）
+	ChronicLoad float64    `json:"chronic_load"` // 最近 28 天平均每周训练量（kg）
+	ACWR        float64    `json:"acwr"`         // 急慢性负荷比，历史不足时为 0
+	Flags       []RiskFlag `json:"flags"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+// RiskAlert 已发送的风险提醒，同一信号在冷却期内不重复提醒
+type RiskAlert struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	UserID         string    `json:"user_id" gorm:"not null;index"`
+	Key            string    `json:"key" gorm:"not null"`
+	Type           string    `json:"type"`
+	Severity       string    `json:"severity"`
+	NotificationID string    `json:"notification_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FeedbackSubmittedEvent 提交动作反馈事件
+type FeedbackSubmittedEvent struct {
+	UserID   string           `json:"user_id"`
+	Feedback ExerciseFeedback `json:"feedback"`
+}
+
+// WorkoutEndedEvent 结束训练事件
+type WorkoutEndedEvent struct {
+	UserID string        `json:"user_id"`
+	Record WorkoutRecord `json:"record"`
+}
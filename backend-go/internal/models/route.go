@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// AppendRoutePointsRequest 训练中分批上传的 GPS 轨迹点，重复时间的点以后上传的为准
+type AppendRoutePointsRequest struct {
+	RecordID string       `json:"record_id" binding:"required"`
+	SetID    string       `json:"set_id"` // 轨迹对应的有氧组，为空时沿用该训练最近的轨迹，没有时自动选择第一个未完成的有氧组
+	Points   []TrackPoint `json:"points" binding:"required,min=1,max=10000"`
+}
+
+// RouteSplit 每公里分段
+type RouteSplit struct {
+	Index         int     `json:"index"`          // 第几公里，从 1 开始
+	Distance      float64 `json:"distance"`       // 公里，最后一段可能不足 1 公里
+	Seconds       int     `json:"seconds"`        // 分段用时
+	Pace          int     `json:"pace"`           // 配速，秒/公里
+	ElevationGain float64 `json:"elevation_gain"` // 米
+}
+
+// WorkoutRoute 一条 GPS 轨迹，对应训练中的一个有氧组
+// 轨迹点按时间排序后编码为 [毫秒差 uvarint][纬度差 varint][经度差 varint][海拔差 varint]，
+// 经纬度精度 1e-6 度，海拔精度 0.1 米，每个点约 6-8 字节
+type WorkoutRoute struct {
+	ID            string       `json:"id" gorm:"primaryKey"`
+	RecordID      string       `json:"record_id" gorm:"not null;index"`
+	UserID        string       `json:"user_id" gorm:"not null"`
+	SetID         string       `json:"set_id"` // 自动填写距离和时长的组，没有时为空
+	StartAt       time.Time    `json:"start_at"`
+	EndAt         time.Time    `json:"end_at"`
+	PointCount    int          `json:"point_count"`
+	Data          []byte       `json:"-"`
+	Distance      float64      `json:"distance"`       // 公里
+	Duration      int          `json:"duration"`       // 秒，不含长时间中断
+	ElevationGain float64      `json:"elevation_gain"` // 米
+	ElevationLoss float64      `json:"elevation_loss"` // 米
+	Splits        []RouteSplit `json:"splits" gorm:"serializer:json"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (WorkoutRoute) TableName() string {
+	return "workout_routes"
+}
+
+// RouteResponse GPS 轨迹及分析
+type RouteResponse struct {
+	ID            string       `json:"id"`
+	RecordID      string       `json:"record_id"`
+	SetID         string       `json:"set_id"`
+	Accepted      int          `json:"accepted,omitempty"` // 本次上传接受的轨迹点数
+	Dropped       int          `json:"dropped,omitempty"`  // 坐标无效、没有时间或不在训练时间内而丢弃的点数
+	Points        int          `json:"points"`
+	StartAt       time.Time    `json:"start_at"`
+	EndAt         time.Time    `json:"end_at"`
+	Distance      float64      `json:"distance"` // 按用户单位制：公里或英里
+	Duration      int          `json:"duration"`
+	Pace          int          `json:"pace"` // 平均配速，秒/公里或秒/英里
+	ElevationGain float64      `json:"elevation_gain"`
+	ElevationLoss float64      `json:"elevation_loss"`
+	Splits        []RouteSplit `json:"splits"`   // 每公里分段，与单位制无关
+	Polyline      string       `json:"polyline"` // Google Encoded Polyline（精度 1e-5），用于绘制地图
+}
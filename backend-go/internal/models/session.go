@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// 训练记录状态
+const (
+	WorkoutStatusInProgress = "in_progress"
+	WorkoutStatusPaused     = "paused"
+	WorkoutStatusCompleted  = "completed"
+)
+
+// 训练卡路里来源
+const (
+	CaloriesSourceMeasured  = "measured"
+	CaloriesSourceHeartRate = "heart_rate"
+	CaloriesSourceEstimated = "estimated"
+)
+
+// WorkoutPause 训练暂停区间，EndedAt 为空表示仍在暂停中
+type WorkoutPause struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	RecordID  string     `json:"record_id" gorm:"not null;index"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// WorkoutActionRequest 暂停/恢复训练请求
+type WorkoutActionRequest struct {
+	RecordID string     `json:"record_id" binding:"required"`
+	At       *time.Time `json:"at"` // 离线记录时的操作时间，为空时使用服务端当前时间
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 离线同步的实体类型
+const (
+	SyncEntityPlan    = "plan"
+	SyncEntitySet     = "set"
+	SyncEntityWorkout = "workout"
+	SyncEntityCheckIn = "checkin"
+)
+
+// 离线同步的操作
+const (
+	SyncOpCreate   = "create"   // plan: CreatePlanRequest; checkin: CreateCheckInRequest
+	SyncOpUpdate   = "update"   // plan: UpdatePlanRequest
+	SyncOpDelete   = "delete"   // plan
+	SyncOpComplete = "complete" // set: CompleteExerciseRequest
+	SyncOpStart    = "start"    // workout: StartWorkoutRequest
+	SyncOpPause    = "pause"    // workout
+	SyncOpResume   = "resume"   // workout
+	SyncOpEnd      = "end"      // workout: EndWorkoutRequest
+)
+
+// 离线变更的处理结果
+const (
+	SyncStatusApplied  = "applied"  // 已应用
+	SyncStatusConflict = "conflict" // 与服务端数据冲突，保留服务端版本，客户端以变更流为准
+	SyncStatusRejected = "rejected" // 数据校验失败，重试也不会成功，客户端应丢弃
+	SyncStatusFailed   = "failed"   // 服务端错误，客户端可原样重试
+)
+
+// SyncMutation 客户端离线期间产生的一条变更
+type SyncMutation struct {
+	ClientID  string          `json:"client_id" binding:"required"` // 客户端生成的唯一ID，用于幂等
+	Entity    string          `json:"entity" binding:"required"`
+	Op        string          `json:"op" binding:"required"`
+	EntityID  string          `json:"entity_id"`                    // 更新、删除计划或暂停、恢复、结束训练时的对象ID
+	Timestamp time.Time       `json:"timestamp" binding:"required"` // 客户端产生变更的时间
+	Data      json.RawMessage `json:"data"`
+}
+
+// SyncRequest 离线同步请求
+type SyncRequest struct {
+	Cursor    string         `json:"cursor"` // 上次同步返回的游标，为空时返回全部数据
+	Mutations []SyncMutation `json:"mutations" binding:"dive"`
+}
+
+// SyncResult 一条变更的处理结果
+type SyncResult struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	EntityID string `json:"entity_id,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Replayed bool   `json:"replayed,omitempty"` // 重复提交，返回首次处理的结果
+}
+
+// SyncChange 服务端变更流中的一项，Deleted 为 true 时 Data 为空
+type SyncChange struct {
+	Entity    string      `json:"entity"`
+	EntityID  string      `json:"entity_id"`
+	Deleted   bool        `json:"deleted"`
+	Data      interface{} `json:"data,omitempty"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// SyncResponse 离线同步响应
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+	Changes []SyncChange `json:"changes"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"` // 变更未取完，客户端应使用新游标继续同步
+}
+
+// SyncMutationLog 已处理的离线变更，同一客户端ID只处理一次
+type SyncMutationLog struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_sync_mutation_client"`
+	ClientID  string    `json:"client_id" gorm:"not null;uniqueIndex:idx_sync_mutation_client"`
+	Entity    string    `json:"entity"`
+	Op        string    `json:"op"`
+	EntityID  string    `json:"entity_id"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SyncTombstone 已删除的实体，用于在变更流中通知其他设备
+type SyncTombstone struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"not null;index"`
+	Entity    string    `json:"entity"`
+	EntityID  string    `json:"entity_id"`
+	DeletedAt time.Time `json:"deleted_at" gorm:"index"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// 训练计划模板可见性
+const (
+	PlanVisibilityPrivate = "private"
+	PlanVisibilityPublic  = "public"
+)
+
+// 模板广场排序方式
+const (
+	GallerySortPopular = "popular" // 按派生次数
+	GallerySortRating  = "rating"  // 按评分
+	GallerySortRecent  = "recent"  // 按发布时间
+)
+
+// TrainingPlanShare 将模板分享给搭子，被分享者可以查看、克隆和评价该模板
+type TrainingPlanShare struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	TemplateID  string    `json:"template_id" gorm:"not null;index"`
+	OwnerID     string    `json:"owner_id" gorm:"not null"`
+	RecipientID string    `json:"recipient_id" gorm:"not null;index"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SaveTemplateRequest 将训练计划保存为模板
+type SaveTemplateRequest struct {
+	PlanID      string `json:"plan_id" binding:"required"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ShareTemplateRequest 分享模板给搭子
+type ShareTemplateRequest struct {
+	TemplateID string `json:"template_id" binding:"required"`
+	BuddyID    string `json:"buddy_id" binding:"required"`
+	Message    string `json:"message"`
+}
+
+// PublishTemplateRequest 发布模板到模板广场（Public 为 false 时撤回）
+type PublishTemplateRequest struct {
+	TemplateID string `json:"template_id" binding:"required"`
+	Public     bool   `json:"public"`
+}
+
+// ForkTemplateRequest 将他人的模板派生到自己的模板库
+type ForkTemplateRequest struct {
+	TemplateID string `json:"template_id" binding:"required"`
+	Name       string `json:"name"`
+}
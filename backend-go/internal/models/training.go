@@ -17,43 +17,64 @@ type TrainingPlanResponse struct {
 	Status        string                     `json:"status"`
 	IsAIGenerated bool                       `json:"is_ai_generated"`
 	AIReason      string                     `json:"ai_reason"`
+	ProgramID     string                     `json:"program_id,omitempty"`
+	ProgramWeek   int                        `json:"program_week,omitempty"`
+	Groups        []ExerciseGroupResponse    `json:"groups,omitempty"`
 	CreatedAt     time.Time                  `json:"created_at"`
 	UpdatedAt     time.Time                  `json:"updated_at"`
 	User          User                       `json:"user"`
+
+	// AI 生成来源
+	AISource           string   `json:"ai_source,omitempty"`
+	AIProvider         string   `json:"ai_provider,omitempty"`
+	AIModel            string   `json:"ai_model,omitempty"`
+	AIAttempts         int      `json:"ai_attempts,omitempty"`
+	AIValidationErrors []string `json:"ai_validation_errors,omitempty"`
 }
 
 // TrainingExerciseResponse 训练动作响应
 type TrainingExerciseResponse struct {
-	ID           string                `json:"id"`
-	PlanID       string                `json:"plan_id"`
-	Name         string                `json:"name"`
-	Description  string                `json:"description"`
-	Category     string                `json:"category"`
-	Difficulty   string                `json:"difficulty"`
-	MuscleGroups []string              `json:"muscle_groups"`
-	Equipment    []string              `json:"equipment"`
-	Sets         []ExerciseSetResponse `json:"sets"`
-	VideoURL     string                `json:"video_url"`
-	ImageURL     string                `json:"image_url"`
-	Instructions string                `json:"instructions"`
-	Order        int                   `json:"order"`
-	CreatedAt    time.Time             `json:"created_at"`
-	UpdatedAt    time.Time             `json:"updated_at"`
+	ID              string                `json:"id"`
+	PlanID          string                `json:"plan_id"`
+	CatalogID       uint                  `json:"catalog_id,omitempty"`
+	Name            string                `json:"name"`
+	Description     string                `json:"description"`
+	Category        string                `json:"category"`
+	Difficulty      string                `json:"difficulty"`
+	MuscleGroups    []string              `json:"muscle_groups"`
+	Equipment       []string              `json:"equipment"`
+	Sets            []ExerciseSetResponse `json:"sets"`
+	VideoURL        string                `json:"video_url"`
+	ImageURL        string                `json:"image_url"`
+	Instructions    string                `json:"instructions"`
+	Order           int                   `json:"order"`
+	GroupKey        string                `json:"group_key,omitempty"`
+	GroupType       string                `json:"group_type,omitempty"`
+	GroupRounds     int                   `json:"group_rounds,omitempty"`
+	SubstitutedWith string                `json:"substituted_with,omitempty"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
 }
 
 // ExerciseSetResponse 动作组数响应
 type ExerciseSetResponse struct {
-	ID         string    `json:"id"`
-	ExerciseID string    `json:"exercise_id"`
-	Reps       int       `json:"reps"`
-	Weight     float64   `json:"weight"`
-	Duration   int       `json:"duration"`
-	Distance   float64   `json:"distance"`
-	RestTime   int       `json:"rest_time"`
-	Completed  bool      `json:"completed"`
-	Order      int       `json:"order"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+	ExerciseID     string    `json:"exercise_id"`
+	Reps           int       `json:"reps"`
+	Weight         float64   `json:"weight"`
+	Duration       int       `json:"duration"`
+	Distance       float64   `json:"distance"`
+	RestTime       int       `json:"rest_time"`
+	Completed      bool      `json:"completed"`
+	Order          int       `json:"order"`
+	SetType        string    `json:"set_type"`
+	TargetReps     int       `json:"target_reps,omitempty"`
+	TargetWeight   float64   `json:"target_weight,omitempty"`
+	TargetDuration int       `json:"target_duration,omitempty"`
+	TargetDistance float64   `json:"target_distance,omitempty"`
+	Extra          bool      `json:"extra,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // TrainingStats 训练统计
@@ -0,0 +1,41 @@
+package models
+
+// 单位制，数据库中始终以公制（kg、cm、km）保存
+const (
+	UnitSystemMetric   = "metric"   // kg、cm、km、g
+	UnitSystemImperial = "imperial" // lb、in、mi、oz
+)
+
+// UnitLabels 当前单位制下各物理量的单位
+type UnitLabels struct {
+	System   string `json:"system"`
+	Weight   string `json:"weight"`
+	Height   string `json:"height"`
+	Distance string `json:"distance"`
+	Food     string `json:"food"` // 食物重量
+}
+
+// PlateLoadingRequest 杠铃配片请求，重量均按用户的单位制
+type PlateLoadingRequest struct {
+	Target    float64   `form:"target" binding:"required,gt=0"` // 目标总重量（含杠铃杆）
+	BarWeight float64   `form:"bar" binding:"min=0"`            // 杠铃杆重量，为空时公制 20、英制 45
+	Plates    []float64 `form:"plate" binding:"dive,gt=0"`      // 可用的杠铃片规格（每种不限数量），为空时使用常见规格
+}
+
+// PlateCount 每侧某一规格杠铃片的数量
+type PlateCount struct {
+	Weight float64 `json:"weight"`
+	Count  int     `json:"count"`
+}
+
+// PlateLoadingResponse 杠铃配片结果
+// 无法精确凑出目标重量时取不超过目标的最接近重量
+type PlateLoadingResponse struct {
+	Unit      string       `json:"unit"`
+	Target    float64      `json:"target"`
+	BarWeight float64      `json:"bar_weight"`
+	PerSide   []PlateCount `json:"per_side"` // 每侧的杠铃片，按规格从大到小
+	Total     float64      `json:"total"`    // 实际装载的总重量
+	Exact     bool         `json:"exact"`
+	Remainder float64      `json:"remainder"` // 目标与实际重量的差
+}
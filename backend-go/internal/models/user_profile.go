@@ -40,7 +40,9 @@ type UserSettings struct {
 	NotificationSMS   bool      `json:"notification_sms"`
 	Language          string    `json:"language"`
 	Timezone          string    `json:"timezone"`
-	Theme             string    `json:"theme"` // light, dark, auto
+	Theme             string    `json:"theme"`          // light, dark, auto
+	UnitSystem        string    `json:"unit_system"`    // metric, imperial，为空时按 metric
+	MaxHeartRate      int       `json:"max_heart_rate"` // 用户填写的最大心率，为空时按年龄估算
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 
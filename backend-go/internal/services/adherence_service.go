@@ -0,0 +1,352 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultAdherenceWeeks 完成度报告默认统计的周数，同时用于 UserStats 中的完成度
+	defaultAdherenceWeeks = 4
+	// maxAdherenceWeeks 完成度报告最多统计的周数
+	maxAdherenceWeeks = 52
+
+	// 完成度各部分的权重：组数完成率、次数完成度、重量完成度
+	adherenceSetWeight  = 0.5
+	adherenceRepsWeight = 0.25
+	adherenceLoadWeight = 0.25
+	// substituteCredit 替换动作完成的组按该比例计入组数完成率
+	substituteCredit = 0.5
+	// skippedExerciseThreshold 统计周期内跳过该次数及以上的动作会在报告中列出
+	skippedExerciseThreshold = 2
+)
+
+// AdherenceService 计划完成度服务
+// 比较计划中每组的目标（次数/时长/距离、重量）与实际完成情况，在训练结束时为训练记录打分，并按周汇总到 UserStats
+type AdherenceService struct {
+	db            *gorm.DB
+	streakService *StreakService
+	refreshes     *userTasks
+}
+
+// NewAdherenceService 创建计划完成度服务
+func NewAdherenceService(db *gorm.DB, streakService *StreakService) *AdherenceService {
+	s := &AdherenceService{db: db, streakService: streakService}
+	s.refreshes = newUserTasks("adherence_refresh", s.RefreshStats)
+	return s
+}
+
+// ApplyWorkoutAdherence 为结束的训练计算计划完成度，没有关联计划的训练不计分
+func (s *AdherenceService) ApplyWorkoutAdherence(record *models.WorkoutRecord) {
+	if record.PlanID == "" {
+		return
+	}
+
+	var exercises []models.TrainingExercise
+	err := s.db.Where("plan_id = ?", record.PlanID).
+		Preload("Sets", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+		Find(&exercises).Error
+	if err != nil {
+		logger.Error.Printf("查询训练动作失败: plan_id=%v, error=%v", record.PlanID, err.Error())
+		return
+	}
+
+	adherence := scoreSession(exercises)
+	record.Adherence = adherence
+	record.AdherenceScore = adherence.Score
+}
+
+// Report 获取最近若干周的计划完成度
+func (s *AdherenceService) Report(userID string, weeks int) (*models.AdherenceReport, error) {
+	if weeks <= 0 {
+		weeks = defaultAdherenceWeeks
+	}
+	weeks = min(weeks, maxAdherenceWeeks)
+
+	now := time.Now()
+	loc := s.streakService.UserLocation(userID)
+	starts := weekStarts(now, loc, weeks)
+	from := starts[0]
+
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND is_template = ? AND date >= ? AND date <= ?", userID, false, from, now).
+		Order("date ASC").
+		Find(&plans).Error
+	if err != nil {
+		logger.Error.Printf("查询训练计划失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	var records []models.WorkoutRecord
+	err = s.db.Where("user_id = ? AND status = ? AND plan_id <> '' AND start_time >= ?", userID, models.WorkoutStatusCompleted, from).
+		Order("start_time ASC").
+		Find(&records).Error
+	if err != nil {
+		logger.Error.Printf("查询训练记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return aggregateAdherence(plans, records, starts, now, loc), nil
+}
+
+// RefreshStats 重新计算并写回 UserStats 中的本周和最近 4 周完成度
+func (s *AdherenceService) RefreshStats(userID string) {
+	report, err := s.Report(userID, defaultAdherenceWeeks)
+	if err != nil {
+		return
+	}
+	weekly := 0.0
+	if len(report.Weeks) > 0 {
+		weekly = report.Weeks[len(report.Weeks)-1].Score
+	}
+
+	err = s.db.Model(&models.UserStats{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"adherence_score": report.Score, "weekly_adherence": weekly}).Error
+	if err != nil {
+		logger.Error.Printf("更新计划完成度失败: user_id=%v, error=%v", userID, err.Error())
+	}
+}
+
+// ScheduleRefresh 在后台刷新 UserStats 中的完成度，不阻塞结束训练的请求
+func (s *AdherenceService) ScheduleRefresh(userID string) {
+	s.refreshes.schedule(userID)
+}
+
+// scoreSession 计算一次训练的计划完成度
+// 热身组和计划外追加的组不计入目标；完成度 = 组数完成率 50% + 次数完成度 25% + 重量完成度 25%，
+// 计划中没有目标重量时按前两项折算。替换了的动作只按组数计分，且完成的组按一半计入；全部组完成的替换动作也算作完成
+func scoreSession(exercises []models.TrainingExercise) *models.SessionAdherence {
+	adherence := &models.SessionAdherence{
+		ExercisesSkipped:     []string{},
+		ExercisesSubstituted: []string{},
+	}
+
+	credited, repsHit, loadHit := 0.0, 0.0, 0.0
+	loadSets := 0
+	for _, i := range exerciseSequence(exercises) {
+		exercise := exercises[i]
+		substituted := exercise.SubstitutedWith != ""
+
+		planned, completed := 0, 0
+		for _, set := range exercise.Sets {
+			if set.Extra {
+				if set.Completed {
+					adherence.ExtraSets++
+				}
+				continue
+			}
+			if !countsTowardVolume(set) {
+				continue
+			}
+			planned++
+
+			target := setTarget(set)
+			if target.Weight > 0 && !substituted {
+				loadSets++
+			}
+			if !set.Completed {
+				continue
+			}
+			completed++
+
+			if substituted {
+				credited += substituteCredit
+				continue
+			}
+			credited++
+			repsHit += workHit(set, target)
+			if target.Weight > 0 {
+				loadHit += math.Min(set.Weight/target.Weight, 1)
+			}
+		}
+		if planned == 0 {
+			continue
+		}
+
+		adherence.ExercisesPlanned++
+		adherence.SetsPlanned += planned
+		adherence.SetsCompleted += completed
+		if substituted {
+			adherence.ExercisesSubstituted = append(adherence.ExercisesSubstituted, exercise.Name)
+		}
+		switch {
+		case completed == 0 && !substituted:
+			adherence.ExercisesSkipped = append(adherence.ExercisesSkipped, exercise.Name)
+		case completed == planned:
+			adherence.ExercisesCompleted++
+		}
+	}
+
+	if adherence.SetsPlanned == 0 {
+		return adherence
+	}
+	setRatio := credited / float64(adherence.SetsPlanned)
+	adherence.RepsHit = roundRatio(repsHit / float64(adherence.SetsPlanned))
+
+	score := adherenceSetWeight*setRatio + adherenceRepsWeight*adherence.RepsHit
+	weights := adherenceSetWeight + adherenceRepsWeight
+	if loadSets > 0 {
+		adherence.LoadHit = roundRatio(loadHit / float64(loadSets))
+		score += adherenceLoadWeight * adherence.LoadHit
+		weights += adherenceLoadWeight
+	}
+	adherence.Score = math.Round(score/weights*1000) / 10
+	return adherence
+}
+
+// setTarget 组的计划目标：已记录目标时取目标，未完成的组计划值仍保存在 Reps 等字段中；
+// 记录目标之前完成的历史数据无法比较，返回空目标，视为达到目标
+func setTarget(set models.ExerciseSet) models.ExerciseSet {
+	switch {
+	case hasTarget(set):
+		return models.ExerciseSet{
+			Reps:     set.TargetReps,
+			Weight:   set.TargetWeight,
+			Duration: set.TargetDuration,
+			Distance: set.TargetDistance,
+			SetType:  set.SetType,
+		}
+	case !set.Completed:
+		return set
+	default:
+		return models.ExerciseSet{SetType: set.SetType}
+	}
+}
+
+// hasTarget 组是否已记录计划目标
+func hasTarget(set models.ExerciseSet) bool {
+	return set.TargetReps > 0 || set.TargetWeight > 0 || set.TargetDuration > 0 || set.TargetDistance > 0
+}
+
+// captureTarget 首次完成一组前把计划值保存为目标
+func captureTarget(set *models.ExerciseSet) {
+	if hasTarget(*set) {
+		return
+	}
+	set.TargetReps = set.Reps
+	set.TargetWeight = set.Weight
+	set.TargetDuration = set.Duration
+	set.TargetDistance = set.Distance
+}
+
+// workHit 完成的组相对目标的完成度：计时组按时长，有距离目标时按距离，否则按次数；没有目标时视为完成
+func workHit(set, target models.ExerciseSet) float64 {
+	switch {
+	case setTypeOf(set) == models.SetTypeTimed && target.Duration > 0:
+		return math.Min(float64(set.Duration)/float64(target.Duration), 1)
+	case target.Distance > 0:
+		return math.Min(set.Distance/target.Distance, 1)
+	case target.Reps > 0:
+		return math.Min(float64(set.Reps)/float64(target.Reps), 1)
+	case target.Duration > 0:
+		return math.Min(float64(set.Duration)/float64(target.Duration), 1)
+	default:
+		return 1
+	}
+}
+
+// aggregateAdherence 按周汇总计划完成度
+// 每个计划取完成度最高的一次训练；已过期但没有训练的计划按 0 分计入，今天及以后的计划未训练时不计入
+func aggregateAdherence(plans []models.TrainingPlan, records []models.WorkoutRecord, starts []time.Time, now time.Time, loc *time.Location) *models.AdherenceReport {
+	report := &models.AdherenceReport{
+		Weeks:            make([]models.WeeklyAdherence, len(starts)),
+		Sessions:         []models.SessionAdherenceItem{},
+		SkippedExercises: []string{},
+	}
+	for i, start := range starts {
+		report.Weeks[i].Week = start.Format(dayLayout)
+	}
+
+	best := make(map[string]models.WorkoutRecord)
+	for _, record := range records {
+		if record.Adherence == nil {
+			continue
+		}
+		if current, ok := best[record.PlanID]; !ok || record.AdherenceScore > current.AdherenceScore {
+			best[record.PlanID] = record
+		}
+	}
+
+	today := localDay(now, loc)
+	weekIndex := make(map[string]int, len(starts))
+	for i, start := range starts {
+		weekIndex[start.Format(dayLayout)] = i
+	}
+	scores := make([]float64, len(starts))
+	total, counted := 0.0, 0
+	skipped := make(map[string]int)
+	for _, plan := range plans {
+		i, ok := weekIndex[weekStart(plan.Date, loc).Format(dayLayout)]
+		if !ok {
+			continue
+		}
+		week := &report.Weeks[i]
+
+		record, done := best[plan.ID]
+		if !done {
+			if !localDay(plan.Date, loc).Before(today) {
+				continue
+			}
+			week.SessionsPlanned++
+			week.SessionsSkipped++
+			counted++
+			continue
+		}
+
+		week.SessionsPlanned++
+		week.SessionsCompleted++
+		week.SetsPlanned += record.Adherence.SetsPlanned
+		week.SetsCompleted += record.Adherence.SetsCompleted
+		scores[i] += record.AdherenceScore
+		total += record.AdherenceScore
+		counted++
+		for _, name := range record.Adherence.ExercisesSkipped {
+			skipped[name]++
+		}
+		report.Sessions = append(report.Sessions, models.SessionAdherenceItem{
+			RecordID:  record.ID,
+			PlanID:    plan.ID,
+			PlanName:  plan.Name,
+			StartTime: record.StartTime,
+			Adherence: *record.Adherence,
+		})
+	}
+
+	for i := range report.Weeks {
+		if report.Weeks[i].SessionsPlanned > 0 {
+			report.Weeks[i].Score = math.Round(scores[i]/float64(report.Weeks[i].SessionsPlanned)*10) / 10
+		}
+	}
+	if counted > 0 {
+		report.Score = math.Round(total/float64(counted)*10) / 10
+	}
+
+	for name, count := range skipped {
+		if count >= skippedExerciseThreshold {
+			report.SkippedExercises = append(report.SkippedExercises, name)
+		}
+	}
+	sort.Slice(report.SkippedExercises, func(i, j int) bool {
+		a, b := report.SkippedExercises[i], report.SkippedExercises[j]
+		if skipped[a] != skipped[b] {
+			return skipped[a] > skipped[b]
+		}
+		return a < b
+	})
+	sort.SliceStable(report.Sessions, func(i, j int) bool {
+		return report.Sessions[i].StartTime.Before(report.Sessions[j].StartTime)
+	})
+	return report
+}
+
+// roundRatio 比例保留三位小数
+func roundRatio(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreSessionFullyCompleted(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "卧推", Order: 1, Sets: []models.ExerciseSet{
+			{SetType: models.SetTypeWarmup, Reps: 10, Weight: 40, Completed: true},
+			{Reps: 5, Weight: 80, TargetReps: 5, TargetWeight: 80, Completed: true},
+			{Reps: 6, Weight: 85, TargetReps: 5, TargetWeight: 80, Completed: true},
+		}},
+		{Name: "平板支撑", Order: 2, Sets: []models.ExerciseSet{
+			{SetType: models.SetTypeTimed, Duration: 60, TargetDuration: 60, Completed: true},
+		}},
+	}
+
+	adherence := scoreSession(exercises)
+
+	assert.Equal(t, 100.0, adherence.Score)
+	assert.Equal(t, 3, adherence.SetsPlanned)
+	assert.Equal(t, 3, adherence.SetsCompleted)
+	assert.Equal(t, 2, adherence.ExercisesPlanned)
+	assert.Equal(t, 2, adherence.ExercisesCompleted)
+	assert.Empty(t, adherence.ExercisesSkipped)
+}
+
+func TestScoreSessionPartial(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "深蹲", Order: 1, Sets: []models.ExerciseSet{
+			{Reps: 4, Weight: 90, TargetReps: 5, TargetWeight: 100, Completed: true},
+			{Reps: 5, Weight: 100, Completed: false},
+		}},
+		{Name: "腿举", Order: 2, Sets: []models.ExerciseSet{
+			{Reps: 12, Weight: 150},
+			{Reps: 12, Weight: 150},
+		}},
+		{Name: "腿弯举", Order: 3, Sets: []models.ExerciseSet{
+			{Reps: 12, Weight: 30, Extra: true, Completed: true},
+		}},
+	}
+
+	adherence := scoreSession(exercises)
+
+	// 组数 1/4，次数 0.8/4，重量 0.9/4
+	assert.Equal(t, 4, adherence.SetsPlanned)
+	assert.Equal(t, 1, adherence.SetsCompleted)
+	assert.Equal(t, 1, adherence.ExtraSets)
+	assert.Equal(t, 0.2, adherence.RepsHit)
+	assert.Equal(t, 0.225, adherence.LoadHit)
+	assert.Equal(t, 23.1, adherence.Score)
+	assert.Equal(t, 2, adherence.ExercisesPlanned)
+	assert.Equal(t, 0, adherence.ExercisesCompleted)
+	assert.Equal(t, []string{"腿举"}, adherence.ExercisesSkipped)
+}
+
+func TestScoreSessionSubstitutedAndBodyweight(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "引体向上", Order: 1, SubstitutedWith: "高位下拉", Sets: []models.ExerciseSet{
+			{Reps: 10, Weight: 50, TargetReps: 8, Completed: true},
+			{Reps: 10, Weight: 50, TargetReps: 8, Completed: true},
+		}},
+		{Name: "俯卧撑", Order: 2, Sets: []models.ExerciseSet{
+			{Reps: 15, TargetReps: 20, Completed: true},
+			{Reps: 20, TargetReps: 20, Completed: true},
+		}},
+	}
+
+	adherence := scoreSession(exercises)
+
+	// 没有目标重量时只按组数（权重 0.5）和次数（权重 0.25）折算
+	assert.Equal(t, []string{"引体向上"}, adherence.ExercisesSubstituted)
+	assert.Equal(t, 2, adherence.ExercisesCompleted, "全部组完成的替换动作算作完成")
+	assert.Equal(t, 0.438, adherence.RepsHit)
+	assert.Equal(t, 64.6, adherence.Score)
+	assert.Zero(t, adherence.LoadHit)
+}
+
+func TestCaptureTarget(t *testing.T) {
+	set := models.ExerciseSet{Reps: 8, Weight: 60}
+	captureTarget(&set)
+	assert.Equal(t, 8, set.TargetReps)
+	assert.Equal(t, 60.0, set.TargetWeight)
+
+	set.Reps, set.Weight = 6, 55
+	captureTarget(&set)
+	assert.Equal(t, 8, set.TargetReps, "已记录的目标不会被实际值覆盖")
+	assert.Equal(t, 60.0, set.TargetWeight)
+}
+
+func TestAggregateAdherence(t *testing.T) {
+	loc := time.UTC
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, loc) // 周三
+	starts := weekStarts(now, loc, 2)
+	plans := []models.TrainingPlan{
+		{ID: "p1", Name: "上肢", Date: time.Date(2024, 3, 4, 0, 0, 0, 0, loc)},
+		{ID: "p2", Name: "下肢", Date: time.Date(2024, 3, 6, 0, 0, 0, 0, loc)},
+		{ID: "p3", Name: "上肢", Date: time.Date(2024, 3, 11, 0, 0, 0, 0, loc)},
+		{ID: "p4", Name: "下肢", Date: time.Date(2024, 3, 12, 0, 0, 0, 0, loc)},
+		{ID: "p5", Name: "全身", Date: time.Date(2024, 3, 13, 0, 0, 0, 0, loc)},
+	}
+	session := func(score float64, skipped ...string) *models.SessionAdherence {
+		return &models.SessionAdherence{Score: score, SetsPlanned: 10, SetsCompleted: int(score / 10), ExercisesSkipped: skipped}
+	}
+	records := []models.WorkoutRecord{
+		{ID: "r1", PlanID: "p1", StartTime: plans[0].Date.Add(18 * time.Hour), AdherenceScore: 60, Adherence: session(60, "硬拉")},
+		{ID: "r2", PlanID: "p1", StartTime: plans[0].Date.Add(20 * time.Hour), AdherenceScore: 80, Adherence: session(80, "硬拉")},
+		{ID: "r3", PlanID: "p3", StartTime: plans[2].Date.Add(18 * time.Hour), AdherenceScore: 90, Adherence: session(90, "硬拉")},
+	}
+
+	report := aggregateAdherence(plans, records, starts, now, loc)
+
+	require.Len(t, report.Weeks, 2)
+	assert.Equal(t, "2024-03-04", report.Weeks[0].Week)
+	assert.Equal(t, 2, report.Weeks[0].SessionsPlanned)
+	assert.Equal(t, 1, report.Weeks[0].SessionsSkipped)
+	assert.Equal(t, 40.0, report.Weeks[0].Score)
+
+	// 今天的计划还没训练，不计入
+	assert.Equal(t, 2, report.Weeks[1].SessionsPlanned)
+	assert.Equal(t, 1, report.Weeks[1].SessionsCompleted)
+	assert.Equal(t, 45.0, report.Weeks[1].Score)
+
+	assert.Equal(t, 42.5, report.Score)
+	require.Len(t, report.Sessions, 2)
+	assert.Equal(t, "r2", report.Sessions[0].RecordID)
+	assert.Equal(t, []string{"硬拉"}, report.SkippedExercises)
+}
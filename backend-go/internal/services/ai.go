@@ -1,67 +1,141 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
 	"gymates/internal/config"
 	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
 )
 
 type AIService struct {
-	config *config.Config
+	config    *config.Config
+	llm       *LLMManager
+	catalog   *ExerciseCatalogService
+	planCache *aiPlanCache
 }
 
-func NewAIService(cfg *config.Config) *AIService {
+// NewAIService 创建AI服务，redisClient 为空时不缓存生成的训练计划
+func NewAIService(cfg *config.Config, catalog *ExerciseCatalogService, redisClient *redis.Client) *AIService {
 	return &AIService{
-		config: cfg,
+		config:    cfg,
+		llm:       NewLLMManagerFromConfig(cfg.AI, cfg.Environment),
+		catalog:   catalog,
+		planCache: newAIPlanCache(redisClient, time.Duration(cfg.AI.PlanCacheTTL)*time.Second),
 	}
 }
 
+// aiPlanMaxAttempts 模型输出未通过校验时最多重新生成的次数，每次生成后先尝试让模型按校验错误修复一次
+const aiPlanMaxAttempts = 2
+
 // GenerateTrainingPlan 生成AI训练计划
-func (s *AIService) GenerateTrainingPlan(req *models.GenerateTrainingPlanRequest) (*models.TrainingPlan, error) {
-	// 构建提示词
-	prompt := s.buildWorkoutPlanPrompt(req)
+// 相同的请求在缓存有效期内直接返回缓存的计划，同时发起的相同请求只生成一次，见 aiPlanCache
+func (s *AIService) GenerateTrainingPlan(ctx context.Context, req *models.GenerateTrainingPlanRequest) (*models.TrainingPlan, error) {
+	return s.planCache.do(ctx, aiPlanFingerprint(req), func(ctx context.Context) (*models.TrainingPlan, error) {
+		return s.generateTrainingPlan(ctx, req)
+	})
+}
 
-	// 调用AI服务
-	response, err := s.callAIService(prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to call AI service: %w", err)
+// generateTrainingPlan 调用模型生成训练计划
+// 模型输出按计划结构校验，未通过时把错误反馈给模型修复；多次仍未通过或模型调用失败时返回模板计划，
+// 没有配置任何提供商时返回 ErrNoLLMProvider。
+// 计划的 AISource 记录来源：model（直接通过）、repaired（修复后通过）、template（模板）
+func (s *AIService) generateTrainingPlan(ctx context.Context, req *models.GenerateTrainingPlanRequest) (*models.TrainingPlan, error) {
+	catalog := s.planCatalog()
+	prompt := s.buildWorkoutPlanPrompt(req, catalog)
+	avoid := aiPlanAvoid(req)
+
+	var (
+		calls    int
+		last     *LLMResponse
+		lastErrs []string
+	)
+	for attempt := 0; attempt < aiPlanMaxAttempts; attempt++ {
+		messages := []LLMMessage{{Role: LLMRoleUser, Content: prompt}}
+		for _, source := range []string{models.AIPlanSourceModel, models.AIPlanSourceRepaired} {
+			if source == models.AIPlanSourceRepaired {
+				messages = append(messages,
+					LLMMessage{Role: LLMRoleAssistant, Content: last.Content},
+					LLMMessage{Role: LLMRoleUser, Content: aiPlanRepairPrompt(lastErrs)},
+				)
+			}
+
+			calls++
+			resp, err := s.callAIService(ctx, messages)
+			if errors.Is(err, ErrNoLLMProvider) {
+				return nil, err
+			}
+			if err != nil {
+				logger.Warn.Printf("AI训练计划生成失败，使用模板计划: error=%v", err.Error())
+				return withAIProvenance(templateWorkoutPlan(req), last, models.AIPlanSourceTemplate, calls, []string{err.Error()}), nil
+			}
+			last = resp
+
+			out, err := decodeAIPlan(resp.Content)
+			if err != nil {
+				lastErrs = []string{err.Error()}
+			} else {
+				lastErrs = validateAIPlan(out, catalog, avoid)
+			}
+			if len(lastErrs) == 0 {
+				return withAIProvenance(buildAIPlan(out), resp, source, calls, nil), nil
+			}
+			logger.Warn.Printf("AI训练计划未通过校验: provider=%v, attempt=%v, source=%v, errors=%v", resp.Provider, attempt+1, source, strings.Join(lastErrs, "; "))
+		}
 	}
 
-	// 解析AI响应并创建训练计划
-	plan, err := s.parseWorkoutPlanResponse(response, req)
+	return withAIProvenance(templateWorkoutPlan(req), last, models.AIPlanSourceTemplate, calls, lastErrs), nil
+}
+
+// planCatalog 校验用的标准动作库，加载失败时不校验动作是否在动作库中
+func (s *AIService) planCatalog() []models.Exercise {
+	if s.catalog == nil {
+		return nil
+	}
+	catalog, err := s.catalog.loadCatalog("")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		logger.Warn.Printf("加载标准动作库失败，训练计划不校验动作名称: error=%v", err.Error())
+		return nil
 	}
+	return catalog
+}
 
-	return plan, nil
+// withAIProvenance 记录计划的来源、最后一次响应的提供商和模型、调用次数以及未通过的校验错误
+func withAIProvenance(plan *models.TrainingPlan, resp *LLMResponse, source string, calls int, errs []string) *models.TrainingPlan {
+	plan.AISource = source
+	plan.AIAttempts = calls
+	plan.AIValidationErrors = errs
+	if resp != nil {
+		plan.AIProvider = resp.Provider
+		plan.AIModel = resp.Model
+	}
+	return plan
 }
 
 // buildWorkoutPlanPrompt 构建训练计划提示词
-func (s *AIService) buildWorkoutPlanPrompt(req *models.GenerateTrainingPlanRequest) string {
+func (s *AIService) buildWorkoutPlanPrompt(req *models.GenerateTrainingPlanRequest, catalog []models.Exercise) string {
 	prompt := fmt.Sprintf(`
-请为我生成一个个性化的健身训练计划，具体要求如下：
+请为我生成今天的个性化健身训练计划，具体要求如下：
 
 目标：%s
-训练周期：%d天
 难度等级：%s
 健身经验：%s
 可用器械：%s
-每日训练时间：%d分钟
-个人偏好：%s
+每次训练时间：%d分钟
+重点部位：%s
 
 请按照以下JSON格式返回训练计划：
 {
   "title": "训练计划标题",
   "description": "计划描述",
   "difficulty": "难度等级",
-  "duration": 训练天数,
+  "duration": 每次训练分钟数,
   "sessions": [
     {
       "day": 1,
@@ -75,6 +149,10 @@ func (s *AIService) buildWorkoutPlanPrompt(req *models.GenerateTrainingPlanReque
           "weight": 重量,
           "duration": 持续时间,
           "rest_time": 休息时间,
+          "set_type": "组类型：warmup/working/drop/amrap/timed，默认 working",
+          "group": "分组标识，可选，相同标识的相邻动作组成超级组/巨型组/循环",
+          "group_type": "分组类型：superset/giant_set/circuit",
+          "rounds": 分组轮数,
           "notes": "注意事项"
         }
       ]
@@ -83,269 +161,115 @@ func (s *AIService) buildWorkoutPlanPrompt(req *models.GenerateTrainingPlanReque
 }
 
 请确保计划科学合理，适合我的水平和目标。
-`, req.Goal, req.Duration, req.Difficulty, "中级", strings.Join(req.Equipment, ","), 60, "力量训练")
+`, req.Goal, req.Difficulty, aiExperienceLevel(req.Context), aiPlanEquipment(req), aiPlanMinutes(req), aiPlanFocus(req))
 
-	return prompt
-}
-
-// callAIService 调用AI服务
-func (s *AIService) callAIService(prompt string) (string, error) {
-	// 优先使用腾讯混元大模型
-	if s.config.AI.TencentSecretID != "" && s.config.AI.TencentSecretKey != "" {
-		return s.callTencentHunyuan(prompt)
+	if summary := summarizeAIPlanContext(req.Context, aiContextTokenBudget); summary != "" {
+		prompt += "\n我的情况：\n" + summary
 	}
 
-	// 备用DeepSeek
-	if s.config.AI.DeepSeekAPIKey != "" {
-		return s.callDeepSeek(prompt)
+	// 最近完成度低时减少训练量，经常跳过的动作换成同部位的其他动作
+	if req.Adherence > 0 {
+		prompt += fmt.Sprintf("\n近 4 周计划完成度：%.0f%%，低于 70%% 时请适当减少动作数量和组数。\n", req.Adherence)
 	}
-
-	// 备用Groq
-	if s.config.AI.GroqAPIKey != "" {
-		return s.callGroq(prompt)
+	if len(req.SkippedExercises) > 0 {
+		prompt += fmt.Sprintf("经常跳过的动作：%s，请换成训练相同部位的其他动作。\n", strings.Join(req.SkippedExercises, "、"))
 	}
 
-	return "", fmt.Errorf("no AI service configured")
-}
-
-// callTencentHunyuan 调用腾讯混元大模型
-func (s *AIService) callTencentHunyuan(prompt string) (string, error) {
-	// 这里需要实现腾讯混元大模型的API调用
-	// 由于需要签名等复杂逻辑，这里返回模拟响应
-	return s.getMockWorkoutPlanResponse(), nil
-}
-
-// callDeepSeek 调用DeepSeek API
-func (s *AIService) callDeepSeek(prompt string) (string, error) {
-	url := "https://api.deepseek.com/v1/chat/completions"
-
-	payload := map[string]interface{}{
-		"model": "deepseek-chat",
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"max_tokens":  2000,
-		"temperature": 0.7,
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	prompt += fmt.Sprintf("\n输出要求：\n- category 只能是：%s\n", strings.Join(aiPlanCategories, "、"))
+	if len(catalog) > 0 {
+		names := make([]string, 0, min(len(catalog), aiPlanPromptNames))
+		for _, exercise := range catalog[:min(len(catalog), aiPlanPromptNames)] {
+			names = append(names, exercise.Name)
+		}
+		prompt += fmt.Sprintf("- name 必须使用动作库中的动作：%s\n", strings.Join(names, "、"))
 	}
+	prompt += fmt.Sprintf("- 每个训练日 1-%d 个动作，每个动作 1-%d 组，reps 0-%d，weight 0-%d 公斤，duration 0-%d 秒，rest_time 0-%d 秒\n",
+		aiPlanMaxExercises, aiPlanMaxSets, aiPlanMaxReps, aiPlanMaxWeight, aiPlanMaxDuration, aiPlanMaxRest)
+	prompt += "- sessions 只包含一个训练日，即今天的训练\n"
+	prompt += "- 只返回 JSON，不要包含其他文字\n"
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.config.AI.DeepSeekAPIKey)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	choices, ok := response["choices"].([]interface{})
-	if !ok || len(choices) == 0 {
-		return "", fmt.Errorf("invalid response format")
-	}
-
-	choice, ok := choices[0].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid choice format")
-	}
-
-	message, ok := choice["message"].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid message format")
-	}
-
-	content, ok := message["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("invalid content format")
-	}
-
-	return content, nil
+	return prompt
 }
 
-// callGroq 调用Groq API
-func (s *AIService) callGroq(prompt string) (string, error) {
-	url := "https://api.groq.com/openai/v1/chat/completions"
-
-	payload := map[string]interface{}{
-		"model": "llama3-8b-8192",
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"max_tokens":  2000,
-		"temperature": 0.7,
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.config.AI.GroqAPIKey)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+// aiPlanEquipment 请求指定的器械，没有指定时使用最近训练用过的器械
+func aiPlanEquipment(req *models.GenerateTrainingPlanRequest) string {
+	equipment := req.Equipment
+	if len(equipment) == 0 && req.Context != nil {
+		equipment = req.Context.Equipment
 	}
-
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	choices, ok := response["choices"].([]interface{})
-	if !ok || len(choices) == 0 {
-		return "", fmt.Errorf("invalid response format")
+	if len(equipment) == 0 {
+		return "不限"
 	}
+	return strings.Join(equipment, ",")
+}
 
-	choice, ok := choices[0].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid choice format")
+// aiPlanMinutes 每次训练时间：按请求的训练时长，未填写时按最近的平均训练时长，都没有时为 60 分钟
+func aiPlanMinutes(req *models.GenerateTrainingPlanRequest) int {
+	if req.Duration > 0 {
+		return req.Duration
 	}
-
-	message, ok := choice["message"].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid message format")
+	if req.Context != nil && req.Context.AvgMinutes > 0 {
+		return req.Context.AvgMinutes
 	}
+	return 60
+}
 
-	content, ok := message["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("invalid content format")
+// aiPlanFocus 重点训练部位
+func aiPlanFocus(req *models.GenerateTrainingPlanRequest) string {
+	if len(req.FocusAreas) == 0 {
+		return "无"
 	}
-
-	return content, nil
+	return strings.Join(req.FocusAreas, ",")
 }
 
-// parseWorkoutPlanResponse 解析AI响应
-func (s *AIService) parseWorkoutPlanResponse(response string, req *models.GenerateTrainingPlanRequest) (*models.TrainingPlan, error) {
-	// 尝试解析JSON响应
-	var planData map[string]interface{}
-	if err := json.Unmarshal([]byte(response), &planData); err != nil {
-		// 如果解析失败，使用模拟数据
-		return s.createMockWorkoutPlan(req), nil
+// aiPlanAvoid 生成的计划中不应包含的动作：最近出现疼痛的动作
+func aiPlanAvoid(req *models.GenerateTrainingPlanRequest) []string {
+	if req.Context == nil {
+		return nil
 	}
-
-	// 创建训练计划
-	plan := &models.TrainingPlan{
-		ID:            fmt.Sprintf("ai_%d", time.Now().Unix()),
-		UserID:        "1",
-		Name:          s.getString(planData, "title", "AI生成训练计划"),
-		Description:   s.getString(planData, "description", ""),
-		IsAIGenerated: true,
-		Date:          time.Now(),
+	names := make([]string, 0, len(req.Context.PainfulExercises))
+	for _, p := range req.Context.PainfulExercises {
+		names = append(names, p.Name)
 	}
-
-	return plan, nil
+	return names
 }
 
-// createMockWorkoutPlan 创建模拟训练计划
-func (s *AIService) createMockWorkoutPlan(req *models.GenerateTrainingPlanRequest) *models.TrainingPlan {
-	return &models.TrainingPlan{
-		ID:            fmt.Sprintf("mock_%d", time.Now().Unix()),
-		UserID:        "1",
-		Name:          fmt.Sprintf("%s - %d天训练计划", req.Goal, req.Duration),
-		Description:   fmt.Sprintf("针对%s目标的%d天训练计划，适合%s水平", req.Goal, req.Duration, req.Difficulty),
-		IsAIGenerated: true,
-		Date:          time.Now(),
-	}
+// callAIService 通过提供商注册表调用大模型
+func (s *AIService) callAIService(ctx context.Context, messages []LLMMessage) (*LLMResponse, error) {
+	return s.llm.Call(ctx, LLMRequest{
+		Messages:    messages,
+		MaxTokens:   2000,
+		Temperature: 0.7,
+	})
 }
 
-// getMockWorkoutPlanResponse 获取模拟训练计划响应
-func (s *AIService) getMockWorkoutPlanResponse() string {
-	return `{
-		"title": "减脂塑形 - 30天训练计划",
-		"description": "针对减脂塑形目标的30天训练计划，适合中级水平",
-		"difficulty": "中级",
-		"duration": 30,
-		"sessions": [
-			{
-				"day": 1,
-				"title": "全身力量训练",
-				"exercises": [
-					{
-						"name": "深蹲",
-						"category": "下肢",
-						"sets": 3,
-						"reps": 15,
-						"weight": 0,
-						"duration": 0,
-						"rest_time": 60,
-						"notes": "保持背部挺直，膝盖不超过脚尖"
-					},
-					{
-						"name": "俯卧撑",
-						"category": "上肢",
-						"sets": 3,
-						"reps": 12,
-						"weight": 0,
-						"duration": 0,
-						"rest_time": 60,
-						"notes": "保持身体成一条直线"
-					}
-				]
-			}
-		]
-	}`
+// ProviderHealth AI 服务提供商的健康状态
+func (s *AIService) ProviderHealth() []models.AIProviderHealth {
+	return s.llm.Health()
 }
 
-// 辅助方法
-func (s *AIService) getString(data map[string]interface{}, key, defaultValue string) string {
-	if value, ok := data[key].(string); ok {
-		return value
-	}
-	return defaultValue
+// PlanCacheStats AI 训练计划缓存的命中统计
+func (s *AIService) PlanCacheStats() models.AIPlanCacheStats {
+	return s.planCache.snapshot()
 }
 
-func (s *AIService) getInt(data map[string]interface{}, key string, defaultValue int) int {
-	if value, ok := data[key].(float64); ok {
-		return int(value)
+// sanitizePlanStructure AI 返回的组类型或分组不合法时退化为普通组和不分组，不因此放弃整个计划
+func sanitizePlanStructure(exercises []models.TrainingExercise) {
+	for i := range exercises {
+		sets := exercises[i].Sets
+		if err := validateSets(exercises[i].Name, sets); err != nil {
+			for j := range sets {
+				sets[j].SetType = models.SetTypeWorking
+			}
+		}
+	}
+	if err := resolveGroups(exercises); err != nil {
+		for i := range exercises {
+			exercises[i].GroupKey = ""
+			exercises[i].GroupType = ""
+			exercises[i].GroupRounds = 0
+		}
 	}
-	return defaultValue
 }
 
 // WorkoutPlanRequest 训练计划请求结构
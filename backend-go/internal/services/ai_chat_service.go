@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// aiChatHistoryLimit 每次回复最多带上的历史消息条数，超出模型上下文窗口的部分再由 LLMManager 裁剪
+	aiChatHistoryLimit = 50
+	// aiChatTitleLength 自动生成的对话标题最多字符数
+	aiChatTitleLength = 20
+	// aiChatMaxTokens 每次回复的最大 token 数
+	aiChatMaxTokens = 1000
+	// aiChatMaxToolRounds 每次回复最多调用工具的轮数，超过后不再提供工具，要求模型直接回答
+	aiChatMaxToolRounds = 3
+)
+
+// aiCoachSystemPrompt AI 教练的角色设定
+const aiCoachSystemPrompt = "你是 Gymates 的 AI 健身教练，根据用户的问题给出安全、具体、可执行的训练和饮食建议。" +
+	"回答使用中文，简洁明了；涉及伤病或疼痛时提醒用户停止训练并咨询医生。" +
+	"需要用户的训练、计划、饮食或身体数据时调用工具查询，不要编造数据；调整计划等修改数据的操作要等用户确认后才会执行。"
+
+var (
+	// ErrConversationNotFound 对话不存在或不属于当前用户
+	ErrConversationNotFound = errors.New("对话不存在")
+	// ErrAIChatUnavailable 所有提供商都无法回复
+	ErrAIChatUnavailable = errors.New("AI教练暂时无法回复，请稍后重试")
+)
+
+// AIChatService AI 教练对话服务
+// 对话和消息持久化保存，回复时带上最近的历史消息，并按提供商流式返回；模型可以调用 AIToolService 中的工具查询用户数据
+type AIChatService struct {
+	db    *gorm.DB
+	llm   *LLMManager
+	tools *AIToolService
+}
+
+// NewAIChatService 创建 AI 教练对话服务
+func NewAIChatService(db *gorm.DB, aiService *AIService, tools *AIToolService) *AIChatService {
+	return &AIChatService{db: db, llm: aiService.llm, tools: tools}
+}
+
+// ListConversations 获取用户的对话列表，最近有消息的在前
+func (s *AIChatService) ListConversations(userID string, skip, limit int) ([]models.AIConversation, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	conversations := []models.AIConversation{}
+	if err := s.db.Where("user_id = ?", userID).
+		Order("last_message_at DESC").
+		Offset(skip).Limit(limit).
+		Find(&conversations).Error; err != nil {
+		logger.Error.Printf("查询AI对话列表失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// GetConversation 获取对话及全部消息
+func (s *AIChatService) GetConversation(userID, conversationID string) (*models.AIConversation, error) {
+	conversation, err := s.userConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("conversation_id = ?", conversation.ID).
+		Order("created_at ASC").
+		Find(&conversation.Messages).Error; err != nil {
+		logger.Error.Printf("查询AI对话消息失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// RenameConversation 修改对话标题
+func (s *AIChatService) RenameConversation(userID, conversationID, title string) (*models.AIConversation, error) {
+	conversation, err := s.userConversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	conversation.Title = strings.TrimSpace(title)
+	conversation.UpdatedAt = time.Now()
+	if err := s.db.Model(conversation).Updates(map[string]interface{}{
+		"title":      conversation.Title,
+		"updated_at": conversation.UpdatedAt,
+	}).Error; err != nil {
+		logger.Error.Printf("修改AI对话标题失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// DeleteConversation 删除对话及其消息
+func (s *AIChatService) DeleteConversation(userID, conversationID string) error {
+	conversation, err := s.userConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ?", conversation.ID).Delete(&models.AIChatMessage{}).Error; err != nil {
+			logger.Error.Printf("删除AI对话消息失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+			return err
+		}
+		if err := tx.Delete(conversation).Error; err != nil {
+			logger.Error.Printf("删除AI对话失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+			return err
+		}
+		return nil
+	})
+}
+
+// OpenConversation 获取要回复的对话，ConversationID 为空时以消息开头为标题创建新对话
+// 用户消息不在这里保存，回复成功后和回复一起保存
+func (s *AIChatService) OpenConversation(userID string, req models.AIChatRequest) (*models.AIConversation, error) {
+	now := time.Now()
+	var conversation *models.AIConversation
+	if req.ConversationID == "" {
+		conversation = &models.AIConversation{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Title:     conversationTitle(req.Message),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.db.Create(conversation).Error; err != nil {
+			logger.Error.Printf("创建AI对话失败: user_id=%v, error=%v", userID, err.Error())
+			return nil, err
+		}
+	} else {
+		var err error
+		if conversation, err = s.userConversation(userID, req.ConversationID); err != nil {
+			return nil, err
+		}
+	}
+	return conversation, nil
+}
+
+// Reply 根据对话历史回复用户消息，内容片段按到达顺序交给 onDelta，每次工具调用交给 onTool，完成后一起保存用户消息和回复
+// extraContext 为本次请求附加的背景信息，不保存到对话中；生成失败或中途断开时用户消息和回复都不保存，用户可以直接重发
+// 工具调用和结果只在本次回复中使用，不保存为对话消息，调用记录见 AIToolService 的审计记录
+func (s *AIChatService) Reply(ctx context.Context, conversation *models.AIConversation, content, extraContext string, onDelta func(delta string) error, onTool func(invocation *models.AIToolInvocation) error) (*models.AIChatMessage, error) {
+	question := models.AIChatMessage{
+		UserID:    conversation.UserID,
+		Role:      LLMRoleUser,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+
+	var history []models.AIChatMessage
+	if err := s.db.Where("conversation_id = ?", conversation.ID).
+		Order("created_at DESC").
+		Limit(aiChatHistoryLimit).
+		Find(&history).Error; err != nil {
+		logger.Error.Printf("查询AI对话消息失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+		return nil, err
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	history = append(history, question)
+
+	messages := buildChatMessages(history, extraContext, question.CreatedAt)
+	var answer strings.Builder
+	var resp *LLMResponse
+	for round := 0; ; round++ {
+		req := LLMRequest{
+			Messages:    messages,
+			MaxTokens:   aiChatMaxTokens,
+			Temperature: 0.7,
+		}
+		if s.tools != nil && round < aiChatMaxToolRounds {
+			req.Tools = s.tools.Definitions()
+		}
+
+		var err error
+		resp, err = s.llm.Stream(ctx, req, onDelta)
+		if err != nil {
+			logger.Error.Printf("AI教练回复失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, ErrAIChatUnavailable
+		}
+		answer.WriteString(resp.Content)
+		if len(resp.ToolCalls) == 0 || len(req.Tools) == 0 {
+			break
+		}
+
+		messages = append(messages, LLMMessage{Role: LLMRoleAssistant, Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			result, invocation := s.tools.Invoke(ctx, conversation.UserID, conversation.ID, call)
+			messages = append(messages, LLMMessage{Role: LLMRoleTool, Content: result, ToolCallID: call.ID})
+			if onTool != nil {
+				if err := onTool(invocation); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	message := models.AIChatMessage{
+		UserID:   conversation.UserID,
+		Role:     LLMRoleAssistant,
+		Content:  answer.String(),
+		Provider: resp.Provider,
+		Model:    resp.Model,
+	}
+	if err := s.appendMessages(conversation, &question, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ConfirmToolCall 用户确认或拒绝修改数据的工具调用，并把处理结果作为教练回复追加到对话中
+func (s *AIChatService) ConfirmToolCall(ctx context.Context, userID, invocationID string, approve bool) (*models.ConfirmAIToolResponse, error) {
+	invocation, err := s.tools.Confirm(ctx, userID, invocationID, approve)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.ConfirmAIToolResponse{Invocation: invocation}
+	if invocation.ConversationID == "" {
+		return response, nil
+	}
+	conversation, err := s.userConversation(userID, invocation.ConversationID)
+	if err != nil {
+		// 对话已删除时只返回处理结果
+		if errors.Is(err, ErrConversationNotFound) {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	message := models.AIChatMessage{
+		UserID:  userID,
+		Role:    LLMRoleAssistant,
+		Content: toolConfirmationMessage(invocation),
+	}
+	if err := s.appendMessages(conversation, &message); err != nil {
+		return nil, err
+	}
+	response.Message = &message
+	return response, nil
+}
+
+// ListToolInvocations 工具调用记录，conversationID 不为空时只返回该对话的记录
+func (s *AIChatService) ListToolInvocations(userID, conversationID string, skip, limit int) ([]models.AIToolInvocation, error) {
+	if conversationID != "" {
+		if _, err := s.userConversation(userID, conversationID); err != nil {
+			return nil, err
+		}
+	}
+	return s.tools.ListInvocations(userID, conversationID, skip, limit)
+}
+
+// appendMessages 在一个事务中保存消息并更新对话的消息数和最后消息时间
+// 消息未设置时间时使用当前时间
+func (s *AIChatService) appendMessages(conversation *models.AIConversation, messages ...*models.AIChatMessage) error {
+	now := time.Now()
+	for _, message := range messages {
+		message.ID = uuid.New().String()
+		message.ConversationID = conversation.ID
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = now
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, message := range messages {
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.AIConversation{}).Where("id = ?", conversation.ID).Updates(map[string]interface{}{
+			"message_count":   gorm.Expr("message_count + ?", len(messages)),
+			"last_message_at": now,
+			"updated_at":      now,
+		}).Error
+	})
+	if err != nil {
+		logger.Error.Printf("保存AI对话消息失败: conversation_id=%v, error=%v", conversation.ID, err.Error())
+		return err
+	}
+
+	conversation.MessageCount += len(messages)
+	conversation.LastMessageAt = now
+	conversation.UpdatedAt = now
+	return nil
+}
+
+// userConversation 获取属于该用户的对话
+func (s *AIChatService) userConversation(userID, conversationID string) (*models.AIConversation, error) {
+	var conversation models.AIConversation
+	if err := s.db.Where("id = ? AND user_id = ?", conversationID, userID).First(&conversation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConversationNotFound
+		}
+		logger.Error.Printf("查询AI对话失败: conversation_id=%v, error=%v", conversationID, err.Error())
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// buildChatMessages 由角色设定、当前日期、附加背景信息和历史消息组成本次调用的消息
+// 当前日期用于模型理解“明天”“这周五”等说法
+func buildChatMessages(history []models.AIChatMessage, extraContext string, now time.Time) []LLMMessage {
+	system := aiCoachSystemPrompt
+	system += fmt.Sprintf("\n今天是 %s（%s）。", now.Format("2006-01-02"), chineseWeekdays[now.Weekday()])
+	if extraContext = strings.TrimSpace(extraContext); extraContext != "" {
+		system += "\n背景信息：" + extraContext
+	}
+
+	messages := make([]LLMMessage, 0, len(history)+1)
+	messages = append(messages, LLMMessage{Role: LLMRoleSystem, Content: system})
+	for _, message := range history {
+		messages = append(messages, LLMMessage{Role: message.Role, Content: message.Content})
+	}
+	return messages
+}
+
+// chineseWeekdays 星期的中文名称，按 time.Weekday 排列
+var chineseWeekdays = [...]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
+
+// toolConfirmationMessage 用户确认或拒绝工具调用后追加到对话中的回复
+func toolConfirmationMessage(invocation *models.AIToolInvocation) string {
+	switch invocation.Status {
+	case models.AIToolStatusSucceeded:
+		return "已完成：" + invocation.Summary
+	case models.AIToolStatusRejected:
+		return "已取消：" + invocation.Summary
+	default:
+		return "操作失败：" + invocation.Summary + "（" + invocation.Error + "）"
+	}
+}
+
+// conversationTitle 取消息的第一行开头作为对话标题
+func conversationTitle(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	runes := []rune(strings.TrimSpace(line))
+	if len(runes) > aiChatTitleLength {
+		return string(runes[:aiChatTitleLength]) + "…"
+	}
+	if len(runes) == 0 {
+		return "新对话"
+	}
+	return string(runes)
+}
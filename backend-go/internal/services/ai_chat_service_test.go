@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationTitle(t *testing.T) {
+	assert.Equal(t, "深蹲膝盖疼怎么办", conversationTitle("  深蹲膝盖疼怎么办\n昨天练完之后一直疼"))
+	assert.Equal(t, "一二三四五六七八九十一二三四五六七八九十…", conversationTitle("一二三四五六七八九十一二三四五六七八九十多出来的部分"))
+	assert.Equal(t, "新对话", conversationTitle(" \n "))
+}
+
+func TestBuildChatMessages(t *testing.T) {
+	history := []models.AIChatMessage{
+		{Role: LLMRoleUser, Content: "卧推卡在底部怎么办"},
+		{Role: LLMRoleAssistant, Content: "可以加入暂停卧推"},
+		{Role: LLMRoleUser, Content: "每周练几次"},
+	}
+
+	now := time.Date(2024, 6, 13, 9, 0, 0, 0, time.UTC)
+	messages := buildChatMessages(history, "当前页面：训练计划", now)
+	require.Len(t, messages, 4)
+	assert.Equal(t, LLMRoleSystem, messages[0].Role)
+	assert.Contains(t, messages[0].Content, "今天是 2024-06-13（星期四）")
+	assert.Contains(t, messages[0].Content, "背景信息：当前页面：训练计划")
+	assert.Equal(t, LLMMessage{Role: LLMRoleUser, Content: "每周练几次"}, messages[3])
+
+	assert.NotContains(t, buildChatMessages(nil, " ", now)[0].Content, "背景信息")
+}
+
+func TestToolConfirmationMessage(t *testing.T) {
+	invocation := &models.AIToolInvocation{Summary: "把 2024-06-14 的「腿部训练」调整到 2024-06-15"}
+
+	invocation.Status = models.AIToolStatusSucceeded
+	assert.Equal(t, "已完成：把 2024-06-14 的「腿部训练」调整到 2024-06-15", toolConfirmationMessage(invocation))
+	invocation.Status = models.AIToolStatusRejected
+	assert.True(t, strings.HasPrefix(toolConfirmationMessage(invocation), "已取消："))
+	invocation.Status = models.AIToolStatusFailed
+	invocation.Error = "训练计划不存在"
+	assert.True(t, strings.HasSuffix(toolConfirmationMessage(invocation), "（训练计划不存在）"))
+}
+
+func TestStubProviderStreamsChatReply(t *testing.T) {
+	m := newTestLLMManager()
+	m.Register(NewStubProvider(nil), LLMProviderOptions{})
+
+	var deltas []string
+	resp, err := m.Stream(context.Background(), LLMRequest{Messages: buildChatMessages(nil, "", time.Now())}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Greater(t, len(deltas), 1)
+	assert.Equal(t, stubChatResponse, resp.Content)
+}
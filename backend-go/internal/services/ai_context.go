@@ -0,0 +1,293 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+)
+
+const (
+	// aiContextHistoryDays 统计平均训练时长、常用器械和疼痛反馈回看的天数
+	aiContextHistoryDays = 28
+	// aiContextTokenBudget 用户资料和训练历史在提示词中最多占用的 token 数（估算）
+	aiContextTokenBudget = 600
+	// aiContextMaxRecords 提示词中最多列出的个人记录条数
+	aiContextMaxRecords = 20
+)
+
+// loadAIPlanContext 加载生成训练计划参考的用户资料和训练历史，单项查询失败时跳过该项，不影响生成
+func (s *TrainingService) loadAIPlanContext(userID string, now time.Time) *models.AIPlanContext {
+	c := &models.AIPlanContext{}
+
+	var user models.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err == nil {
+		c.Gender = user.Gender
+		c.Height = user.Height
+		c.Weight = user.Weight
+		c.BMI = user.BMI
+		if c.BMI == 0 && user.Height > 0 && user.Weight > 0 {
+			c.BMI = roundTo(user.Weight/math.Pow(user.Height/100, 2), 1)
+		}
+		if !user.Birthday.IsZero() {
+			c.Age = ageAt(user.Birthday, now)
+		}
+	}
+
+	from := now.AddDate(0, 0, -aiContextHistoryDays)
+	var total int64
+	s.db.Model(&models.WorkoutRecord{}).Where("user_id = ? AND status = ?", userID, "completed").Count(&total)
+	c.TotalWorkouts = int(total)
+
+	var records []models.WorkoutRecord
+	if err := s.db.Select("start_time", "duration").
+		Where("user_id = ? AND status = ? AND start_time >= ?", userID, "completed", from).
+		Find(&records).Error; err != nil {
+		logger.Error.Printf("查询训练记录失败: user_id=%v, error=%v", userID, err.Error())
+	}
+	minutes := 0
+	for _, record := range records {
+		minutes += record.Duration
+		if !record.StartTime.Before(now.AddDate(0, 0, -7)) {
+			c.LastWeekWorkouts++
+		}
+	}
+	if len(records) > 0 {
+		c.AvgMinutes = minutes / len(records)
+	}
+
+	if sets, err := s.analyticsService.loadSets(userID, from); err == nil {
+		c.LastWeek = summarizeLastWeek(sets, now)
+		if catalog, err := s.catalogService.loadCatalog(""); err == nil {
+			c.Equipment = recentEquipment(sets, catalog)
+		}
+	}
+
+	var prs []models.PersonalRecord
+	if err := s.db.Where("user_id = ? AND record_type IN ?", userID, []string{models.RecordEstimated1RM, models.RecordMaxWeight}).
+		Order("achieved_at DESC").
+		Find(&prs).Error; err != nil {
+		logger.Error.Printf("查询个人记录失败: user_id=%v, error=%v", userID, err.Error())
+	}
+	c.Records = latestRecords(prs)
+
+	var feedbacks []models.ExerciseFeedback
+	if err := s.db.Where("user_id = ? AND created_at >= ?", userID, from).
+		Preload("Exercise").
+		Order("created_at DESC").
+		Find(&feedbacks).Error; err != nil {
+		logger.Error.Printf("查询动作反馈失败: user_id=%v, error=%v", userID, err.Error())
+	}
+	c.PainfulExercises = painfulExercises(feedbacks)
+
+	return c
+}
+
+// summarizeLastWeek 汇总最近 7 天每个动作的组数和最大重量，按组数从多到少排列
+func summarizeLastWeek(sets []volumeSet, now time.Time) []models.AIExerciseSummary {
+	since := now.AddDate(0, 0, -7)
+	byName := make(map[string]*models.AIExerciseSummary)
+	var summaries []*models.AIExerciseSummary
+	for _, set := range sets {
+		if set.At.Before(since) {
+			continue
+		}
+		summary, ok := byName[set.Exercise]
+		if !ok {
+			summary = &models.AIExerciseSummary{Name: set.Exercise}
+			byName[set.Exercise] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Sets++
+		if set.Weight > summary.Weight || (set.Weight == summary.Weight && set.Reps > summary.Reps) {
+			summary.Weight = set.Weight
+			summary.Reps = set.Reps
+		}
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Sets != summaries[j].Sets {
+			return summaries[i].Sets > summaries[j].Sets
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	result := make([]models.AIExerciseSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	return result
+}
+
+// recentEquipment 最近训练的标准动作用到的器械
+func recentEquipment(sets []volumeSet, catalog []models.Exercise) []string {
+	byID := make(map[uint]models.Exercise, len(catalog))
+	for _, entry := range catalog {
+		byID[entry.ID] = entry
+	}
+	seen := make(map[string]bool)
+	var equipment []string
+	for _, set := range sets {
+		for _, item := range byID[set.CatalogID].Equipment {
+			if item != "" && !seen[item] {
+				seen[item] = true
+				equipment = append(equipment, item)
+			}
+		}
+	}
+	sort.Strings(equipment)
+	return equipment
+}
+
+// latestRecords 每个动作、每种类型的当前记录，prs 需按达成时间倒序
+func latestRecords(prs []models.PersonalRecord) []models.AIRecordSummary {
+	seen := make(map[string]bool)
+	var records []models.AIRecordSummary
+	for _, pr := range prs {
+		key := pr.ExerciseName + "|" + pr.RecordType
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		records = append(records, models.AIRecordSummary{Name: pr.ExerciseName, Type: pr.RecordType, Value: pr.Value})
+		if len(records) == aiContextMaxRecords {
+			break
+		}
+	}
+	return records
+}
+
+// painfulExercises 最近一次反馈疼痛达到 painModerateLevel 的动作，feedbacks 需按时间倒序
+// 之后的反馈不再疼痛的动作视为已恢复
+func painfulExercises(feedbacks []models.ExerciseFeedback) []models.AIPainSummary {
+	seen := make(map[string]bool)
+	var painful []models.AIPainSummary
+	for _, feedback := range feedbacks {
+		name := feedback.Exercise.Name
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if feedback.PainLevel >= painModerateLevel {
+			painful = append(painful, models.AIPainSummary{Name: name, Level: feedback.PainLevel})
+		}
+	}
+	return painful
+}
+
+// aiExperienceLevel 按累计训练次数判断训练经验
+func aiExperienceLevel(c *models.AIPlanContext) string {
+	switch {
+	case c == nil:
+		return "未知"
+	case c.TotalWorkouts < 12:
+		return "新手"
+	case c.TotalWorkouts < 100:
+		return "有一定基础"
+	default:
+		return "经验丰富"
+	}
+}
+
+// summarizeAIPlanContext 把用户资料和训练历史整理为提示词，总长度不超过 budget 个 token
+// 按重要程度依次加入：身体数据、疼痛动作、上周训练、个人记录、常用器械，超出预算的条目不再加入
+func summarizeAIPlanContext(c *models.AIPlanContext, budget int) string {
+	if c == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	used := 0
+	add := func(line string) bool {
+		tokens := estimateTokens(line) + 1
+		if used+tokens > budget {
+			return false
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		used += tokens
+		return true
+	}
+	// section 标题和至少一个条目都放得下时才加入
+	section := func(title string, items []string) {
+		if len(items) == 0 || used+estimateTokens(title)+estimateTokens(items[0])+2 > budget {
+			return
+		}
+		add(title)
+		for _, item := range items {
+			if !add(item) {
+				return
+			}
+		}
+	}
+
+	var profile []string
+	if c.Gender != "" {
+		profile = append(profile, c.Gender)
+	}
+	if c.Age > 0 {
+		profile = append(profile, fmt.Sprintf("%d 岁", c.Age))
+	}
+	if c.Height > 0 {
+		profile = append(profile, fmt.Sprintf("身高 %g cm", roundTo(c.Height, 1)))
+	}
+	if c.Weight > 0 {
+		profile = append(profile, fmt.Sprintf("体重 %g kg", roundTo(c.Weight, 1)))
+	}
+	if c.BMI > 0 {
+		profile = append(profile, fmt.Sprintf("BMI %g", roundTo(c.BMI, 1)))
+	}
+	if len(profile) > 0 {
+		add("身体数据：" + strings.Join(profile, "，"))
+	}
+
+	var pain []string
+	for _, p := range c.PainfulExercises {
+		pain = append(pain, fmt.Sprintf("- %s（疼痛 %d 级）", p.Name, p.Level))
+	}
+	section("最近训练时出现疼痛的动作（不要安排这些动作，换成训练相同部位的其他动作）：", pain)
+
+	var lastWeek []string
+	for _, e := range c.LastWeek {
+		line := fmt.Sprintf("- %s %d 组", e.Name, e.Sets)
+		if e.Weight > 0 {
+			line += fmt.Sprintf("，最大 %gkg×%d", roundTo(e.Weight, 1), e.Reps)
+		} else if e.Reps > 0 {
+			line += fmt.Sprintf("，最多 %d 次", e.Reps)
+		}
+		lastWeek = append(lastWeek, line)
+	}
+	section(fmt.Sprintf("上周完成 %d 次训练（请参考这些重量和训练量安排，循序渐进）：", c.LastWeekWorkouts), lastWeek)
+
+	var records []string
+	for _, r := range c.Records {
+		label := "最大重量"
+		if r.Type == models.RecordEstimated1RM {
+			label = "估算1RM"
+		}
+		records = append(records, fmt.Sprintf("- %s %s %gkg", r.Name, label, roundTo(r.Value, 1)))
+	}
+	section("个人记录：", records)
+
+	if len(c.Equipment) > 0 {
+		add("最近使用的器械：" + strings.Join(c.Equipment, "、"))
+	}
+
+	return b.String()
+}
+
+// estimateTokens 估算文本的 token 数：中日韩文字约 1 字 1 token，其他字符约 4 个 1 token
+func estimateTokens(s string) int {
+	wide, other := 0, 0
+	for _, r := range s {
+		if r >= 0x2E80 {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeLastWeek(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	sets := []volumeSet{
+		{At: now.AddDate(0, 0, -2), Exercise: "卧推", Reps: 8, Weight: 60},
+		{At: now.AddDate(0, 0, -2), Exercise: "卧推", Reps: 6, Weight: 65},
+		{At: now.AddDate(0, 0, -1), Exercise: "深蹲", Reps: 5, Weight: 100},
+		{At: now.AddDate(0, 0, -1), Exercise: "卧推", Reps: 10, Weight: 65},
+		{At: now.AddDate(0, 0, -10), Exercise: "硬拉", Reps: 5, Weight: 140},
+	}
+
+	summaries := summarizeLastWeek(sets, now)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, models.AIExerciseSummary{Name: "卧推", Sets: 3, Weight: 65, Reps: 10}, summaries[0])
+	assert.Equal(t, "深蹲", summaries[1].Name)
+}
+
+func TestPainfulExercisesUsesLatestFeedback(t *testing.T) {
+	feedbacks := []models.ExerciseFeedback{
+		{PainLevel: 6, Exercise: models.TrainingExercise{Name: "深蹲"}},
+		{PainLevel: 2, Exercise: models.TrainingExercise{Name: "卧推"}},
+		{PainLevel: 8, Exercise: models.TrainingExercise{Name: "卧推"}},
+		{PainLevel: 3, Exercise: models.TrainingExercise{Name: "深蹲"}},
+	}
+
+	assert.Equal(t, []models.AIPainSummary{{Name: "深蹲", Level: 6}}, painfulExercises(feedbacks))
+}
+
+func TestSummarizeAIPlanContextBudget(t *testing.T) {
+	c := &models.AIPlanContext{
+		Gender:           "男",
+		Age:              30,
+		Height:           178,
+		Weight:           75,
+		BMI:              23.7,
+		LastWeekWorkouts: 3,
+		PainfulExercises: []models.AIPainSummary{{Name: "深蹲", Level: 6}},
+		Equipment:        []string{"杠铃", "哑铃"},
+	}
+	for i := 0; i < 50; i++ {
+		c.LastWeek = append(c.LastWeek, models.AIExerciseSummary{Name: "动作" + strings.Repeat("练", i%5), Sets: 3, Weight: 50, Reps: 8})
+		c.Records = append(c.Records, models.AIRecordSummary{Name: "卧推", Type: models.RecordEstimated1RM, Value: 80})
+	}
+
+	full := summarizeAIPlanContext(c, 100000)
+	assert.Contains(t, full, "身体数据：男，30 岁，身高 178 cm，体重 75 kg，BMI 23.7")
+	assert.Contains(t, full, "深蹲（疼痛 6 级）")
+	assert.Contains(t, full, "最大 50kg×8")
+	assert.Contains(t, full, "卧推 估算1RM 80kg")
+	assert.Contains(t, full, "最近使用的器械：杠铃、哑铃")
+
+	// 预算不足时保留身体数据和疼痛动作，丢弃优先级低的内容
+	short := summarizeAIPlanContext(c, 120)
+	assert.LessOrEqual(t, estimateTokens(short), 120)
+	assert.Contains(t, short, "身体数据")
+	assert.Contains(t, short, "深蹲（疼痛 6 级）")
+	assert.NotContains(t, short, "个人记录")
+	assert.NotContains(t, short, "器械")
+
+	assert.Empty(t, summarizeAIPlanContext(nil, 100))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 4, estimateTokens("深蹲卧推"))
+	assert.Equal(t, 2, estimateTokens("BMI 23.7"))
+	assert.Equal(t, 0, estimateTokens(""))
+}
+
+func TestTemplateWorkoutPlanSkipsPainfulExercises(t *testing.T) {
+	plan := templateWorkoutPlan(&models.GenerateTrainingPlanRequest{
+		Goal:    "减脂",
+		Context: &models.AIPlanContext{PainfulExercises: []models.AIPainSummary{{Name: "深蹲", Level: 7}}},
+	})
+
+	var names []string
+	for _, exercise := range plan.Exercises {
+		names = append(names, exercise.Name)
+	}
+	assert.NotContains(t, names, "深蹲")
+	assert.Contains(t, names, "跑步")
+}
+
+func TestBuildWorkoutPlanPromptUsesContext(t *testing.T) {
+	prompt := (&AIService{}).buildWorkoutPlanPrompt(&models.GenerateTrainingPlanRequest{
+		Goal:       "增肌",
+		Duration:   30,
+		Difficulty: "中级",
+		FocusAreas: []string{"胸", "背"},
+		Context: &models.AIPlanContext{
+			TotalWorkouts:    40,
+			AvgMinutes:       45,
+			Equipment:        []string{"哑铃"},
+			PainfulExercises: []models.AIPainSummary{{Name: "深蹲", Level: 6}},
+		},
+	}, nil)
+
+	assert.Contains(t, prompt, "健身经验：有一定基础")
+	assert.Contains(t, prompt, "可用器械：哑铃")
+	assert.Contains(t, prompt, "每次训练时间：30分钟")
+	assert.NotContains(t, prompt, "训练周期")
+	assert.Contains(t, prompt, "重点部位：胸,背")
+	assert.Contains(t, prompt, "深蹲（疼痛 6 级）")
+}
+
+func TestAIPlanMinutes(t *testing.T) {
+	history := &models.AIPlanContext{AvgMinutes: 45}
+
+	// 请求的训练时长优先，未填写时按最近的平均训练时长
+	assert.Equal(t, 30, aiPlanMinutes(&models.GenerateTrainingPlanRequest{Duration: 30, Context: history}))
+	assert.Equal(t, 45, aiPlanMinutes(&models.GenerateTrainingPlanRequest{Context: history}))
+	assert.Equal(t, 60, aiPlanMinutes(&models.GenerateTrainingPlanRequest{}))
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+)
+
+// AI 训练计划的校验范围
+const (
+	aiPlanMaxExercises = 12   // 每个训练日最多动作数
+	aiPlanMaxSets      = 10   // 每个动作最多组数
+	aiPlanMaxReps      = 100  // 每组最多次数
+	aiPlanMaxWeight    = 500  // 每组最大重量（kg）
+	aiPlanMaxDuration  = 7200 // 每组最长时长（秒）
+	aiPlanMaxRest      = 600  // 最长组间休息（秒）
+	aiPlanMaxErrors    = 10   // 反馈给模型的校验错误最多条数
+	aiPlanPromptNames  = 80   // 提示词中列出的动作库名称最多个数
+)
+
+// aiPlanCategories 计划中允许的动作分类，与标准动作库一致
+var aiPlanCategories = []string{"胸", "背", "腿", "肩", "臂", "核心", "有氧"}
+
+// aiCategoryAliases 模型常用的分类写法
+var aiCategoryAliases = map[string]string{
+	"胸部": "胸", "背部": "背", "腿部": "腿", "下肢": "腿", "肩部": "肩", "手臂": "臂",
+	"腹部": "核心", "腹肌": "核心", "心肺": "有氧",
+	"chest": "胸", "back": "背", "legs": "腿", "leg": "腿", "shoulders": "肩", "shoulder": "肩",
+	"arms": "臂", "arm": "臂", "core": "核心", "abs": "核心", "cardio": "有氧",
+}
+
+// aiPlanOutput 模型返回的训练计划
+type aiPlanOutput struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Difficulty  string          `json:"difficulty"`
+	Duration    int             `json:"duration"`
+	Sessions    []aiPlanSession `json:"sessions"`
+}
+
+type aiPlanSession struct {
+	Day       int              `json:"day"`
+	Title     string           `json:"title"`
+	Exercises []aiPlanExercise `json:"exercises"`
+}
+
+type aiPlanExercise struct {
+	Name     string     `json:"name"`
+	Category string     `json:"category"`
+	Sets     aiPlanSets `json:"sets"`
+	aiPlanSetFields
+	Group     string `json:"group"`
+	GroupType string `json:"group_type"`
+	Rounds    int    `json:"rounds"`
+	Notes     string `json:"notes"`
+}
+
+// aiPlanSetFields 组的参数，可以写在动作上（每组相同）或逐组描述中，逐组描述缺少的字段沿用动作上的值
+type aiPlanSetFields struct {
+	Reps     *int     `json:"reps"`
+	Weight   *float64 `json:"weight"`
+	Duration *int     `json:"duration"`
+	RestTime *int     `json:"rest_time"`
+	SetType  *string  `json:"set_type"`
+}
+
+// aiPlanSets sets 可以是组数，也可以是逐组描述的数组（用于热身组、递减组等不同类型的组）
+type aiPlanSets struct {
+	Count int
+	Items []aiPlanSetFields
+}
+
+func (s *aiPlanSets) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case trimmed == "null":
+		return nil
+	case strings.HasPrefix(trimmed, "["):
+		return json.Unmarshal(data, &s.Items)
+	default:
+		if err := json.Unmarshal(data, &s.Count); err != nil {
+			return errors.New("sets 需要是整数组数或逐组描述的数组")
+		}
+		return nil
+	}
+}
+
+// size 组数，未指定时为 0
+func (s aiPlanSets) size() int {
+	if len(s.Items) > 0 {
+		return len(s.Items)
+	}
+	return s.Count
+}
+
+// decodeAIPlan 解析模型返回的 JSON，允许前后带有说明文字或 ``` 代码块
+func decodeAIPlan(response string) (*aiPlanOutput, error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("没有找到 JSON 对象")
+	}
+	var out aiPlanOutput
+	if err := json.Unmarshal([]byte(response[start:end+1]), &out); err != nil {
+		return nil, fmt.Errorf("JSON 格式错误: %v", err)
+	}
+	return &out, nil
+}
+
+// validateAIPlan 按计划结构校验模型输出，返回的错误会反馈给模型用于修复
+// catalog 为空时不校验动作是否在动作库中；avoid 中的动作（最近出现疼痛）不允许出现在计划中
+func validateAIPlan(out *aiPlanOutput, catalog []models.Exercise, avoid []string) []string {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		if len(errs) < aiPlanMaxErrors {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if strings.TrimSpace(out.Title) == "" {
+		add("title 不能为空")
+	}
+	// 生成的是当天的计划，多余的训练日不会被保存
+	if len(out.Sessions) != 1 {
+		add("sessions 需要且只能包含一个训练日（今天的训练）")
+	}
+
+	for d, session := range out.Sessions {
+		day := fmt.Sprintf("第 %d 个训练日", d+1)
+		if len(session.Exercises) == 0 || len(session.Exercises) > aiPlanMaxExercises {
+			add("%s: 动作数量需要在 1-%d 之间", day, aiPlanMaxExercises)
+		}
+
+		exercises := buildAIExercises(session)
+		for i, raw := range session.Exercises {
+			name := strings.TrimSpace(raw.Name)
+			if name == "" {
+				add("%s第 %d 个动作: name 不能为空", day, i+1)
+				continue
+			}
+			var match *models.ExerciseMatch
+			if len(catalog) > 0 {
+				if match = matchExercise(catalog, name); match == nil {
+					add("%s: 不在动作库中，请换成动作库中的动作", name)
+				}
+			}
+			if avoidedExercise(name, match, avoid, catalog) {
+				add("%s: 最近训练时出现疼痛，请换成训练相同部位的其他动作", name)
+			}
+			// 动作库中的动作可以不写分类，保存时按动作库补全
+			if _, ok := normalizeAICategory(raw.Category); !ok && (raw.Category != "" || match == nil) {
+				add("%s: category %q 无效，只能是 %s", name, raw.Category, strings.Join(aiPlanCategories, "、"))
+			}
+			if n := raw.Sets.size(); n < 1 || n > aiPlanMaxSets {
+				add("%s: sets 需要在 1-%d 之间", name, aiPlanMaxSets)
+			}
+			for j, set := range exercises[i].Sets {
+				if msg := aiSetRangeError(set); msg != "" {
+					add("%s 第 %d 组: %s", name, j+1, msg)
+				}
+			}
+			sets := append([]models.ExerciseSet(nil), exercises[i].Sets...)
+			if err := validateSets(name, sets); err != nil {
+				add("%v", err)
+			}
+		}
+		if err := resolveGroups(exercises); err != nil {
+			add("%s: %v", day, err)
+		}
+	}
+	return errs
+}
+
+// avoidedExercise 动作是否在 avoid 中，关联到动作库时按标准动作比较，否则按名称比较
+func avoidedExercise(name string, match *models.ExerciseMatch, avoid []string, catalog []models.Exercise) bool {
+	for _, avoided := range avoid {
+		if name == avoided {
+			return true
+		}
+		if match != nil {
+			if m := matchExercise(catalog, avoided); m != nil && m.Exercise.ID == match.Exercise.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aiSetRangeError 组参数超出合理范围时返回错误说明
+func aiSetRangeError(set models.ExerciseSet) string {
+	switch {
+	case set.Reps < 0 || set.Reps > aiPlanMaxReps:
+		return fmt.Sprintf("reps 需要在 0-%d 之间", aiPlanMaxReps)
+	case set.Weight < 0 || set.Weight > aiPlanMaxWeight:
+		return fmt.Sprintf("weight 需要在 0-%d 公斤之间", aiPlanMaxWeight)
+	case set.Duration < 0 || set.Duration > aiPlanMaxDuration:
+		return fmt.Sprintf("duration 需要在 0-%d 秒之间", aiPlanMaxDuration)
+	case set.RestTime < 0 || set.RestTime > aiPlanMaxRest:
+		return fmt.Sprintf("rest_time 需要在 0-%d 秒之间", aiPlanMaxRest)
+	}
+	return ""
+}
+
+// normalizeAICategory 返回标准分类
+func normalizeAICategory(category string) (string, bool) {
+	c := strings.TrimSpace(category)
+	for _, known := range aiPlanCategories {
+		if c == known {
+			return c, true
+		}
+	}
+	normalized, ok := aiCategoryAliases[strings.ToLower(c)]
+	return normalized, ok
+}
+
+// buildAIPlan 把模型输出（已通过校验，只有一个训练日）转换为当天的训练计划
+// 计划会被缓存并复用，ID 和用户在保存时设置
+func buildAIPlan(out *aiPlanOutput) *models.TrainingPlan {
+	plan := &models.TrainingPlan{
+		Name:          out.Title,
+		Description:   out.Description,
+		IsAIGenerated: true,
+		Date:          time.Now(),
+	}
+	if plan.Name == "" {
+		plan.Name = "AI生成训练计划"
+	}
+	if len(out.Sessions) > 0 {
+		plan.Exercises = buildAIExercises(out.Sessions[0])
+	}
+	sanitizePlanStructure(plan.Exercises)
+	return plan
+}
+
+// buildAIExercises 转换一个训练日的动作，没有名称的动作被忽略
+func buildAIExercises(session aiPlanSession) []models.TrainingExercise {
+	var exercises []models.TrainingExercise
+	for _, raw := range session.Exercises {
+		name := strings.TrimSpace(raw.Name)
+		if name == "" {
+			continue
+		}
+		category := raw.Category
+		if normalized, ok := normalizeAICategory(category); ok {
+			category = normalized
+		}
+		exercise := models.TrainingExercise{
+			Name:         name,
+			Category:     category,
+			Instructions: raw.Notes,
+			Order:        len(exercises) + 1,
+			GroupKey:     raw.Group,
+			GroupType:    raw.GroupType,
+			GroupRounds:  raw.Rounds,
+		}
+		if len(raw.Sets.Items) > 0 {
+			for _, item := range raw.Sets.Items {
+				exercise.Sets = append(exercise.Sets, buildAISet(item, raw.aiPlanSetFields, len(exercise.Sets)+1))
+			}
+		} else {
+			for j := 0; j < max(raw.Sets.Count, 1); j++ {
+				exercise.Sets = append(exercise.Sets, buildAISet(aiPlanSetFields{}, raw.aiPlanSetFields, j+1))
+			}
+		}
+		exercises = append(exercises, exercise)
+	}
+	return exercises
+}
+
+// buildAISet 转换一组，逐组描述中缺少的字段沿用动作上的值，休息时间默认 60 秒
+func buildAISet(set, exercise aiPlanSetFields, order int) models.ExerciseSet {
+	result := models.ExerciseSet{RestTime: 60, Order: order}
+	for _, fields := range []aiPlanSetFields{exercise, set} {
+		if fields.Reps != nil {
+			result.Reps = *fields.Reps
+		}
+		if fields.Weight != nil {
+			result.Weight = *fields.Weight
+		}
+		if fields.Duration != nil {
+			result.Duration = *fields.Duration
+		}
+		if fields.RestTime != nil {
+			result.RestTime = *fields.RestTime
+		}
+		if fields.SetType != nil {
+			result.SetType = *fields.SetType
+		}
+	}
+	return result
+}
+
+// aiPlanRepairPrompt 把校验错误反馈给模型，要求返回修正后的完整 JSON
+func aiPlanRepairPrompt(errs []string) string {
+	return "上面返回的训练计划没有通过校验：\n- " + strings.Join(errs, "\n- ") +
+		"\n请修正这些问题，只返回修正后的完整 JSON，不要包含其他文字。"
+}
+
+// aiTemplateExercise 模板计划中的一个动作
+type aiTemplateExercise struct {
+	Name     string
+	Category string
+	Sets     int
+	Reps     int
+	Duration int
+	RestTime int
+}
+
+// aiTemplateExercises 模型不可用或输出始终无效时使用的全身基础训练
+var aiTemplateExercises = []aiTemplateExercise{
+	{Name: "深蹲", Category: "腿", Sets: 3, Reps: 10, RestTime: 90},
+	{Name: "俯卧撑", Category: "胸", Sets: 3, Reps: 12, RestTime: 60},
+	{Name: "杠铃划船", Category: "背", Sets: 3, Reps: 10, RestTime: 90},
+	{Name: "推举", Category: "肩", Sets: 3, Reps: 10, RestTime: 90},
+	{Name: "平板支撑", Category: "核心", Sets: 3, Duration: 60, RestTime: 45},
+}
+
+// aiTemplateCardio 减脂目标的模板计划追加的有氧
+var aiTemplateCardio = aiTemplateExercise{Name: "跑步", Category: "有氧", Sets: 1, Duration: 1200}
+
+// templateWorkoutPlan 模板训练计划
+func templateWorkoutPlan(req *models.GenerateTrainingPlanRequest) *models.TrainingPlan {
+	avoid := make(map[string]bool)
+	for _, name := range aiPlanAvoid(req) {
+		avoid[name] = true
+	}
+	var templates []aiTemplateExercise
+	for _, t := range aiTemplateExercises {
+		if !avoid[t.Name] {
+			templates = append(templates, t)
+		}
+	}
+	goal := strings.ToLower(req.Goal)
+	for _, keyword := range []string{"减脂", "减重", "燃脂", "瘦", "fat", "weight loss"} {
+		if strings.Contains(goal, keyword) && !avoid[aiTemplateCardio.Name] {
+			templates = append(templates, aiTemplateCardio)
+			break
+		}
+	}
+
+	plan := &models.TrainingPlan{
+		Name:          fmt.Sprintf("%s - 基础训练计划", req.Goal),
+		Description:   fmt.Sprintf("AI 暂时无法生成计划，这是针对%s目标的全身基础训练，可以按需调整", req.Goal),
+		IsAIGenerated: true,
+		Date:          time.Now(),
+	}
+	for i, t := range templates {
+		exercise := models.TrainingExercise{Name: t.Name, Category: t.Category, Order: i + 1}
+		for j := 0; j < t.Sets; j++ {
+			set := models.ExerciseSet{Reps: t.Reps, Duration: t.Duration, RestTime: t.RestTime, Order: j + 1, SetType: models.SetTypeWorking}
+			if t.Reps == 0 {
+				set.SetType = models.SetTypeTimed
+			}
+			exercise.Sets = append(exercise.Sets, set)
+		}
+		plan.Exercises = append(plan.Exercises, exercise)
+	}
+	return plan
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// aiPlanCacheKeyPrefix AI 训练计划缓存的 Redis 键前缀
+const aiPlanCacheKeyPrefix = "ai:plan:"
+
+// aiPlanPromptVersion 训练计划提示词和动作库校验规则的版本，计入缓存键
+// 修改提示词、计划的 JSON 结构或动作名称的校验方式时加一，使按旧规则生成的缓存失效
+const aiPlanPromptVersion = 3
+
+// aiPlanCache AI 训练计划缓存
+// 相同的请求（目标、难度、时长、器械、部位相同，且用户资料和训练历史相同）在有效期内复用已生成的计划；
+// 同时发起的相同请求只调用一次模型，其余请求等待并共用结果。未配置 Redis 时只合并同时发起的请求
+type aiPlanCache struct {
+	redis *redis.Client
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*aiPlanCall
+	stats models.AIPlanCacheStats
+}
+
+// aiPlanCall 一次正在进行的生成，done 关闭后 data 和 err 可读
+type aiPlanCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+func newAIPlanCache(redisClient *redis.Client, ttl time.Duration) *aiPlanCache {
+	return &aiPlanCache{redis: redisClient, ttl: ttl, calls: make(map[string]*aiPlanCall)}
+}
+
+// enabled 是否读写 Redis 缓存
+func (c *aiPlanCache) enabled() bool {
+	return c.redis != nil && c.ttl > 0
+}
+
+// do 返回 key 对应的计划：先查缓存，未命中时调用 generate，同时发起的相同请求共用一次 generate
+// generate 使用不随调用方取消的 ctx，发起请求的用户断开后仍然完成生成并写入缓存，供等待的请求和之后的重试使用
+// 每个调用方拿到独立的副本，可以放心修改；模板计划不缓存，模型恢复后重新生成
+func (c *aiPlanCache) do(ctx context.Context, key string, generate func(ctx context.Context) (*models.TrainingPlan, error)) (*models.TrainingPlan, error) {
+	if plan, ok := c.load(ctx, key); ok {
+		return plan, nil
+	}
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.stats.Shared++
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		return decodeCachedPlan(call.data)
+	}
+	call := &aiPlanCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	plan, err := generate(context.WithoutCancel(ctx))
+	if err != nil {
+		call.err = err
+		return nil, err
+	}
+	if call.data, call.err = json.Marshal(plan); call.err != nil {
+		return nil, call.err
+	}
+	if plan.AISource != models.AIPlanSourceTemplate {
+		c.store(ctx, key, call.data)
+	}
+	return decodeCachedPlan(call.data)
+}
+
+// load 读取缓存，Redis 不可用时视为未命中
+func (c *aiPlanCache) load(ctx context.Context, key string) (*models.TrainingPlan, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+	data, err := c.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn.Printf("读取AI训练计划缓存失败: key=%v, error=%v", key, err.Error())
+			c.count(func(stats *models.AIPlanCacheStats) { stats.Errors++ })
+		}
+		return nil, false
+	}
+	plan, err := decodeCachedPlan(data)
+	if err != nil {
+		logger.Warn.Printf("解析AI训练计划缓存失败: key=%v, error=%v", key, err.Error())
+		c.count(func(stats *models.AIPlanCacheStats) { stats.Errors++ })
+		return nil, false
+	}
+	c.count(func(stats *models.AIPlanCacheStats) { stats.Hits++ })
+	return plan, true
+}
+
+// store 写入缓存，失败时只记录日志
+func (c *aiPlanCache) store(ctx context.Context, key string, data []byte) {
+	if !c.enabled() {
+		return
+	}
+	if err := c.redis.Set(context.WithoutCancel(ctx), key, data, c.ttl).Err(); err != nil {
+		logger.Warn.Printf("写入AI训练计划缓存失败: key=%v, error=%v", key, err.Error())
+		c.count(func(stats *models.AIPlanCacheStats) { stats.Errors++ })
+		return
+	}
+	c.count(func(stats *models.AIPlanCacheStats) { stats.Stored++ })
+}
+
+func (c *aiPlanCache) count(update func(stats *models.AIPlanCacheStats)) {
+	c.mu.Lock()
+	update(&c.stats)
+	c.mu.Unlock()
+}
+
+// snapshot 缓存命中统计
+func (c *aiPlanCache) snapshot() models.AIPlanCacheStats {
+	c.mu.Lock()
+	stats := c.stats
+	c.mu.Unlock()
+
+	stats.Enabled = c.enabled()
+	stats.TTLSeconds = int(c.ttl / time.Second)
+	if total := stats.Hits + stats.Misses + stats.Shared; total > 0 {
+		stats.HitRate = roundTo(float64(stats.Hits+stats.Shared)/float64(total), 3)
+	}
+	return stats
+}
+
+func decodeCachedPlan(data []byte) (*models.TrainingPlan, error) {
+	var plan models.TrainingPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("解析训练计划失败: %w", err)
+	}
+	return &plan, nil
+}
+
+// aiPlanFingerprint 训练计划请求的缓存键
+// 请求参数规范化后（去除首尾空格、器械和部位去重排序）与用户资料和训练历史的摘要一起计算哈希，
+// 用户资料、训练记录、疼痛反馈或计划完成度变化后自然生成新的键，提示词版本变化后也生成新的键
+func aiPlanFingerprint(req *models.GenerateTrainingPlanRequest) string {
+	key := struct {
+		Version          int                   `json:"version"`
+		Goal             string                `json:"goal"`
+		Duration         int                   `json:"duration"`
+		Difficulty       string                `json:"difficulty"`
+		Equipment        []string              `json:"equipment"`
+		FocusAreas       []string              `json:"focus_areas"`
+		Adherence        float64               `json:"adherence"`
+		SkippedExercises []string              `json:"skipped_exercises"`
+		Context          *models.AIPlanContext `json:"context"`
+	}{
+		Version:          aiPlanPromptVersion,
+		Goal:             strings.ToLower(strings.TrimSpace(req.Goal)),
+		Duration:         req.Duration,
+		Difficulty:       strings.ToLower(strings.TrimSpace(req.Difficulty)),
+		Equipment:        normalizedSet(req.Equipment),
+		FocusAreas:       normalizedSet(req.FocusAreas),
+		Adherence:        math.Round(req.Adherence*100) / 100,
+		SkippedExercises: normalizedSet(req.SkippedExercises),
+		Context:          req.Context,
+	}
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return aiPlanCacheKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// normalizedSet 去除首尾空格和空值后去重排序
+func normalizedSet(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := []string{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIPlanFingerprintNormalizesRequest(t *testing.T) {
+	req := &models.GenerateTrainingPlanRequest{
+		Goal:       "增肌 ",
+		Duration:   45,
+		Difficulty: "中级",
+		Equipment:  []string{"哑铃", "杠铃", "哑铃", ""},
+		FocusAreas: []string{"背", "胸"},
+		Context:    &models.AIPlanContext{Gender: "男", Age: 30, Weight: 75},
+	}
+	same := &models.GenerateTrainingPlanRequest{
+		Goal:       "增肌",
+		Duration:   45,
+		Difficulty: " 中级",
+		Equipment:  []string{"杠铃", "哑铃"},
+		FocusAreas: []string{"胸", "背"},
+		Context:    &models.AIPlanContext{Gender: "男", Age: 30, Weight: 75},
+	}
+	assert.Equal(t, aiPlanFingerprint(req), aiPlanFingerprint(same))
+	assert.Contains(t, aiPlanFingerprint(req), aiPlanCacheKeyPrefix)
+
+	// 用户资料或训练历史变化后不再命中
+	heavier := *same
+	heavier.Context = &models.AIPlanContext{Gender: "男", Age: 30, Weight: 78}
+	assert.NotEqual(t, aiPlanFingerprint(req), aiPlanFingerprint(&heavier))
+
+	harder := *same
+	harder.Difficulty = "高级"
+	assert.NotEqual(t, aiPlanFingerprint(req), aiPlanFingerprint(&harder))
+}
+
+func TestAIPlanCacheSharesConcurrentGeneration(t *testing.T) {
+	cache := newAIPlanCache(nil, time.Hour)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	calls := 0
+	generate := func(ctx context.Context) (*models.TrainingPlan, error) {
+		calls++
+		close(started)
+		<-release
+		return &models.TrainingPlan{Name: "增肌计划", AISource: models.AIPlanSourceModel, Exercises: []models.TrainingExercise{{Name: "深蹲"}}}, nil
+	}
+
+	const callers = 5
+	plans := make([]*models.TrainingPlan, callers)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		plans[0], _ = cache.do(context.Background(), "key", generate)
+	}()
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			plans[i], _ = cache.do(context.Background(), "key", generate)
+		}(i)
+	}
+	// 等待其余请求都加入正在进行的生成
+	require.Eventually(t, func() bool { return cache.snapshot().Shared == callers-1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls)
+	for i, plan := range plans {
+		require.NotNil(t, plan)
+		assert.Equal(t, "增肌计划", plan.Name)
+		if i > 0 {
+			// 每个调用方拿到独立的副本
+			assert.NotSame(t, plans[0], plan)
+			plan.Exercises[0].Name = "卧推"
+			assert.Equal(t, "深蹲", plans[0].Exercises[0].Name)
+		}
+	}
+
+	stats := cache.snapshot()
+	assert.False(t, stats.Enabled)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 0.8, stats.HitRate)
+}
+
+func TestAIPlanCacheDoesNotKeepFailures(t *testing.T) {
+	cache := newAIPlanCache(nil, time.Hour)
+	_, err := cache.do(context.Background(), "key", func(ctx context.Context) (*models.TrainingPlan, error) {
+		return nil, errors.New("模型不可用")
+	})
+	assert.EqualError(t, err, "模型不可用")
+
+	// 失败后再次请求重新生成
+	plan, err := cache.do(context.Background(), "key", func(ctx context.Context) (*models.TrainingPlan, error) {
+		return &models.TrainingPlan{Name: "减脂计划"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "减脂计划", plan.Name)
+	assert.Equal(t, int64(2), cache.snapshot().Misses)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAIPlanStructure(t *testing.T) {
+	response := `{
+		"title": "上肢超级组",
+		"sessions": [{
+			"day": 1,
+			"exercises": [
+				{"name": "卧推", "sets": [
+					{"reps": 12, "weight": 20, "set_type": "warmup", "rest_time": 30},
+					{"reps": 8, "weight": 60},
+					{"reps": 8, "weight": 60},
+					{"reps": 12, "weight": 40, "set_type": "drop"}
+				], "rest_time": 90, "group": "A", "group_type": "superset"},
+				{"name": "划船", "sets": 3, "reps": 10, "weight": 50, "group": "A"},
+				{"name": "平板支撑", "sets": 2, "duration": 60, "set_type": "timed"},
+				{"name": "俯卧撑", "sets": 1, "reps": 10, "set_type": "amrap"}
+			]
+		}]
+	}`
+
+	out, err := decodeAIPlan(response)
+	require.NoError(t, err)
+	plan := buildAIPlan(out)
+	require.Len(t, plan.Exercises, 4)
+
+	bench := plan.Exercises[0]
+	require.Len(t, bench.Sets, 4)
+	assert.Equal(t, models.SetTypeWarmup, bench.Sets[0].SetType)
+	assert.Equal(t, 30, bench.Sets[0].RestTime)
+	assert.Equal(t, models.SetTypeWorking, bench.Sets[1].SetType)
+	assert.Equal(t, 90, bench.Sets[1].RestTime)
+	assert.Equal(t, models.SetTypeDrop, bench.Sets[3].SetType)
+	assert.Equal(t, 4, bench.Sets[3].Order)
+	assert.Equal(t, models.GroupTypeSuperset, bench.GroupType)
+	assert.Equal(t, 3, bench.GroupRounds)
+	assert.Equal(t, "A", plan.Exercises[1].GroupKey)
+	assert.Equal(t, models.GroupTypeSuperset, plan.Exercises[1].GroupType)
+
+	assert.Equal(t, models.SetTypeTimed, plan.Exercises[2].Sets[1].SetType)
+	assert.Equal(t, models.SetTypeAMRAP, plan.Exercises[3].Sets[0].SetType)
+}
+
+func TestBuildAIPlanFallsBackOnInvalidStructure(t *testing.T) {
+	response := `{
+		"title": "结构错误",
+		"sessions": [{
+			"exercises": [
+				{"name": "卧推", "sets": 2, "reps": 8, "set_type": "drop", "group": "A", "group_type": "superset"},
+				{"name": "划船", "sets": 2, "reps": 8, "group": "A"},
+				{"name": "飞鸟", "sets": 2, "reps": 8, "group": "A"}
+			]
+		}]
+	}`
+
+	out, err := decodeAIPlan(response)
+	require.NoError(t, err)
+	plan := buildAIPlan(out)
+	require.Len(t, plan.Exercises, 3)
+	assert.Equal(t, models.SetTypeWorking, plan.Exercises[0].Sets[0].SetType)
+	assert.Equal(t, models.SetTypeWorking, plan.Exercises[0].Sets[1].SetType)
+	for _, exercise := range plan.Exercises {
+		assert.Empty(t, exercise.GroupKey)
+		assert.Empty(t, exercise.GroupType)
+	}
+}
+
+func TestDecodeAIPlan(t *testing.T) {
+	out, err := decodeAIPlan("```json\n" + `{"title": "计划", "sessions": [{"exercises": [
+		{"name": "卧推", "sets": [{"reps": 10}, {"reps": 8, "weight": 70}], "weight": 60},
+		{"name": "深蹲", "sets": 3, "reps": 5}
+	]}]}` + "\n```")
+	require.NoError(t, err)
+	exercises := buildAIExercises(out.Sessions[0])
+	require.Len(t, exercises, 2)
+	require.Len(t, exercises[0].Sets, 2)
+	assert.Equal(t, 60.0, exercises[0].Sets[0].Weight)
+	assert.Equal(t, 70.0, exercises[0].Sets[1].Weight)
+	require.Len(t, exercises[1].Sets, 3)
+	assert.Equal(t, 5, exercises[1].Sets[2].Reps)
+
+	_, err = decodeAIPlan("抱歉，我无法生成计划")
+	assert.Error(t, err)
+	_, err = decodeAIPlan(`{"title": "计划", "sessions": [{"exercises": [{"name": "卧推", "sets": "三组"}]}]}`)
+	assert.Error(t, err)
+}
+
+func TestValidateAIPlan(t *testing.T) {
+	catalog := []models.Exercise{{ID: 1, Name: "卧推", Category: "胸"}, {ID: 2, Name: "深蹲", Category: "腿"}}
+	decode := func(s string) *aiPlanOutput {
+		out, err := decodeAIPlan(s)
+		require.NoError(t, err)
+		return out
+	}
+
+	valid := decode(`{"title": "计划", "sessions": [{"exercises": [
+		{"name": "卧推", "category": "胸部", "sets": 3, "reps": 8, "weight": 60},
+		{"name": "深蹲", "sets": 3, "reps": 5, "weight": 100}
+	]}]}`)
+	assert.Empty(t, validateAIPlan(valid, catalog, nil))
+	assert.Equal(t, "胸", buildAIExercises(valid.Sessions[0])[0].Category)
+
+	invalid := decode(`{"title": "", "sessions": [{"exercises": [
+		{"name": "卧推", "category": "上肢", "sets": 3, "reps": 8},
+		{"name": "深蹲", "category": "腿", "sets": 20, "reps": 5},
+		{"name": "火箭推", "category": "肩", "sets": 3, "reps": 8},
+		{"name": "卧推", "category": "胸", "sets": 1, "reps": 500, "weight": 900},
+		{"name": "深蹲", "category": "腿", "sets": [{"reps": 5, "set_type": "drop"}]}
+	]}]}`)
+	errs := validateAIPlan(invalid, catalog, nil)
+	require.Len(t, errs, 6)
+	assert.Contains(t, errs[0], "title")
+	assert.Contains(t, errs[1], "上肢")
+	assert.Contains(t, errs[2], "sets")
+	assert.Contains(t, errs[3], "火箭推")
+	assert.Contains(t, errs[4], "reps")
+
+	// 最近疼痛的动作按标准动作比较
+	avoidErrs := validateAIPlan(valid, catalog, []string{"深蹲"})
+	require.Len(t, avoidErrs, 1)
+	assert.Contains(t, avoidErrs[0], "疼痛")
+
+	// 没有动作库时不校验动作名称
+	assert.Empty(t, validateAIPlan(decode(`{"title": "计划", "sessions": [{"exercises": [{"name": "火箭推", "category": "肩", "sets": 3, "reps": 8}]}]}`), nil, nil))
+	assert.NotEmpty(t, validateAIPlan(decode(`{"title": "计划", "sessions": []}`), nil, nil))
+
+	// 只生成当天的计划，多个训练日需要模型修复
+	twoDays := decode(`{"title": "计划", "sessions": [
+		{"exercises": [{"name": "卧推", "sets": 3, "reps": 8}]},
+		{"exercises": [{"name": "深蹲", "sets": 3, "reps": 5}]}
+	]}`)
+	assert.Equal(t, []string{"sessions 需要且只能包含一个训练日（今天的训练）"}, validateAIPlan(twoDays, catalog, nil))
+}
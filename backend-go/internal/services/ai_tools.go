@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// aiToolMaxResult 返回给模型的工具结果最大字符数
+	aiToolMaxResult = 4000
+	// aiToolConfirmTTL 等待用户确认的工具调用有效期
+	aiToolConfirmTTL = 30 * time.Minute
+	// aiToolRunTimeout 已确认的工具调用超过该时长仍在执行中时视为中断（如服务重启），标记为失败
+	aiToolRunTimeout = 2 * time.Minute
+	// aiToolMaxRangeDays 查询计划和饮食时最多覆盖的天数
+	aiToolMaxRangeDays = 31
+	// aiToolDefaultProgressDays 查询动作进步时默认回看的天数
+	aiToolDefaultProgressDays = 30
+)
+
+var (
+	// ErrToolInvocationNotFound 工具调用不存在或不属于当前用户
+	ErrToolInvocationNotFound = errors.New("工具调用不存在")
+	// ErrToolInvocationNotPending 工具调用不在等待确认状态（已处理或已过期）
+	ErrToolInvocationNotPending = errors.New("该操作已处理或已过期")
+	// errToolInterrupted 工具执行中断，不确定是否已经修改数据
+	errToolInterrupted = errors.New("执行中断，请检查数据后重试")
+)
+
+// aiToolArgs 工具参数，各工具只使用其中的部分字段
+type aiToolArgs struct {
+	Exercise string `json:"exercise"`
+	Days     int    `json:"days"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	PlanID   string `json:"plan_id"`
+	Date     string `json:"date"`
+}
+
+// aiTool 允许模型调用的工具
+type aiTool struct {
+	name        string
+	description string
+	parameters  map[string]interface{}
+	// mutating 修改用户数据，需要用户确认后才执行
+	mutating bool
+	// summary 给用户确认的操作说明，只有修改数据的工具需要
+	summary func(userID string, args aiToolArgs) (string, error)
+	run     func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error)
+}
+
+// AIToolService AI 教练可以调用的工具
+// 只有白名单中的工具可以调用，修改数据的工具先记录为待确认，用户确认后才执行；每次调用都记录审计日志
+type AIToolService struct {
+	db        *gorm.DB
+	training  *TrainingService
+	nutrition *NutritionService
+	profiles  *UserProfileService
+	streaks   *StreakService
+	tools     []aiTool
+}
+
+// NewAIToolService 创建 AI 教练工具服务
+func NewAIToolService(db *gorm.DB, training *TrainingService, nutrition *NutritionService, profiles *UserProfileService, streaks *StreakService) *AIToolService {
+	s := &AIToolService{db: db, training: training, nutrition: nutrition, profiles: profiles, streaks: streaks}
+	s.tools = s.registry()
+	return s
+}
+
+// registry 工具白名单
+func (s *AIToolService) registry() []aiTool {
+	dateRange := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"from": map[string]interface{}{"type": "string", "description": "开始日期 YYYY-MM-DD"},
+			"to":   map[string]interface{}{"type": "string", "description": "结束日期 YYYY-MM-DD"},
+		},
+	}
+
+	return []aiTool{
+		{
+			name:        "get_profile",
+			description: "查询用户的个人资料：昵称、性别、年龄、身高、体重、BMI",
+			parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				profile, err := s.profiles.GetProfile(userID)
+				if err != nil {
+					return nil, err
+				}
+				result := map[string]interface{}{
+					"nickname": profile.Nickname,
+					"gender":   profile.Gender,
+					"height":   profile.Height,
+					"weight":   profile.Weight,
+					"bmi":      profile.BMI,
+				}
+				if !profile.Birthday.IsZero() {
+					result["age"] = ageAt(profile.Birthday, time.Now())
+				}
+				return result, nil
+			},
+		},
+		{
+			name:        "get_training_stats",
+			description: "查询训练统计：总训练次数、总时长、消耗热量、连续训练天数、本周和本月训练次数、最常做的动作",
+			parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				return s.training.GetTrainingStats(userID)
+			},
+		},
+		{
+			name:        "get_exercise_progress",
+			description: "查询某个动作在最近一段时间的进步：估算 1RM 和最大重量的起止值和变化",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"exercise": map[string]interface{}{"type": "string", "description": "动作名称，如 深蹲"},
+					"days":     map[string]interface{}{"type": "integer", "description": "回看天数，默认 30"},
+				},
+				"required": []string{"exercise"},
+			},
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				if strings.TrimSpace(args.Exercise) == "" {
+					return nil, errors.New("缺少 exercise")
+				}
+				days := args.Days
+				if days <= 0 || days > 365 {
+					days = aiToolDefaultProgressDays
+				}
+				timeline, err := s.training.GetPersonalRecordTimeline(userID, strings.TrimSpace(args.Exercise), "")
+				if err != nil {
+					return nil, err
+				}
+				return exerciseProgress(args.Exercise, timeline, days, time.Now()), nil
+			},
+		},
+		{
+			name:        "get_plans",
+			description: "查询日期范围内的训练计划（id、日期、名称、状态、动作），默认从今天起 7 天，最多 31 天",
+			parameters:  dateRange,
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				today := s.today(userID)
+				from, to, err := toolDateRange(args, today, today.AddDate(0, 0, 6))
+				if err != nil {
+					return nil, err
+				}
+				plans, err := s.training.GetPlansBetween(userID, from, to)
+				if err != nil {
+					return nil, err
+				}
+				return summarizePlans(plans), nil
+			},
+		},
+		{
+			name:        "reschedule_plan",
+			description: "把训练计划调整到另一天。会修改用户数据，需要用户确认后才执行",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"plan_id": map[string]interface{}{"type": "string", "description": "训练计划 id，先用 get_plans 查询"},
+					"date":    map[string]interface{}{"type": "string", "description": "新的日期 YYYY-MM-DD"},
+				},
+				"required": []string{"plan_id", "date"},
+			},
+			mutating: true,
+			summary: func(userID string, args aiToolArgs) (string, error) {
+				date, err := time.Parse("2006-01-02", args.Date)
+				if err != nil {
+					return "", errors.New("date 格式错误，应为 YYYY-MM-DD")
+				}
+				// 提前检查，避免请用户确认一个无法执行的操作
+				plan, err := s.reschedulablePlan(userID, args.PlanID)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("把 %s 的「%s」调整到 %s", plan.Date.Format("2006-01-02"), plan.Name, date.Format("2006-01-02")), nil
+			},
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				// 确认前后计划可能已经完成，执行时再检查一次
+				if _, err := s.reschedulablePlan(userID, args.PlanID); err != nil {
+					return nil, err
+				}
+				plan, err := s.training.UpdatePlan(userID, args.PlanID, models.UpdatePlanRequest{Date: args.Date})
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"id": plan.ID, "name": plan.Name, "date": plan.Date.Format("2006-01-02")}, nil
+			},
+		},
+		{
+			name:        "get_nutrition_summary",
+			description: "查询日期范围内每天的饮食记录汇总（热量、蛋白质、碳水、脂肪），默认最近 7 天，最多 31 天",
+			parameters:  dateRange,
+			run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+				today := s.today(userID)
+				from, to, err := toolDateRange(args, today.AddDate(0, 0, -6), today)
+				if err != nil {
+					return nil, err
+				}
+				return s.nutrition.Summary(userID, from, to)
+			},
+		},
+	}
+}
+
+// Definitions 提供给模型的工具列表
+func (s *AIToolService) Definitions() []LLMTool {
+	tools := make([]LLMTool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, LLMTool{
+			Type:     "function",
+			Function: LLMFunction{Name: tool.name, Description: tool.description, Parameters: tool.parameters},
+		})
+	}
+	return tools
+}
+
+// Invoke 执行模型请求的工具调用，返回交给模型的结果（JSON）和审计记录
+// 修改数据的工具不执行，记录为待确认，结果中告知模型等待用户确认
+func (s *AIToolService) Invoke(ctx context.Context, userID, conversationID string, call LLMToolCall) (string, *models.AIToolInvocation) {
+	now := time.Now()
+	invocation := &models.AIToolInvocation{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		ConversationID: conversationID,
+		ToolCallID:     call.ID,
+		Tool:           call.Function.Name,
+		Arguments:      call.Function.Arguments,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	tool, args, err := s.resolve(call.Function.Name, call.Function.Arguments)
+	if err != nil {
+		s.finish(invocation, nil, err)
+		return invocation.Result, invocation
+	}
+	invocation.Mutating = tool.mutating
+
+	if tool.mutating {
+		summary, err := tool.summary(userID, args)
+		if err != nil {
+			s.finish(invocation, nil, err)
+			return invocation.Result, invocation
+		}
+		invocation.Status = models.AIToolStatusPending
+		invocation.Summary = summary
+		invocation.Result = toolResultJSON(map[string]interface{}{
+			"status":  "pending_confirmation",
+			"message": "已请用户在界面上确认：" + summary + "。用户确认前操作不会执行，请告诉用户需要确认，不要说已经完成。",
+		})
+		s.save(invocation)
+		return invocation.Result, invocation
+	}
+
+	result, err := tool.run(ctx, userID, args)
+	s.finish(invocation, result, err)
+	return invocation.Result, invocation
+}
+
+// Confirm 用户确认或拒绝待确认的工具调用，确认后执行
+func (s *AIToolService) Confirm(ctx context.Context, userID, invocationID string, approve bool) (*models.AIToolInvocation, error) {
+	s.failInterrupted(userID)
+
+	var invocation models.AIToolInvocation
+	if err := s.db.Where("id = ? AND user_id = ?", invocationID, userID).First(&invocation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrToolInvocationNotFound
+		}
+		logger.Error.Printf("查询工具调用失败: id=%v, error=%v", invocationID, err.Error())
+		return nil, err
+	}
+	if invocation.Status != models.AIToolStatusPending {
+		return nil, ErrToolInvocationNotPending
+	}
+
+	now := time.Now()
+	next := models.AIToolStatusRunning
+	switch {
+	case now.Sub(invocation.CreatedAt) > aiToolConfirmTTL:
+		next = models.AIToolStatusExpired
+	case !approve:
+		next = models.AIToolStatusRejected
+	}
+	// 只有仍在等待确认时才能改变状态，避免重复确认导致重复执行
+	result := s.db.Model(&models.AIToolInvocation{}).
+		Where("id = ? AND status = ?", invocation.ID, models.AIToolStatusPending).
+		Updates(map[string]interface{}{"status": next, "confirmed_at": now, "updated_at": now})
+	if result.Error != nil {
+		logger.Error.Printf("更新工具调用失败: id=%v, error=%v", invocation.ID, result.Error.Error())
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || next == models.AIToolStatusExpired {
+		return nil, ErrToolInvocationNotPending
+	}
+	invocation.Status = next
+	invocation.ConfirmedAt = &now
+	if next == models.AIToolStatusRejected {
+		return &invocation, nil
+	}
+
+	tool, args, err := s.resolve(invocation.Tool, invocation.Arguments)
+	if err != nil {
+		s.finish(&invocation, nil, err)
+		return &invocation, nil
+	}
+	output, err := runTool(ctx, tool, userID, args)
+	s.finish(&invocation, output, err)
+	return &invocation, nil
+}
+
+// runTool 执行工具，panic 时按执行失败处理，避免调用记录停留在执行中
+func runTool(ctx context.Context, tool *aiTool, userID string, args aiToolArgs) (output interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error.Printf("执行工具失败: tool=%v, user_id=%v, panic=%v", tool.name, userID, r)
+			output, err = nil, errToolInterrupted
+		}
+	}()
+	return tool.run(ctx, userID, args)
+}
+
+// failInterrupted 把执行超过 aiToolRunTimeout 仍未结束的调用标记为失败
+// 执行中服务重启时调用记录会停留在执行中，用户查看或确认时再处理
+func (s *AIToolService) failInterrupted(userID string) {
+	now := time.Now()
+	if err := s.db.Model(&models.AIToolInvocation{}).
+		Where("user_id = ? AND status = ? AND updated_at < ?", userID, models.AIToolStatusRunning, now.Add(-aiToolRunTimeout)).
+		Updates(map[string]interface{}{
+			"status":     models.AIToolStatusFailed,
+			"error":      errToolInterrupted.Error(),
+			"result":     toolResultJSON(map[string]string{"error": errToolInterrupted.Error()}),
+			"updated_at": now,
+		}).Error; err != nil {
+		logger.Error.Printf("更新中断的工具调用失败: user_id=%v, error=%v", userID, err.Error())
+	}
+}
+
+// ListInvocations 工具调用审计记录，最新的在前；conversationID 为空时返回全部对话的记录
+func (s *AIToolService) ListInvocations(userID, conversationID string, skip, limit int) ([]models.AIToolInvocation, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	s.failInterrupted(userID)
+
+	query := s.db.Where("user_id = ?", userID)
+	if conversationID != "" {
+		query = query.Where("conversation_id = ?", conversationID)
+	}
+	invocations := []models.AIToolInvocation{}
+	if err := query.Order("created_at DESC").Offset(skip).Limit(limit).Find(&invocations).Error; err != nil {
+		logger.Error.Printf("查询工具调用记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return invocations, nil
+}
+
+// resolve 查找白名单中的工具并解析参数
+func (s *AIToolService) resolve(name, arguments string) (*aiTool, aiToolArgs, error) {
+	var args aiToolArgs
+	for i := range s.tools {
+		if s.tools[i].name != name {
+			continue
+		}
+		if strings.TrimSpace(arguments) != "" {
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return nil, args, fmt.Errorf("参数格式错误: %v", err)
+			}
+		}
+		return &s.tools[i], args, nil
+	}
+	return nil, args, fmt.Errorf("不支持的工具: %s", name)
+}
+
+// finish 记录执行结果并保存审计记录
+func (s *AIToolService) finish(invocation *models.AIToolInvocation, output interface{}, err error) {
+	if err != nil {
+		invocation.Status = models.AIToolStatusFailed
+		invocation.Error = err.Error()
+		invocation.Result = toolResultJSON(map[string]string{"error": err.Error()})
+	} else {
+		invocation.Status = models.AIToolStatusSucceeded
+		invocation.Result = toolResultJSON(output)
+	}
+	s.save(invocation)
+}
+
+// save 保存审计记录，失败时只记录日志，不影响对话
+func (s *AIToolService) save(invocation *models.AIToolInvocation) {
+	invocation.UpdatedAt = time.Now()
+	if err := s.db.Save(invocation).Error; err != nil {
+		logger.Error.Printf("保存工具调用记录失败: id=%v, tool=%v, error=%v", invocation.ID, invocation.Tool, err.Error())
+	}
+}
+
+// toolResultJSON 把工具结果编码为 JSON，超过 aiToolMaxResult 个字符时截断
+func toolResultJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return `{"error":"结果编码失败"}`
+	}
+	if runes := []rune(string(data)); len(runes) > aiToolMaxResult {
+		return string(runes[:aiToolMaxResult]) + "…（结果过长，已截断）"
+	}
+	return string(data)
+}
+
+// reschedulablePlan 查询 reschedule_plan 要调整的计划，并检查是否允许调整
+func (s *AIToolService) reschedulablePlan(userID, planID string) (*models.TrainingPlan, error) {
+	var plan models.TrainingPlan
+	if err := s.db.Where("id = ? AND user_id = ? AND is_template = ?", planID, userID, false).First(&plan).Error; err != nil {
+		return nil, errors.New("训练计划不存在")
+	}
+	if err := checkReschedule(plan, s.today(userID)); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// today 用户时区的当前时间，工具中的"今天"和默认日期范围按用户时区计算
+func (s *AIToolService) today(userID string) time.Time {
+	return time.Now().In(s.streaks.UserLocation(userID))
+}
+
+// checkReschedule AI 教练只能调整未完成且日期未过去的计划，today 为用户时区的当前时间
+func checkReschedule(plan models.TrainingPlan, today time.Time) error {
+	if plan.Status == "completed" {
+		return errors.New("已完成的训练计划不能调整日期")
+	}
+	if plan.Date.Format("2006-01-02") < today.Format("2006-01-02") {
+		return errors.New("已过去的训练计划不能调整日期")
+	}
+	return nil
+}
+
+// toolDateRange 解析日期范围参数，缺省时使用默认值，超过 aiToolMaxRangeDays 天时缩短结束日期
+// 参数中的日期按默认值的时区（用户时区）解析
+func toolDateRange(args aiToolArgs, defaultFrom, defaultTo time.Time) (time.Time, time.Time, error) {
+	parse := func(value string, fallback time.Time) (time.Time, error) {
+		if value == "" {
+			return fallback, nil
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, fallback.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("日期 %s 格式错误，应为 YYYY-MM-DD", value)
+		}
+		return date, nil
+	}
+
+	from, err := parse(args.From, defaultFrom)
+	if err != nil {
+		return from, from, err
+	}
+	to, err := parse(args.To, defaultTo)
+	if err != nil {
+		return from, to, err
+	}
+	if to.Before(from) {
+		from, to = to, from
+	}
+	if latest := from.AddDate(0, 0, aiToolMaxRangeDays-1); to.After(latest) {
+		to = latest
+	}
+	return from, to, nil
+}
+
+// aiToolPlan get_plans 返回的计划摘要
+type aiToolPlan struct {
+	ID        string   `json:"id"`
+	Date      string   `json:"date"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Exercises []string `json:"exercises"`
+}
+
+// summarizePlans 计划摘要，只保留模型需要的字段
+func summarizePlans(plans []models.TrainingPlanResponse) []aiToolPlan {
+	result := make([]aiToolPlan, 0, len(plans))
+	for _, plan := range plans {
+		summary := aiToolPlan{ID: plan.ID, Date: plan.Date.Format("2006-01-02"), Name: plan.Name, Status: plan.Status}
+		for _, exercise := range plan.Exercises {
+			summary.Exercises = append(summary.Exercises, exercise.Name)
+		}
+		result = append(result, summary)
+	}
+	return result
+}
+
+// aiToolProgress get_exercise_progress 返回的某一类记录的变化
+type aiToolProgress struct {
+	Type          string  `json:"type"` // estimated_1rm, max_weight
+	Start         float64 `json:"start"`
+	Current       float64 `json:"current"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+	NewRecords    int     `json:"new_records"` // 期间刷新记录的次数
+}
+
+// exerciseProgress 按 PR 时间线计算动作最近 days 天的进步，timeline 需按时间升序
+// 起始值取期间开始前的最后一条记录；期间开始前没有记录时取期间第一条记录刷新前的值，也没有时取第一条记录
+func exerciseProgress(exercise string, timeline []models.PersonalRecord, days int, now time.Time) map[string]interface{} {
+	since := now.AddDate(0, 0, -days)
+	var metrics []aiToolProgress
+	for _, recordType := range []string{models.RecordEstimated1RM, models.RecordMaxWeight} {
+		var before, first *models.PersonalRecord
+		var current float64
+		progress := aiToolProgress{Type: recordType}
+		for i := range timeline {
+			row := &timeline[i]
+			if row.RecordType != recordType {
+				continue
+			}
+			current = row.Value
+			if row.AchievedAt.Before(since) {
+				before = row
+				continue
+			}
+			if first == nil {
+				first = row
+			}
+			progress.NewRecords++
+		}
+		switch {
+		case before != nil:
+			progress.Start = before.Value
+		case first != nil && first.PreviousValue > 0:
+			progress.Start = first.PreviousValue
+		case first != nil:
+			progress.Start = first.Value
+		default:
+			continue
+		}
+		progress.Current = current
+		progress.Change = roundTo(current-progress.Start, 1)
+		if progress.Start > 0 {
+			progress.ChangePercent = roundTo((current-progress.Start)/progress.Start*100, 1)
+		}
+		metrics = append(metrics, progress)
+	}
+
+	result := map[string]interface{}{"exercise": exercise, "days": days, "metrics": metrics}
+	if len(metrics) == 0 {
+		result["message"] = "没有找到该动作的训练记录"
+	}
+	return result
+}
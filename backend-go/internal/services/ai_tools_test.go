@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExerciseProgress(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	timeline := []models.PersonalRecord{
+		{RecordType: models.RecordEstimated1RM, Value: 100, AchievedAt: now.AddDate(0, 0, -45)},
+		{RecordType: models.RecordMaxWeight, Value: 90, PreviousValue: 85, AchievedAt: now.AddDate(0, 0, -20)},
+		{RecordType: models.RecordEstimated1RM, Value: 105, PreviousValue: 100, AchievedAt: now.AddDate(0, 0, -20)},
+		{RecordType: models.RecordEstimated1RM, Value: 110, PreviousValue: 105, AchievedAt: now.AddDate(0, 0, -3)},
+	}
+
+	result := exerciseProgress("深蹲", timeline, 30, now)
+	metrics := result["metrics"].([]aiToolProgress)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, aiToolProgress{Type: models.RecordEstimated1RM, Start: 100, Current: 110, Change: 10, ChangePercent: 10, NewRecords: 2}, metrics[0])
+	// 期间开始前没有记录时，起始值取第一次刷新前的值
+	assert.Equal(t, aiToolProgress{Type: models.RecordMaxWeight, Start: 85, Current: 90, Change: 5, ChangePercent: 5.9, NewRecords: 1}, metrics[1])
+
+	empty := exerciseProgress("硬拉", nil, 30, now)
+	assert.Empty(t, empty["metrics"])
+	assert.Equal(t, "没有找到该动作的训练记录", empty["message"])
+}
+
+func TestToolDateRange(t *testing.T) {
+	today := time.Date(2024, 6, 13, 0, 0, 0, 0, time.Local)
+
+	from, to, err := toolDateRange(aiToolArgs{}, today, today.AddDate(0, 0, 6))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-13", from.Format("2006-01-02"))
+	assert.Equal(t, "2024-06-19", to.Format("2006-01-02"))
+
+	from, to, err = toolDateRange(aiToolArgs{From: "2024-06-20", To: "2024-01-01"}, today, today)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", from.Format("2006-01-02"))
+	assert.Equal(t, "2024-01-31", to.Format("2006-01-02"))
+
+	_, _, err = toolDateRange(aiToolArgs{From: "6月20日"}, today, today)
+	assert.Error(t, err)
+
+	// 参数日期按用户时区解析
+	shanghai := loadLocation("Asia/Shanghai")
+	from, _, err = toolDateRange(aiToolArgs{From: "2024-06-20"}, today.In(shanghai), today.In(shanghai))
+	require.NoError(t, err)
+	assert.Equal(t, shanghai, from.Location())
+}
+
+func TestAIToolResolve(t *testing.T) {
+	s := NewAIToolService(nil, nil, nil, nil, nil)
+
+	tool, args, err := s.resolve("reschedule_plan", `{"plan_id":"p1","date":"2024-06-14"}`)
+	require.NoError(t, err)
+	assert.True(t, tool.mutating)
+	assert.Equal(t, aiToolArgs{PlanID: "p1", Date: "2024-06-14"}, args)
+
+	_, _, err = s.resolve("delete_account", `{}`)
+	assert.EqualError(t, err, "不支持的工具: delete_account")
+	_, _, err = s.resolve("get_plans", `{"from":`)
+	assert.Error(t, err)
+
+	var names []string
+	for _, definition := range s.Definitions() {
+		names = append(names, definition.Function.Name)
+	}
+	assert.Equal(t, []string{"get_profile", "get_training_stats", "get_exercise_progress", "get_plans", "reschedule_plan", "get_nutrition_summary"}, names)
+}
+
+func TestRunToolRecoversPanic(t *testing.T) {
+	logger.Init("error")
+	tool := &aiTool{name: "broken", run: func(ctx context.Context, userID string, args aiToolArgs) (interface{}, error) {
+		panic("nil plan")
+	}}
+
+	output, err := runTool(context.Background(), tool, "u1", aiToolArgs{})
+	assert.Nil(t, output)
+	assert.ErrorIs(t, err, errToolInterrupted)
+}
+
+func TestToolResultJSONTruncates(t *testing.T) {
+	assert.Equal(t, `{"ok":true}`, toolResultJSON(map[string]bool{"ok": true}))
+
+	long := toolResultJSON(strings.Repeat("练", aiToolMaxResult))
+	assert.True(t, strings.HasSuffix(long, "（结果过长，已截断）"))
+	assert.Equal(t, aiToolMaxResult, len([]rune(strings.TrimSuffix(long, "…（结果过长，已截断）"))))
+}
+
+func TestSummarizeNutrition(t *testing.T) {
+	day1 := time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	records := []models.NutritionRecord{
+		{Date: day1, MealType: "早餐", Calories: 300, Protein: 20},
+		{Date: day1, MealType: "早餐", Calories: 150, Protein: 5},
+		{Date: day1.Add(4 * time.Hour), MealType: "午餐", Calories: 650, Protein: 35},
+		{Date: day2, MealType: "晚餐", Calories: 500, Protein: 30},
+	}
+
+	summary := summarizeNutrition(records, day1, day2.AddDate(0, 0, 1))
+	assert.Equal(t, "2024-06-10", summary.From)
+	assert.Equal(t, "2024-06-12", summary.To)
+	require.Len(t, summary.Days, 2)
+	assert.Equal(t, models.NutritionDaySummary{Date: "2024-06-10", Calories: 1100, Protein: 60, Meals: 2, Records: 3}, summary.Days[0])
+	assert.Equal(t, 1, summary.Days[1].Meals)
+	assert.Equal(t, 800.0, summary.AvgCalories)
+	assert.Equal(t, 45.0, summary.AvgProtein)
+}
+
+func TestStreamCollectsToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Len(t, body["tools"], 1)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, call := range []map[string]interface{}{
+			{"index": 0, "id": "call_1", "type": "function", "function": map[string]string{"name": "get_plans", "arguments": `{"from":`}},
+			{"index": 0, "function": map[string]string{"arguments": `"2024-06-14"}`}},
+		} {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"model":   "mock-model",
+				"choices": []map[string]interface{}{{"delta": map[string]interface{}{"tool_calls": []interface{}{call}}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewLocalProvider(server.URL, "", "")
+	resp, err := provider.Stream(context.Background(), LLMRequest{
+		Messages: []LLMMessage{{Role: LLMRoleUser, Content: "明天练什么"}},
+		Tools:    NewAIToolService(nil, nil, nil, nil, nil).Definitions()[3:4],
+	}, func(delta string) error { return nil })
+	require.NoError(t, err)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "call_1", resp.ToolCalls[0].ID)
+	assert.Equal(t, "get_plans", resp.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"from":"2024-06-14"}`, resp.ToolCalls[0].Function.Arguments)
+	assert.Empty(t, resp.Content)
+}
+
+func TestCheckReschedule(t *testing.T) {
+	now := time.Date(2024, 6, 12, 15, 0, 0, 0, time.UTC)
+	plan := func(status string, date time.Time) models.TrainingPlan {
+		return models.TrainingPlan{Status: status, Date: date}
+	}
+
+	assert.NoError(t, checkReschedule(plan("pending", now.Add(2*time.Hour)), now))
+	// 当天早些时候的计划仍可调整
+	assert.NoError(t, checkReschedule(plan("pending", time.Date(2024, 6, 12, 7, 0, 0, 0, time.UTC)), now))
+	assert.EqualError(t, checkReschedule(plan("pending", now.AddDate(0, 0, -1)), now), "已过去的训练计划不能调整日期")
+	assert.EqualError(t, checkReschedule(plan("completed", now.AddDate(0, 0, 1)), now), "已完成的训练计划不能调整日期")
+
+	// 按用户时区判断：UTC 仍是 6 月 12 日时，上海已经是 6 月 13 日
+	shanghai := now.Add(6 * time.Hour).In(loadLocation("Asia/Shanghai"))
+	assert.EqualError(t, checkReschedule(plan("pending", time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)), shanghai), "已过去的训练计划不能调整日期")
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultVolumeWeeks 训练量分析默认统计的周数
+	defaultVolumeWeeks = 8
+	// maxVolumeWeeks 训练量分析最多统计的周数
+	maxVolumeWeeks = 52
+	// favoriteWindowWeeks 计算最常训练动作时统计的周数
+	favoriteWindowWeeks = 12
+	// secondaryMuscleFactor 次要肌群按该比例计入组数和训练量
+	secondaryMuscleFactor = 0.5
+	// unknownMuscleGroup 动作没有肌群和分类信息时的归类
+	unknownMuscleGroup = "其他"
+)
+
+// AnalyticsService 训练数据分析服务
+// 从已完成的组（ExerciseSet）按周、肌群、动作聚合训练量，周的边界按用户时区划分（周一为一周开始）
+type AnalyticsService struct {
+	db            *gorm.DB
+	streakService *StreakService
+}
+
+// volumeSet 参与训练量统计的一组
+type volumeSet struct {
+	At        time.Time
+	Exercise  string
+	CatalogID uint
+	Primary   []string
+	Secondary []string
+	Reps      int
+	Weight    float64
+}
+
+// NewAnalyticsService 创建训练数据分析服务
+func NewAnalyticsService(db *gorm.DB, streakService *StreakService) *AnalyticsService {
+	return &AnalyticsService{db: db, streakService: streakService}
+}
+
+// GetVolume 获取最近若干周的训练量时间序列
+func (s *AnalyticsService) GetVolume(userID string, weeks int) (*models.VolumeAnalyticsResponse, error) {
+	if weeks <= 0 {
+		weeks = defaultVolumeWeeks
+	}
+	if weeks > maxVolumeWeeks {
+		weeks = maxVolumeWeeks
+	}
+
+	loc := s.streakService.UserLocation(userID)
+	starts := weekStarts(time.Now(), loc, weeks)
+
+	sets, err := s.loadSets(userID, localDayStart(starts[0], loc))
+	if err != nil {
+		return nil, err
+	}
+	return aggregateVolume(sets, starts, loc), nil
+}
+
+// FavoriteExercise 最近一段时间完成组数最多的动作，没有训练数据时返回空字符串
+func (s *AnalyticsService) FavoriteExercise(userID string) string {
+	volume, err := s.GetVolume(userID, favoriteWindowWeeks)
+	if err != nil {
+		return ""
+	}
+	return favoriteExercise(volume.Exercises)
+}
+
+// favoriteExercise 区间内总组数最多的动作；按组数而不是训练量比较，自重动作的训练量为 0
+func favoriteExercise(exercises []models.VolumeSeries) string {
+	favorite, best := "", 0.0
+	for _, exercise := range exercises {
+		total := 0.0
+		for _, sets := range exercise.Sets {
+			total += sets
+		}
+		if total > best || (total == best && total > 0 && exercise.Name < favorite) {
+			favorite, best = exercise.Name, total
+		}
+	}
+	return favorite
+}
+
+// RefreshFavorite 重新计算并写回 UserStats.FavoriteExercise
+func (s *AnalyticsService) RefreshFavorite(userID string) {
+	favorite := s.FavoriteExercise(userID)
+	if favorite == "" {
+		return
+	}
+	err := s.db.Model(&models.UserStats{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"favorite_exercise": favorite, "updated_at": time.Now()}).Error
+	if err != nil {
+		logger.Error.Printf("更新最常训练动作失败: user_id=%v, error=%v", userID, err.Error())
+	}
+}
+
+// WeeklyStats 最近若干周每周的训练次数、时长和卡路里
+func (s *AnalyticsService) WeeklyStats(userID string, weeks int) []models.WeeklyStat {
+	loc := s.streakService.UserLocation(userID)
+	starts := weekStarts(time.Now(), loc, weeks)
+
+	var records []models.WorkoutRecord
+	err := s.db.Select("start_time", "duration", "calories").
+		Where("user_id = ? AND status = ? AND start_time >= ?", userID, models.WorkoutStatusCompleted, localDayStart(starts[0], loc)).
+		Find(&records).Error
+	if err != nil {
+		logger.Error.Printf("查询周训练统计失败: user_id=%v, error=%v", userID, err.Error())
+		return []models.WeeklyStat{}
+	}
+
+	return aggregateWeeklyStats(records, starts, loc)
+}
+
+// loadSets 加载用户自 from 起完成的所有组，并补全动作对应的标准动作肌群
+func (s *AnalyticsService) loadSets(userID string, from time.Time) ([]volumeSet, error) {
+	// 组的完成时间可能晚于计划日期，按计划日期多取一周
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND is_template = ? AND date >= ?", userID, false, from.AddDate(0, 0, -7)).
+		Preload("Exercises.Sets", "completed = ?", true).
+		Find(&plans).Error
+	if err != nil {
+		logger.Error.Printf("查询训练量数据失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	catalog := make(map[uint]models.Exercise)
+	var catalogIDs []uint
+	for _, plan := range plans {
+		for _, exercise := range plan.Exercises {
+			if exercise.CatalogID != 0 {
+				catalogIDs = append(catalogIDs, exercise.CatalogID)
+			}
+		}
+	}
+	if len(catalogIDs) > 0 {
+		var entries []models.Exercise
+		if err := s.db.Where("id IN ?", catalogIDs).Find(&entries).Error; err != nil {
+			logger.Error.Printf("查询标准动作失败: error=%v", err.Error())
+		}
+		for _, entry := range entries {
+			catalog[entry.ID] = entry
+		}
+	}
+
+	var sets []volumeSet
+	for _, plan := range plans {
+		for _, exercise := range plan.Exercises {
+			name := exercise.Name
+			primary, secondary := exerciseMuscles(exercise, catalog)
+			if entry, ok := catalog[exercise.CatalogID]; ok {
+				name = entry.Name
+			}
+
+			for _, set := range exercise.Sets {
+				if !countsTowardVolume(set) {
+					continue
+				}
+				at := plan.Date
+				if set.CompletedAt != nil {
+					at = *set.CompletedAt
+				}
+				if at.Before(from) {
+					continue
+				}
+				sets = append(sets, volumeSet{
+					At:        at,
+					Exercise:  name,
+					CatalogID: exercise.CatalogID,
+					Primary:   primary,
+					Secondary: secondary,
+					Reps:      set.Reps,
+					Weight:    set.Weight,
+				})
+			}
+		}
+	}
+	return sets, nil
+}
+
+// exerciseMuscles 动作的主要和次要肌群
+// 关联了标准动作时使用动作库的主次肌群，否则计划中的肌群都按主要肌群计算，再退化为动作分类
+func exerciseMuscles(exercise models.TrainingExercise, catalog map[uint]models.Exercise) ([]string, []string) {
+	if entry, ok := catalog[exercise.CatalogID]; ok && len(entry.PrimaryMuscleGroups) > 0 {
+		return entry.PrimaryMuscleGroups, entry.SecondaryMuscleGroups
+	}
+	if len(exercise.MuscleGroups) > 0 {
+		return exercise.MuscleGroups, nil
+	}
+	if exercise.Category != "" {
+		return []string{exercise.Category}, nil
+	}
+	return []string{unknownMuscleGroup}, nil
+}
+
+// aggregateVolume 按周聚合训练量，生成总量、肌群和动作三个维度的时间序列
+func aggregateVolume(sets []volumeSet, starts []time.Time, loc *time.Location) *models.VolumeAnalyticsResponse {
+	n := len(starts)
+	index := make(map[time.Time]int, n)
+	weeks := make([]string, n)
+	for i, start := range starts {
+		index[start] = i
+		weeks[i] = start.Format(dayLayout)
+	}
+
+	resp := &models.VolumeAnalyticsResponse{
+		Weeks:       weeks,
+		TotalSets:   make([]float64, n),
+		TotalVolume: make([]float64, n),
+	}
+	muscles := make(map[string]*models.VolumeSeries)
+	exercises := make(map[string]*models.VolumeSeries)
+	thisWeek := make(map[string]*models.VolumeBucket)
+	thisWeekExercises := make(map[string]*models.VolumeBucket)
+
+	series := func(m map[string]*models.VolumeSeries, name string) *models.VolumeSeries {
+		if m[name] == nil {
+			m[name] = &models.VolumeSeries{Name: name, Sets: make([]float64, n), Volume: make([]float64, n)}
+		}
+		return m[name]
+	}
+	addMuscle := func(i int, name string, factor float64, set volumeSet) {
+		volume := set.Weight * float64(set.Reps) * factor
+		ms := series(muscles, name)
+		ms.Sets[i] += factor
+		ms.Volume[i] += volume
+
+		if i != n-1 {
+			return
+		}
+		if thisWeek[name] == nil {
+			thisWeek[name] = &models.VolumeBucket{Name: name}
+		}
+		thisWeek[name].Sets += factor
+		thisWeek[name].Volume += volume
+		if factor == 1 {
+			thisWeek[name].Reps += set.Reps
+		}
+	}
+
+	for _, set := range sets {
+		i, ok := index[weekStart(set.At, loc)]
+		if !ok {
+			continue
+		}
+		volume := set.Weight * float64(set.Reps)
+		resp.TotalSets[i]++
+		resp.TotalVolume[i] += volume
+
+		es := series(exercises, set.Exercise)
+		es.Sets[i]++
+		es.Volume[i] += volume
+		if i == n-1 {
+			if thisWeekExercises[set.Exercise] == nil {
+				thisWeekExercises[set.Exercise] = &models.VolumeBucket{Name: set.Exercise, CatalogID: set.CatalogID}
+			}
+			thisWeekExercises[set.Exercise].Sets++
+			thisWeekExercises[set.Exercise].Reps += set.Reps
+			thisWeekExercises[set.Exercise].Volume += volume
+		}
+
+		for _, muscle := range set.Primary {
+			addMuscle(i, muscle, 1, set)
+		}
+		for _, muscle := range set.Secondary {
+			addMuscle(i, muscle, secondaryMuscleFactor, set)
+		}
+	}
+
+	roundSeries(resp.TotalVolume)
+	resp.MuscleGroups = sortedSeries(muscles, func(s *models.VolumeSeries) []float64 { return s.Sets })
+	resp.Exercises = sortedSeries(exercises, func(s *models.VolumeSeries) []float64 { return s.Volume })
+
+	resp.ThisWeek = sortedBuckets(thisWeek, func(b models.VolumeBucket) float64 { return b.Sets })
+	resp.ThisWeekExercises = sortedBuckets(thisWeekExercises, func(b models.VolumeBucket) float64 { return b.Volume })
+
+	return resp
+}
+
+// sortedBuckets 按 key 降序排列本周训练量，并对训练量取一位小数
+func sortedBuckets(m map[string]*models.VolumeBucket, key func(models.VolumeBucket) float64) []models.VolumeBucket {
+	result := make([]models.VolumeBucket, 0, len(m))
+	for _, bucket := range m {
+		bucket.Volume = roundVolume(bucket.Volume)
+		result = append(result, *bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if key(result[i]) != key(result[j]) {
+			return key(result[i]) > key(result[j])
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// aggregateWeeklyStats 按周汇总训练次数、时长和卡路里
+func aggregateWeeklyStats(records []models.WorkoutRecord, starts []time.Time, loc *time.Location) []models.WeeklyStat {
+	stats := make([]models.WeeklyStat, len(starts))
+	index := make(map[time.Time]int, len(starts))
+	for i, start := range starts {
+		index[start] = i
+		stats[i].Week = start.Format(dayLayout)
+	}
+
+	for _, record := range records {
+		i, ok := index[weekStart(record.StartTime, loc)]
+		if !ok {
+			continue
+		}
+		stats[i].Workouts++
+		stats[i].Duration += record.Duration
+		stats[i].Calories += record.Calories
+	}
+	return stats
+}
+
+// sortedSeries 按 key 序列之和降序排列时间序列，并对训练量取一位小数
+func sortedSeries(m map[string]*models.VolumeSeries, key func(*models.VolumeSeries) []float64) []models.VolumeSeries {
+	result := make([]models.VolumeSeries, 0, len(m))
+	totals := make(map[string]float64, len(m))
+	for name, s := range m {
+		roundSeries(s.Volume)
+		for _, v := range key(s) {
+			totals[name] += v
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if totals[result[i].Name] != totals[result[j].Name] {
+			return totals[result[i].Name] > totals[result[j].Name]
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// roundSeries 训练量保留一位小数
+func roundSeries(values []float64) {
+	for i := range values {
+		values[i] = roundVolume(values[i])
+	}
+}
+
+func roundVolume(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// weekStarts 截至 now 所在周的最近 weeks 个周一（本地日期），按时间升序
+func weekStarts(now time.Time, loc *time.Location, weeks int) []time.Time {
+	if weeks <= 0 {
+		weeks = 1
+	}
+	current := weekStart(now, loc)
+	starts := make([]time.Time, weeks)
+	for i := range starts {
+		starts[i] = current.AddDate(0, 0, -7*(weeks-1-i))
+	}
+	return starts
+}
+
+// weekStart 时刻所在周的周一（本地日期，以 UTC 零点表示）
+func weekStart(t time.Time, loc *time.Location) time.Time {
+	day := localDay(t, loc)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// localDayStart 本地日期（UTC 零点表示）对应的真实起始时刻
+func localDayStart(day time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeekStart(t *testing.T) {
+	loc := loadLocation("Asia/Shanghai")
+
+	// 周日 23:30（上海）仍属于本周，周一 00:30（上海）属于下一周
+	sunday := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	monday := time.Date(2024, 3, 10, 16, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2024-03-04", weekStart(sunday, loc).Format(dayLayout))
+	assert.Equal(t, "2024-03-11", weekStart(monday, loc).Format(dayLayout))
+
+	starts := weekStarts(monday, loc, 3)
+	assert.Equal(t, "2024-02-26", starts[0].Format(dayLayout))
+	assert.Equal(t, "2024-03-11", starts[2].Format(dayLayout))
+}
+
+func TestAggregateVolume(t *testing.T) {
+	loc := time.UTC
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, loc)
+	starts := weekStarts(now, loc, 2)
+	lastWeek := now.AddDate(0, 0, -7)
+
+	bench := volumeSet{Exercise: "卧推", CatalogID: 1, Primary: []string{"胸肌"}, Secondary: []string{"三头肌"}, Reps: 10, Weight: 60}
+	row := volumeSet{Exercise: "杠铃划船", Primary: []string{"背阔肌"}, Reps: 8, Weight: 50}
+
+	var sets []volumeSet
+	for i := 0; i < 3; i++ {
+		s := bench
+		s.At = now
+		sets = append(sets, s)
+	}
+	r := row
+	r.At = now
+	sets = append(sets, r)
+	r.At = lastWeek
+	sets = append(sets, r)
+	r.At = now.AddDate(0, 0, -30) // 超出统计区间
+	sets = append(sets, r)
+
+	resp := aggregateVolume(sets, starts, loc)
+
+	assert.Equal(t, []string{"2024-03-04", "2024-03-11"}, resp.Weeks)
+	assert.Equal(t, []float64{1, 4}, resp.TotalSets)
+	assert.Equal(t, []float64{400, 2200}, resp.TotalVolume)
+
+	assert.Equal(t, "胸肌", resp.MuscleGroups[0].Name)
+	assert.Equal(t, []float64{0, 3}, resp.MuscleGroups[0].Sets)
+	assert.Equal(t, "杠铃划船", resp.Exercises[1].Name)
+
+	assert.Len(t, resp.ThisWeek, 3)
+	assert.Equal(t, models.VolumeBucket{Name: "胸肌", Sets: 3, Reps: 30, Volume: 1800}, resp.ThisWeek[0])
+	assert.Equal(t, models.VolumeBucket{Name: "三头肌", Sets: 1.5, Volume: 900}, resp.ThisWeek[1])
+	assert.Equal(t, models.VolumeBucket{Name: "背阔肌", Sets: 1, Reps: 8, Volume: 400}, resp.ThisWeek[2])
+
+	assert.Equal(t, []models.VolumeBucket{
+		{Name: "卧推", CatalogID: 1, Sets: 3, Reps: 30, Volume: 1800},
+		{Name: "杠铃划船", Sets: 1, Reps: 8, Volume: 400},
+	}, resp.ThisWeekExercises)
+}
+
+func TestFavoriteExercise(t *testing.T) {
+	assert.Equal(t, "", favoriteExercise(nil))
+	assert.Equal(t, "引体向上", favoriteExercise([]models.VolumeSeries{
+		{Name: "卧推", Sets: []float64{1, 2}, Volume: []float64{600, 1200}},
+		{Name: "引体向上", Sets: []float64{3, 2}, Volume: []float64{0, 0}},
+		{Name: "深蹲", Sets: []float64{0, 0}, Volume: []float64{0, 0}},
+	}))
+}
+
+func TestExerciseMuscles(t *testing.T) {
+	catalog := map[uint]models.Exercise{
+		1: {ID: 1, PrimaryMuscleGroups: []string{"胸肌"}, SecondaryMuscleGroups: []string{"三头肌"}},
+	}
+
+	primary, secondary := exerciseMuscles(models.TrainingExercise{CatalogID: 1, MuscleGroups: []string{"胸肌", "三头肌"}}, catalog)
+	assert.Equal(t, []string{"胸肌"}, primary)
+	assert.Equal(t, []string{"三头肌"}, secondary)
+
+	primary, secondary = exerciseMuscles(models.TrainingExercise{MuscleGroups: []string{"股四头肌"}}, catalog)
+	assert.Equal(t, []string{"股四头肌"}, primary)
+	assert.Nil(t, secondary)
+
+	primary, _ = exerciseMuscles(models.TrainingExercise{Category: "腿"}, catalog)
+	assert.Equal(t, []string{"腿"}, primary)
+
+	primary, _ = exerciseMuscles(models.TrainingExercise{}, catalog)
+	assert.Equal(t, []string{unknownMuscleGroup}, primary)
+}
+
+func TestAggregateWeeklyStats(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	starts := weekStarts(now, time.UTC, 2)
+
+	stats := aggregateWeeklyStats([]models.WorkoutRecord{
+		{StartTime: now, Duration: 40, Calories: 300},
+		{StartTime: now.AddDate(0, 0, -1), Duration: 30, Calories: 200},
+		{StartTime: now.AddDate(0, 0, -7), Duration: 50, Calories: 350},
+	}, starts, time.UTC)
+
+	assert.Equal(t, models.WeeklyStat{Week: "2024-03-04", Workouts: 1, Duration: 50, Calories: 350}, stats[0])
+	assert.Equal(t, models.WeeklyStat{Week: "2024-03-11", Workouts: 2, Duration: 70, Calories: 500}, stats[1])
+}
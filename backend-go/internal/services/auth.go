@@ -22,6 +22,16 @@ func NewAuthService(cfg *config.Config, userService *UserService) *AuthService {
 	}
 }
 
+// IsAdmin 用户是否为管理员（ADMIN_USER_IDS 中配置的用户）
+func (s *AuthService) IsAdmin(userID string) bool {
+	for _, id := range s.config.Admin.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateToken 生成JWT token
 func (s *AuthService) GenerateToken(userID uint) (string, error) {
 	claims := jwt.MapClaims{
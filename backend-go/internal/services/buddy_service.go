@@ -308,6 +308,15 @@ func (s *BuddyService) GetMyBuddies(userID string, skip, limit int) ([]models.Bu
 	return responses, nil
 }
 
+// IsBuddy 判断两个用户是否是搭子关系
+func (s *BuddyService) IsBuddy(userID string, buddyID string) bool {
+	var count int64
+	s.db.Model(&models.BuddyRelationship{}).
+		Where("user_id = ? AND buddy_id = ? AND status = ?", userID, buddyID, "active").
+		Count(&count)
+	return count > 0
+}
+
 // DeleteBuddy 删除搭子关系
 func (s *BuddyService) DeleteBuddy(userID string, buddyID string) error {
 	// 开始事务
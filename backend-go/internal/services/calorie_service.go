@@ -0,0 +1,187 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// defaultBodyWeight 用户未填写体重时使用的默认体重（kg）
+const defaultBodyWeight = 70.0
+
+// 训练强度，由动作难度推导
+const (
+	intensityLight    = "light"
+	intensityModerate = "moderate"
+	intensityVigorous = "vigorous"
+)
+
+// metTable 各类训练在不同强度下的 MET 值
+// 参考 Compendium of Physical Activities：力量训练 3.5/5.0/6.0，跑步骑行等有氧 4.8/7.0/9.8
+var metTable = map[string]map[string]float64{
+	"strength":    {intensityLight: 3.5, intensityModerate: 5.0, intensityVigorous: 6.0},
+	"cardio":      {intensityLight: 4.8, intensityModerate: 7.0, intensityVigorous: 9.8},
+	"core":        {intensityLight: 2.8, intensityModerate: 3.8, intensityVigorous: 5.0},
+	"flexibility": {intensityLight: 2.3, intensityModerate: 2.5, intensityVigorous: 3.0},
+}
+
+// CalorieService 卡路里估算服务
+// 客户端没有上报设备实测值时，按 MET × 体重(kg) × 实际训练时长(小时) 估算消耗
+type CalorieService struct {
+	db *gorm.DB
+}
+
+// NewCalorieService 创建卡路里估算服务
+func NewCalorieService(db *gorm.DB) *CalorieService {
+	return &CalorieService{db: db}
+}
+
+// ApplyWorkoutCalories 为结束的训练设置卡路里和来源：有实测值时直接使用，其次按上传的心率估算，否则按 MET 估算
+// 需在训练时长（不含暂停）计算完成后调用
+func (s *CalorieService) ApplyWorkoutCalories(record *models.WorkoutRecord, calories int, measured bool) {
+	if measured && calories > 0 {
+		record.Calories = calories
+		record.CaloriesSource = models.CaloriesSourceMeasured
+		return
+	}
+	if record.HeartRate != nil && record.HeartRate.Calories > 0 {
+		record.Calories = record.HeartRate.Calories
+		record.CaloriesSource = models.CaloriesSourceHeartRate
+		return
+	}
+
+	record.Calories = s.EstimateWorkout(record)
+	record.CaloriesSource = models.CaloriesSourceEstimated
+}
+
+// ApplySessionCalories 为手动记录的训练会话设置卡路里来源：调用方标记为 measured 且有卡路里时直接使用，否则按动作和时长估算
+func (s *CalorieService) ApplySessionCalories(session *models.WorkoutSession, exercises []models.TrainingExercise) {
+	if session.CaloriesSource == models.CaloriesSourceMeasured && session.Calories > 0 {
+		return
+	}
+	session.Calories = estimateCalories(workoutMET(exercises), s.bodyWeight(session.UserID), session.Duration)
+	session.CaloriesSource = models.CaloriesSourceEstimated
+}
+
+// EstimateWorkout 按训练计划中的动作和实际训练时长估算卡路里
+func (s *CalorieService) EstimateWorkout(record *models.WorkoutRecord) int {
+	weight := s.bodyWeight(record.UserID)
+
+	var exercises []models.TrainingExercise
+	if record.PlanID != "" {
+		if err := s.db.Where("plan_id = ?", record.PlanID).Preload("Sets").Find(&exercises).Error; err != nil {
+			logger.Error.Printf("查询训练动作失败: plan_id=%v, error=%v", record.PlanID, err.Error())
+		}
+	}
+
+	return estimateCalories(workoutMET(exercises), weight, record.Duration)
+}
+
+// bodyWeight 用户体重，未填写时使用默认体重
+func (s *CalorieService) bodyWeight(userID string) float64 {
+	var user models.User
+	if err := s.db.Select("weight").Where("id = ?", userID).First(&user).Error; err == nil && user.Weight > 0 {
+		return user.Weight
+	}
+	return defaultBodyWeight
+}
+
+// heartRateCalories 按平均心率估算卡路里（Keytel 等 2005 年公式），性别未知时取男女公式的平均值
+func heartRateCalories(avgBPM int, weight float64, age int, gender string, seconds int) int {
+	if avgBPM <= 0 || seconds <= 0 {
+		return 0
+	}
+	hr, w, a := float64(avgBPM), weight, float64(age)
+	male := (-55.0969 + 0.6309*hr + 0.1988*w + 0.2017*a) / 4.184
+	female := (-20.4022 + 0.4472*hr - 0.1263*w + 0.074*a) / 4.184
+
+	var perMinute float64
+	switch gender {
+	case "male", "男":
+		perMinute = male
+	case "female", "女":
+		perMinute = female
+	default:
+		perMinute = (male + female) / 2
+	}
+	if perMinute <= 0 {
+		return 0
+	}
+	return int(math.Round(perMinute * float64(seconds) / 60))
+}
+
+// estimateCalories 卡路里 = MET × 体重(kg) × 时长(小时)
+func estimateCalories(met, weight float64, minutes int) int {
+	if met <= 0 || weight <= 0 || minutes <= 0 {
+		return 0
+	}
+	return int(math.Round(met * weight * float64(minutes) / 60))
+}
+
+// workoutMET 按各动作的组数加权平均 MET，优先使用已完成的组；没有动作时按中等强度力量训练计算
+func workoutMET(exercises []models.TrainingExercise) float64 {
+	completed := 0
+	for _, exercise := range exercises {
+		for _, set := range exercise.Sets {
+			if set.Completed {
+				completed++
+			}
+		}
+	}
+
+	var total, sets float64
+	for _, exercise := range exercises {
+		count := 0
+		for _, set := range exercise.Sets {
+			if set.Completed || completed == 0 {
+				count++
+			}
+		}
+		if completed == 0 && count == 0 {
+			count = 1
+		}
+		total += exerciseMET(exercise) * float64(count)
+		sets += float64(count)
+	}
+
+	if sets == 0 {
+		return metTable["strength"][intensityModerate]
+	}
+	return total / sets
+}
+
+// exerciseMET 动作的 MET 值，由动作分类和难度决定
+func exerciseMET(exercise models.TrainingExercise) float64 {
+	return metTable[metCategory(exercise.Category)][exerciseIntensity(exercise.Difficulty)]
+}
+
+// metCategory 将动作分类映射到 MET 分类
+func metCategory(category string) string {
+	c := strings.ToLower(category)
+	switch {
+	case strings.Contains(c, "有氧") || strings.Contains(c, "cardio") || strings.Contains(c, "跑") || strings.Contains(c, "骑"):
+		return "cardio"
+	case strings.Contains(c, "核心") || strings.Contains(c, "腹") || strings.Contains(c, "core"):
+		return "core"
+	case strings.Contains(c, "拉伸") || strings.Contains(c, "柔韧") || strings.Contains(c, "瑜伽") || strings.Contains(c, "stretch"):
+		return "flexibility"
+	default:
+		return "strength"
+	}
+}
+
+// exerciseIntensity 按动作难度推导训练强度
+func exerciseIntensity(difficulty string) string {
+	switch difficulty {
+	case "初级", "beginner":
+		return intensityLight
+	case "高级", "advanced":
+		return intensityVigorous
+	default:
+		return intensityModerate
+	}
+}
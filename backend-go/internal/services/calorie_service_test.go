@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateCalories(t *testing.T) {
+	// 5 MET × 70kg × 1h
+	assert.Equal(t, 350, estimateCalories(5, 70, 60))
+	assert.Equal(t, 0, estimateCalories(5, 70, 0))
+	assert.Equal(t, 0, estimateCalories(0, 70, 30))
+}
+
+func TestWorkoutMET(t *testing.T) {
+	assert.Equal(t, 5.0, workoutMET(nil))
+
+	exercises := []models.TrainingExercise{
+		{Category: "胸", Difficulty: "中级", Sets: []models.ExerciseSet{{Completed: true}, {Completed: true}, {Completed: true}}},
+		{Category: "有氧", Difficulty: "高级", Sets: []models.ExerciseSet{{Completed: true}, {Completed: false}}},
+		{Category: "核心", Difficulty: "初级", Sets: []models.ExerciseSet{{Completed: false}}},
+	}
+	// 只按已完成的组加权：(5.0×3 + 9.8×1) / 4
+	assert.InDelta(t, 6.2, workoutMET(exercises), 0.001)
+
+	// 没有完成的组时按计划中的所有组加权
+	for i := range exercises {
+		for j := range exercises[i].Sets {
+			exercises[i].Sets[j].Completed = false
+		}
+	}
+	assert.InDelta(t, (5.0*3+9.8*2+2.8)/6, workoutMET(exercises), 0.001)
+}
+
+func TestMetCategory(t *testing.T) {
+	assert.Equal(t, "strength", metCategory("胸肌"))
+	assert.Equal(t, "cardio", metCategory("有氧"))
+	assert.Equal(t, "cardio", metCategory("Cardio"))
+	assert.Equal(t, "core", metCategory("核心"))
+	assert.Equal(t, "flexibility", metCategory("拉伸"))
+	assert.Equal(t, intensityVigorous, exerciseIntensity("高级"))
+	assert.Equal(t, intensityModerate, exerciseIntensity(""))
+}
+
+func TestHeartRateCalories(t *testing.T) {
+	// Keytel：男性 150bpm、70kg、30 岁，每分钟约 14.2 千卡
+	assert.Equal(t, 853, heartRateCalories(150, 70, 30, "male", 3600))
+	assert.Less(t, heartRateCalories(150, 70, 30, "female", 3600), 853)
+
+	// 性别未知时取男女公式的平均
+	male := heartRateCalories(150, 70, 30, "male", 3600)
+	female := heartRateCalories(150, 70, 30, "female", 3600)
+	assert.InDelta(t, float64(male+female)/2, float64(heartRateCalories(150, 70, 30, "", 3600)), 1)
+
+	assert.Equal(t, 0, heartRateCalories(0, 70, 30, "male", 3600))
+	assert.Equal(t, 0, heartRateCalories(150, 70, 30, "male", 0))
+	// 心率过低时公式为负，不返回负数
+	assert.Equal(t, 0, heartRateCalories(40, 70, 30, "male", 3600))
+}
+
+func TestApplySessionCaloriesKeepsMeasured(t *testing.T) {
+	session := &models.WorkoutSession{Calories: 420, CaloriesSource: models.CaloriesSourceMeasured}
+	NewCalorieService(nil).ApplySessionCalories(session, nil)
+	assert.Equal(t, 420, session.Calories)
+	assert.Equal(t, models.CaloriesSourceMeasured, session.CaloriesSource)
+}
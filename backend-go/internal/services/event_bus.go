@@ -0,0 +1,59 @@
+package services
+
+import (
+	"sync"
+
+	"gymates/pkg/logger"
+)
+
+// 事件主题
+const (
+	EventPersonalRecord    = "personal_record"    // 负载: models.PersonalRecordEvent
+	EventSetCompleted      = "set_completed"      // 负载: models.SetCompletedEvent
+	EventFeedbackSubmitted = "feedback_submitted" // 负载: models.FeedbackSubmittedEvent
+	EventWorkoutEnded      = "workout_ended"      // 负载: models.WorkoutEndedEvent
+)
+
+// EventHandler 事件处理函数
+type EventHandler func(payload interface{})
+
+// EventBus 进程内事件总线
+// 用于功能之间解耦，例如刷新 PR 后由通知、动态等模块各自处理
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]EventHandler)}
+}
+
+// Subscribe 订阅事件
+func (b *EventBus) Subscribe(topic string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+// Publish 同步发布事件，单个处理函数 panic 不影响其他处理函数和发布方
+func (b *EventBus) Publish(topic string, payload interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := append([]EventHandler(nil), b.handlers[topic]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error.Printf("事件处理失败: topic=%v, panic=%v", topic, r)
+				}
+			}()
+			handler(payload)
+		}()
+	}
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// exerciseMatchThreshold 自动关联标准动作的最低相似度
+const exerciseMatchThreshold = 0.75
+
+// ExerciseCatalogService 标准动作库服务
+// 提供动作检索、自由文本名称的匹配，以及历史训练动作的回填关联
+type ExerciseCatalogService struct {
+	repo ExerciseRepository
+}
+
+// NewExerciseCatalogService 创建标准动作库服务
+func NewExerciseCatalogService(repo ExerciseRepository) *ExerciseCatalogService {
+	return &ExerciseCatalogService{repo: repo}
+}
+
+// List 获取标准动作列表，keyword 不为空时按名称和别名模糊检索
+func (s *ExerciseCatalogService) List(category, keyword string, limit int) ([]models.Exercise, error) {
+	catalog, err := s.loadCatalog(category)
+	if err != nil {
+		return nil, err
+	}
+	if keyword == "" {
+		if limit > 0 && len(catalog) > limit {
+			catalog = catalog[:limit]
+		}
+		return catalog, nil
+	}
+
+	var results []models.Exercise
+	for _, match := range rankExercises(catalog, keyword, 0.5) {
+		results = append(results, *match.Exercise)
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Get 获取单个标准动作
+func (s *ExerciseCatalogService) Get(id uint) (*models.Exercise, error) {
+	exercise, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("动作不存在")
+		}
+		return nil, err
+	}
+	return exercise, nil
+}
+
+// Create 创建标准动作
+func (s *ExerciseCatalogService) Create(req models.CreateCatalogExerciseRequest) (*models.Exercise, error) {
+	if _, err := s.repo.GetByName(req.Name); err == nil {
+		return nil, errors.New("动作名称已存在")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	exercise := models.Exercise{
+		Name:                  req.Name,
+		NameEn:                req.NameEn,
+		Names:                 req.Names,
+		Aliases:               req.Aliases,
+		Description:           req.Description,
+		Category:              req.Category,
+		Difficulty:            req.Difficulty,
+		PrimaryMuscleGroups:   req.PrimaryMuscleGroups,
+		SecondaryMuscleGroups: req.SecondaryMuscleGroups,
+		Equipment:             req.Equipment,
+		Instructions:          req.Instructions,
+		VideoURL:              req.VideoURL,
+		ImageURL:              req.ImageURL,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	if err := s.repo.Create(&exercise); err != nil {
+		logger.Error.Printf("创建标准动作失败: name=%v, error=%v", req.Name, err.Error())
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+// Match 为自由文本动作名称匹配标准动作，相似度不足时返回 nil
+func (s *ExerciseCatalogService) Match(name string) (*models.ExerciseMatch, error) {
+	catalog, err := s.loadCatalog("")
+	if err != nil {
+		return nil, err
+	}
+	return matchExercise(catalog, name), nil
+}
+
+// LinkExercises 为计划中的动作关联标准动作，并用标准动作补全缺失的肌群和器械
+func (s *ExerciseCatalogService) LinkExercises(exercises []models.TrainingExercise) {
+	catalog, err := s.loadCatalog("")
+	if err != nil {
+		return
+	}
+	linkExercises(catalog, exercises)
+}
+
+// LinkPlans 为多个计划中的动作关联标准动作（只加载一次动作库）
+func (s *ExerciseCatalogService) LinkPlans(plans []models.TrainingPlan) {
+	catalog, err := s.loadCatalog("")
+	if err != nil {
+		return
+	}
+	for i := range plans {
+		linkExercises(catalog, plans[i].Exercises)
+	}
+}
+
+// CanonicalName 返回标准动作名称，未关联或不存在时返回原名称
+func (s *ExerciseCatalogService) CanonicalName(catalogID uint, fallback string) string {
+	if catalogID == 0 {
+		return fallback
+	}
+	exercise, err := s.repo.GetByID(catalogID)
+	if err != nil || exercise.Name == "" {
+		return fallback
+	}
+	return exercise.Name
+}
+
+// Backfill 为尚未关联的历史训练动作按名称匹配标准动作
+func (s *ExerciseCatalogService) Backfill(dryRun bool) (*models.ExerciseBackfillReport, error) {
+	catalog, err := s.loadCatalog("")
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.repo.UnlinkedNames()
+	if err != nil {
+		logger.Error.Printf("查询待回填动作失败: error=%v", err.Error())
+		return nil, err
+	}
+	sort.Strings(names)
+
+	report := &models.ExerciseBackfillReport{DryRun: dryRun, DistinctNames: len(names)}
+	for _, name := range names {
+		match := matchExercise(catalog, name)
+		if match == nil {
+			report.UnmatchedNames = append(report.UnmatchedNames, name)
+			continue
+		}
+		report.Matches = append(report.Matches, *match)
+
+		var rows int64
+		if dryRun {
+			rows, err = s.repo.CountUnlinked(name)
+		} else {
+			rows, err = s.repo.SetCatalogID(name, match.Exercise.ID)
+		}
+		if err != nil {
+			logger.Error.Printf("回填动作关联失败: name=%v, error=%v", name, err.Error())
+			return nil, err
+		}
+		report.LinkedRows += rows
+	}
+
+	return report, nil
+}
+
+// loadCatalog 加载标准动作库（动作库规模较小，直接全量加载后在内存中匹配）
+func (s *ExerciseCatalogService) loadCatalog(category string) ([]models.Exercise, error) {
+	exercises, err := s.repo.GetAll()
+	if err != nil {
+		logger.Error.Printf("加载标准动作库失败: error=%v", err.Error())
+		return nil, err
+	}
+	catalog := make([]models.Exercise, 0, len(exercises))
+	for _, exercise := range exercises {
+		if category == "" || exercise.Category == category {
+			catalog = append(catalog, *exercise)
+		}
+	}
+	return catalog, nil
+}
+
+// linkExercises 按已指定的 CatalogID 或名称匹配结果关联标准动作
+func linkExercises(catalog []models.Exercise, exercises []models.TrainingExercise) {
+	byID := make(map[uint]*models.Exercise, len(catalog))
+	for i := range catalog {
+		byID[catalog[i].ID] = &catalog[i]
+	}
+
+	for i := range exercises {
+		entry := byID[exercises[i].CatalogID]
+		if entry == nil {
+			match := matchExercise(catalog, exercises[i].Name)
+			if match == nil {
+				exercises[i].CatalogID = 0
+				continue
+			}
+			entry = match.Exercise
+		}
+
+		exercises[i].CatalogID = entry.ID
+		if len(exercises[i].MuscleGroups) == 0 {
+			exercises[i].MuscleGroups = append(append([]string{}, entry.PrimaryMuscleGroups...), entry.SecondaryMuscleGroups...)
+		}
+		if len(exercises[i].Equipment) == 0 {
+			exercises[i].Equipment = entry.Equipment
+		}
+		if exercises[i].Category == "" {
+			exercises[i].Category = entry.Category
+		}
+	}
+}
+
+// matchExercise 返回与名称对应的标准动作，没有足够确定的候选时返回 nil
+//
+// 关联结果会写入训练记录并影响进度和统计，因此只接受以下几种情况：
+// 与任一名称或别名完全一致；去掉器械前后缀后与标准名称完全一致（"杠铃卧推" → "卧推"）；
+// 两者都足够长时的拼写差异（"squats" → "squat"）。
+// 不做子串包含匹配，避免 "腿弯举" 被关联到 "二头弯举"、"直腿硬拉" 被关联到 "硬拉"。
+func matchExercise(catalog []models.Exercise, name string) *models.ExerciseMatch {
+	input := normalizeExerciseName(name)
+	if input == "" {
+		return nil
+	}
+	stripped := stripEquipmentModifiers(input)
+
+	var best *models.ExerciseMatch
+	for i := range catalog {
+		for _, candidate := range exerciseNames(catalog[i]) {
+			score := matchScore(input, stripped, normalizeExerciseName(candidate), isAlias(catalog[i], candidate))
+			if score >= exerciseMatchThreshold && (best == nil || score > best.Score) {
+				best = &models.ExerciseMatch{Input: name, Exercise: &catalog[i], MatchedName: candidate, Score: score}
+			}
+		}
+	}
+	return best
+}
+
+// matchScore 计算规范化输入与单个候选名称的匹配得分（0-1）
+func matchScore(input, stripped, candidate string, alias bool) float64 {
+	switch {
+	case candidate == "":
+		return 0
+	case input == candidate:
+		return 1
+	case !alias && stripped != input && stripped == candidate:
+		return 0.9
+	}
+
+	ra, rb := []rune(input), []rune(candidate)
+	if len(ra) < minFuzzyMatchRunes || len(rb) < minFuzzyMatchRunes {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+// minFuzzyMatchRunes 参与编辑距离匹配的最短名称长度；
+// 中文动作名通常只有两三个字，一字之差往往就是另一个动作
+const minFuzzyMatchRunes = 5
+
+// equipmentModifiers 名称前后可省略的器械修饰词（规范化形式）
+var equipmentModifiers = []string{
+	"杠铃", "哑铃", "壶铃", "绳索", "器械", "史密斯", "自重",
+	"barbell", "dumbbell", "kettlebell", "cable", "machine", "smith", "bodyweight",
+}
+
+// stripEquipmentModifiers 去掉规范化名称首尾的器械修饰词
+func stripEquipmentModifiers(name string) string {
+	for changed := true; changed; {
+		changed = false
+		for _, modifier := range equipmentModifiers {
+			if len(name) > len(modifier) && strings.HasPrefix(name, modifier) {
+				name, changed = strings.TrimPrefix(name, modifier), true
+			}
+			if len(name) > len(modifier) && strings.HasSuffix(name, modifier) {
+				name, changed = strings.TrimSuffix(name, modifier), true
+			}
+		}
+	}
+	return name
+}
+
+// isAlias 判断候选名称是否只是别名而非标准名称
+func isAlias(exercise models.Exercise, candidate string) bool {
+	if candidate == exercise.Name || candidate == exercise.NameEn {
+		return false
+	}
+	for _, name := range exercise.Names {
+		if candidate == name {
+			return false
+		}
+	}
+	return true
+}
+
+// rankExercises 按相似度降序返回不低于 minScore 的候选动作，仅用于列表检索排序
+func rankExercises(catalog []models.Exercise, name string, minScore float64) []models.ExerciseMatch {
+	input := normalizeExerciseName(name)
+	if input == "" {
+		return nil
+	}
+
+	var matches []models.ExerciseMatch
+	for i := range catalog {
+		best := models.ExerciseMatch{Input: name, Exercise: &catalog[i]}
+		for _, candidate := range exerciseNames(catalog[i]) {
+			if score := nameSimilarity(input, normalizeExerciseName(candidate)); score > best.Score {
+				best.Score = score
+				best.MatchedName = candidate
+			}
+		}
+		if best.Score >= minScore {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// exerciseNames 标准动作的所有可匹配名称：标准名、英文名、多语言名称和别名
+func exerciseNames(exercise models.Exercise) []string {
+	names := []string{exercise.Name}
+	if exercise.NameEn != "" {
+		names = append(names, exercise.NameEn)
+	}
+	for _, name := range exercise.Names {
+		names = append(names, name)
+	}
+	return append(names, exercise.Aliases...)
+}
+
+// normalizeExerciseName 规范化动作名称：全角转半角、转小写、去掉空白和标点
+func normalizeExerciseName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '　':
+			continue
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// nameSimilarity 计算两个规范化名称的相似度（0-1）
+//
+// 取编辑距离相似度与包含关系得分中的较大值：
+// "杠铃卧推" 包含 "卧推"，即使编辑距离较大也应视为同一动作。
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longer := len(ra)
+	if len(rb) > longer {
+		longer = len(rb)
+	}
+	score := 1 - float64(levenshtein(ra, rb))/float64(longer)
+
+	shorter, long := ra, rb
+	if len(shorter) > len(long) {
+		shorter, long = long, shorter
+	}
+	if len(shorter) >= 2 && strings.Contains(string(long), string(shorter)) {
+		if contain := 0.6 + 0.4*float64(len(shorter))/float64(len(long)); contain > score {
+			score = contain
+		}
+	}
+
+	return score
+}
+
+// levenshtein 按字符（rune）计算编辑距离
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCatalog() []models.Exercise {
+	return []models.Exercise{
+		{ID: 1, Name: "卧推", NameEn: "Bench Press", Aliases: []string{"平板卧推"}, Category: "胸",
+			PrimaryMuscleGroups: []string{"胸肌"}, SecondaryMuscleGroups: []string{"三头肌"}, Equipment: []string{"杠铃"}},
+		{ID: 2, Name: "上斜卧推", NameEn: "Incline Bench Press", Category: "胸"},
+		{ID: 3, Name: "深蹲", NameEn: "Squat", Aliases: []string{"后蹲"}, Category: "腿"},
+		{ID: 4, Name: "引体向上", NameEn: "Pull-up", Names: map[string]string{"ja": "懸垂"}, Category: "背"},
+		{ID: 5, Name: "二头弯举", NameEn: "Biceps Curl", Aliases: []string{"弯举", "curl"}, Category: "手臂"},
+		{ID: 6, Name: "跑步", NameEn: "Running", Aliases: []string{"run"}, Category: "有氧"},
+		{ID: 7, Name: "硬拉", NameEn: "Deadlift", Category: "背"},
+	}
+}
+
+func TestNormalizeExerciseName(t *testing.T) {
+	assert.Equal(t, "benchpress", normalizeExerciseName(" Bench  Press "))
+	assert.Equal(t, "pullup", normalizeExerciseName("Ｐｕｌｌ－ｕｐ"))
+	assert.Equal(t, "杠铃卧推", normalizeExerciseName("杠铃·卧推（）"))
+}
+
+func TestMatchExercise(t *testing.T) {
+	catalog := testCatalog()
+
+	tests := []struct {
+		input      string
+		expectedID uint
+	}{
+		{"卧推", 1},
+		{"Bench Press", 1},
+		{"bench-press", 1},
+		{"杠铃卧推", 1},
+		{"平板卧推", 1},
+		{"上斜卧推", 2},
+		{"incline bench press", 2},
+		{"后蹲", 3},
+		{"Pull up", 4},
+		{"懸垂", 4},
+		{"squats", 3},
+		{"Barbell Squat", 3},
+		{"哑铃二头弯举", 5},
+		{"Leg Curl", 0},
+		{"腿弯举", 0},
+		{"Crunch", 0},
+		{"Split Squat", 0},
+		{"直腿硬拉", 0},
+		{"跳绳", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			match := matchExercise(catalog, tt.input)
+			if tt.expectedID == 0 {
+				assert.Nil(t, match)
+				return
+			}
+			if assert.NotNil(t, match) {
+				assert.Equal(t, tt.expectedID, match.Exercise.ID)
+				assert.GreaterOrEqual(t, match.Score, exerciseMatchThreshold)
+			}
+		})
+	}
+}
+
+func TestLinkExercises(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "Bench Press"},
+		{Name: "深蹲", MuscleGroups: []string{"股四头肌"}},
+		{Name: "随便练练"},
+		{Name: "自定义名称", CatalogID: 4},
+	}
+
+	linkExercises(testCatalog(), exercises)
+
+	assert.Equal(t, uint(1), exercises[0].CatalogID)
+	assert.Equal(t, []string{"胸肌", "三头肌"}, exercises[0].MuscleGroups)
+	assert.Equal(t, []string{"杠铃"}, exercises[0].Equipment)
+	assert.Equal(t, "胸", exercises[0].Category)
+
+	assert.Equal(t, uint(3), exercises[1].CatalogID)
+	assert.Equal(t, []string{"股四头肌"}, exercises[1].MuscleGroups)
+
+	assert.Equal(t, uint(0), exercises[2].CatalogID)
+	assert.Equal(t, uint(4), exercises[3].CatalogID)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"gymates/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ExerciseRepository 标准动作库的存储，方法与 domain/repositories.ExerciseRepository 一致
+// 动作库的匹配和回填只通过该接口读写数据
+type ExerciseRepository interface {
+	Create(exercise *models.Exercise) error
+	GetByID(id uint) (*models.Exercise, error)
+	GetByName(name string) (*models.Exercise, error)
+	GetAll() ([]*models.Exercise, error)
+	// UnlinkedNames 尚未关联标准动作的训练动作名称（去重）
+	UnlinkedNames() ([]string, error)
+	// CountUnlinked 该名称尚未关联标准动作的训练动作数
+	CountUnlinked(name string) (int64, error)
+	// SetCatalogID 将同名且尚未关联的训练动作关联到标准动作
+	SetCatalogID(name string, catalogID uint) (int64, error)
+}
+
+// exerciseRepository 基于 GORM 的标准动作库存储
+type exerciseRepository struct {
+	db *gorm.DB
+}
+
+// NewExerciseRepository 创建标准动作库存储
+func NewExerciseRepository(db *gorm.DB) ExerciseRepository {
+	return &exerciseRepository{db: db}
+}
+
+func (r *exerciseRepository) Create(exercise *models.Exercise) error {
+	return r.db.Create(exercise).Error
+}
+
+func (r *exerciseRepository) GetByID(id uint) (*models.Exercise, error) {
+	var exercise models.Exercise
+	if err := r.db.Where("id = ?", id).First(&exercise).Error; err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+func (r *exerciseRepository) GetByName(name string) (*models.Exercise, error) {
+	var exercise models.Exercise
+	if err := r.db.Where("name = ?", name).First(&exercise).Error; err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+func (r *exerciseRepository) GetAll() ([]*models.Exercise, error) {
+	var exercises []*models.Exercise
+	err := r.db.Order("name ASC").Find(&exercises).Error
+	return exercises, err
+}
+
+func (r *exerciseRepository) UnlinkedNames() ([]string, error) {
+	var names []string
+	err := r.db.Model(&models.TrainingExercise{}).
+		Where("catalog_id IS NULL OR catalog_id = ?", 0).
+		Distinct("name").
+		Pluck("name", &names).Error
+	return names, err
+}
+
+func (r *exerciseRepository) CountUnlinked(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TrainingExercise{}).
+		Where("name = ? AND (catalog_id IS NULL OR catalog_id = ?)", name, 0).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *exerciseRepository) SetCatalogID(name string, catalogID uint) (int64, error) {
+	result := r.db.Model(&models.TrainingExercise{}).
+		Where("name = ? AND (catalog_id IS NULL OR catalog_id = ?)", name, 0).
+		Update("catalog_id", catalogID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,544 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// exportBatchSize 导出时每批加载的训练记录数，避免一次性加载全部历史
+const exportBatchSize = 100
+
+// exportCSVHeader CSV 导出表头（与导入支持的格式一致，可直接重新导入）
+var exportCSVHeader = []string{
+	"Date", "Workout Name", "Duration", "Exercise Name", "Category", "Set Order",
+	"Weight (kg)", "Reps", "Distance (km)", "Seconds", "Completed At", "Notes",
+}
+
+// exportErrorMarker 导出中途失败时写在文件末尾的标记
+// 导出按批次直接写入响应，出错时响应头已经发出，只能在内容中说明文件不完整
+const exportErrorMarker = "#ERROR"
+
+// exportErrorMessage 导出中途失败时写入文件的说明，不包含内部错误细节
+const exportErrorMessage = "导出中断，文件不完整"
+
+// TrackProvider 提供训练记录的 GPS 轨迹，没有轨迹的记录不出现在结果中
+type TrackProvider interface {
+	Tracks(recordIDs []string) (map[string][]models.TrackPoint, error)
+}
+
+// ExportService 训练数据导出服务
+// 全部训练按组导出为 CSV，有氧训练导出为 TCX/GPX；按批次查询并直接写入输出流
+type ExportService struct {
+	db     *gorm.DB
+	tracks TrackProvider
+}
+
+// cardioLap 有氧训练中的一组，对应 TCX 的一圈
+type cardioLap struct {
+	Exercise int // 所属动作在计划中的下标
+	Start    time.Time
+	Seconds  int
+	Meters   float64
+	Calories int
+	Points   []models.TrackPoint
+}
+
+// cardioActivity 一次训练中的一个有氧动作
+type cardioActivity struct {
+	Name  string
+	Sport string // TCX 运动类型：Running, Biking, Other
+	Start time.Time
+	Notes string
+	Laps  []cardioLap
+}
+
+// NewExportService 创建训练数据导出服务，tracks 为空时导出不含 GPS 轨迹
+func NewExportService(db *gorm.DB, tracks TrackProvider) *ExportService {
+	return &ExportService{db: db, tracks: tracks}
+}
+
+// Export 将用户在 [from, to) 内已完成的训练按指定格式写入 w，from/to 为零值时不限制
+// 中途出错时在已写出的内容末尾追加错误标记（见 writeExportError）并返回错误
+func (s *ExportService) Export(userID, format string, from, to time.Time, w io.Writer) error {
+	var err error
+	switch format {
+	case models.ExportFormatCSV:
+		err = s.exportCSV(userID, from, to, w)
+	case models.ExportFormatTCX:
+		err = s.exportTCX(userID, from, to, w)
+	case models.ExportFormatGPX:
+		err = s.exportGPX(userID, from, to, w)
+	default:
+		return fmt.Errorf("不支持的导出格式: %s", format)
+	}
+	if err != nil {
+		writeExportError(format, w)
+	}
+	return err
+}
+
+// writeExportError 在导出内容末尾写入错误标记
+// CSV 追加一行 "#ERROR,导出中断，文件不完整"（重新导入时该行因日期无效被跳过）；
+// TCX/GPX 追加 XML 注释且不写结束标签，文件无法被当作完整文件解析
+func writeExportError(format string, w io.Writer) {
+	if format == models.ExportFormatCSV {
+		writer := csv.NewWriter(w)
+		writer.Write([]string{exportErrorMarker, exportErrorMessage})
+		writer.Flush()
+		return
+	}
+	io.WriteString(w, fmt.Sprintf("\n<!-- %s: %s -->\n", exportErrorMarker, exportErrorMessage))
+}
+
+// exportCSV 每个已完成的组导出为一行，每批写完后刷新到 w
+func (s *ExportService) exportCSV(userID string, from, to time.Time, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+
+	err := s.eachRecords(userID, from, to, func(records []models.WorkoutRecord) error {
+		for _, record := range records {
+			if err := writer.WriteAll(exportCSVRows(record)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// exportTCX 有氧训练导出为 TCX（Garmin Training Center），每个有氧动作一个 Activity
+func (s *ExportService) exportTCX(userID string, from, to time.Time, w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header+
+		`<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">`+"\n<Activities>\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err := s.eachCardio(userID, from, to, func(activity cardioActivity) error {
+		return encoder.Encode(buildTCXActivity(activity))
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n</Activities>\n</TrainingCenterDatabase>\n")
+	return err
+}
+
+// exportGPX 有氧训练导出为 GPX，每个有氧动作一条轨迹，记录过 GPS 时包含轨迹点
+func (s *ExportService) exportGPX(userID string, from, to time.Time, w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header+
+		`<gpx version="1.1" creator="Gymates" xmlns="http://www.topografix.com/GPX/1/1">`+"\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err := s.eachCardio(userID, from, to, func(activity cardioActivity) error {
+		return encoder.Encode(buildGPXTrack(activity))
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n</gpx>\n")
+	return err
+}
+
+// eachRecords 按开始时间分批加载已完成的训练记录（含计划、动作和组数）
+// 以 (start_time, id) 作为游标分页，避免大量历史时 OFFSET 越来越慢
+func (s *ExportService) eachRecords(userID string, from, to time.Time, fn func([]models.WorkoutRecord) error) error {
+	var lastStart time.Time
+	var lastID string
+	for {
+		query := s.db.Where("user_id = ? AND status = ?", userID, models.WorkoutStatusCompleted)
+		if !from.IsZero() {
+			query = query.Where("start_time >= ?", from)
+		}
+		if !to.IsZero() {
+			query = query.Where("start_time < ?", to)
+		}
+		if lastID != "" {
+			query = query.Where("(start_time, id) > (?, ?)", lastStart, lastID)
+		}
+
+		var records []models.WorkoutRecord
+		err := query.
+			Preload("Plan.Exercises", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+			Preload("Plan.Exercises.Sets", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+			Order("start_time ASC, id ASC").
+			Limit(exportBatchSize).
+			Find(&records).Error
+		if err != nil {
+			logger.Error.Printf("导出训练数据失败: user_id=%v, error=%v", userID, err.Error())
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := s.assignLegacySets(userID, records); err != nil {
+			logger.Error.Printf("导出训练数据失败: user_id=%v, error=%v", userID, err.Error())
+			return err
+		}
+
+		if err := fn(records); err != nil {
+			return err
+		}
+		if len(records) < exportBatchSize {
+			return nil
+		}
+		lastStart, lastID = records[len(records)-1].StartTime, records[len(records)-1].ID
+	}
+}
+
+// assignLegacySets 为没有记录所属训练的历史组（RecordID 为空）确定唯一的所属训练，
+// 同一计划被训练多次时，这些组只导出一次
+func (s *ExportService) assignLegacySets(userID string, records []models.WorkoutRecord) error {
+	planIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		if record.PlanID != "" {
+			planIDs = append(planIDs, record.PlanID)
+		}
+	}
+	if len(planIDs) == 0 {
+		return nil
+	}
+
+	var siblings []models.WorkoutRecord
+	err := s.db.Select("id", "plan_id", "start_time", "end_time").
+		Where("user_id = ? AND status = ? AND plan_id IN ?", userID, models.WorkoutStatusCompleted, planIDs).
+		Order("start_time ASC, id ASC").
+		Find(&siblings).Error
+	if err != nil {
+		return err
+	}
+	byPlan := make(map[string][]models.WorkoutRecord)
+	for _, sibling := range siblings {
+		byPlan[sibling.PlanID] = append(byPlan[sibling.PlanID], sibling)
+	}
+
+	for i := range records {
+		assignRecordLegacySets(&records[i], byPlan[records[i].PlanID])
+	}
+	return nil
+}
+
+// assignRecordLegacySets 将训练记录中历史组的 RecordID 设为其所属训练：
+// 完成时间落在某次训练时间范围内的归属该次训练，否则归属该计划最早的一次训练
+// 同一计划的多条记录预加载时共享动作和组数的底层数组，这里先复制再修改
+func assignRecordLegacySets(record *models.WorkoutRecord, siblings []models.WorkoutRecord) {
+	exercises := make([]models.TrainingExercise, len(record.Plan.Exercises))
+	for i, exercise := range record.Plan.Exercises {
+		sets := make([]models.ExerciseSet, len(exercise.Sets))
+		for j, set := range exercise.Sets {
+			if set.RecordID == "" {
+				set.RecordID = legacySetOwner(set, siblings)
+			}
+			sets[j] = set
+		}
+		exercise.Sets = sets
+		exercises[i] = exercise
+	}
+	record.Plan.Exercises = exercises
+}
+
+// legacySetOwner 历史组所属的训练记录 ID，siblings 按开始时间升序
+func legacySetOwner(set models.ExerciseSet, siblings []models.WorkoutRecord) string {
+	if len(siblings) == 0 {
+		return ""
+	}
+	if set.CompletedAt != nil {
+		for _, sibling := range siblings {
+			if !set.CompletedAt.Before(sibling.StartTime) && !set.CompletedAt.After(sibling.EndTime) {
+				return sibling.ID
+			}
+		}
+	}
+	return siblings[0].ID
+}
+
+// eachCardio 遍历有氧训练，并为每次训练附上记录的 GPS 轨迹
+func (s *ExportService) eachCardio(userID string, from, to time.Time, fn func(cardioActivity) error) error {
+	return s.eachRecords(userID, from, to, func(records []models.WorkoutRecord) error {
+		tracks := map[string][]models.TrackPoint{}
+		if s.tracks != nil {
+			ids := make([]string, len(records))
+			for i, record := range records {
+				ids[i] = record.ID
+			}
+			var err error
+			if tracks, err = s.tracks.Tracks(ids); err != nil {
+				logger.Error.Printf("查询 GPS 轨迹失败: user_id=%v, error=%v", userID, err.Error())
+				tracks = map[string][]models.TrackPoint{}
+			}
+		}
+
+		for _, record := range records {
+			for _, activity := range cardioActivities(record, tracks[record.ID]) {
+				if err := fn(activity); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// exportSets 训练记录中已完成的组，兼容没有记录所属训练的历史数据
+func exportSets(record models.WorkoutRecord, exercise models.TrainingExercise) []models.ExerciseSet {
+	var sets []models.ExerciseSet
+	for _, set := range exercise.Sets {
+		if set.Completed && (set.RecordID == "" || set.RecordID == record.ID) {
+			sets = append(sets, set)
+		}
+	}
+	return sets
+}
+
+// exportCSVRows 训练记录中每个已完成的组对应一行
+func exportCSVRows(record models.WorkoutRecord) [][]string {
+	var rows [][]string
+	name := record.Plan.Name
+	for _, exercise := range record.Plan.Exercises {
+		for i, set := range exportSets(record, exercise) {
+			completedAt := ""
+			if set.CompletedAt != nil {
+				completedAt = set.CompletedAt.Format(time.RFC3339)
+			}
+			rows = append(rows, []string{
+				record.StartTime.Format("2006-01-02 15:04:05"),
+				name,
+				strconv.Itoa(record.Duration * 60),
+				exercise.Name,
+				exercise.Category,
+				strconv.Itoa(i + 1),
+				formatExportFloat(set.Weight),
+				strconv.Itoa(set.Reps),
+				formatExportFloat(set.Distance),
+				strconv.Itoa(set.Duration),
+				completedAt,
+				record.Notes,
+			})
+		}
+	}
+	return rows
+}
+
+// cardioActivities 提取训练中的有氧动作，每组作为一圈，GPS 轨迹点按时间分配到各圈
+func cardioActivities(record models.WorkoutRecord, track []models.TrackPoint) []cardioActivity {
+	var laps []cardioLap
+	var activities []cardioActivity
+	index := make(map[int]int)
+	cursor := record.StartTime
+
+	for i, exercise := range record.Plan.Exercises {
+		if metCategory(exercise.Category) != "cardio" && cardioSport(exercise.Name) == "Other" {
+			continue
+		}
+		for _, set := range exportSets(record, exercise) {
+			if set.Distance <= 0 && set.Duration <= 0 {
+				continue
+			}
+			start := cursor
+			if set.CompletedAt != nil {
+				start = set.CompletedAt.Add(-time.Duration(set.Duration) * time.Second)
+			}
+			cursor = start.Add(time.Duration(set.Duration) * time.Second)
+			laps = append(laps, cardioLap{Exercise: i, Start: start, Seconds: set.Duration, Meters: set.Distance * 1000})
+		}
+		if len(laps) > 0 && laps[len(laps)-1].Exercise == i {
+			if _, ok := index[i]; !ok {
+				index[i] = len(activities)
+				activities = append(activities, cardioActivity{
+					Name:  exercise.Name,
+					Sport: cardioSport(exercise.Name + exercise.Category),
+					Notes: record.Notes,
+				})
+			}
+		}
+	}
+	if len(laps) == 0 {
+		return nil
+	}
+
+	// 卡路里按时长分摊到每一圈
+	totalSeconds := 0
+	for _, lap := range laps {
+		totalSeconds += lap.Seconds
+	}
+	for i := range laps {
+		if totalSeconds > 0 {
+			laps[i].Calories = int(math.Round(float64(record.Calories) * float64(laps[i].Seconds) / float64(totalSeconds)))
+		}
+	}
+
+	// 轨迹点归属到开始时间不晚于该点的最后一圈
+	for _, point := range track {
+		target := 0
+		for i := range laps {
+			if !laps[i].Start.After(point.Time) {
+				target = i
+			}
+		}
+		laps[target].Points = append(laps[target].Points, point)
+	}
+
+	for _, lap := range laps {
+		activity := &activities[index[lap.Exercise]]
+		if len(activity.Laps) == 0 {
+			activity.Start = lap.Start
+		}
+		activity.Laps = append(activity.Laps, lap)
+	}
+	return activities
+}
+
+// cardioSport 按动作名称推断 TCX 运动类型
+func cardioSport(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "跑") || strings.Contains(name, "run") || strings.Contains(name, "jog"):
+		return "Running"
+	case strings.Contains(name, "骑") || strings.Contains(name, "单车") || strings.Contains(name, "bik") || strings.Contains(name, "cycl"):
+		return "Biking"
+	default:
+		return "Other"
+	}
+}
+
+// TCX 文档结构（仅包含导出用到的字段）
+type tcxActivity struct {
+	XMLName xml.Name `xml:"Activity"`
+	Sport   string   `xml:"Sport,attr"`
+	ID      string   `xml:"Id"`
+	Laps    []tcxLap `xml:"Lap"`
+	Notes   string   `xml:"Notes,omitempty"`
+}
+
+type tcxLap struct {
+	StartTime        string    `xml:"StartTime,attr"`
+	TotalTimeSeconds int       `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64   `xml:"DistanceMeters"`
+	Calories         int       `xml:"Calories"`
+	Intensity        string    `xml:"Intensity"`
+	TriggerMethod    string    `xml:"TriggerMethod"`
+	Track            *tcxTrack `xml:"Track,omitempty"`
+}
+
+type tcxTrack struct {
+	Points []tcxTrackpoint `xml:"Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time           string      `xml:"Time"`
+	Position       tcxPosition `xml:"Position"`
+	AltitudeMeters float64     `xml:"AltitudeMeters"`
+}
+
+type tcxPosition struct {
+	Latitude  float64 `xml:"LatitudeDegrees"`
+	Longitude float64 `xml:"LongitudeDegrees"`
+}
+
+// buildTCXActivity 生成 TCX Activity
+func buildTCXActivity(activity cardioActivity) tcxActivity {
+	result := tcxActivity{
+		Sport: activity.Sport,
+		ID:    activity.Start.UTC().Format(time.RFC3339),
+		Notes: activity.Notes,
+	}
+	for _, lap := range activity.Laps {
+		tl := tcxLap{
+			StartTime:        lap.Start.UTC().Format(time.RFC3339),
+			TotalTimeSeconds: lap.Seconds,
+			DistanceMeters:   math.Round(lap.Meters*10) / 10,
+			Calories:         lap.Calories,
+			Intensity:        "Active",
+			TriggerMethod:    "Manual",
+		}
+		if len(lap.Points) > 0 {
+			tl.Track = &tcxTrack{}
+			for _, p := range lap.Points {
+				tl.Track.Points = append(tl.Track.Points, tcxTrackpoint{
+					Time:           p.Time.UTC().Format(time.RFC3339),
+					Position:       tcxPosition{Latitude: p.Latitude, Longitude: p.Longitude},
+					AltitudeMeters: p.Elevation,
+				})
+			}
+		}
+		result.Laps = append(result.Laps, tl)
+	}
+	return result
+}
+
+// GPX 文档结构（仅包含导出用到的字段）
+type gpxTrack struct {
+	XMLName  xml.Name     `xml:"trk"`
+	Name     string       `xml:"name"`
+	Desc     string       `xml:"desc,omitempty"`
+	Type     string       `xml:"type"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Elevation float64 `xml:"ele"`
+	Time      string  `xml:"time"`
+}
+
+// buildGPXTrack 生成 GPX 轨迹，没有 GPS 轨迹时只包含名称和距离、时长摘要
+func buildGPXTrack(activity cardioActivity) gpxTrack {
+	seconds, meters := 0, 0.0
+	var segment gpxSegment
+	for _, lap := range activity.Laps {
+		seconds += lap.Seconds
+		meters += lap.Meters
+		for _, p := range lap.Points {
+			segment.Points = append(segment.Points, gpxPoint{
+				Latitude:  p.Latitude,
+				Longitude: p.Longitude,
+				Elevation: p.Elevation,
+				Time:      p.Time.UTC().Format(time.RFC3339),
+			})
+		}
+	}
+
+	track := gpxTrack{
+		Name: fmt.Sprintf("%s %s", activity.Name, activity.Start.Format("2006-01-02 15:04")),
+		Desc: fmt.Sprintf("距离 %.2f 公里，用时 %d 秒", meters/1000, seconds),
+		Type: strings.ToLower(activity.Sport),
+	}
+	if len(segment.Points) > 0 {
+		track.Segments = []gpxSegment{segment}
+	}
+	return track
+}
+
+// formatExportFloat 去掉多余的小数位
+func formatExportFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestRecord() models.WorkoutRecord {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	runDone := start.Add(20 * time.Minute)
+	return models.WorkoutRecord{
+		ID:        "r1",
+		StartTime: start,
+		Duration:  40,
+		Calories:  300,
+		Notes:     "状态不错",
+		Plan: models.TrainingPlan{
+			Name: "周一训练",
+			Exercises: []models.TrainingExercise{
+				{
+					Name:     "卧推",
+					Category: "胸部",
+					Sets: []models.ExerciseSet{
+						{Reps: 10, Weight: 60, Completed: true, RecordID: "r1"},
+						{Reps: 8, Weight: 70, Completed: true},
+						{Reps: 8, Weight: 70, Completed: false},
+						{Reps: 8, Weight: 70, Completed: true, RecordID: "other"},
+					},
+				},
+				{
+					Name:     "跑步",
+					Category: "有氧",
+					Sets: []models.ExerciseSet{
+						{Duration: 600, Distance: 2, Completed: true, CompletedAt: &runDone},
+						{Duration: 1200, Distance: 3.5, Completed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestExportCSVRows(t *testing.T) {
+	rows := exportCSVRows(exportTestRecord())
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, []string{"2024-03-04 18:00:00", "周一训练", "2400", "卧推", "胸部", "1", "60", "10", "0", "0", "", "状态不错"}, rows[0])
+	assert.Equal(t, "2", rows[1][5])
+	assert.Equal(t, "70", rows[1][6])
+	assert.Equal(t, "跑步", rows[2][3])
+	assert.Equal(t, "2", rows[2][8])
+	assert.Equal(t, "600", rows[2][9])
+	assert.Equal(t, "2024-03-04T18:20:00Z", rows[2][10])
+	assert.Equal(t, "3.5", rows[3][8])
+}
+
+func TestCardioActivities(t *testing.T) {
+	record := exportTestRecord()
+	start := record.StartTime
+	track := []models.TrackPoint{
+		{Latitude: 31.1, Longitude: 121.1, Time: start.Add(11 * time.Minute)},
+		{Latitude: 31.2, Longitude: 121.2, Time: start.Add(25 * time.Minute)},
+		{Latitude: 31.3, Longitude: 121.3, Time: start.Add(5 * time.Minute)},
+	}
+
+	activities := cardioActivities(record, track)
+	require.Len(t, activities, 1)
+	activity := activities[0]
+	assert.Equal(t, "跑步", activity.Name)
+	assert.Equal(t, "Running", activity.Sport)
+	assert.Equal(t, start.Add(10*time.Minute), activity.Start)
+
+	require.Len(t, activity.Laps, 2)
+	assert.Equal(t, 600, activity.Laps[0].Seconds)
+	assert.Equal(t, 2000.0, activity.Laps[0].Meters)
+	assert.Equal(t, 100, activity.Laps[0].Calories)
+	assert.Equal(t, start.Add(20*time.Minute), activity.Laps[1].Start)
+	assert.Equal(t, 200, activity.Laps[1].Calories)
+
+	// 早于第一圈的点归入第一圈
+	require.Len(t, activity.Laps[0].Points, 2)
+	require.Len(t, activity.Laps[1].Points, 1)
+	assert.Equal(t, 31.2, activity.Laps[1].Points[0].Latitude)
+}
+
+func TestCardioActivitiesSkipsStrength(t *testing.T) {
+	record := exportTestRecord()
+	record.Plan.Exercises = record.Plan.Exercises[:1]
+	assert.Nil(t, cardioActivities(record, nil))
+}
+
+func TestCardioSport(t *testing.T) {
+	assert.Equal(t, "Running", cardioSport("Treadmill Run"))
+	assert.Equal(t, "Biking", cardioSport("动感单车"))
+	assert.Equal(t, "Other", cardioSport("划船机"))
+}
+
+func TestBuildTCXAndGPX(t *testing.T) {
+	record := exportTestRecord()
+	track := []models.TrackPoint{
+		{Latitude: 31.1, Longitude: 121.1, Elevation: 5, Time: record.StartTime.Add(12 * time.Minute)},
+	}
+	activity := cardioActivities(record, track)[0]
+
+	tcx, err := xml.Marshal(buildTCXActivity(activity))
+	require.NoError(t, err)
+	assert.Contains(t, string(tcx), `<Activity Sport="Running"><Id>2024-03-04T18:10:00Z</Id>`)
+	assert.Contains(t, string(tcx), `<Lap StartTime="2024-03-04T18:20:00Z"><TotalTimeSeconds>1200</TotalTimeSeconds><DistanceMeters>3500</DistanceMeters>`)
+	assert.Contains(t, string(tcx), `<LatitudeDegrees>31.1</LatitudeDegrees>`)
+
+	gpx, err := xml.Marshal(buildGPXTrack(activity))
+	require.NoError(t, err)
+	assert.Contains(t, string(gpx), `<name>跑步 2024-03-04 18:10</name>`)
+	assert.Contains(t, string(gpx), `<desc>距离 5.50 公里，用时 1800 秒</desc>`)
+	assert.Contains(t, string(gpx), `<trkpt lat="31.1" lon="121.1"><ele>5</ele><time>2024-03-04T18:12:00Z</time></trkpt>`)
+
+	activity.Laps[0].Points = nil
+	gpx, err = xml.Marshal(buildGPXTrack(activity))
+	require.NoError(t, err)
+	assert.NotContains(t, string(gpx), "trkseg")
+}
+
+func TestAssignRecordLegacySets(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	later := start.AddDate(0, 0, 7)
+	inLater := later.Add(10 * time.Minute)
+	siblings := []models.WorkoutRecord{
+		{ID: "r1", StartTime: start, EndTime: start.Add(time.Hour)},
+		{ID: "r2", StartTime: later, EndTime: later.Add(time.Hour)},
+	}
+
+	plan := models.TrainingPlan{Exercises: []models.TrainingExercise{{
+		Name: "卧推",
+		Sets: []models.ExerciseSet{
+			{Reps: 10, Completed: true},
+			{Reps: 8, Completed: true, CompletedAt: &inLater},
+			{Reps: 6, Completed: true, RecordID: "r2"},
+		},
+	}}}
+	first := models.WorkoutRecord{ID: "r1", Plan: plan}
+	second := models.WorkoutRecord{ID: "r2", Plan: plan}
+	assignRecordLegacySets(&first, siblings)
+	assignRecordLegacySets(&second, siblings)
+
+	// 没有完成时间的历史组归属最早的一次训练，有完成时间的按时间范围归属，每组只导出一次
+	assert.Len(t, exportCSVRows(first), 1)
+	assert.Len(t, exportCSVRows(second), 2)
+	// 原计划的组数不被修改
+	assert.Equal(t, "", plan.Exercises[0].Sets[0].RecordID)
+}
+
+func TestWriteExportError(t *testing.T) {
+	var csvOut strings.Builder
+	writeExportError(models.ExportFormatCSV, &csvOut)
+	assert.Equal(t, "#ERROR,导出中断，文件不完整\n", csvOut.String())
+
+	var xmlOut strings.Builder
+	writeExportError(models.ExportFormatGPX, &xmlOut)
+	assert.Contains(t, xmlOut.String(), "<!-- #ERROR: 导出中断，文件不完整 -->")
+}
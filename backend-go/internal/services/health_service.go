@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrBodyMetricsOutOfRange 身高或体重超出合理范围
+var ErrBodyMetricsOutOfRange = errors.New("身高应在50-300cm之间，体重应在10-500kg之间")
+
+// HealthService BMI 等健康指标服务，身高体重均为公制（cm、kg），单位换算在处理器中完成
+type HealthService struct {
+	db *gorm.DB
+}
+
+// NewHealthService 创建健康指标服务
+func NewHealthService(db *gorm.DB) *HealthService {
+	return &HealthService{db: db}
+}
+
+// CalculateBMI 计算 BMI、理想体重、体脂率和基础代谢
+func (s *HealthService) CalculateBMI(req models.BMIRequest) (*models.BMIResponse, error) {
+	return calculateBMI(req.Height, req.Weight, req.Age, req.Gender)
+}
+
+// CreateBMIRecord 计算 BMI 并保存为健康记录
+func (s *HealthService) CreateBMIRecord(userID string, req models.BMIRequest) (*models.HealthRecord, error) {
+	result, err := calculateBMI(req.Height, req.Weight, req.Age, req.Gender)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.HealthRecord{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Date:      now,
+		Type:      models.HealthRecordTypeBMI,
+		Value:     result.BMI,
+		Unit:      "kg/m²",
+		Notes:     req.Notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		logger.Error.Printf("创建BMI记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return record, nil
+}
+
+// BMIRecords 获取用户的 BMI 记录，按日期倒序
+func (s *HealthService) BMIRecords(userID string) ([]models.HealthRecord, error) {
+	records := []models.HealthRecord{}
+	if err := s.db.Where("user_id = ? AND type = ?", userID, models.HealthRecordTypeBMI).
+		Order("date DESC").
+		Find(&records).Error; err != nil {
+		logger.Error.Printf("获取BMI记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return records, nil
+}
+
+// calculateBMI 按身高（cm）和体重（kg）计算 BMI 及相关指标
+// 体脂率使用 BMI 估算公式，基础代谢使用修订版 Harris-Benedict 公式，每日消耗按中等活动水平估算
+func calculateBMI(height, weight float64, age int, gender string) (*models.BMIResponse, error) {
+	if height < 50 || height > 300 || weight < 10 || weight > 500 {
+		return nil, ErrBodyMetricsOutOfRange
+	}
+
+	heightM := height / 100
+	bmi := weight / (heightM * heightM)
+
+	var category string
+	switch {
+	case bmi < 18.5:
+		category = models.BMICategoryUnderweight
+	case bmi < 24:
+		category = models.BMICategoryNormal
+	case bmi < 28:
+		category = models.BMICategoryOverweight
+	default:
+		category = models.BMICategoryObese
+	}
+
+	var bodyFat, bmr float64
+	if gender == "male" {
+		bodyFat = 1.20*bmi + 0.23*float64(age) - 16.2
+		bmr = 88.362 + 13.397*weight + 4.799*height - 5.677*float64(age)
+	} else {
+		bodyFat = 1.20*bmi + 0.23*float64(age) - 5.4
+		bmr = 447.593 + 9.247*weight + 3.098*height - 4.330*float64(age)
+	}
+
+	return &models.BMIResponse{
+		BMI:      roundTo(bmi, 2),
+		Category: category,
+		IdealWeight: models.WeightRange{
+			Min: roundTo(18.5*heightM*heightM, 2),
+			Max: roundTo(24*heightM*heightM, 2),
+		},
+		BodyFat: roundTo(bodyFat, 2),
+		BMR:     roundTo(bmr, 2),
+		TDEE:    roundTo(bmr*1.55, 2),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateBMI(t *testing.T) {
+	result, err := calculateBMI(175, 70, 25, "male")
+	require.NoError(t, err)
+
+	assert.Equal(t, 22.86, result.BMI)
+	assert.Equal(t, models.BMICategoryNormal, result.Category)
+	assert.Equal(t, 56.66, result.IdealWeight.Min)
+	assert.Equal(t, 73.5, result.IdealWeight.Max)
+
+	result, err = calculateBMI(160, 85, 30, "female")
+	require.NoError(t, err)
+	assert.Equal(t, models.BMICategoryObese, result.Category)
+
+	_, err = calculateBMI(40, 60, 30, "male")
+	assert.ErrorIs(t, err, ErrBodyMetricsOutOfRange)
+}
+
+func TestCalculateBMIImperial(t *testing.T) {
+	units := NewUnitConverter(models.UnitSystemImperial)
+
+	// 英制请求换算为公制后计算，理想体重按英制返回
+	req := models.BMIRequest{Height: 69, Weight: 154, Age: 25, Gender: "male"}
+	units.BMIRequest(&req)
+	assert.Equal(t, 175.3, req.Height)
+	assert.Equal(t, 69.85, req.Weight)
+
+	result, err := calculateBMI(req.Height, req.Weight, req.Age, req.Gender)
+	require.NoError(t, err)
+	units.BMI(result)
+
+	assert.Equal(t, 22.73, result.BMI)
+	assert.Equal(t, "lb", result.Units.Weight)
+	assert.Equal(t, 125.3, result.IdealWeight.Min)
+	assert.Equal(t, 162.6, result.IdealWeight.Max)
+}
+
+func TestScaleNutrition(t *testing.T) {
+	units := NewUnitConverter(models.UnitSystemImperial)
+
+	// 英制默认按盎司，4 oz 约 113.4 克
+	req := models.NutritionRequest{FoodName: "鸡胸肉", Quantity: 4}
+	grams := units.NutritionRequest(&req)
+	assert.Equal(t, "oz", req.Unit)
+	assert.Equal(t, 113.4, grams)
+
+	facts := scaleNutrition(nutritionFacts["鸡胸肉"], grams)
+	assert.Equal(t, 187.11, facts.Calories)
+	assert.Equal(t, 35.15, facts.Protein)
+
+	records := []models.NutritionRecord{{Quantity: 113.4, Unit: "g"}}
+	units.NutritionRecords(records)
+	assert.Equal(t, 4.0, records[0].Quantity)
+	assert.Equal(t, "oz", records[0].Unit)
+}
+
+func TestDailyIntakeGroupsMeals(t *testing.T) {
+	records := []models.NutritionRecord{
+		{MealType: "午餐", Calories: 130.004, Protein: 2.7},
+		{MealType: "早餐", Calories: 155, Protein: 13},
+		{MealType: "午餐", Calories: 165, Protein: 31},
+	}
+
+	intake := dailyIntake("2024-03-01", records)
+
+	assert.Equal(t, 450.0, intake.Calories)
+	assert.Equal(t, 46.7, intake.Protein)
+	require.Len(t, intake.Meals, 2)
+	assert.Equal(t, "午餐", intake.Meals[0].MealType)
+	assert.Equal(t, 2, intake.Meals[0].Count)
+	assert.Equal(t, 295.0, intake.Meals[0].Calories)
+	assert.Equal(t, "早餐", intake.Meals[1].MealType)
+}
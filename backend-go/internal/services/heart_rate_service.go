@@ -0,0 +1,373 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// 有效心率范围，超出范围的采样视为传感器异常
+	minHeartRateBPM = 25
+	maxHeartRateBPM = 250
+	// maxHeartRateGap 相邻采样间隔超过该值视为断连，间隔内不计时长
+	maxHeartRateGap = 30 * time.Second
+	// heartRateSampleMargin 允许采样时间早于训练开始或晚于训练结束的范围
+	heartRateSampleMargin = time.Minute
+	// defaultMaxHeartRate 没有填写最大心率也没有生日时使用的最大心率
+	defaultMaxHeartRate = 190
+	// defaultHeartRateAge 没有生日时估算卡路里使用的年龄
+	defaultHeartRateAge = 30
+	// heartRateChartPoints 返回的心率曲线最多采样点数
+	heartRateChartPoints = 600
+)
+
+// heartRateZoneBounds 五个心率区间的下限（占最大心率的比例），低于第一个下限不计入任何区间
+var heartRateZoneBounds = []float64{0.5, 0.6, 0.7, 0.8, 0.9}
+
+var (
+	// ErrWorkoutRecordNotFound 训练记录不存在或不属于当前用户
+	ErrWorkoutRecordNotFound = errors.New("训练记录不存在或无权操作")
+	// ErrNoHeartRateSamples 上传的采样点都无效
+	ErrNoHeartRateSamples = errors.New("没有有效的心率采样点")
+)
+
+// HeartRateService 训练心率服务
+// 接收心率带/手表上传的采样点，按训练记录分块压缩保存；每次上传后重新计算区间时间、平均/峰值心率、
+// 训练负荷和按心率估算的卡路里，结果保存在训练记录上。已结束的训练没有设备实测卡路里时，用心率估算值更新训练的卡路里
+type HeartRateService struct {
+	db *gorm.DB
+}
+
+// NewHeartRateService 创建训练心率服务
+func NewHeartRateService(db *gorm.DB) *HeartRateService {
+	return &HeartRateService{db: db}
+}
+
+// Upload 上传训练的心率采样点并更新心率分析
+func (s *HeartRateService) Upload(userID string, req models.UploadHeartRateRequest) (*models.HeartRateResponse, error) {
+	record, err := userWorkoutRecord(s.db, userID, req.RecordID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if record.Status == models.WorkoutStatusCompleted && !record.EndTime.IsZero() {
+		end = record.EndTime
+	}
+	samples, dropped := filterHeartRate(req.Samples, record.StartTime.Add(-heartRateSampleMargin), end.Add(heartRateSampleMargin))
+	if len(samples) == 0 {
+		return nil, ErrNoHeartRateSamples
+	}
+
+	start, data := encodeHeartRate(samples)
+	chunk := models.HeartRateChunk{
+		ID:          uuid.New().String(),
+		RecordID:    record.ID,
+		UserID:      userID,
+		StartAt:     start,
+		EndAt:       samples[len(samples)-1].Time,
+		SampleCount: len(samples),
+		Data:        data,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.Create(&chunk).Error; err != nil {
+		logger.Error.Printf("保存心率数据失败: record_id=%v, error=%v", record.ID, err.Error())
+		return nil, err
+	}
+
+	series, err := s.series(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	summary := s.summarize(record, series)
+	record.HeartRate = summary
+	record.TrainingLoad = summary.TrainingLoad
+	if record.Status == models.WorkoutStatusCompleted && record.CaloriesSource != models.CaloriesSourceMeasured && summary.Calories > 0 {
+		record.Calories = summary.Calories
+		record.CaloriesSource = models.CaloriesSourceHeartRate
+	}
+	record.UpdatedAt = time.Now()
+
+	err = s.db.Model(record).
+		Select("heart_rate", "training_load", "calories", "calories_source", "updated_at").
+		Updates(record).Error
+	if err != nil {
+		logger.Error.Printf("更新心率分析失败: record_id=%v, error=%v", record.ID, err.Error())
+		return nil, err
+	}
+
+	return &models.HeartRateResponse{
+		RecordID:       record.ID,
+		Accepted:       len(samples),
+		Dropped:        dropped,
+		Calories:       record.Calories,
+		CaloriesSource: record.CaloriesSource,
+		Summary:        summary,
+	}, nil
+}
+
+// Get 获取训练的心率分析和降采样后的心率曲线
+func (s *HeartRateService) Get(userID, recordID string) (*models.HeartRateResponse, error) {
+	record, err := userWorkoutRecord(s.db, userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.series(record.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HeartRateResponse{
+		RecordID:       record.ID,
+		Calories:       record.Calories,
+		CaloriesSource: record.CaloriesSource,
+		Summary:        record.HeartRate,
+		Samples:        downsampleHeartRate(series, heartRateChartPoints),
+	}, nil
+}
+
+// userWorkoutRecord 获取用户自己的训练记录
+func userWorkoutRecord(db *gorm.DB, userID, recordID string) (*models.WorkoutRecord, error) {
+	var record models.WorkoutRecord
+	err := db.Where("id = ? AND user_id = ?", recordID, userID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorkoutRecordNotFound
+		}
+		logger.Error.Printf("查询训练记录失败: record_id=%v, user_id=%v, error=%v", recordID, userID, err.Error())
+		return nil, err
+	}
+	return &record, nil
+}
+
+// series 解码训练的全部心率数据，按上传顺序合并
+func (s *HeartRateService) series(recordID string) ([]models.HeartRateSample, error) {
+	var chunks []models.HeartRateChunk
+	if err := s.db.Where("record_id = ?", recordID).Order("created_at ASC").Find(&chunks).Error; err != nil {
+		logger.Error.Printf("查询心率数据失败: record_id=%v, error=%v", recordID, err.Error())
+		return nil, err
+	}
+
+	decoded := make([][]models.HeartRateSample, 0, len(chunks))
+	for _, chunk := range chunks {
+		samples, err := decodeHeartRate(chunk.StartAt, chunk.Data, chunk.SampleCount)
+		if err != nil {
+			logger.Error.Printf("解码心率数据失败: chunk_id=%v, error=%v", chunk.ID, err.Error())
+			continue
+		}
+		decoded = append(decoded, samples)
+	}
+	return mergeHeartRate(decoded), nil
+}
+
+// summarize 按用户的最大心率、体重、年龄和性别计算心率分析
+func (s *HeartRateService) summarize(record *models.WorkoutRecord, series []models.HeartRateSample) *models.HeartRateSummary {
+	var settings models.UserSettings
+	entered := 0
+	if err := s.db.Select("max_heart_rate").Where("user_id = ?", record.UserID).First(&settings).Error; err == nil {
+		entered = settings.MaxHeartRate
+	}
+
+	var user models.User
+	s.db.Select("weight", "gender", "birthday").Where("id = ?", record.UserID).First(&user)
+
+	maxHR, source := maxHeartRate(entered, user.Birthday, record.StartTime)
+	summary := summarizeHeartRate(series, maxHR)
+	summary.MaxHeartRateSource = source
+
+	weight := defaultBodyWeight
+	if user.Weight > 0 {
+		weight = user.Weight
+	}
+	age := defaultHeartRateAge
+	if !user.Birthday.IsZero() {
+		age = ageAt(user.Birthday, record.StartTime)
+	}
+	summary.Calories = heartRateCalories(summary.AvgBPM, weight, age, user.Gender, summary.Seconds)
+	return summary
+}
+
+// filterHeartRate 丢弃心率超出范围或时间不在 [from, to] 内的采样点，结果按时间排序
+func filterHeartRate(samples []models.HeartRateSample, from, to time.Time) ([]models.HeartRateSample, int) {
+	kept := make([]models.HeartRateSample, 0, len(samples))
+	for _, sample := range samples {
+		if sample.BPM < minHeartRateBPM || sample.BPM > maxHeartRateBPM {
+			continue
+		}
+		if sample.Time.Before(from) || sample.Time.After(to) {
+			continue
+		}
+		kept = append(kept, sample)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	return kept, len(samples) - len(kept)
+}
+
+// encodeHeartRate 编码按时间排序的采样点：第一个点的时间作为起点，之后每个点为 [毫秒差 uvarint][bpm 1 字节]
+func encodeHeartRate(samples []models.HeartRateSample) (time.Time, []byte) {
+	if len(samples) == 0 {
+		return time.Time{}, nil
+	}
+	start := samples[0].Time.Truncate(time.Millisecond)
+	data := make([]byte, 0, len(samples)*3)
+	buf := make([]byte, binary.MaxVarintLen64)
+	prev := start
+	for _, sample := range samples {
+		at := sample.Time.Truncate(time.Millisecond)
+		n := binary.PutUvarint(buf, uint64(at.Sub(prev).Milliseconds()))
+		data = append(data, buf[:n]...)
+		data = append(data, byte(sample.BPM))
+		prev = at
+	}
+	return start, data
+}
+
+// decodeHeartRate 解码 encodeHeartRate 编码的采样点
+func decodeHeartRate(start time.Time, data []byte, count int) ([]models.HeartRateSample, error) {
+	samples := make([]models.HeartRateSample, 0, count)
+	at := start
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 || n >= len(data) {
+			return nil, errors.New("心率数据已损坏")
+		}
+		at = at.Add(time.Duration(delta) * time.Millisecond)
+		samples = append(samples, models.HeartRateSample{Time: at, BPM: int(data[n])})
+		data = data[n+1:]
+	}
+	return samples, nil
+}
+
+// mergeHeartRate 合并多次上传的采样点，同一时间（毫秒）的采样以后上传的为准
+func mergeHeartRate(chunks [][]models.HeartRateSample) []models.HeartRateSample {
+	byTime := make(map[int64]models.HeartRateSample)
+	for _, chunk := range chunks {
+		for _, sample := range chunk {
+			byTime[sample.Time.UnixMilli()] = sample
+		}
+	}
+	merged := make([]models.HeartRateSample, 0, len(byTime))
+	for _, sample := range byTime {
+		merged = append(merged, sample)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
+
+// summarizeHeartRate 计算区间时间、平均/峰值心率和训练负荷
+// 每个采样点的心率持续到下一个采样点，间隔超过 maxHeartRateGap 的部分视为断连不计入；平均心率按时长加权
+func summarizeHeartRate(samples []models.HeartRateSample, maxHR int) *models.HeartRateSummary {
+	summary := &models.HeartRateSummary{
+		Samples:      len(samples),
+		MaxHeartRate: maxHR,
+		Zones:        make([]models.HeartRateZone, len(heartRateZoneBounds)),
+	}
+	for i, lower := range heartRateZoneBounds {
+		summary.Zones[i] = models.HeartRateZone{Zone: i + 1, MinBPM: int(math.Round(lower * float64(maxHR))), MaxBPM: maxHR}
+		if i+1 < len(heartRateZoneBounds) {
+			summary.Zones[i].MaxBPM = int(math.Round(heartRateZoneBounds[i+1]*float64(maxHR))) - 1
+		}
+	}
+
+	var weighted, seconds float64
+	zoneSeconds := make([]float64, len(heartRateZoneBounds))
+	for i, sample := range samples {
+		summary.PeakBPM = max(summary.PeakBPM, sample.BPM)
+		if i+1 == len(samples) {
+			break
+		}
+		gap := samples[i+1].Time.Sub(sample.Time)
+		if gap <= 0 || gap > maxHeartRateGap {
+			continue
+		}
+		d := gap.Seconds()
+		weighted += float64(sample.BPM) * d
+		seconds += d
+		if zone := heartRateZone(sample.BPM, maxHR); zone > 0 {
+			zoneSeconds[zone-1] += d
+		}
+	}
+
+	summary.Seconds = int(math.Round(seconds))
+	if seconds > 0 {
+		summary.AvgBPM = int(math.Round(weighted / seconds))
+	} else if len(samples) > 0 {
+		summary.AvgBPM = samples[0].BPM
+	}
+
+	load := 0.0
+	for i, d := range zoneSeconds {
+		summary.Zones[i].Seconds = int(math.Round(d))
+		if seconds > 0 {
+			summary.Zones[i].Percent = math.Round(d/seconds*1000) / 10
+		}
+		load += d / 60 * float64(i+1)
+	}
+	summary.TrainingLoad = math.Round(load*10) / 10
+	return summary
+}
+
+// heartRateZone 心率所在的区间（1-5），低于最大心率 50% 时为 0
+func heartRateZone(bpm, maxHR int) int {
+	if maxHR <= 0 {
+		return 0
+	}
+	ratio := float64(bpm) / float64(maxHR)
+	zone := 0
+	for i, lower := range heartRateZoneBounds {
+		if ratio >= lower {
+			zone = i + 1
+		}
+	}
+	return zone
+}
+
+// maxHeartRate 最大心率：优先使用用户填写的值，其次按训练时的年龄估算（Tanaka：208 - 0.7 × 年龄）
+func maxHeartRate(entered int, birthday, at time.Time) (int, string) {
+	if entered > 0 {
+		return entered, models.MaxHeartRateEntered
+	}
+	if birthday.IsZero() || !birthday.Before(at) {
+		return defaultMaxHeartRate, models.MaxHeartRateDefault
+	}
+	return int(math.Round(208 - 0.7*float64(ageAt(birthday, at)))), models.MaxHeartRateEstimated
+}
+
+// ageAt 某一时间的周岁年龄
+func ageAt(birthday, at time.Time) int {
+	age := at.Year() - birthday.Year()
+	if at.Month() < birthday.Month() || (at.Month() == birthday.Month() && at.Day() < birthday.Day()) {
+		age--
+	}
+	return max(age, 0)
+}
+
+// downsampleHeartRate 把心率曲线按时间均分为不超过 points 段，每段取平均心率
+func downsampleHeartRate(samples []models.HeartRateSample, points int) []models.HeartRateSample {
+	if len(samples) <= points {
+		return samples
+	}
+	result := make([]models.HeartRateSample, 0, points)
+	size := float64(len(samples)) / float64(points)
+	for i := 0; i < points; i++ {
+		from, to := int(float64(i)*size), int(float64(i+1)*size)
+		sum := 0
+		for _, sample := range samples[from:to] {
+			sum += sample.BPM
+		}
+		result = append(result, models.HeartRateSample{
+			Time: samples[from].Time,
+			BPM:  int(math.Round(float64(sum) / float64(to-from))),
+		})
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func heartRateSeries(start time.Time, step time.Duration, bpms ...int) []models.HeartRateSample {
+	samples := make([]models.HeartRateSample, len(bpms))
+	for i, bpm := range bpms {
+		samples[i] = models.HeartRateSample{Time: start.Add(time.Duration(i) * step), BPM: bpm}
+	}
+	return samples
+}
+
+func TestEncodeDecodeHeartRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	samples := heartRateSeries(start, 1500*time.Millisecond, 90, 120, 185, 60)
+	samples = append(samples, models.HeartRateSample{Time: start.Add(10 * time.Minute), BPM: 250})
+
+	at, data := encodeHeartRate(samples)
+	assert.Equal(t, start, at)
+	// 间隔 1.5 秒时每个点 3 字节
+	assert.Less(t, len(data), len(samples)*4)
+
+	decoded, err := decodeHeartRate(at, data, len(samples))
+	require.NoError(t, err)
+	require.Len(t, decoded, len(samples))
+	for i := range samples {
+		assert.True(t, samples[i].Time.Equal(decoded[i].Time))
+		assert.Equal(t, samples[i].BPM, decoded[i].BPM)
+	}
+
+	_, err = decodeHeartRate(at, data[:len(data)-1], len(samples))
+	assert.Error(t, err)
+}
+
+func TestFilterHeartRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	samples := []models.HeartRateSample{
+		{Time: start.Add(2 * time.Second), BPM: 120},
+		{Time: start.Add(time.Second), BPM: 110},
+		{Time: start.Add(3 * time.Second), BPM: 300}, // 超出范围
+		{Time: start.Add(-time.Hour), BPM: 100},      // 早于训练开始
+		{Time: start.Add(4 * time.Second), BPM: 10},  // 超出范围
+		{Time: start.Add(2 * time.Hour), BPM: 100},   // 晚于训练结束
+		{Time: start.Add(5 * time.Second), BPM: maxHeartRateBPM},
+	}
+
+	kept, dropped := filterHeartRate(samples, start, start.Add(time.Hour))
+	assert.Equal(t, 4, dropped)
+	require.Len(t, kept, 3)
+	assert.Equal(t, 110, kept[0].BPM)
+	assert.Equal(t, 120, kept[1].BPM)
+	assert.Equal(t, maxHeartRateBPM, kept[2].BPM)
+}
+
+func TestMergeHeartRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	first := heartRateSeries(start, time.Second, 100, 110, 120)
+	// 第二次上传与第一次重叠一个点，以后上传的为准
+	second := heartRateSeries(start.Add(2*time.Second), time.Second, 125, 130)
+
+	merged := mergeHeartRate([][]models.HeartRateSample{first, second})
+	require.Len(t, merged, 4)
+	assert.Equal(t, []int{100, 110, 125, 130}, []int{merged[0].BPM, merged[1].BPM, merged[2].BPM, merged[3].BPM})
+}
+
+func TestSummarizeHeartRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	// 最大心率 200：区间下限 100/120/140/160/180
+	var samples []models.HeartRateSample
+	samples = append(samples, heartRateSeries(start, 10*time.Second, 90, 90, 90, 90, 90, 90)...)                          // 低于区间，60 秒
+	samples = append(samples, heartRateSeries(start.Add(time.Minute), 10*time.Second, 130, 130, 130, 130, 130, 130)...)   // 区间 2，60 秒
+	samples = append(samples, heartRateSeries(start.Add(2*time.Minute), 10*time.Second, 185, 185, 185, 185, 185, 185)...) // 区间 5，50 秒
+	// 断连 5 分钟后恢复，断连期间不计时长
+	samples = append(samples, heartRateSeries(start.Add(8*time.Minute), 10*time.Second, 165, 165)...) // 区间 4，10 秒
+
+	summary := summarizeHeartRate(samples, 200)
+	assert.Equal(t, len(samples), summary.Samples)
+	assert.Equal(t, 180, summary.Seconds)
+	assert.Equal(t, 185, summary.PeakBPM)
+	assert.Equal(t, 200, summary.MaxHeartRate)
+	require.Len(t, summary.Zones, 5)
+
+	assert.Equal(t, 100, summary.Zones[0].MinBPM)
+	assert.Equal(t, 119, summary.Zones[0].MaxBPM)
+	assert.Equal(t, 180, summary.Zones[4].MinBPM)
+	assert.Equal(t, 200, summary.Zones[4].MaxBPM)
+
+	assert.Equal(t, 0, summary.Zones[0].Seconds)
+	assert.Equal(t, 60, summary.Zones[1].Seconds)
+	assert.Equal(t, 10, summary.Zones[3].Seconds)
+	assert.Equal(t, 50, summary.Zones[4].Seconds)
+	assert.InDelta(t, 33.3, summary.Zones[1].Percent, 0.01)
+
+	// (90×60 + 130×60 + 185×50 + 165×10) / 180
+	assert.Equal(t, 134, summary.AvgBPM)
+	// TRIMP：1 分钟 × 2 + 10/60 × 4 + 50/60 × 5
+	assert.InDelta(t, 6.8, summary.TrainingLoad, 0.001)
+}
+
+func TestSummarizeHeartRateSingleSample(t *testing.T) {
+	summary := summarizeHeartRate(heartRateSeries(time.Now(), time.Second, 140), 190)
+	assert.Equal(t, 140, summary.AvgBPM)
+	assert.Equal(t, 140, summary.PeakBPM)
+	assert.Equal(t, 0, summary.Seconds)
+	assert.Equal(t, 0.0, summary.TrainingLoad)
+}
+
+func TestHeartRateZone(t *testing.T) {
+	assert.Equal(t, 0, heartRateZone(99, 200))
+	assert.Equal(t, 1, heartRateZone(100, 200))
+	assert.Equal(t, 3, heartRateZone(150, 200))
+	assert.Equal(t, 5, heartRateZone(210, 200))
+	assert.Equal(t, 0, heartRateZone(150, 0))
+}
+
+func TestMaxHeartRate(t *testing.T) {
+	at := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	birthday := time.Date(1994, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	maxHR, source := maxHeartRate(195, birthday, at)
+	assert.Equal(t, 195, maxHR)
+	assert.Equal(t, models.MaxHeartRateEntered, source)
+
+	// 30 岁：208 - 21
+	maxHR, source = maxHeartRate(0, birthday, at)
+	assert.Equal(t, 187, maxHR)
+	assert.Equal(t, models.MaxHeartRateEstimated, source)
+
+	maxHR, source = maxHeartRate(0, time.Time{}, at)
+	assert.Equal(t, defaultMaxHeartRate, maxHR)
+	assert.Equal(t, models.MaxHeartRateDefault, source)
+}
+
+func TestAgeAt(t *testing.T) {
+	birthday := time.Date(1994, 6, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 29, ageAt(birthday, time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 30, ageAt(birthday, time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 0, ageAt(birthday, time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestDownsampleHeartRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	samples := heartRateSeries(start, time.Second, 100, 110, 120, 130, 140, 150)
+
+	assert.Len(t, downsampleHeartRate(samples, 10), 6)
+
+	down := downsampleHeartRate(samples, 3)
+	require.Len(t, down, 3)
+	assert.Equal(t, 105, down[0].BPM)
+	assert.Equal(t, 125, down[1].BPM)
+	assert.Equal(t, 145, down[2].BPM)
+	assert.Equal(t, start.Add(2*time.Second), down[1].Time)
+}
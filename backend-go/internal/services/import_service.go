@@ -0,0 +1,697 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxImportRows 单次导入的最大行数
+	maxImportRows = 100000
+	// lbToKg 磅转千克
+	lbToKg = 0.45359237
+	// mileToKm 英里转公里
+	mileToKm = 1.609344
+	// importDuplicateWindow 开始时间相差不超过该时长的训练视为重复
+	importDuplicateWindow = time.Minute
+	// defaultImportWorkoutName 源文件没有训练名称时使用的名称
+	defaultImportWorkoutName = "导入的训练"
+)
+
+// ErrInvalidImportCSV CSV 文件无法识别或超过导入限制
+var ErrInvalidImportCSV = errors.New("CSV 文件无法导入")
+
+// CSV 列的含义
+const (
+	importColDate            = "date"
+	importColEndTime         = "end_time"
+	importColWorkout         = "workout"
+	importColWorkoutDuration = "workout_duration"
+	importColExercise        = "exercise"
+	importColSetOrder        = "set_order"
+	importColWeight          = "weight"
+	importColReps            = "reps"
+	importColDistance        = "distance"
+	importColDistanceUnit    = "distance_unit"
+	importColDuration        = "duration"
+	importColNotes           = "notes"
+)
+
+// importHeaderAliases 常见训练应用（Strong、Hevy、FitNotes 等）导出文件的表头，已按 normalizeExerciseName 规范化
+var importHeaderAliases = map[string][]string{
+	importColDate:            {"date", "starttime", "workoutdate", "日期", "开始时间"},
+	importColEndTime:         {"endtime", "结束时间"},
+	importColWorkout:         {"workoutname", "title", "workout", "routine", "routinename", "训练名称"},
+	importColWorkoutDuration: {"duration", "workoutduration", "训练时长"},
+	importColExercise:        {"exercisename", "exercise", "exercisetitle", "动作", "动作名称"},
+	importColSetOrder:        {"setorder", "setindex", "set", "setnumber", "组序"},
+	importColWeight:          {"weight", "weightkg", "weightkgs", "weightlb", "weightlbs", "重量", "重量kg"},
+	importColReps:            {"reps", "repetitions", "次数"},
+	importColDistance:        {"distance", "distancekm", "distancemi", "distancemiles", "距离", "距离km"},
+	importColDistanceUnit:    {"distanceunit", "距离单位"},
+	importColDuration:        {"seconds", "durationseconds", "time", "setduration", "用时", "用时秒"},
+	importColNotes:           {"notes", "exercisenotes", "note", "备注"},
+}
+
+// importTimeLayouts 支持的日期时间格式
+var importTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2 Jan 2006, 15:04",
+	"Jan 2, 2006 3:04 PM",
+	"01/02/2006 15:04",
+	"01/02/2006",
+}
+
+// importDurationPattern 形如 "1h 5m"、"45min"、"30s" 的时长
+var importDurationPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(h|hr|hrs|hour|hours|m|min|mins|minute|minutes|s|sec|secs|second|seconds)`)
+
+// importThousandsPatterns 以逗号或点作为千位分隔符的整数，如 "1,000"、"12.345.678"
+var importThousandsPatterns = map[rune]*regexp.Regexp{
+	',': regexp.MustCompile(`^-?\d{1,3}(,\d{3})+$`),
+	'.': regexp.MustCompile(`^-?\d{1,3}(\.\d{3})+$`),
+}
+
+// ImportService 训练历史导入服务
+// 解析其他训练应用导出的 CSV，按训练拆分为 TrainingPlan + WorkoutRecord，动作和组数映射为 TrainingExercise 和 ExerciseSet
+type ImportService struct {
+	db               *gorm.DB
+	catalog          *ExerciseCatalogService
+	streakService    *StreakService
+	recordService    *RecordService
+	adherenceService *AdherenceService
+	analyticsService *AnalyticsService
+
+	// 导入后的统计刷新在后台执行，pending 为每个用户等待刷新个人记录的导入计划
+	refreshes *userTasks
+	mu        sync.Mutex
+	pending   map[string][]models.TrainingPlan
+}
+
+// importSet 解析出的一组（已统一为 kg、公里、秒）
+type importSet struct {
+	Line     int
+	Order    int
+	Weight   float64
+	Reps     int
+	Distance float64
+	Duration int
+}
+
+// importExercise 解析出的一个动作
+type importExercise struct {
+	Name  string
+	Notes string
+	Sets  []importSet
+}
+
+// importWorkout 解析出的一次训练
+type importWorkout struct {
+	Key       string
+	Name      string
+	Start     time.Time
+	End       time.Time
+	Duration  int // 秒，源文件没有时为 0
+	Exercises []*importExercise
+	byName    map[string]*importExercise
+}
+
+// importParseResult CSV 解析结果
+type importParseResult struct {
+	WeightUnit string
+	TotalRows  int
+	Workouts   []*importWorkout
+	Skipped    []models.ImportSkippedRow
+}
+
+// NewImportService 创建训练历史导入服务
+func NewImportService(db *gorm.DB, catalog *ExerciseCatalogService, streakService *StreakService, recordService *RecordService, adherenceService *AdherenceService, analyticsService *AnalyticsService) *ImportService {
+	s := &ImportService{
+		db:               db,
+		catalog:          catalog,
+		streakService:    streakService,
+		recordService:    recordService,
+		adherenceService: adherenceService,
+		analyticsService: analyticsService,
+		pending:          make(map[string][]models.TrainingPlan),
+	}
+	s.refreshes = newUserTasks("import_refresh", s.refreshStats)
+	return s
+}
+
+// Import 导入 CSV 训练历史
+// unit 为源文件的重量单位（kg/lb），表头中标明单位时以表头为准；dryRun 为 true 时只返回预览报告，不写入数据
+func (s *ImportService) Import(userID string, r io.Reader, unit string, dryRun bool) (*models.ImportReport, error) {
+	parsed, err := parseImportCSV(r, unit, s.streakService.UserLocation(userID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportCSV, err)
+	}
+
+	report := &models.ImportReport{
+		DryRun:             dryRun,
+		WeightUnit:         parsed.WeightUnit,
+		TotalRows:          parsed.TotalRows,
+		SkippedRows:        parsed.Skipped,
+		UnmatchedExercises: []string{},
+		Workouts:           []models.ImportedWorkoutSummary{},
+	}
+	if len(parsed.Workouts) == 0 {
+		return report, nil
+	}
+
+	duplicates, err := s.findDuplicates(userID, parsed.Workouts)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := s.catalog.loadCatalog("")
+	if err != nil {
+		return nil, err
+	}
+
+	unmatched := make(map[string]bool)
+	var plans []models.TrainingPlan
+	var records []models.WorkoutRecord
+	for _, workout := range parsed.Workouts {
+		summary := models.ImportedWorkoutSummary{
+			Name:      workout.Name,
+			StartTime: workout.Start.Format(time.RFC3339),
+			Exercises: len(workout.Exercises),
+		}
+		for _, exercise := range workout.Exercises {
+			summary.Sets += len(exercise.Sets)
+		}
+
+		if duplicates[workout.Key] {
+			summary.Duplicate = true
+			report.DuplicateWorkouts++
+			report.Workouts = append(report.Workouts, summary)
+			continue
+		}
+
+		plan, record := buildImportedWorkout(userID, workout)
+		linkExercises(catalog, plan.Exercises)
+		for _, exercise := range plan.Exercises {
+			if exercise.CatalogID == 0 && !unmatched[exercise.Name] {
+				unmatched[exercise.Name] = true
+				report.UnmatchedExercises = append(report.UnmatchedExercises, exercise.Name)
+			}
+		}
+
+		plans = append(plans, plan)
+		records = append(records, record)
+		report.ImportedWorkouts++
+		report.ImportedExercises += summary.Exercises
+		report.ImportedSets += summary.Sets
+		report.Workouts = append(report.Workouts, summary)
+	}
+	sort.Strings(report.UnmatchedExercises)
+
+	if dryRun || len(plans) == 0 {
+		return report, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range plans {
+			if err := tx.Create(&plans[i]).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error.Printf("导入训练历史失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	logger.Info.Printf("导入训练历史成功: user_id=%v, workouts=%d, sets=%d", userID, report.ImportedWorkouts, report.ImportedSets)
+	s.mu.Lock()
+	s.pending[userID] = append(s.pending[userID], plans...)
+	s.mu.Unlock()
+	s.refreshes.schedule(userID)
+	return report, nil
+}
+
+// refreshStats 导入后按训练日期先后刷新个人记录，并重算连续天数、计划完成度和最常训练动作，失败不影响导入结果
+// 逐个动作刷新个人记录较慢，在后台执行，不阻塞导入请求
+func (s *ImportService) refreshStats(userID string) {
+	s.mu.Lock()
+	plans := s.pending[userID]
+	delete(s.pending, userID)
+	s.mu.Unlock()
+
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].Date.Before(plans[j].Date) })
+	for _, plan := range plans {
+		for _, exercise := range plan.Exercises {
+			if _, err := s.recordService.ProcessImported(userID, exercise.ID, plan.Date); err != nil {
+				logger.Error.Printf("更新导入训练的个人记录失败: user_id=%v, exercise_id=%v, error=%v", userID, exercise.ID, err.Error())
+			}
+		}
+	}
+
+	if _, err := s.streakService.Rebuild(userID); err != nil {
+		logger.Error.Printf("重算连续训练天数失败: user_id=%v, error=%v", userID, err.Error())
+	}
+	s.adherenceService.RefreshStats(userID)
+	s.analyticsService.RefreshFavorite(userID)
+}
+
+// findDuplicates 找出已导入过（去重键相同）或已有开始时间相近训练记录的训练
+func (s *ImportService) findDuplicates(userID string, workouts []*importWorkout) (map[string]bool, error) {
+	from, to := workouts[0].Start, workouts[0].Start
+	for _, workout := range workouts {
+		if workout.Start.Before(from) {
+			from = workout.Start
+		}
+		if workout.Start.After(to) {
+			to = workout.Start
+		}
+	}
+
+	var existing []models.WorkoutRecord
+	err := s.db.Select("import_key", "start_time").
+		Where("user_id = ? AND start_time BETWEEN ? AND ?", userID, from.Add(-importDuplicateWindow), to.Add(importDuplicateWindow)).
+		Find(&existing).Error
+	if err != nil {
+		logger.Error.Printf("查询已有训练记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return detectImportDuplicates(workouts, existing), nil
+}
+
+// detectImportDuplicates 按去重键或开始时间判断重复的训练
+func detectImportDuplicates(workouts []*importWorkout, existing []models.WorkoutRecord) map[string]bool {
+	keys := make(map[string]bool, len(existing))
+	for _, record := range existing {
+		if record.ImportKey != "" {
+			keys[record.ImportKey] = true
+		}
+	}
+
+	duplicates := make(map[string]bool)
+	for _, workout := range workouts {
+		if keys[workout.Key] {
+			duplicates[workout.Key] = true
+			continue
+		}
+		for _, record := range existing {
+			diff := workout.Start.Sub(record.StartTime)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= importDuplicateWindow {
+				duplicates[workout.Key] = true
+				break
+			}
+		}
+	}
+	return duplicates
+}
+
+// buildImportedWorkout 将解析出的训练映射为已完成的训练计划和训练记录
+func buildImportedWorkout(userID string, workout *importWorkout) (models.TrainingPlan, models.WorkoutRecord) {
+	now := time.Now()
+	start := workout.Start
+	end := workout.End
+	if end.IsZero() {
+		end = start.Add(time.Duration(workout.Duration) * time.Second)
+	}
+	minutes := int(end.Sub(start).Minutes())
+
+	plan := models.TrainingPlan{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        workout.Name,
+		Description: "从其他应用导入",
+		Date:        start,
+		Duration:    minutes,
+		Status:      models.WorkoutStatusCompleted,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	record := models.WorkoutRecord{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		PlanID:         plan.ID,
+		StartTime:      start,
+		EndTime:        end,
+		Duration:       minutes,
+		Status:         models.WorkoutStatusCompleted,
+		LastActivityAt: end,
+		ImportKey:      workout.Key,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	for i, source := range workout.Exercises {
+		exercise := models.TrainingExercise{
+			ID:          uuid.New().String(),
+			PlanID:      plan.ID,
+			Name:        source.Name,
+			Description: source.Notes,
+			Order:       i + 1,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		for j, set := range source.Sets {
+			exercise.Sets = append(exercise.Sets, models.ExerciseSet{
+				ID:          uuid.New().String(),
+				ExerciseID:  exercise.ID,
+				Reps:        set.Reps,
+				Weight:      set.Weight,
+				Duration:    set.Duration,
+				Distance:    set.Distance,
+				Completed:   true,
+				Order:       j + 1,
+				CompletedAt: &start,
+				RecordID:    record.ID,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+		}
+		plan.Exercises = append(plan.Exercises, exercise)
+	}
+
+	return plan, record
+}
+
+// parseImportCSV 解析 CSV：识别表头、统一单位，并按训练（开始时间 + 训练名称）分组
+func parseImportCSV(r io.Reader, unit string, loc *time.Location) (*importParseResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV 文件为空")
+		}
+		return nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, weightUnit, distanceUnit := mapImportHeader(header, unit)
+	if _, ok := columns[importColDate]; !ok {
+		return nil, errors.New("无法识别的 CSV 格式：缺少日期列")
+	}
+	if _, ok := columns[importColExercise]; !ok {
+		return nil, errors.New("无法识别的 CSV 格式：缺少动作名称列")
+	}
+
+	result := &importParseResult{WeightUnit: weightUnit}
+	workouts := make(map[string]*importWorkout)
+	skip := func(line int, reason string) {
+		result.Skipped = append(result.Skipped, models.ImportSkippedRow{Line: line, Reason: reason})
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		result.TotalRows++
+		if result.TotalRows > maxImportRows {
+			return nil, fmt.Errorf("单次最多导入 %d 行", maxImportRows)
+		}
+		if err != nil {
+			skip(line, "CSV 格式错误")
+			continue
+		}
+
+		field := func(col string) string {
+			if i, ok := columns[col]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		start, err := parseImportTime(field(importColDate), loc)
+		if err != nil {
+			skip(line, "日期无法解析")
+			continue
+		}
+		name := field(importColExercise)
+		if name == "" {
+			skip(line, "缺少动作名称")
+			continue
+		}
+
+		set, err := parseImportSet(field, weightUnit, distanceUnit)
+		if err != nil {
+			skip(line, err.Error())
+			continue
+		}
+		set.Line = line
+
+		workoutName := field(importColWorkout)
+		if workoutName == "" {
+			workoutName = defaultImportWorkoutName
+		}
+		key := importKey(start, workoutName)
+		workout := workouts[key]
+		if workout == nil {
+			workout = &importWorkout{Key: key, Name: workoutName, Start: start, byName: make(map[string]*importExercise)}
+			if end, err := parseImportTime(field(importColEndTime), loc); err == nil && end.After(start) {
+				workout.End = end
+			}
+			workout.Duration, _ = parseImportDuration(field(importColWorkoutDuration))
+			workouts[key] = workout
+			result.Workouts = append(result.Workouts, workout)
+		}
+
+		exercise := workout.byName[name]
+		if exercise == nil {
+			exercise = &importExercise{Name: name}
+			workout.byName[name] = exercise
+			workout.Exercises = append(workout.Exercises, exercise)
+		}
+		if notes := field(importColNotes); notes != "" && exercise.Notes == "" {
+			exercise.Notes = notes
+		}
+		exercise.Sets = append(exercise.Sets, set)
+	}
+
+	for _, workout := range result.Workouts {
+		for _, exercise := range workout.Exercises {
+			sort.SliceStable(exercise.Sets, func(i, j int) bool { return exercise.Sets[i].Order < exercise.Sets[j].Order })
+		}
+	}
+	sort.SliceStable(result.Workouts, func(i, j int) bool { return result.Workouts[i].Start.Before(result.Workouts[j].Start) })
+
+	return result, nil
+}
+
+// mapImportHeader 识别各列的含义，并从表头推断重量和距离单位
+func mapImportHeader(header []string, unit string) (map[string]int, string, string) {
+	lookup := make(map[string]string)
+	for col, aliases := range importHeaderAliases {
+		for _, alias := range aliases {
+			lookup[alias] = col
+		}
+	}
+
+	weightUnit := models.WeightUnitKg
+	if strings.EqualFold(unit, models.WeightUnitLb) || strings.EqualFold(unit, "lbs") {
+		weightUnit = models.WeightUnitLb
+	}
+	distanceUnit := "km"
+
+	columns := make(map[string]int)
+	for i, raw := range header {
+		name := normalizeExerciseName(raw)
+		col, ok := lookup[name]
+		if !ok {
+			continue
+		}
+		if _, exists := columns[col]; exists {
+			continue
+		}
+		columns[col] = i
+
+		switch col {
+		case importColWeight:
+			if strings.Contains(name, "lb") {
+				weightUnit = models.WeightUnitLb
+			} else if strings.Contains(name, "kg") {
+				weightUnit = models.WeightUnitKg
+			}
+		case importColDistance:
+			if strings.Contains(name, "mi") {
+				distanceUnit = "mi"
+			}
+		}
+	}
+	return columns, weightUnit, distanceUnit
+}
+
+// parseImportSet 解析一行中的组数据，重量统一为 kg，距离统一为公里，时长统一为秒
+func parseImportSet(field func(string) string, weightUnit, distanceUnit string) (importSet, error) {
+	var set importSet
+
+	weight, err := parseImportNumber(field(importColWeight))
+	if err != nil {
+		return set, errors.New("重量无法解析")
+	}
+	reps, err := parseImportNumber(field(importColReps))
+	if err != nil {
+		return set, errors.New("次数无法解析")
+	}
+	distance, err := parseImportNumber(field(importColDistance))
+	if err != nil {
+		return set, errors.New("距离无法解析")
+	}
+	duration, err := parseImportDuration(field(importColDuration))
+	if err != nil {
+		return set, errors.New("时长无法解析")
+	}
+	order, _ := parseImportNumber(field(importColSetOrder))
+
+	if weight < 0 || reps < 0 || distance < 0 {
+		return set, errors.New("数值不能为负数")
+	}
+	if weight == 0 && reps == 0 && distance == 0 && duration == 0 {
+		return set, errors.New("没有有效的训练数据")
+	}
+
+	if weightUnit == models.WeightUnitLb {
+		weight *= lbToKg
+	}
+	switch strings.ToLower(field(importColDistanceUnit)) {
+	case "mi", "mile", "miles":
+		distance *= mileToKm
+	case "m", "meter", "meters", "米":
+		distance /= 1000
+	case "km", "kilometers", "公里":
+	default:
+		if distanceUnit == "mi" {
+			distance *= mileToKm
+		}
+	}
+
+	set.Weight = math.Round(weight*100) / 100
+	set.Reps = int(reps)
+	set.Distance = math.Round(distance*1000) / 1000
+	set.Duration = duration
+	set.Order = int(order)
+	return set, nil
+}
+
+// parseImportTime 按常见格式解析日期时间，未带时区的时间按用户时区解释
+func parseImportTime(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("日期为空")
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析日期: %s", value)
+}
+
+// parseImportNumber 解析数字，空值为 0
+// 兼容千位分隔符（"1,000"、"1.000.000"、"1 000"）和逗号作为小数点（"62,5"、"1.000,5"）
+func parseImportNumber(value string) (float64, error) {
+	value = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	comma, dot := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+	switch {
+	case comma >= 0 && dot >= 0:
+		// 两种符号都有时，靠后的是小数点
+		if comma > dot {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case comma >= 0:
+		if importThousandsPatterns[','].MatchString(value) {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	case strings.Count(value, ".") > 1 && importThousandsPatterns['.'].MatchString(value):
+		value = strings.ReplaceAll(value, ".", "")
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseImportDuration 解析时长（秒）：纯数字为秒，也支持 "1:05:00"、"05:30" 和 "1h 5m" 格式
+func parseImportDuration(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return int(seconds), nil
+	}
+
+	if strings.Contains(value, ":") {
+		total := 0
+		for _, part := range strings.Split(value, ":") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("无法解析时长: %s", value)
+			}
+			total = total*60 + n
+		}
+		return total, nil
+	}
+
+	matches := importDurationPattern.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("无法解析时长: %s", value)
+	}
+	total := 0.0
+	for _, m := range matches {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2][0] {
+		case 'h':
+			total += n * 3600
+		case 'm':
+			total += n * 60
+		default:
+			total += n
+		}
+	}
+	return int(total), nil
+}
+
+// importKey 导入训练的去重键：开始时间 + 训练名称
+func importKey(start time.Time, name string) string {
+	sum := sha1.Sum([]byte(start.UTC().Format(time.RFC3339) + "|" + normalizeExerciseName(name)))
+	return hex.EncodeToString(sum[:])
+}
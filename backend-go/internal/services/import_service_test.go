@@ -0,0 +1,156 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportCSVStrong(t *testing.T) {
+	data := `Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes
+2024-03-04 18:00:00,Push,1h 5m,Bench Press,2,225,5,0,0,
+2024-03-04 18:00:00,Push,1h 5m,Bench Press,1,135,10,0,0,warmup
+2024-03-04 18:00:00,Push,1h 5m,Treadmill,1,0,0,1,600,
+2024-03-04 18:00:00,Push,1h 5m,Bench Press,3,0,0,0,0,
+not a date,Push,1h 5m,Bench Press,4,225,5,0,0,
+2024-03-06 18:00:00,Pull,45m,Barbell Row,1,abc,5,0,0,
+2024-03-06 18:00:00,Pull,45m,,1,135,5,0,0,
+2024-03-06 18:00:00,Pull,45m,Barbell Row,2,185,8,0,0,
+`
+	result, err := parseImportCSV(strings.NewReader(data), "lb", time.UTC)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.WeightUnitLb, result.WeightUnit)
+	assert.Equal(t, 8, result.TotalRows)
+	assert.Equal(t, []models.ImportSkippedRow{
+		{Line: 5, Reason: "没有有效的训练数据"},
+		{Line: 6, Reason: "日期无法解析"},
+		{Line: 7, Reason: "重量无法解析"},
+		{Line: 8, Reason: "缺少动作名称"},
+	}, result.Skipped)
+
+	require.Len(t, result.Workouts, 2)
+	push := result.Workouts[0]
+	assert.Equal(t, "Push", push.Name)
+	assert.Equal(t, 3900, push.Duration)
+	require.Len(t, push.Exercises, 2)
+
+	bench := push.Exercises[0]
+	assert.Equal(t, "warmup", bench.Notes)
+	require.Len(t, bench.Sets, 2)
+	// 按组序排列，磅换算为千克
+	assert.Equal(t, 61.23, bench.Sets[0].Weight)
+	assert.Equal(t, 102.06, bench.Sets[1].Weight)
+	assert.Equal(t, 5, bench.Sets[1].Reps)
+
+	run := push.Exercises[1].Sets[0]
+	assert.Equal(t, 1.0, run.Distance)
+	assert.Equal(t, 600, run.Duration)
+}
+
+func TestParseImportCSVHeaderUnits(t *testing.T) {
+	data := "\ufeffstart_time,title,exercise_title,set_index,weight_kg,reps,distance_km,duration_seconds\n" +
+		"2024-03-04T18:00:00+08:00,腿日,深蹲,0,100,5,,\n"
+
+	// 表头标明 kg 时忽略请求中的单位
+	result, err := parseImportCSV(strings.NewReader(data), "lb", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, models.WeightUnitKg, result.WeightUnit)
+	assert.Equal(t, 100.0, result.Workouts[0].Exercises[0].Sets[0].Weight)
+
+	_, err = parseImportCSV(strings.NewReader("foo,bar\n1,2\n"), "", time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseImportDuration(t *testing.T) {
+	tests := map[string]int{
+		"":        0,
+		"90":      90,
+		"05:30":   330,
+		"1:05:00": 3900,
+		"1h 5m":   3900,
+		"45min":   2700,
+		"30s":     30,
+	}
+	for input, expected := range tests {
+		got, err := parseImportDuration(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	_, err := parseImportDuration("abc")
+	assert.Error(t, err)
+}
+
+func TestParseImportNumber(t *testing.T) {
+	tests := map[string]float64{
+		"":           0,
+		"62.5":       62.5,
+		"62,5":       62.5,
+		"1,000":      1000,
+		"12,345,678": 12345678,
+		"1,000.5":    1000.5,
+		"1.000,5":    1000.5,
+		"1.000.000":  1000000,
+		"1 000":      1000,
+	}
+	for input, expected := range tests {
+		got, err := parseImportNumber(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, got, input)
+	}
+
+	_, err := parseImportNumber("abc")
+	assert.Error(t, err)
+}
+
+func TestDetectImportDuplicates(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	workouts := []*importWorkout{
+		{Key: importKey(start, "Push"), Start: start},
+		{Key: importKey(start.AddDate(0, 0, 1), "Pull"), Start: start.AddDate(0, 0, 1)},
+		{Key: importKey(start.AddDate(0, 0, 2), "Legs"), Start: start.AddDate(0, 0, 2)},
+	}
+	existing := []models.WorkoutRecord{
+		{ImportKey: importKey(start, "push"), StartTime: start},
+		{StartTime: start.AddDate(0, 0, 1).Add(30 * time.Second)},
+	}
+
+	duplicates := detectImportDuplicates(workouts, existing)
+	assert.True(t, duplicates[workouts[0].Key])
+	assert.True(t, duplicates[workouts[1].Key])
+	assert.False(t, duplicates[workouts[2].Key])
+}
+
+func TestBuildImportedWorkout(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	workout := &importWorkout{
+		Key:      "key",
+		Name:     "Push",
+		Start:    start,
+		Duration: 3600,
+		Exercises: []*importExercise{
+			{Name: "卧推", Sets: []importSet{{Weight: 60, Reps: 10}, {Weight: 70, Reps: 8}}},
+		},
+	}
+
+	plan, record := buildImportedWorkout("user-1", workout)
+
+	assert.Equal(t, models.WorkoutStatusCompleted, plan.Status)
+	assert.Equal(t, plan.ID, record.PlanID)
+	assert.Equal(t, 60, record.Duration)
+	assert.Equal(t, start.Add(time.Hour), record.EndTime)
+	assert.Equal(t, "key", record.ImportKey)
+
+	sets := plan.Exercises[0].Sets
+	assert.Len(t, sets, 2)
+	assert.True(t, sets[1].Completed)
+	assert.Equal(t, 2, sets[1].Order)
+	assert.Equal(t, record.ID, sets[1].RecordID)
+	assert.Equal(t, plan.Exercises[0].ID, sets[1].ExerciseID)
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gymates/internal/config"
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+)
+
+const (
+	// defaultLLMTimeout 未配置超时时每个提供商单次调用的超时
+	defaultLLMTimeout = 20 * time.Second
+	// llmRetryBackoff 重试前的等待时间，每次重试翻倍
+	llmRetryBackoff = 300 * time.Millisecond
+	// llmFailureThreshold 连续失败达到该次数后暂停使用该提供商
+	llmFailureThreshold = 3
+	// llmCooldown 暂停使用的时长，之后重新尝试
+	llmCooldown = 30 * time.Second
+)
+
+// ErrNoLLMProvider 没有配置或没有可用的提供商
+var ErrNoLLMProvider = errors.New("没有可用的AI服务")
+
+// errLLMStreamIdle 流式调用超过超时时间没有收到新的内容片段
+var errLLMStreamIdle = errors.New("AI服务响应超时")
+
+// LLMProviderOptions 提供商的调用参数
+type LLMProviderOptions struct {
+	Timeout       time.Duration // 单次调用超时，流式调用时为等待下一个内容片段的超时；为 0 时使用 defaultLLMTimeout
+	MaxRetries    int           // 失败后的重试次数，不可重试的错误（如密钥无效）不重试
+	ContextWindow int           // 模型的上下文窗口（token），消息超出时裁剪最早的对话，为 0 时不裁剪
+}
+
+// llmContextWindows 内置提供商所用模型的上下文窗口（token）
+var llmContextWindows = map[string]int{
+	"hunyuan-lite":   256000,
+	"deepseek-chat":  65536,
+	"llama3-8b-8192": 8192,
+}
+
+// llmProviderEntry 已注册的提供商及其健康状态
+type llmProviderEntry struct {
+	provider     LLMProvider
+	options      LLMProviderOptions
+	health       models.AIProviderHealth
+	totalLatency time.Duration
+	successes    int64
+	openUntil    time.Time
+}
+
+// LLMManager 大模型提供商注册表
+// 按注册顺序调用提供商，每个提供商有独立的超时和重试次数；失败时切换到下一个提供商。
+// 连续失败 llmFailureThreshold 次的提供商暂停使用 llmCooldown，所有提供商都在暂停中时仍按顺序尝试
+type LLMManager struct {
+	mu        sync.Mutex
+	providers []*llmProviderEntry
+	backoff   time.Duration
+}
+
+// NewLLMManager 创建空的提供商注册表
+func NewLLMManager() *LLMManager {
+	return &LLMManager{backoff: llmRetryBackoff}
+}
+
+// NewLLMManagerFromConfig 按配置注册提供商
+// 顺序：自定义服务（AI_BASE_URL）、腾讯混元、DeepSeek、Groq；开启 AI_STUB 时只注册本地模拟提供商。
+// 没有配置任何提供商时，生产环境不注册提供商（调用返回 ErrNoLLMProvider），其他环境注册本地模拟提供商
+func NewLLMManagerFromConfig(cfg config.AIConfig, environment string) *LLMManager {
+	m := NewLLMManager()
+	options := LLMProviderOptions{
+		Timeout:    time.Duration(cfg.Timeout) * time.Second,
+		MaxRetries: cfg.MaxRetries,
+	}
+
+	if cfg.UseStub {
+		m.Register(NewStubProvider(nil), options)
+		logger.Info.Printf("AI服务使用本地模拟提供商")
+		return m
+	}
+
+	withWindow := func(model string) LLMProviderOptions {
+		o := options
+		o.ContextWindow = llmContextWindows[model]
+		return o
+	}
+
+	if cfg.BaseURL != "" {
+		local := options
+		local.ContextWindow = cfg.ContextWindow
+		m.Register(NewLocalProvider(chatCompletionsURL(cfg.BaseURL), cfg.APIKey, cfg.Model), local)
+	}
+	// 混元的 OpenAI 兼容接口使用控制台创建的 API Key 认证，不能使用腾讯云 SecretKey
+	if cfg.HunyuanAPIKey != "" {
+		m.Register(NewOpenAICompatibleProvider("hunyuan", "https://api.hunyuan.cloud.tencent.com/v1/chat/completions", cfg.HunyuanAPIKey, "hunyuan-lite"), withWindow("hunyuan-lite"))
+	}
+	if cfg.DeepSeekAPIKey != "" {
+		m.Register(NewOpenAICompatibleProvider("deepseek", "https://api.deepseek.com/v1/chat/completions", cfg.DeepSeekAPIKey, "deepseek-chat"), withWindow("deepseek-chat"))
+	}
+	if cfg.GroqAPIKey != "" {
+		m.Register(NewOpenAICompatibleProvider("groq", "https://api.groq.com/openai/v1/chat/completions", cfg.GroqAPIKey, "llama3-8b-8192"), withWindow("llama3-8b-8192"))
+	}
+
+	if len(m.providers) == 0 {
+		if environment == "production" {
+			logger.Error.Printf("未配置任何AI服务，AI 功能不可用")
+			return m
+		}
+		logger.Warn.Printf("未配置任何AI服务，使用本地模拟提供商")
+		m.Register(NewStubProvider(nil), options)
+	}
+	return m
+}
+
+// chatCompletionsURL 补全 chat/completions 路径，允许只配置到 /v1
+func chatCompletionsURL(baseURL string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(baseURL, "/chat/completions") {
+		return baseURL
+	}
+	return baseURL + "/chat/completions"
+}
+
+// Register 注册提供商，先注册的优先使用
+func (m *LLMManager) Register(provider LLMProvider, options LLMProviderOptions) {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultLLMTimeout
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.providers = append(m.providers, &llmProviderEntry{
+		provider: provider,
+		options:  options,
+		health:   models.AIProviderHealth{Name: provider.GetName(), Healthy: true},
+	})
+}
+
+// Call 调用大模型，ctx 取消或超时后不再切换提供商
+func (m *LLMManager) Call(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return m.call(ctx, req, nil)
+}
+
+// Stream 流式调用大模型，内容片段按到达顺序交给 onDelta
+// 提供商已经输出内容后失败时不再重试或切换提供商，避免调用方收到重复的内容
+func (m *LLMManager) Stream(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	return m.call(ctx, req, onDelta)
+}
+
+// call 按顺序尝试提供商，onDelta 为空时一次性调用
+func (m *LLMManager) call(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	var lastErr error
+	for _, entry := range m.candidates() {
+		streamed := false
+		resp, err := m.callProvider(ctx, entry, req, onDelta, &streamed)
+		if err == nil {
+			return resp, nil
+		}
+		logger.Warn.Printf("AI服务调用失败: provider=%v, error=%v", entry.provider.GetName(), err.Error())
+		lastErr = err
+		if ctx.Err() != nil || streamed {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("所有AI服务都调用失败: %w", lastErr)
+	}
+	return nil, ErrNoLLMProvider
+}
+
+// Health 所有提供商的健康状态
+func (m *LLMManager) Health() []models.AIProviderHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	health := make([]models.AIProviderHealth, 0, len(m.providers))
+	for _, entry := range m.providers {
+		h := entry.health
+		h.Healthy = !now.Before(entry.openUntil)
+		if !h.Healthy {
+			until := entry.openUntil
+			h.CooldownUntil = &until
+		}
+		health = append(health, h)
+	}
+	return health
+}
+
+// candidates 本次调用依次尝试的提供商：可用且不在暂停中的提供商；都在暂停中时返回全部可用的提供商
+func (m *LLMManager) candidates() []*llmProviderEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var healthy, available []*llmProviderEntry
+	for _, entry := range m.providers {
+		if !entry.provider.IsAvailable() {
+			continue
+		}
+		available = append(available, entry)
+		if !now.Before(entry.openUntil) {
+			healthy = append(healthy, entry)
+		}
+	}
+	if len(healthy) > 0 {
+		return healthy
+	}
+	return available
+}
+
+// callProvider 调用一个提供商，可重试的错误按指数退避重试，已经输出内容后不再重试
+func (m *LLMManager) callProvider(ctx context.Context, entry *llmProviderEntry, req LLMRequest, onDelta func(delta string) error, streamed *bool) (*LLMResponse, error) {
+	req.Messages = fitContextWindow(req.Messages, entry.options.ContextWindow-req.MaxTokens)
+
+	var err error
+	for attempt := 0; attempt <= entry.options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, m.backoff<<(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+
+		callCtx, emit, cancel := attemptContext(ctx, entry.options.Timeout, onDelta)
+		start := time.Now()
+		var resp *LLMResponse
+		resp, err = invokeProvider(callCtx, entry.provider, req, emit, streamed)
+		if err != nil && errors.Is(context.Cause(callCtx), errLLMStreamIdle) {
+			err = errLLMStreamIdle
+		}
+		cancel()
+		// 调用方取消或超时导致的失败不计入提供商的健康状态
+		if err == nil || ctx.Err() == nil {
+			m.record(entry, time.Since(start), err)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || *streamed || !retryableLLMError(err) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// attemptContext 单次调用的 ctx：一次性调用整体超时；流式调用按空闲超时，
+// 首个内容片段或相邻片段之间超过 timeout 时取消，输出较长的回复不会被整体超时截断
+func attemptContext(ctx context.Context, timeout time.Duration, onDelta func(delta string) error) (context.Context, func(delta string) error, context.CancelFunc) {
+	if onDelta == nil {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		return callCtx, nil, cancel
+	}
+
+	callCtx, cancel := context.WithCancelCause(ctx)
+	idle := time.AfterFunc(timeout, func() { cancel(errLLMStreamIdle) })
+	emit := func(delta string) error {
+		idle.Reset(timeout)
+		return onDelta(delta)
+	}
+	return callCtx, emit, func() {
+		idle.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// invokeProvider 调用提供商；onDelta 不为空时流式调用，不支持流式的提供商在调用完成后一次性交给 onDelta
+func invokeProvider(ctx context.Context, provider LLMProvider, req LLMRequest, onDelta func(delta string) error, streamed *bool) (*LLMResponse, error) {
+	if onDelta == nil {
+		return provider.Call(ctx, req)
+	}
+	emit := func(delta string) error {
+		*streamed = true
+		return onDelta(delta)
+	}
+	if streamer, ok := provider.(LLMStreamer); ok {
+		return streamer.Stream(ctx, req, emit)
+	}
+	resp, err := provider.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, emit(resp.Content)
+}
+
+// llmMessageOverhead 每条消息在角色、分隔符等格式上占用的 token 数（估算）
+const llmMessageOverhead = 4
+
+// messageTokens 估算一条消息占用的 token 数，包括工具调用的参数
+func messageTokens(message LLMMessage) int {
+	tokens := estimateTokens(message.Content) + llmMessageOverhead
+	for _, call := range message.ToolCalls {
+		tokens += estimateTokens(call.Function.Name) + estimateTokens(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// fitContextWindow 裁剪消息使估算的 token 数不超过 budget
+// 保留开头的 system 消息和当前一轮对话，从最早的对话开始丢弃，丢弃后对话仍从用户消息开始
+// （工具调用和结果随所在的一轮对话一起丢弃）；budget <= 0 时不裁剪
+func fitContextWindow(messages []LLMMessage, budget int) []LLMMessage {
+	if budget <= 0 || len(messages) == 0 {
+		return messages
+	}
+
+	system := 0
+	for system < len(messages)-1 && messages[system].Role == LLMRoleSystem {
+		system++
+	}
+	total := 0
+	for _, message := range messages {
+		total += messageTokens(message)
+	}
+
+	// 当前一轮（最后一条用户消息及之后的工具调用）不裁剪
+	first := system
+	last := len(messages) - 1
+	for last > system && messages[last].Role != LLMRoleUser {
+		last--
+	}
+	for total > budget && first < last {
+		total -= messageTokens(messages[first])
+		first++
+		for first < last && messages[first].Role != LLMRoleUser {
+			total -= messageTokens(messages[first])
+			first++
+		}
+	}
+	if first == system {
+		return messages
+	}
+
+	trimmed := make([]LLMMessage, 0, system+len(messages)-first)
+	trimmed = append(trimmed, messages[:system]...)
+	return append(trimmed, messages[first:]...)
+}
+
+// record 记录一次调用结果
+func (m *LLMManager) record(entry *llmProviderEntry, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	h := &entry.health
+	h.Calls++
+	if err == nil {
+		h.ConsecutiveFailures = 0
+		h.LastSuccessAt = &now
+		entry.successes++
+		entry.totalLatency += latency
+		h.AvgLatencyMs = (entry.totalLatency / time.Duration(entry.successes)).Milliseconds()
+		entry.openUntil = time.Time{}
+		return
+	}
+
+	h.Failures++
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastFailureAt = &now
+	if h.ConsecutiveFailures >= llmFailureThreshold {
+		entry.openUntil = now.Add(llmCooldown)
+	}
+}
+
+// retryableLLMError 网络错误、超时、限流和服务端错误可以重试
+func retryableLLMError(err error) bool {
+	var statusErr *LLMStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// sleepContext 等待 d，ctx 结束时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gymates/internal/config"
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLLMServer 模拟兼容 OpenAI 接口的服务，handler 返回状态码和回复内容
+func mockLLMServer(t *testing.T, handler func(call int, r *http.Request) (int, string)) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, content := handler(int(atomic.AddInt32(&calls, 1)), r)
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			w.Write([]byte(content))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   "mock-model",
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// fakeLLMProvider 按顺序返回预设结果的提供商
+type fakeLLMProvider struct {
+	name   string
+	errs   []error
+	calls  int
+	delay  time.Duration
+	result string
+}
+
+func (p *fakeLLMProvider) GetName() string   { return p.name }
+func (p *fakeLLMProvider) IsAvailable() bool { return true }
+
+func (p *fakeLLMProvider) Call(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	p.calls++
+	if p.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.delay):
+		}
+	}
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &LLMResponse{Content: p.result, Provider: p.name}, nil
+}
+
+func newTestLLMManager() *LLMManager {
+	logger.Init("error")
+	m := NewLLMManager()
+	m.backoff = 0
+	return m
+}
+
+func TestOpenAICompatibleProvider(t *testing.T) {
+	server, _ := mockLLMServer(t, func(_ int, r *http.Request) (int, string) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var body struct {
+			Model     string       `json:"model"`
+			Messages  []LLMMessage `json:"messages"`
+			MaxTokens int          `json:"max_tokens"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "test-model", body.Model)
+		assert.Equal(t, 100, body.MaxTokens)
+		return http.StatusOK, "回复：" + body.Messages[0].Content
+	})
+
+	provider := NewOpenAICompatibleProvider("mock", server.URL, "test-key", "test-model")
+	resp, err := provider.Call(context.Background(), LLMRequest{
+		Messages:  []LLMMessage{{Role: LLMRoleUser, Content: "你好"}},
+		MaxTokens: 100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "回复：你好", resp.Content)
+	assert.Equal(t, "mock", resp.Provider)
+	assert.Equal(t, "mock-model", resp.Model)
+
+	assert.False(t, NewOpenAICompatibleProvider("mock", server.URL, "", "m").IsAvailable())
+	assert.True(t, NewLocalProvider(server.URL, "", "m").IsAvailable())
+}
+
+func TestOpenAICompatibleProviderStatusError(t *testing.T) {
+	server, _ := mockLLMServer(t, func(int, *http.Request) (int, string) {
+		return http.StatusTooManyRequests, "rate limited"
+	})
+
+	_, err := NewLocalProvider(server.URL, "", "m").Call(context.Background(), LLMRequest{})
+	var statusErr *LLMStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.True(t, statusErr.Retryable())
+	assert.False(t, (&LLMStatusError{StatusCode: http.StatusUnauthorized}).Retryable())
+}
+
+func TestLLMManagerRetriesThenSucceeds(t *testing.T) {
+	server, calls := mockLLMServer(t, func(call int, _ *http.Request) (int, string) {
+		if call == 1 {
+			return http.StatusBadGateway, "upstream error"
+		}
+		return http.StatusOK, "ok"
+	})
+
+	m := newTestLLMManager()
+	m.Register(NewLocalProvider(server.URL, "", "m"), LLMProviderOptions{MaxRetries: 1})
+	resp, err := m.Call(context.Background(), LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Content)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	health := m.Health()
+	require.Len(t, health, 1)
+	assert.Equal(t, int64(2), health[0].Calls)
+	assert.Equal(t, int64(1), health[0].Failures)
+	assert.Equal(t, 0, health[0].ConsecutiveFailures)
+	assert.True(t, health[0].Healthy)
+}
+
+func TestLLMManagerFailover(t *testing.T) {
+	// 密钥无效不重试，直接切换到下一个提供商
+	primary := &fakeLLMProvider{name: "primary", errs: []error{&LLMStatusError{StatusCode: http.StatusUnauthorized}}}
+	backup := &fakeLLMProvider{name: "backup", result: "from backup"}
+
+	m := newTestLLMManager()
+	m.Register(primary, LLMProviderOptions{MaxRetries: 2})
+	m.Register(backup, LLMProviderOptions{})
+
+	resp, err := m.Call(context.Background(), LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "from backup", resp.Content)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 1, backup.calls)
+}
+
+func TestLLMManagerTimeout(t *testing.T) {
+	slow := &fakeLLMProvider{name: "slow", delay: time.Second}
+	fast := &fakeLLMProvider{name: "fast", result: "fast"}
+
+	m := newTestLLMManager()
+	m.Register(slow, LLMProviderOptions{Timeout: 20 * time.Millisecond})
+	m.Register(fast, LLMProviderOptions{})
+
+	start := time.Now()
+	resp, err := m.Call(context.Background(), LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "fast", resp.Content)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Contains(t, m.Health()[0].LastError, context.DeadlineExceeded.Error())
+}
+
+func TestLLMManagerCooldown(t *testing.T) {
+	failing := &fakeLLMProvider{name: "failing", errs: []error{errors.New("e1"), errors.New("e2"), errors.New("e3")}}
+	backup := &fakeLLMProvider{name: "backup", result: "ok"}
+
+	m := newTestLLMManager()
+	m.Register(failing, LLMProviderOptions{MaxRetries: llmFailureThreshold - 1})
+	m.Register(backup, LLMProviderOptions{})
+
+	_, err := m.Call(context.Background(), LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, llmFailureThreshold, failing.calls)
+
+	health := m.Health()
+	assert.False(t, health[0].Healthy)
+	require.NotNil(t, health[0].CooldownUntil)
+
+	// 暂停期间不再调用失败的提供商
+	_, err = m.Call(context.Background(), LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, llmFailureThreshold, failing.calls)
+	assert.Equal(t, 2, backup.calls)
+}
+
+func TestLLMManagerCanceledContext(t *testing.T) {
+	first := &fakeLLMProvider{name: "first", delay: time.Second}
+	second := &fakeLLMProvider{name: "second", result: "ok"}
+
+	m := newTestLLMManager()
+	m.Register(first, LLMProviderOptions{MaxRetries: 3})
+	m.Register(second, LLMProviderOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := m.Call(ctx, LLMRequest{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// 请求已超时，不重试也不切换提供商，也不计入提供商的失败次数
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 0, second.calls)
+	health := m.Health()
+	assert.Equal(t, int64(0), health[0].Failures)
+	assert.Empty(t, health[0].LastError)
+}
+
+func TestLLMManagerNoProvider(t *testing.T) {
+	_, err := newTestLLMManager().Call(context.Background(), LLMRequest{})
+	assert.ErrorIs(t, err, ErrNoLLMProvider)
+}
+
+func TestNewLLMManagerFromConfig(t *testing.T) {
+	logger.Init("error")
+	names := func(m *LLMManager) []string {
+		var result []string
+		for _, h := range m.Health() {
+			result = append(result, h.Name)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"stub"}, names(NewLLMManagerFromConfig(config.AIConfig{}, "development")))
+	assert.Equal(t, []string{"stub"}, names(NewLLMManagerFromConfig(config.AIConfig{DeepSeekAPIKey: "k", UseStub: true}, "production")))
+	assert.Equal(t, []string{"local", "deepseek", "groq"}, names(NewLLMManagerFromConfig(config.AIConfig{
+		BaseURL:        "http://localhost:11434/v1",
+		DeepSeekAPIKey: "k",
+		GroqAPIKey:     "k",
+	}, "production")))
+	// 生产环境没有配置提供商时不使用模拟提供商；混元需要专用的 API Key
+	assert.Empty(t, names(NewLLMManagerFromConfig(config.AIConfig{}, "production")))
+	assert.Empty(t, names(NewLLMManagerFromConfig(config.AIConfig{TencentSecretID: "id", TencentSecretKey: "key"}, "production")))
+	assert.Equal(t, []string{"hunyuan"}, names(NewLLMManagerFromConfig(config.AIConfig{HunyuanAPIKey: "k"}, "production")))
+
+	assert.Equal(t, "http://localhost/v1/chat/completions", chatCompletionsURL("http://localhost/v1/"))
+	assert.Equal(t, "http://localhost/v1/chat/completions", chatCompletionsURL("http://localhost/v1/chat/completions"))
+}
+
+func TestGenerateTrainingPlanWithMockServer(t *testing.T) {
+	logger.Init("error")
+	server, _ := mockLLMServer(t, func(int, *http.Request) (int, string) {
+		return http.StatusOK, stubWorkoutPlanResponse
+	})
+
+	service := NewAIService(&config.Config{AI: config.AIConfig{BaseURL: server.URL}}, nil, nil)
+	plan, err := service.GenerateTrainingPlan(context.Background(), &models.GenerateTrainingPlanRequest{Goal: "减脂", Duration: 30, Difficulty: "中级"})
+	require.NoError(t, err)
+	assert.Equal(t, "减脂塑形 - 30天训练计划", plan.Name)
+	require.Len(t, plan.Exercises, 2)
+	assert.Equal(t, "深蹲", plan.Exercises[0].Name)
+	assert.Equal(t, models.AIPlanSourceModel, plan.AISource)
+	assert.Equal(t, "local", plan.AIProvider)
+	assert.Equal(t, "mock-model", plan.AIModel)
+	assert.Equal(t, 1, plan.AIAttempts)
+}
+
+func TestGenerateTrainingPlanRepairsInvalidOutput(t *testing.T) {
+	logger.Init("error")
+	var repairPrompt string
+	server, calls := mockLLMServer(t, func(call int, r *http.Request) (int, string) {
+		if call == 1 {
+			return http.StatusOK, "计划如下：" + strings.Replace(stubWorkoutPlanResponse, `"category": "腿"`, `"category": "全身"`, 1)
+		}
+		var body struct {
+			Messages []LLMMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		repairPrompt = body.Messages[len(body.Messages)-1].Content
+		return http.StatusOK, stubWorkoutPlanResponse
+	})
+
+	service := NewAIService(&config.Config{AI: config.AIConfig{BaseURL: server.URL}}, nil, nil)
+	plan, err := service.GenerateTrainingPlan(context.Background(), &models.GenerateTrainingPlanRequest{Goal: "减脂"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	assert.Contains(t, repairPrompt, "全身")
+	assert.Equal(t, models.AIPlanSourceRepaired, plan.AISource)
+	assert.Equal(t, 2, plan.AIAttempts)
+	assert.Equal(t, "腿", plan.Exercises[0].Category)
+}
+
+func TestGenerateTrainingPlanFallsBackToTemplate(t *testing.T) {
+	logger.Init("error")
+	server, calls := mockLLMServer(t, func(int, *http.Request) (int, string) {
+		return http.StatusOK, `{"title": "", "sessions": []}`
+	})
+
+	service := NewAIService(&config.Config{AI: config.AIConfig{BaseURL: server.URL}}, nil, nil)
+	plan, err := service.GenerateTrainingPlan(context.Background(), &models.GenerateTrainingPlanRequest{Goal: "减脂"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2*aiPlanMaxAttempts), atomic.LoadInt32(calls))
+	assert.Equal(t, models.AIPlanSourceTemplate, plan.AISource)
+	assert.Equal(t, 2*aiPlanMaxAttempts, plan.AIAttempts)
+	assert.NotEmpty(t, plan.AIValidationErrors)
+	assert.Equal(t, "跑步", plan.Exercises[len(plan.Exercises)-1].Name)
+
+	// 提供商不可用时直接使用模板
+	failing, _ := mockLLMServer(t, func(int, *http.Request) (int, string) {
+		return http.StatusUnauthorized, "invalid key"
+	})
+	service = NewAIService(&config.Config{AI: config.AIConfig{BaseURL: failing.URL}}, nil, nil)
+	plan, err = service.GenerateTrainingPlan(context.Background(), &models.GenerateTrainingPlanRequest{Goal: "增肌"})
+	require.NoError(t, err)
+	assert.Equal(t, models.AIPlanSourceTemplate, plan.AISource)
+	assert.Len(t, plan.Exercises, len(aiTemplateExercises))
+}
+
+// sseLLMServer 以 SSE 返回内容片段的模拟服务，broken 为 true 时输出第一段后返回无法解析的数据
+func sseLLMServer(t *testing.T, deltas []string, broken bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, true, body["stream"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, delta := range deltas {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"model":   "mock-model",
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": delta}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			if broken && i == 0 {
+				fmt.Fprint(w, "data: {broken\n\n")
+				return
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStreamFromOpenAICompatibleProvider(t *testing.T) {
+	server := sseLLMServer(t, []string{"深蹲", "时膝盖", "对准脚尖"}, false)
+	provider := NewLocalProvider(server.URL, "", "")
+
+	var deltas []string
+	resp, err := provider.Stream(context.Background(), LLMRequest{Messages: []LLMMessage{{Role: LLMRoleUser, Content: "深蹲要点"}}}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"深蹲", "时膝盖", "对准脚尖"}, deltas)
+	assert.Equal(t, "深蹲时膝盖对准脚尖", resp.Content)
+	assert.Equal(t, "mock-model", resp.Model)
+}
+
+func TestLLMManagerStreamFailover(t *testing.T) {
+	collect := func(m *LLMManager) (string, *LLMResponse, error) {
+		var content strings.Builder
+		resp, err := m.Stream(context.Background(), LLMRequest{}, func(delta string) error {
+			content.WriteString(delta)
+			return nil
+		})
+		return content.String(), resp, err
+	}
+
+	// 没有输出内容前失败时切换提供商，不支持流式的提供商一次性输出
+	m := newTestLLMManager()
+	failing := &fakeLLMProvider{name: "failing", errs: []error{&LLMStatusError{StatusCode: http.StatusUnauthorized}}}
+	backup := &fakeLLMProvider{name: "backup", result: "换一个提供商"}
+	m.Register(failing, LLMProviderOptions{})
+	m.Register(backup, LLMProviderOptions{})
+	content, resp, err := collect(m)
+	require.NoError(t, err)
+	assert.Equal(t, "换一个提供商", content)
+	assert.Equal(t, "backup", resp.Provider)
+
+	// 已经输出内容后失败时不重试也不切换，避免重复输出
+	m = newTestLLMManager()
+	backup = &fakeLLMProvider{name: "backup", result: "不应出现"}
+	m.Register(NewLocalProvider(sseLLMServer(t, []string{"第一段", "第二段"}, true).URL, "", ""), LLMProviderOptions{MaxRetries: 2})
+	m.Register(backup, LLMProviderOptions{})
+	content, _, err = collect(m)
+	require.Error(t, err)
+	assert.Equal(t, "第一段", content)
+	assert.Equal(t, 0, backup.calls)
+	assert.Equal(t, int64(1), m.Health()[0].Calls)
+}
+
+// fakeLLMStreamer 按固定间隔输出内容片段的流式提供商，firstDelay 为输出第一个片段前的等待
+type fakeLLMStreamer struct {
+	fakeLLMProvider
+	firstDelay time.Duration
+	interval   time.Duration
+	deltas     []string
+}
+
+func (p *fakeLLMStreamer) Stream(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	p.calls++
+	var content strings.Builder
+	for i, delta := range p.deltas {
+		wait := p.interval
+		if i == 0 {
+			wait = p.firstDelay
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+		content.WriteString(delta)
+	}
+	return &LLMResponse{Content: content.String(), Provider: p.name}, nil
+}
+
+func TestLLMManagerStreamIdleTimeout(t *testing.T) {
+	// 总时长超过超时，但片段之间的间隔都在超时内，不会被截断
+	m := newTestLLMManager()
+	long := &fakeLLMStreamer{fakeLLMProvider: fakeLLMProvider{name: "long"}, firstDelay: 20 * time.Millisecond, interval: 20 * time.Millisecond, deltas: []string{"一", "二", "三", "四", "五"}}
+	m.Register(long, LLMProviderOptions{Timeout: 60 * time.Millisecond})
+	resp, err := m.Stream(context.Background(), LLMRequest{}, func(string) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, "一二三四五", resp.Content)
+
+	// 首个片段超时时按超时失败，切换到下一个提供商
+	m = newTestLLMManager()
+	stalled := &fakeLLMStreamer{fakeLLMProvider: fakeLLMProvider{name: "stalled"}, firstDelay: time.Second, deltas: []string{"不应出现"}}
+	backup := &fakeLLMProvider{name: "backup", result: "换一个提供商"}
+	m.Register(stalled, LLMProviderOptions{Timeout: 20 * time.Millisecond})
+	m.Register(backup, LLMProviderOptions{})
+	resp, err = m.Stream(context.Background(), LLMRequest{}, func(string) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, "backup", resp.Provider)
+	assert.Equal(t, errLLMStreamIdle.Error(), m.Health()[0].LastError)
+}
+
+func TestFitContextWindow(t *testing.T) {
+	turn := strings.Repeat("练", 96) // 每条消息约 100 token
+	messages := []LLMMessage{
+		{Role: LLMRoleSystem, Content: turn},
+		{Role: LLMRoleUser, Content: turn},
+		{Role: LLMRoleAssistant, Content: turn},
+		{Role: LLMRoleUser, Content: turn},
+		{Role: LLMRoleAssistant, Content: turn},
+		{Role: LLMRoleUser, Content: "最新的问题"},
+	}
+
+	assert.Equal(t, messages, fitContextWindow(messages, 0))
+	assert.Equal(t, messages, fitContextWindow(messages, 10000))
+
+	// 保留 system 消息和最近的对话，裁剪后仍从用户消息开始
+	trimmed := fitContextWindow(messages, 350)
+	require.Len(t, trimmed, 4)
+	assert.Equal(t, LLMRoleSystem, trimmed[0].Role)
+	assert.Equal(t, LLMRoleUser, trimmed[1].Role)
+	assert.Equal(t, "最新的问题", trimmed[3].Content)
+
+	// 预算不足时至少保留 system 消息和最后一条消息
+	trimmed = fitContextWindow(messages, 50)
+	require.Len(t, trimmed, 2)
+	assert.Equal(t, LLMRoleSystem, trimmed[0].Role)
+	assert.Equal(t, "最新的问题", trimmed[1].Content)
+
+	// 按每个提供商的上下文窗口裁剪
+	m := newTestLLMManager()
+	small := &recordingLLMProvider{}
+	m.Register(small, LLMProviderOptions{ContextWindow: 450, MaxRetries: 0})
+	_, err := m.Call(context.Background(), LLMRequest{Messages: messages, MaxTokens: 100})
+	require.NoError(t, err)
+	assert.Len(t, small.messages, 4)
+}
+
+// recordingLLMProvider 记录收到的消息
+type recordingLLMProvider struct {
+	messages []LLMMessage
+}
+
+func (p *recordingLLMProvider) GetName() string   { return "recording" }
+func (p *recordingLLMProvider) IsAvailable() bool { return true }
+
+func (p *recordingLLMProvider) Call(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	p.messages = req.Messages
+	return &LLMResponse{Content: "ok", Provider: "recording"}, nil
+}
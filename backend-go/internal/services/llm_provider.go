@@ -0,0 +1,408 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LLM 消息角色
+const (
+	LLMRoleSystem    = "system"
+	LLMRoleUser      = "user"
+	LLMRoleAssistant = "assistant"
+	LLMRoleTool      = "tool"
+)
+
+// LLMProvider 大模型提供商
+type LLMProvider interface {
+	Call(ctx context.Context, req LLMRequest) (*LLMResponse, error)
+	GetName() string
+	IsAvailable() bool
+}
+
+// LLMStreamer 支持流式返回的提供商，onDelta 按到达顺序接收内容片段，返回错误时停止接收
+// 不支持流式的提供商由 LLMManager 在调用完成后一次性交给 onDelta
+type LLMStreamer interface {
+	Stream(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error)
+}
+
+// LLMMessage 对话消息
+type LLMMessage struct {
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	ToolCalls  []LLMToolCall `json:"tool_calls,omitempty"`   // 模型请求调用的工具
+	ToolCallID string        `json:"tool_call_id,omitempty"` // 工具结果对应的调用
+}
+
+// LLMTool 提供给模型调用的函数
+type LLMTool struct {
+	Type     string      `json:"type"` // function
+	Function LLMFunction `json:"function"`
+}
+
+// LLMFunction 函数说明，Parameters 为 JSON Schema
+type LLMFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// LLMToolCall 模型请求的一次工具调用
+type LLMToolCall struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Function LLMFunctionCall `json:"function"`
+}
+
+// LLMFunctionCall 调用的函数名和 JSON 格式的参数
+type LLMFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// LLMRequest 一次大模型调用
+type LLMRequest struct {
+	Messages    []LLMMessage
+	Tools       []LLMTool // 可以调用的工具，不支持工具的提供商忽略
+	Temperature float64
+	MaxTokens   int
+}
+
+// LLMResponse 大模型返回的内容
+type LLMResponse struct {
+	Content   string
+	ToolCalls []LLMToolCall // 模型请求调用的工具，为空时 Content 即为最终回复
+	Provider  string
+	Model     string
+	Latency   time.Duration
+	Timestamp time.Time
+}
+
+// LLMStatusError 提供商返回的非 200 响应
+type LLMStatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *LLMStatusError) Error() string {
+	return fmt.Sprintf("%s 返回错误 %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable 限流和服务端错误可以重试，其他 4xx（密钥无效、请求格式错误）重试也不会成功
+func (e *LLMStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// maxLLMErrorBody 错误信息中保留的响应内容长度
+const maxLLMErrorBody = 512
+
+// OpenAICompatibleProvider 兼容 OpenAI Chat Completions 接口的提供商
+// DeepSeek、Groq、混元等都提供这种接口；也可以指向本地的模拟服务或 Ollama 等本地模型，apiKey 为空时不发送认证头
+type OpenAICompatibleProvider struct {
+	name    string
+	url     string
+	apiKey  string
+	model   string
+	client  *http.Client
+	keyless bool // 是否允许不配置 apiKey（本地服务）
+}
+
+// NewOpenAICompatibleProvider 创建兼容 OpenAI 接口的提供商，url 为完整的 chat/completions 地址
+func NewOpenAICompatibleProvider(name, url, apiKey, model string) *OpenAICompatibleProvider {
+	return &OpenAICompatibleProvider{
+		name:   name,
+		url:    url,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{},
+	}
+}
+
+// NewLocalProvider 创建指向本地或自建服务的提供商，不要求 apiKey
+func NewLocalProvider(url, apiKey, model string) *OpenAICompatibleProvider {
+	p := NewOpenAICompatibleProvider("local", url, apiKey, model)
+	p.keyless = true
+	return p
+}
+
+func (p *OpenAICompatibleProvider) GetName() string {
+	return p.name
+}
+
+func (p *OpenAICompatibleProvider) IsAvailable() bool {
+	return p.url != "" && (p.keyless || p.apiKey != "")
+}
+
+// Call 调用 chat/completions，超时由 ctx 控制
+func (p *OpenAICompatibleProvider) Call(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	start := time.Now()
+	resp, err := p.send(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Choices []struct {
+			Message LLMMessage `json:"message"`
+		} `json:"choices"`
+		Model string `json:"model"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if len(result.Choices) == 0 || (result.Choices[0].Message.Content == "" && len(result.Choices[0].Message.ToolCalls) == 0) {
+		return nil, errors.New("返回内容为空")
+	}
+	message := result.Choices[0].Message
+	return p.response(message.Content, message.ToolCalls, result.Model, start), nil
+}
+
+// Stream 以 stream 模式调用 chat/completions，逐条解析 SSE 中的内容片段
+// 工具调用的参数分多段返回，按 index 拼接完整后放在结果中
+func (p *OpenAICompatibleProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	start := time.Now()
+	resp, err := p.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var model string
+	var toolCalls []LLMToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int             `json:"index"`
+						ID       string          `json:"id"`
+						Type     string          `json:"type"`
+						Function LLMFunctionCall `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Model string `json:"model"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		for _, call := range chunk.Choices[0].Delta.ToolCalls {
+			for len(toolCalls) <= call.Index {
+				toolCalls = append(toolCalls, LLMToolCall{Type: "function"})
+			}
+			c := &toolCalls[call.Index]
+			if call.ID != "" {
+				c.ID = call.ID
+			}
+			if call.Function.Name != "" {
+				c.Function.Name = call.Function.Name
+			}
+			c.Function.Arguments += call.Function.Arguments
+		}
+		delta := chunk.Choices[0].Delta.Content
+		if delta == "" {
+			continue
+		}
+		content.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if content.Len() == 0 && len(toolCalls) == 0 {
+		return nil, errors.New("返回内容为空")
+	}
+	return p.response(content.String(), toolCalls, model, start), nil
+}
+
+// send 发送请求，非 200 响应转换为 LLMStatusError
+func (p *OpenAICompatibleProvider) send(ctx context.Context, req LLMRequest, stream bool) (*http.Response, error) {
+	payload := map[string]interface{}{
+		"model":    p.model,
+		"messages": req.Messages,
+	}
+	if req.Temperature > 0 {
+		payload["temperature"] = req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		payload["max_tokens"] = req.MaxTokens
+	}
+	if len(req.Tools) > 0 {
+		payload["tools"] = req.Tools
+	}
+	if stream {
+		payload["stream"] = true
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxLLMErrorBody))
+		return nil, &LLMStatusError{Provider: p.name, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// response 组装返回结果，响应中没有模型名时使用配置的模型
+func (p *OpenAICompatibleProvider) response(content string, toolCalls []LLMToolCall, model string, start time.Time) *LLMResponse {
+	if model == "" {
+		model = p.model
+	}
+	return &LLMResponse{
+		Content:   content,
+		ToolCalls: toolCalls,
+		Provider:  p.name,
+		Model:     model,
+		Latency:   time.Since(start),
+		Timestamp: time.Now(),
+	}
+}
+
+// StubProvider 不访问网络的本地模拟提供商，没有配置任何提供商或显式开启 AI_STUB 时使用
+type StubProvider struct {
+	respond func(req LLMRequest) string
+}
+
+// NewStubProvider 创建本地模拟提供商
+// respond 为空时，对话请求（以 system 消息开头）返回固定的教练回复，其他请求返回固定的训练计划
+func NewStubProvider(respond func(req LLMRequest) string) *StubProvider {
+	if respond == nil {
+		respond = func(req LLMRequest) string {
+			if len(req.Messages) > 0 && req.Messages[0].Role == LLMRoleSystem {
+				return stubChatResponse
+			}
+			return stubWorkoutPlanResponse
+		}
+	}
+	return &StubProvider{respond: respond}
+}
+
+func (p *StubProvider) GetName() string {
+	return "stub"
+}
+
+func (p *StubProvider) IsAvailable() bool {
+	return true
+}
+
+func (p *StubProvider) Call(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &LLMResponse{
+		Content:   p.respond(req),
+		Provider:  p.GetName(),
+		Model:     "stub",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// Stream 把回复按 stubStreamChunk 个字符一段依次交给 onDelta
+func (p *StubProvider) Stream(ctx context.Context, req LLMRequest, onDelta func(delta string) error) (*LLMResponse, error) {
+	resp, err := p.Call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(resp.Content)
+	for i := 0; i < len(runes); i += stubStreamChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := onDelta(string(runes[i:min(i+stubStreamChunk, len(runes))])); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// stubStreamChunk 模拟提供商流式返回时每段的字符数
+const stubStreamChunk = 8
+
+// stubChatResponse 模拟提供商返回的教练回复
+const stubChatResponse = "（本地模拟回复）AI 教练暂时没有连接到模型服务。训练前记得充分热身，循序渐进地增加重量，出现疼痛时及时停止。"
+
+// stubWorkoutPlanResponse 模拟提供商返回的训练计划
+const stubWorkoutPlanResponse = `{
+	"title": "减脂塑形 - 30天训练计划",
+	"description": "针对减脂塑形目标的30天训练计划，适合中级水平",
+	"difficulty": "中级",
+	"duration": 30,
+	"sessions": [
+		{
+			"day": 1,
+			"title": "全身力量训练",
+			"exercises": [
+				{
+					"name": "深蹲",
+					"category": "腿",
+					"sets": 3,
+					"reps": 15,
+					"weight": 0,
+					"duration": 0,
+					"rest_time": 60,
+					"notes": "保持背部挺直，膝盖不超过脚尖"
+				},
+				{
+					"name": "俯卧撑",
+					"category": "胸",
+					"sets": 3,
+					"reps": 12,
+					"weight": 0,
+					"duration": 0,
+					"rest_time": 60,
+					"notes": "保持身体成一条直线"
+				}
+			]
+		}
+	]
+}`
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxNutritionSummaryDays 饮食汇总最多统计的天数
+const maxNutritionSummaryDays = 31
+
+var (
+	// ErrFoodNotFound 食物库中没有该食物
+	ErrFoodNotFound = errors.New("未找到该食物的营养信息")
+	// ErrInvalidNutritionDate 日期不是 YYYY-MM-DD 格式
+	ErrInvalidNutritionDate = errors.New("日期格式错误")
+)
+
+// nutritionFacts 简化的食物营养库，每 100 克的含量（实际应用中应该使用专业的营养数据库）
+var nutritionFacts = map[string]models.NutritionFacts{
+	"米饭":  {Calories: 130, Protein: 2.7, Carbs: 28, Fat: 0.3, Fiber: 0.4, Sugar: 0.1, Sodium: 1},
+	"鸡胸肉": {Calories: 165, Protein: 31, Carbs: 0, Fat: 3.6, Fiber: 0, Sugar: 0, Sodium: 74},
+	"鸡蛋":  {Calories: 155, Protein: 13, Carbs: 1.1, Fat: 11, Fiber: 0, Sugar: 1.1, Sodium: 124},
+	"牛奶":  {Calories: 42, Protein: 3.4, Carbs: 5, Fat: 1, Fiber: 0, Sugar: 5, Sodium: 44},
+	"苹果":  {Calories: 52, Protein: 0.3, Carbs: 14, Fat: 0.2, Fiber: 2.4, Sugar: 10, Sodium: 1},
+	"香蕉":  {Calories: 89, Protein: 1.1, Carbs: 23, Fat: 0.3, Fiber: 2.6, Sugar: 12, Sodium: 1},
+	"燕麦":  {Calories: 389, Protein: 17, Carbs: 66, Fat: 7, Fiber: 11, Sugar: 1, Sodium: 2},
+	"三文鱼": {Calories: 208, Protein: 25, Carbs: 0, Fat: 12, Fiber: 0, Sugar: 0, Sodium: 44},
+}
+
+// searchableFoods 可搜索的食物，包含暂无营养数据的常见食物
+var searchableFoods = []string{"米饭", "鸡胸肉", "鸡蛋", "牛奶", "苹果", "香蕉", "燕麦", "三文鱼", "牛肉", "猪肉", "豆腐", "青菜", "胡萝卜", "土豆", "红薯"}
+
+// NutritionService 饮食记录服务，数量均以克为单位，单位换算在处理器中完成
+type NutritionService struct {
+	db *gorm.DB
+}
+
+// NewNutritionService 创建饮食记录服务
+func NewNutritionService(db *gorm.DB) *NutritionService {
+	return &NutritionService{db: db}
+}
+
+// Calculate 计算一定克数的食物的营养成分
+func (s *NutritionService) Calculate(foodName string, grams float64) (*models.NutritionFacts, error) {
+	facts, ok := nutritionFacts[foodName]
+	if !ok {
+		return nil, ErrFoodNotFound
+	}
+	scaled := scaleNutrition(facts, grams)
+	return &scaled, nil
+}
+
+// SearchFoods 按名称搜索食物
+func (s *NutritionService) SearchFoods(query string) []models.Food {
+	foods := []models.Food{}
+	for _, name := range searchableFoods {
+		if strings.Contains(name, query) {
+			foods = append(foods, models.Food{Name: name, Type: "food"})
+		}
+	}
+	return foods
+}
+
+// CreateRecord 按食物库计算营养成分并保存饮食记录，req.Quantity 为克
+func (s *NutritionService) CreateRecord(userID string, req models.NutritionRecordRequest) (*models.NutritionRecord, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, ErrInvalidNutritionDate
+	}
+	facts, err := s.Calculate(req.FoodName, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.NutritionRecord{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Date:      date,
+		MealType:  req.MealType,
+		FoodName:  req.FoodName,
+		Quantity:  req.Quantity,
+		Unit:      "g",
+		Calories:  facts.Calories,
+		Protein:   facts.Protein,
+		Carbs:     facts.Carbs,
+		Fat:       facts.Fat,
+		Fiber:     facts.Fiber,
+		Sugar:     facts.Sugar,
+		Sodium:    facts.Sodium,
+		Notes:     req.Notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		logger.Error.Printf("创建饮食记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return record, nil
+}
+
+// Records 分页获取饮食记录和总数，date 不为空时只返回该日的记录
+func (s *NutritionService) Records(userID, date string, skip, limit int) ([]models.NutritionRecord, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, 0, ErrInvalidNutritionDate
+		}
+	}
+	query := func() *gorm.DB {
+		db := s.db.Model(&models.NutritionRecord{}).Where("user_id = ?", userID)
+		if date != "" {
+			db = db.Where("DATE(date) = ?", date)
+		}
+		return db
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		logger.Error.Printf("统计饮食记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, 0, err
+	}
+
+	records := []models.NutritionRecord{}
+	if err := query().Order("date DESC").Offset(skip).Limit(limit).Find(&records).Error; err != nil {
+		logger.Error.Printf("获取饮食记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// DailyIntake 汇总一天的摄入，按餐次分组
+func (s *NutritionService) DailyIntake(userID, date string) (*models.DailyIntakeResponse, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, ErrInvalidNutritionDate
+	}
+
+	var records []models.NutritionRecord
+	if err := s.db.Where("user_id = ? AND DATE(date) = ?", userID, date).Find(&records).Error; err != nil {
+		logger.Error.Printf("获取饮食记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return dailyIntake(date, records), nil
+}
+
+// Summary 汇总 [from, to] 每天的热量和三大营养素，超过 maxNutritionSummaryDays 天时只统计最后的部分
+func (s *NutritionService) Summary(userID string, from, to time.Time) (*models.NutritionSummary, error) {
+	if to.Before(from) {
+		from, to = to, from
+	}
+	if earliest := to.AddDate(0, 0, -(maxNutritionSummaryDays - 1)); from.Before(earliest) {
+		from = earliest
+	}
+
+	var records []models.NutritionRecord
+	if err := s.db.Where("user_id = ? AND DATE(date) BETWEEN ? AND ?", userID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC").
+		Find(&records).Error; err != nil {
+		logger.Error.Printf("查询饮食记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return summarizeNutrition(records, from, to), nil
+}
+
+// scaleNutrition 每 100 克的营养成分换算为 grams 克的含量
+func scaleNutrition(per100g models.NutritionFacts, grams float64) models.NutritionFacts {
+	ratio := grams / 100
+	return roundNutrition(models.NutritionFacts{
+		Calories: per100g.Calories * ratio,
+		Protein:  per100g.Protein * ratio,
+		Carbs:    per100g.Carbs * ratio,
+		Fat:      per100g.Fat * ratio,
+		Fiber:    per100g.Fiber * ratio,
+		Sugar:    per100g.Sugar * ratio,
+		Sodium:   per100g.Sodium * ratio,
+	})
+}
+
+// roundNutrition 营养成分保留两位小数
+func roundNutrition(facts models.NutritionFacts) models.NutritionFacts {
+	return models.NutritionFacts{
+		Calories: roundTo(facts.Calories, 2),
+		Protein:  roundTo(facts.Protein, 2),
+		Carbs:    roundTo(facts.Carbs, 2),
+		Fat:      roundTo(facts.Fat, 2),
+		Fiber:    roundTo(facts.Fiber, 2),
+		Sugar:    roundTo(facts.Sugar, 2),
+		Sodium:   roundTo(facts.Sodium, 2),
+	}
+}
+
+// dailyIntake 汇总一天的饮食记录，餐次按名称排序
+func dailyIntake(date string, records []models.NutritionRecord) *models.DailyIntakeResponse {
+	intake := &models.DailyIntakeResponse{Date: date, Meals: []models.MealSummary{}}
+	meals := make(map[string]*models.MealSummary)
+	for _, record := range records {
+		intake.Calories += record.Calories
+		intake.Protein += record.Protein
+		intake.Carbs += record.Carbs
+		intake.Fat += record.Fat
+		intake.Fiber += record.Fiber
+		intake.Sugar += record.Sugar
+		intake.Sodium += record.Sodium
+
+		meal, ok := meals[record.MealType]
+		if !ok {
+			meal = &models.MealSummary{MealType: record.MealType}
+			meals[record.MealType] = meal
+		}
+		meal.Calories += record.Calories
+		meal.Protein += record.Protein
+		meal.Carbs += record.Carbs
+		meal.Fat += record.Fat
+		meal.Count++
+	}
+
+	intake.NutritionFacts = roundNutrition(intake.NutritionFacts)
+	for _, meal := range meals {
+		meal.Calories = roundTo(meal.Calories, 2)
+		meal.Protein = roundTo(meal.Protein, 2)
+		meal.Carbs = roundTo(meal.Carbs, 2)
+		meal.Fat = roundTo(meal.Fat, 2)
+		intake.Meals = append(intake.Meals, *meal)
+	}
+	sort.Slice(intake.Meals, func(i, j int) bool { return intake.Meals[i].MealType < intake.Meals[j].MealType })
+	return intake
+}
+
+// summarizeNutrition 按日期汇总饮食记录，records 需按时间升序
+func summarizeNutrition(records []models.NutritionRecord, from, to time.Time) *models.NutritionSummary {
+	summary := &models.NutritionSummary{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: []models.NutritionDaySummary{},
+	}
+
+	meals := make(map[string]bool)
+	for _, record := range records {
+		date := record.Date.Format("2006-01-02")
+		if len(summary.Days) == 0 || summary.Days[len(summary.Days)-1].Date != date {
+			summary.Days = append(summary.Days, models.NutritionDaySummary{Date: date})
+		}
+		day := &summary.Days[len(summary.Days)-1]
+		day.Calories += record.Calories
+		day.Protein += record.Protein
+		day.Carbs += record.Carbs
+		day.Fat += record.Fat
+		day.Records++
+		if key := date + "|" + record.MealType; !meals[key] {
+			meals[key] = true
+			day.Meals++
+		}
+	}
+
+	for i := range summary.Days {
+		day := &summary.Days[i]
+		summary.AvgCalories += day.Calories
+		summary.AvgProtein += day.Protein
+		day.Calories = roundTo(day.Calories, 1)
+		day.Protein = roundTo(day.Protein, 1)
+		day.Carbs = roundTo(day.Carbs, 1)
+		day.Fat = roundTo(day.Fat, 1)
+	}
+	if n := float64(len(summary.Days)); n > 0 {
+		summary.AvgCalories = roundTo(summary.AvgCalories/n, 1)
+		summary.AvgProtein = roundTo(summary.AvgProtein/n, 1)
+	}
+	return summary
+}
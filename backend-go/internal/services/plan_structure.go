@@ -0,0 +1,337 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gymates/internal/models"
+)
+
+var (
+	// ErrInvalidPlanStructure 计划中的组类型或动作分组不合法
+	ErrInvalidPlanStructure = errors.New("训练计划结构无效")
+	// ErrInvalidSetData 完成的组缺少该类型需要的数据，或提交的组不属于该动作
+	ErrInvalidSetData = errors.New("组数据无效")
+	// ErrInvalidPlanDate 计划日期不是 YYYY-MM-DD 格式
+	ErrInvalidPlanDate = errors.New("日期格式错误")
+)
+
+// setTypeAliases 组类型的常见写法，用于解析用户和 AI 的输入
+var setTypeAliases = map[string]string{
+	"":         models.SetTypeWorking,
+	"working":  models.SetTypeWorking,
+	"work":     models.SetTypeWorking,
+	"normal":   models.SetTypeWorking,
+	"正式组":      models.SetTypeWorking,
+	"warmup":   models.SetTypeWarmup,
+	"warm-up":  models.SetTypeWarmup,
+	"warm_up":  models.SetTypeWarmup,
+	"热身":       models.SetTypeWarmup,
+	"热身组":      models.SetTypeWarmup,
+	"drop":     models.SetTypeDrop,
+	"dropset":  models.SetTypeDrop,
+	"drop_set": models.SetTypeDrop,
+	"drop-set": models.SetTypeDrop,
+	"递减组":      models.SetTypeDrop,
+	"amrap":    models.SetTypeAMRAP,
+	"力竭组":      models.SetTypeAMRAP,
+	"timed":    models.SetTypeTimed,
+	"time":     models.SetTypeTimed,
+	"计时":       models.SetTypeTimed,
+	"计时组":      models.SetTypeTimed,
+}
+
+// groupTypeAliases 分组类型的常见写法，空字符串表示按动作数推断
+var groupTypeAliases = map[string]string{
+	"":          "",
+	"superset":  models.GroupTypeSuperset,
+	"super_set": models.GroupTypeSuperset,
+	"super-set": models.GroupTypeSuperset,
+	"超级组":       models.GroupTypeSuperset,
+	"giant_set": models.GroupTypeGiantSet,
+	"giantset":  models.GroupTypeGiantSet,
+	"giant-set": models.GroupTypeGiantSet,
+	"巨型组":       models.GroupTypeGiantSet,
+	"circuit":   models.GroupTypeCircuit,
+	"循环":        models.GroupTypeCircuit,
+	"循环训练":      models.GroupTypeCircuit,
+}
+
+// normalizeSetType 统一组类型写法，空值视为正式组
+func normalizeSetType(setType string) (string, error) {
+	if normalized, ok := setTypeAliases[strings.ToLower(strings.TrimSpace(setType))]; ok {
+		return normalized, nil
+	}
+	return "", fmt.Errorf("未知的组类型: %s", setType)
+}
+
+// setTypeOf 组的类型，历史数据没有类型时视为正式组
+func setTypeOf(set models.ExerciseSet) string {
+	if set.SetType == "" {
+		return models.SetTypeWorking
+	}
+	return set.SetType
+}
+
+// countsTowardVolume 热身组不计入训练量、个人记录和分组轮次
+func countsTowardVolume(set models.ExerciseSet) bool {
+	return setTypeOf(set) != models.SetTypeWarmup
+}
+
+// validateSets 校验并统一动作中各组的类型
+func validateSets(name string, sets []models.ExerciseSet) error {
+	for i := range sets {
+		setType, err := normalizeSetType(sets[i].SetType)
+		if err != nil {
+			return fmt.Errorf("%s 第 %d 组: %w", name, i+1, err)
+		}
+		sets[i].SetType = setType
+
+		set := sets[i]
+		switch {
+		case setType == models.SetTypeTimed && set.Duration <= 0:
+			return fmt.Errorf("%s 第 %d 组: 计时组需要指定时长", name, i+1)
+		case setType == models.SetTypeDrop && i == 0:
+			return fmt.Errorf("%s 第 %d 组: 递减组不能是第一组", name, i+1)
+		case setType == models.SetTypeDrop && setTypeOf(sets[i-1]) == models.SetTypeWarmup:
+			return fmt.Errorf("%s 第 %d 组: 递减组需要跟在正式组之后", name, i+1)
+		case setType != models.SetTypeAMRAP && set.Reps <= 0 && set.Duration <= 0 && set.Distance <= 0:
+			return fmt.Errorf("%s 第 %d 组: 需要指定次数、时长或距离", name, i+1)
+		}
+	}
+	return nil
+}
+
+// validateCompletedSet 按组类型校验完成时提交的数据
+func validateCompletedSet(setType string, req models.CompleteSetRequest) error {
+	if !req.Completed {
+		return nil
+	}
+	switch setType {
+	case models.SetTypeTimed:
+		if req.Duration <= 0 {
+			return fmt.Errorf("%w: 计时组需要记录完成时长", ErrInvalidSetData)
+		}
+	case models.SetTypeAMRAP:
+		if req.Reps <= 0 {
+			return fmt.Errorf("%w: 力竭组需要记录实际完成次数", ErrInvalidSetData)
+		}
+	}
+	return nil
+}
+
+// exerciseSequence 动作按 Order 排列的下标，Order 相同时保持原顺序
+func exerciseSequence(exercises []models.TrainingExercise) []int {
+	sequence := make([]int, len(exercises))
+	for i := range sequence {
+		sequence[i] = i
+	}
+	sort.SliceStable(sequence, func(a, b int) bool {
+		return exercises[sequence[a]].Order < exercises[sequence[b]].Order
+	})
+	return sequence
+}
+
+// resolveGroups 校验动作分组并补全分组类型和轮数
+// 同一分组的动作必须连续排列，超级组恰好两个动作，巨型组至少三个，循环至少两个；
+// 未指定类型时按动作数推断为超级组或巨型组，未指定轮数时取分组内动作的最大组数（不含热身组）
+func resolveGroups(exercises []models.TrainingExercise) error {
+	members := make(map[string][]int)
+	var keys []string
+	last := ""
+	for _, i := range exerciseSequence(exercises) {
+		exercise := &exercises[i]
+		exercise.GroupKey = strings.TrimSpace(exercise.GroupKey)
+		groupType, ok := groupTypeAliases[strings.ToLower(strings.TrimSpace(exercise.GroupType))]
+		if !ok {
+			return fmt.Errorf("%s: 未知的分组类型 %s", exercise.Name, exercise.GroupType)
+		}
+		exercise.GroupType = groupType
+
+		key := exercise.GroupKey
+		if key == "" {
+			if groupType != "" || exercise.GroupRounds != 0 {
+				return fmt.Errorf("%s: 指定分组类型或轮数时需要指定分组", exercise.Name)
+			}
+			last = ""
+			continue
+		}
+		if _, seen := members[key]; seen && key != last {
+			return fmt.Errorf("分组 %s 的动作需要连续排列", key)
+		}
+		if _, seen := members[key]; !seen {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], i)
+		last = key
+	}
+
+	for _, key := range keys {
+		indexes := members[key]
+		groupType, rounds, maxSets := "", 0, 0
+		for _, i := range indexes {
+			exercise := exercises[i]
+			if exercise.GroupType != "" {
+				if groupType != "" && groupType != exercise.GroupType {
+					return fmt.Errorf("分组 %s 的动作分组类型不一致", key)
+				}
+				groupType = exercise.GroupType
+			}
+			if exercise.GroupRounds != 0 {
+				if rounds != 0 && rounds != exercise.GroupRounds {
+					return fmt.Errorf("分组 %s 的动作轮数不一致", key)
+				}
+				rounds = exercise.GroupRounds
+			}
+			count := 0
+			for _, set := range exercise.Sets {
+				if countsTowardVolume(set) {
+					count++
+				}
+			}
+			maxSets = max(maxSets, count)
+		}
+
+		n := len(indexes)
+		if groupType == "" {
+			groupType = models.GroupTypeSuperset
+			if n >= 3 {
+				groupType = models.GroupTypeGiantSet
+			}
+		}
+		switch {
+		case groupType == models.GroupTypeSuperset && n != 2:
+			return fmt.Errorf("超级组 %s 需要恰好两个动作", key)
+		case groupType == models.GroupTypeGiantSet && n < 3:
+			return fmt.Errorf("巨型组 %s 至少需要三个动作", key)
+		case groupType == models.GroupTypeCircuit && n < 2:
+			return fmt.Errorf("循环 %s 至少需要两个动作", key)
+		case rounds < 0:
+			return fmt.Errorf("分组 %s 的轮数不能为负数", key)
+		}
+		if rounds == 0 {
+			rounds = max(maxSets, 1)
+		}
+
+		for _, i := range indexes {
+			exercises[i].GroupType = groupType
+			exercises[i].GroupRounds = rounds
+		}
+	}
+	return nil
+}
+
+// validatePlanStructure 校验计划中各动作的组类型和动作分组
+func validatePlanStructure(exercises []models.TrainingExercise) error {
+	for i := range exercises {
+		if err := validateSets(exercises[i].Name, exercises[i].Sets); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPlanStructure, err)
+		}
+	}
+	if err := resolveGroups(exercises); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPlanStructure, err)
+	}
+	return nil
+}
+
+// planGroups 计划中的动作分组，按分组内第一个动作的顺序排列
+func planGroups(exercises []models.TrainingExercise) []models.ExerciseGroupResponse {
+	var groups []models.ExerciseGroupResponse
+	index := make(map[string]int)
+	for _, i := range exerciseSequence(exercises) {
+		exercise := exercises[i]
+		if exercise.GroupKey == "" {
+			continue
+		}
+		j, ok := index[exercise.GroupKey]
+		if !ok {
+			j = len(groups)
+			index[exercise.GroupKey] = j
+			groups = append(groups, models.ExerciseGroupResponse{
+				Key:    exercise.GroupKey,
+				Type:   exercise.GroupType,
+				Rounds: exercise.GroupRounds,
+			})
+		}
+		groups[j].ExerciseIDs = append(groups[j].ExerciseIDs, exercise.ID)
+	}
+	return groups
+}
+
+// groupProgress 完成分组中某个动作后的进度
+// 每个动作完成的组数（不含热身组）即完成的轮数，下一个动作从当前动作之后循环查找完成轮数最少的动作；
+// 回到分组开头说明进入下一轮，此时休息当前动作最后一组的休息时间，同一轮内的动作之间不休息
+func groupProgress(exercises []models.TrainingExercise, currentID string) *models.GroupProgress {
+	var members []models.TrainingExercise
+	var current *models.TrainingExercise
+	for _, exercise := range exercises {
+		if exercise.ID == currentID {
+			current = &exercise
+			break
+		}
+	}
+	if current == nil || current.GroupKey == "" {
+		return nil
+	}
+	for _, i := range exerciseSequence(exercises) {
+		if exercises[i].GroupKey == current.GroupKey {
+			members = append(members, exercises[i])
+		}
+	}
+
+	position := 0
+	counts := make([]int, len(members))
+	minCount := -1
+	for i, member := range members {
+		if member.ID == currentID {
+			position = i
+		}
+		for _, set := range member.Sets {
+			if set.Completed && countsTowardVolume(set) {
+				counts[i]++
+			}
+		}
+		if minCount < 0 || counts[i] < minCount {
+			minCount = counts[i]
+		}
+	}
+
+	progress := &models.GroupProgress{
+		Key:    current.GroupKey,
+		Type:   current.GroupType,
+		Rounds: current.GroupRounds,
+		Round:  minCount + 1,
+	}
+	if minCount >= current.GroupRounds {
+		progress.Round = current.GroupRounds
+		progress.Done = true
+		return progress
+	}
+
+	for step := 1; step <= len(members); step++ {
+		next := (position + step) % len(members)
+		if counts[next] != minCount {
+			continue
+		}
+		progress.NextExerciseID = members[next].ID
+		progress.NextExerciseName = members[next].Name
+		if next <= position {
+			progress.RestTime = lastRestTime(*current)
+		}
+		break
+	}
+	return progress
+}
+
+// lastRestTime 动作最近完成的一组的休息时间
+func lastRestTime(exercise models.TrainingExercise) int {
+	rest := 0
+	for _, set := range exercise.Sets {
+		if set.Completed {
+			rest = set.RestTime
+		}
+	}
+	return rest
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSets(t *testing.T) {
+	sets := []models.ExerciseSet{
+		{Reps: 10, Weight: 20, SetType: "Warm-up"},
+		{Reps: 8, Weight: 60},
+		{Reps: 6, Weight: 45, SetType: "dropset"},
+		{Weight: 40, SetType: "AMRAP"},
+		{Duration: 60, SetType: "timed"},
+	}
+	require.NoError(t, validateSets("卧推", sets))
+	assert.Equal(t, models.SetTypeWarmup, sets[0].SetType)
+	assert.Equal(t, models.SetTypeWorking, sets[1].SetType)
+	assert.Equal(t, models.SetTypeDrop, sets[2].SetType)
+	assert.Equal(t, models.SetTypeAMRAP, sets[3].SetType)
+	assert.Equal(t, models.SetTypeTimed, sets[4].SetType)
+
+	cases := map[string][]models.ExerciseSet{
+		"未知的组类型":    {{Reps: 8, SetType: "cluster"}},
+		"计时组需要指定时长": {{Reps: 8, SetType: models.SetTypeTimed}},
+		"递减组不能是第一组": {{Reps: 8, SetType: models.SetTypeDrop}},
+		"递减组需要跟在正式组之后": {
+			{Reps: 8, SetType: models.SetTypeWarmup},
+			{Reps: 8, SetType: models.SetTypeDrop},
+		},
+		"需要指定次数、时长或距离": {{Weight: 60}},
+	}
+	for message, sets := range cases {
+		err := validateSets("卧推", sets)
+		require.Error(t, err, message)
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidateCompletedSet(t *testing.T) {
+	assert.ErrorIs(t, validateCompletedSet(models.SetTypeTimed, models.CompleteSetRequest{Completed: true}), ErrInvalidSetData)
+	assert.NoError(t, validateCompletedSet(models.SetTypeTimed, models.CompleteSetRequest{Completed: true, Duration: 45}))
+	assert.ErrorIs(t, validateCompletedSet(models.SetTypeAMRAP, models.CompleteSetRequest{Completed: true}), ErrInvalidSetData)
+	assert.NoError(t, validateCompletedSet(models.SetTypeAMRAP, models.CompleteSetRequest{Completed: false}))
+	assert.NoError(t, validateCompletedSet(models.SetTypeWorking, models.CompleteSetRequest{Completed: true}))
+}
+
+func groupTestSets(n int) []models.ExerciseSet {
+	sets := []models.ExerciseSet{{Reps: 10, SetType: models.SetTypeWarmup}}
+	for i := 0; i < n; i++ {
+		sets = append(sets, models.ExerciseSet{Reps: 10, SetType: models.SetTypeWorking})
+	}
+	return sets
+}
+
+func TestResolveGroups(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{ID: "e1", Name: "卧推", Order: 1, GroupKey: "A", Sets: groupTestSets(3)},
+		{ID: "e2", Name: "划船", Order: 2, GroupKey: "A", Sets: groupTestSets(4)},
+		{ID: "e3", Name: "深蹲", Order: 3},
+		{ID: "e4", Name: "开合跳", Order: 4, GroupKey: "B", GroupType: "Circuit", GroupRounds: 3, Sets: groupTestSets(1)},
+		{ID: "e5", Name: "波比跳", Order: 5, GroupKey: "B", Sets: groupTestSets(1)},
+		{ID: "e6", Name: "登山跑", Order: 6, GroupKey: "B", Sets: groupTestSets(1)},
+	}
+	require.NoError(t, resolveGroups(exercises))
+
+	assert.Equal(t, models.GroupTypeSuperset, exercises[0].GroupType)
+	assert.Equal(t, 4, exercises[0].GroupRounds)
+	assert.Equal(t, 4, exercises[1].GroupRounds)
+	assert.Empty(t, exercises[2].GroupType)
+	for _, exercise := range exercises[3:] {
+		assert.Equal(t, models.GroupTypeCircuit, exercise.GroupType)
+		assert.Equal(t, 3, exercise.GroupRounds)
+	}
+
+	groups := planGroups(exercises)
+	require.Len(t, groups, 2)
+	assert.Equal(t, []string{"e1", "e2"}, groups[0].ExerciseIDs)
+	assert.Equal(t, models.GroupTypeCircuit, groups[1].Type)
+	assert.Equal(t, []string{"e4", "e5", "e6"}, groups[1].ExerciseIDs)
+}
+
+func TestResolveGroupsInfersGiantSet(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "a", Order: 1, GroupKey: "G"},
+		{Name: "b", Order: 2, GroupKey: "G"},
+		{Name: "c", Order: 3, GroupKey: "G"},
+	}
+	require.NoError(t, resolveGroups(exercises))
+	assert.Equal(t, models.GroupTypeGiantSet, exercises[0].GroupType)
+	assert.Equal(t, 1, exercises[0].GroupRounds)
+}
+
+func TestResolveGroupsErrors(t *testing.T) {
+	cases := map[string][]models.TrainingExercise{
+		"需要连续排列": {
+			{Name: "a", Order: 1, GroupKey: "A"},
+			{Name: "b", Order: 2},
+			{Name: "c", Order: 3, GroupKey: "A"},
+		},
+		"超级组 A 需要恰好两个动作": {
+			{Name: "a", Order: 1, GroupKey: "A", GroupType: models.GroupTypeSuperset},
+			{Name: "b", Order: 2, GroupKey: "A"},
+			{Name: "c", Order: 3, GroupKey: "A"},
+		},
+		"巨型组 A 至少需要三个动作": {
+			{Name: "a", Order: 1, GroupKey: "A", GroupType: models.GroupTypeGiantSet},
+			{Name: "b", Order: 2, GroupKey: "A"},
+		},
+		"循环 A 至少需要两个动作": {
+			{Name: "a", Order: 1, GroupKey: "A", GroupType: models.GroupTypeCircuit},
+		},
+		"分组类型不一致": {
+			{Name: "a", Order: 1, GroupKey: "A", GroupType: models.GroupTypeSuperset},
+			{Name: "b", Order: 2, GroupKey: "A", GroupType: models.GroupTypeCircuit},
+		},
+		"需要指定分组": {
+			{Name: "a", Order: 1, GroupType: models.GroupTypeSuperset},
+		},
+		"未知的分组类型": {
+			{Name: "a", Order: 1, GroupKey: "A", GroupType: "pyramid"},
+		},
+	}
+	for message, exercises := range cases {
+		err := resolveGroups(exercises)
+		require.Error(t, err, message)
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidatePlanStructureWrapsError(t *testing.T) {
+	err := validatePlanStructure([]models.TrainingExercise{{Name: "a", Sets: []models.ExerciseSet{{SetType: "cluster", Reps: 5}}}})
+	assert.ErrorIs(t, err, ErrInvalidPlanStructure)
+}
+
+func TestGroupProgress(t *testing.T) {
+	done := func(n int, rest int) []models.ExerciseSet {
+		sets := []models.ExerciseSet{{Reps: 10, SetType: models.SetTypeWarmup, Completed: true}}
+		for i := 0; i < 3; i++ {
+			sets = append(sets, models.ExerciseSet{Reps: 10, RestTime: rest, Completed: i < n})
+		}
+		return sets
+	}
+	exercises := []models.TrainingExercise{
+		{ID: "b", Name: "划船", Order: 2, GroupKey: "A", GroupType: models.GroupTypeSuperset, GroupRounds: 3, Sets: done(0, 90)},
+		{ID: "a", Name: "卧推", Order: 1, GroupKey: "A", GroupType: models.GroupTypeSuperset, GroupRounds: 3, Sets: done(1, 0)},
+		{ID: "c", Name: "深蹲", Order: 3},
+	}
+
+	// 第一轮做完卧推，紧接着做划船，中间不休息
+	progress := groupProgress(exercises, "a")
+	require.NotNil(t, progress)
+	assert.Equal(t, 1, progress.Round)
+	assert.Equal(t, "b", progress.NextExerciseID)
+	assert.Equal(t, 0, progress.RestTime)
+	assert.False(t, progress.Done)
+
+	// 第一轮做完划船，回到卧推开始第二轮，休息划船的休息时间
+	exercises[0].Sets = done(1, 90)
+	progress = groupProgress(exercises, "b")
+	assert.Equal(t, 2, progress.Round)
+	assert.Equal(t, "a", progress.NextExerciseID)
+	assert.Equal(t, "卧推", progress.NextExerciseName)
+	assert.Equal(t, 90, progress.RestTime)
+
+	exercises[0].Sets = done(3, 90)
+	exercises[1].Sets = done(3, 0)
+	progress = groupProgress(exercises, "b")
+	assert.True(t, progress.Done)
+	assert.Equal(t, 3, progress.Round)
+	assert.Empty(t, progress.NextExerciseID)
+
+	assert.Nil(t, groupProgress(exercises, "c"))
+	assert.Nil(t, groupProgress(exercises, "missing"))
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 训练周期状态
+const (
+	ProgramStatusActive    = "active"
+	ProgramStatusCompleted = "completed"
+	ProgramStatusArchived  = "archived"
+)
+
+// ProgramService 训练周期服务
+// 负责把多周训练周期物化为每日的 TrainingPlan，并在跳过训练日时重新排期
+type ProgramService struct {
+	db      *gorm.DB
+	catalog *ExerciseCatalogService
+}
+
+// NewProgramService 创建训练周期服务
+func NewProgramService(db *gorm.DB, catalog *ExerciseCatalogService) *ProgramService {
+	return &ProgramService{db: db, catalog: catalog}
+}
+
+// CreateProgram 创建训练周期并排期所有训练日
+func (s *ProgramService) CreateProgram(userID string, req models.CreateProgramRequest) (*models.TrainingProgram, error) {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, ErrInvalidPlanDate
+	}
+
+	program := models.TrainingProgram{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Goal:        req.Goal,
+		StartDate:   startDate,
+		Status:      ProgramStatusActive,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	for i, phaseReq := range req.Phases {
+		phase := models.ProgramPhase{
+			ID:              uuid.New().String(),
+			ProgramID:       program.ID,
+			Name:            phaseReq.Name,
+			Order:           i + 1,
+			Weeks:           phaseReq.Weeks,
+			IsDeload:        phaseReq.IsDeload,
+			IntensityFactor: phaseReq.IntensityFactor,
+			VolumeFactor:    phaseReq.VolumeFactor,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+		if phase.IntensityFactor <= 0 {
+			phase.IntensityFactor = 1
+			if phase.IsDeload {
+				phase.IntensityFactor = 0.6
+			}
+		}
+		if phase.VolumeFactor <= 0 {
+			phase.VolumeFactor = 1
+			if phase.IsDeload {
+				phase.VolumeFactor = 0.5
+			}
+		}
+
+		for _, dayReq := range phaseReq.Days {
+			phase.Days = append(phase.Days, models.ProgramDayTemplate{
+				ID:        uuid.New().String(),
+				PhaseID:   phase.ID,
+				DayOffset: dayReq.DayOffset,
+				Name:      dayReq.Name,
+				Exercises: dayReq.Exercises,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		}
+
+		program.TotalWeeks += phase.Weeks
+		program.Phases = append(program.Phases, phase)
+	}
+
+	plans := buildProgramPlans(userID, &program)
+	for i := range plans {
+		if err := validatePlanStructure(plans[i].Exercises); err != nil {
+			return nil, fmt.Errorf("%s: %w", plans[i].Name, err)
+		}
+	}
+	s.catalog.LinkPlans(plans)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&program).Error; err != nil {
+			return err
+		}
+		if len(plans) > 0 {
+			if err := tx.Create(&plans).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error.Printf("创建训练周期失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+// GetPrograms 获取用户的训练周期列表
+func (s *ProgramService) GetPrograms(userID string) ([]models.TrainingProgram, error) {
+	var programs []models.TrainingProgram
+	err := s.db.Where("user_id = ?", userID).
+		Preload("Phases", func(db *gorm.DB) *gorm.DB { return db.Order("\"order\" ASC") }).
+		Preload("Phases.Days").
+		Order("start_date DESC").
+		Find(&programs).Error
+	if err != nil {
+		logger.Error.Printf("获取训练周期失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return programs, nil
+}
+
+// SkipDay 跳过某个训练日，并把该训练及之后的训练顺延一个训练日
+//
+// 被跳过的计划保留为 skipped 记录，其内容复制到下一个排期位置，
+// 后续未完成的计划依次顺延，最后一个计划按周节奏追加到周期末尾。
+func (s *ProgramService) SkipDay(userID, planID string) ([]models.TrainingPlan, error) {
+	var skipped models.TrainingPlan
+	err := s.db.Where("id = ? AND user_id = ?", planID, userID).
+		Preload("Exercises.Sets").
+		First(&skipped).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("训练计划不存在或无权操作")
+		}
+		return nil, err
+	}
+	if skipped.ProgramID == "" {
+		return nil, errors.New("该训练计划不属于任何训练周期")
+	}
+	if skipped.Status != "pending" {
+		return nil, fmt.Errorf("只能跳过未开始的训练，当前状态: %s", skipped.Status)
+	}
+
+	// 每周训练天数取最后一个阶段，阶段和训练日需按顺序加载
+	var program models.TrainingProgram
+	if err := s.db.
+		Preload("Phases", func(db *gorm.DB) *gorm.DB { return db.Order("\"order\" ASC") }).
+		Preload("Phases.Days", func(db *gorm.DB) *gorm.DB { return db.Order("day_offset ASC") }).
+		First(&program, "id = ?", skipped.ProgramID).Error; err != nil {
+		return nil, err
+	}
+
+	var upcoming []models.TrainingPlan
+	if err := s.db.Where("program_id = ? AND status = ? AND id <> ? AND date >= ?", skipped.ProgramID, "pending", skipped.ID, skipped.Date).
+		Order("date ASC, id ASC").
+		Find(&upcoming).Error; err != nil {
+		return nil, err
+	}
+
+	slots := []time.Time{skipped.Date}
+	for _, plan := range upcoming {
+		slots = append(slots, plan.Date)
+	}
+	newDates := reflowDates(slots, programDaysPerWeek(&program))
+
+	replacement := clonePlan(skipped, newDates[0])
+	replacement.Status = "pending"
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TrainingPlan{}).Where("id = ?", skipped.ID).
+			Updates(map[string]interface{}{"status": "skipped", "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		for i := range upcoming {
+			upcoming[i].Date = newDates[i+1]
+			if err := tx.Model(&models.TrainingPlan{}).Where("id = ?", upcoming[i].ID).
+				Updates(map[string]interface{}{"date": upcoming[i].Date, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&replacement).Error
+	})
+	if err != nil {
+		logger.Error.Printf("训练周期重新排期失败: plan_id=%v, error=%v", planID, err.Error())
+		return nil, err
+	}
+
+	return append([]models.TrainingPlan{replacement}, upcoming...), nil
+}
+
+// GetProgress 获取训练周期进度
+func (s *ProgramService) GetProgress(userID, programID string) (*models.ProgramProgressResponse, error) {
+	var program models.TrainingProgram
+	err := s.db.Where("id = ? AND user_id = ?", programID, userID).
+		Preload("Phases", func(db *gorm.DB) *gorm.DB { return db.Order("\"order\" ASC") }).
+		First(&program).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("训练周期不存在或无权查看")
+		}
+		return nil, err
+	}
+
+	var plans []models.TrainingPlan
+	if err := s.db.Where("program_id = ?", programID).Order("date ASC").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	progress := programProgress(&program, plans, time.Now())
+
+	// 所有训练都已结束时自动标记周期完成
+	if progress.PendingCount == 0 && progress.TotalWorkouts > 0 && program.Status == ProgramStatusActive {
+		s.db.Model(&program).Updates(map[string]interface{}{"status": ProgramStatusCompleted, "updated_at": time.Now()})
+		progress.Status = ProgramStatusCompleted
+	}
+
+	return progress, nil
+}
+
+// buildProgramPlans 把训练周期的各阶段周模板展开为每日训练计划
+func buildProgramPlans(userID string, program *models.TrainingProgram) []models.TrainingPlan {
+	var plans []models.TrainingPlan
+	week := 0
+	for _, phase := range program.Phases {
+		for w := 0; w < phase.Weeks; w++ {
+			weekStart := program.StartDate.AddDate(0, 0, 7*week)
+			for _, day := range phase.Days {
+				plan := models.TrainingPlan{
+					ID:          uuid.New().String(),
+					UserID:      userID,
+					Name:        fmt.Sprintf("%s · 第%d周 · %s", program.Name, week+1, day.Name),
+					Description: phase.Name,
+					Date:        weekStart.AddDate(0, 0, day.DayOffset),
+					Status:      "pending",
+					ProgramID:   program.ID,
+					ProgramWeek: week + 1,
+					CreatedAt:   time.Now(),
+					UpdatedAt:   time.Now(),
+				}
+				for _, exerciseReq := range day.Exercises {
+					plan.Exercises = append(plan.Exercises, phaseExercise(plan.ID, exerciseReq, phase))
+				}
+				plans = append(plans, plan)
+			}
+			week++
+		}
+	}
+
+	sort.SliceStable(plans, func(i, j int) bool { return plans[i].Date.Before(plans[j].Date) })
+	return plans
+}
+
+// phaseExercise 按阶段的强度/容量系数生成动作，保留组类型和动作分组
+func phaseExercise(planID string, req models.CreateExerciseRequest, phase models.ProgramPhase) models.TrainingExercise {
+	exercise := models.TrainingExercise{
+		ID:           uuid.New().String(),
+		PlanID:       planID,
+		CatalogID:    req.CatalogID,
+		Name:         req.Name,
+		Description:  req.Description,
+		Category:     req.Category,
+		Difficulty:   req.Difficulty,
+		MuscleGroups: req.MuscleGroups,
+		Equipment:    req.Equipment,
+		VideoURL:     req.VideoURL,
+		ImageURL:     req.ImageURL,
+		Instructions: req.Instructions,
+		Order:        req.Order,
+		GroupKey:     req.GroupKey,
+		GroupType:    req.GroupType,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if req.GroupRounds > 0 {
+		exercise.GroupRounds = int(math.Max(1, math.Round(float64(req.GroupRounds)*phase.VolumeFactor)))
+	}
+
+	for _, setReq := range phaseSets(req.Sets, phase.VolumeFactor) {
+		exercise.Sets = append(exercise.Sets, models.ExerciseSet{
+			ID:         uuid.New().String(),
+			ExerciseID: exercise.ID,
+			Reps:       setReq.Reps,
+			Weight:     roundToIncrement(setReq.Weight*phase.IntensityFactor, 0.5),
+			Duration:   setReq.Duration,
+			Distance:   setReq.Distance,
+			RestTime:   setReq.RestTime,
+			Order:      setReq.Order,
+			SetType:    setReq.SetType,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		})
+	}
+
+	return exercise
+}
+
+// phaseSets 按容量系数调整组数：热身组保留，其余组按系数增减（至少一组）
+// 减少时去掉靠后的组；增加时在末尾重复最后一个正式组（没有时重复最后一组）
+func phaseSets(sets []models.CreateSetRequest, volumeFactor float64) []models.CreateSetRequest {
+	work, order := 0, 0
+	var repeat *models.CreateSetRequest
+	for i, set := range sets {
+		order = max(order, set.Order)
+		switch setType, _ := normalizeSetType(set.SetType); setType {
+		case models.SetTypeWarmup:
+			continue
+		case models.SetTypeDrop:
+			if repeat == nil {
+				repeat = &sets[i]
+			}
+		default:
+			repeat = &sets[i]
+		}
+		work++
+	}
+	if work == 0 {
+		return sets
+	}
+
+	target := int(math.Max(1, math.Round(float64(work)*volumeFactor)))
+	result := make([]models.CreateSetRequest, 0, len(sets)+max(target-work, 0))
+	kept := 0
+	for _, set := range sets {
+		if setType, _ := normalizeSetType(set.SetType); setType != models.SetTypeWarmup {
+			if kept == target {
+				continue
+			}
+			kept++
+		}
+		result = append(result, set)
+	}
+	for ; kept < target; kept++ {
+		order++
+		extra := *repeat
+		extra.Order = order
+		result = append(result, extra)
+	}
+	return result
+}
+
+// clonePlan 深拷贝训练计划（动作和组数使用新的ID）
+func clonePlan(plan models.TrainingPlan, date time.Time) models.TrainingPlan {
+	clone := plan
+	clone.ID = uuid.New().String()
+	clone.Date = date
+	clone.CreatedAt = time.Now()
+	clone.UpdatedAt = time.Now()
+	clone.Exercises = nil
+
+	for _, exercise := range plan.Exercises {
+		exerciseClone := exercise
+		exerciseClone.ID = uuid.New().String()
+		exerciseClone.PlanID = clone.ID
+		exerciseClone.CreatedAt = time.Now()
+		exerciseClone.UpdatedAt = time.Now()
+		exerciseClone.SubstitutedWith = ""
+		exerciseClone.Sets = nil
+
+		for _, set := range exercise.Sets {
+			setClone := set
+			setClone.ID = uuid.New().String()
+			setClone.ExerciseID = exerciseClone.ID
+			setClone.Completed = false
+			setClone.CompletedAt = nil
+			setClone.RecordID = ""
+			setClone.TargetReps, setClone.TargetWeight = 0, 0
+			setClone.TargetDuration, setClone.TargetDistance = 0, 0
+			setClone.Extra = false
+			setClone.CreatedAt = time.Now()
+			setClone.UpdatedAt = time.Now()
+			exerciseClone.Sets = append(exerciseClone.Sets, setClone)
+		}
+
+		clone.Exercises = append(clone.Exercises, exerciseClone)
+	}
+
+	return clone
+}
+
+// reflowDates 顺延排期：每个训练占用下一个训练日，末尾按周节奏追加一个新的训练日
+func reflowDates(slots []time.Time, daysPerWeek int) []time.Time {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	var next time.Time
+	switch {
+	case daysPerWeek > 0 && len(slots) >= daysPerWeek:
+		next = slots[len(slots)-daysPerWeek].AddDate(0, 0, 7)
+	default:
+		next = slots[len(slots)-1].AddDate(0, 0, 1)
+	}
+
+	return append(append([]time.Time{}, slots[1:]...), next)
+}
+
+// programDaysPerWeek 训练周期最后一个阶段的每周训练天数
+func programDaysPerWeek(program *models.TrainingProgram) int {
+	days := 0
+	for _, phase := range program.Phases {
+		if len(phase.Days) > 0 {
+			days = len(phase.Days)
+		}
+	}
+	return days
+}
+
+// programProgress 根据已排期的训练计划计算进度
+func programProgress(program *models.TrainingProgram, plans []models.TrainingPlan, now time.Time) *models.ProgramProgressResponse {
+	progress := &models.ProgramProgressResponse{
+		ProgramID:     program.ID,
+		Name:          program.Name,
+		Status:        program.Status,
+		TotalWeeks:    program.TotalWeeks,
+		TotalWorkouts: len(plans),
+		EndDate:       program.StartDate.AddDate(0, 0, 7*program.TotalWeeks-1),
+	}
+
+	for i := range plans {
+		switch plans[i].Status {
+		case "completed":
+			progress.CompletedCount++
+		case "skipped":
+			progress.SkippedCount++
+		default:
+			progress.PendingCount++
+			if progress.NextWorkoutDate == nil {
+				progress.NextWorkoutDate = &plans[i].Date
+			}
+		}
+		if plans[i].Date.After(progress.EndDate) {
+			progress.EndDate = plans[i].Date
+		}
+	}
+
+	// 跳过的训练会被顺延重新排期，不计入应完成总数
+	if scheduled := progress.TotalWorkouts - progress.SkippedCount; scheduled > 0 {
+		progress.ProgressPercent = math.Round(float64(progress.CompletedCount)/float64(scheduled)*1000) / 10
+	}
+
+	elapsedDays := int(now.Sub(program.StartDate).Hours() / 24)
+	switch {
+	case elapsedDays < 0:
+		progress.CurrentWeek = 0
+	default:
+		progress.CurrentWeek = elapsedDays/7 + 1
+	}
+	if progress.CurrentWeek > program.TotalWeeks {
+		progress.CurrentWeek = program.TotalWeeks
+	}
+
+	week := 0
+	for _, phase := range program.Phases {
+		week += phase.Weeks
+		if progress.CurrentWeek <= week {
+			progress.CurrentPhase = phase.Name
+			progress.IsDeloadWeek = phase.IsDeload
+			break
+		}
+	}
+
+	return progress
+}
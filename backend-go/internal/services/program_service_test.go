@@ -0,0 +1,153 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testProgram() *models.TrainingProgram {
+	squat := models.CreateExerciseRequest{
+		Name: "深蹲",
+		Sets: []models.CreateSetRequest{
+			{Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}, {Reps: 5, Weight: 100}, {Reps: 5, Weight: 100},
+		},
+	}
+	days := []models.ProgramDayTemplate{
+		{DayOffset: 0, Name: "A", Exercises: []models.CreateExerciseRequest{squat}},
+		{DayOffset: 2, Name: "B", Exercises: []models.CreateExerciseRequest{squat}},
+		{DayOffset: 4, Name: "C", Exercises: []models.CreateExerciseRequest{squat}},
+	}
+
+	return &models.TrainingProgram{
+		ID:         "program-1",
+		Name:       "增肌",
+		StartDate:  time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		TotalWeeks: 4,
+		Status:     ProgramStatusActive,
+		Phases: []models.ProgramPhase{
+			{Name: "积累期", Weeks: 3, IntensityFactor: 1, VolumeFactor: 1, Days: days},
+			{Name: "减载周", Weeks: 1, IsDeload: true, IntensityFactor: 0.6, VolumeFactor: 0.5, Days: days},
+		},
+	}
+}
+
+func TestBuildProgramPlans(t *testing.T) {
+	program := testProgram()
+	plans := buildProgramPlans("user-1", program)
+
+	assert.Len(t, plans, 12)
+	assert.Equal(t, program.StartDate, plans[0].Date)
+	assert.Equal(t, program.StartDate.AddDate(0, 0, 2), plans[1].Date)
+	assert.Equal(t, 1, plans[0].ProgramWeek)
+
+	// 减载周：重量 60%，组数减半
+	deload := plans[9]
+	assert.Equal(t, 4, deload.ProgramWeek)
+	assert.Equal(t, program.StartDate.AddDate(0, 0, 21), deload.Date)
+	assert.Len(t, deload.Exercises[0].Sets, 2)
+	assert.Equal(t, 60.0, deload.Exercises[0].Sets[0].Weight)
+
+	normal := plans[0]
+	assert.Len(t, normal.Exercises[0].Sets, 4)
+	assert.Equal(t, 100.0, normal.Exercises[0].Sets[0].Weight)
+	assert.Equal(t, normal.ID, normal.Exercises[0].PlanID)
+	assert.Equal(t, program.ID, normal.ProgramID)
+}
+
+func TestPhaseSets(t *testing.T) {
+	sets := []models.CreateSetRequest{
+		{Reps: 10, Weight: 40, Order: 1, SetType: "warmup"},
+		{Reps: 8, Weight: 80, Order: 2},
+		{Reps: 8, Weight: 80, Order: 3},
+		{Reps: 12, Weight: 60, Order: 4, SetType: "drop"},
+	}
+	orders := func(sets []models.CreateSetRequest) []int {
+		var result []int
+		for _, set := range sets {
+			result = append(result, set.Order)
+		}
+		return result
+	}
+
+	// 减少时保留热身组，去掉靠后的组
+	assert.Equal(t, []int{1, 2, 3}, orders(phaseSets(sets, 0.6)))
+	assert.Equal(t, []int{1, 2}, orders(phaseSets(sets, 0.1)))
+	assert.Equal(t, sets, phaseSets(sets, 1))
+
+	// 增加时重复最后一个正式组，不重复递减组
+	more := phaseSets(sets, 1.5)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, orders(more))
+	assert.Equal(t, 80.0, more[5].Weight)
+	assert.Equal(t, "", more[5].SetType)
+}
+
+func TestPhaseExerciseKeepsStructure(t *testing.T) {
+	req := models.CreateExerciseRequest{
+		Name:        "卧推",
+		GroupKey:    "A",
+		GroupType:   "superset",
+		GroupRounds: 4,
+		Sets:        []models.CreateSetRequest{{Reps: 10, Weight: 40, SetType: "warmup"}, {Reps: 8, Weight: 80}, {Reps: 8, Weight: 80}, {Reps: 8, Weight: 80}, {Reps: 8, Weight: 80}},
+	}
+	exercise := phaseExercise("plan-1", req, models.ProgramPhase{IntensityFactor: 0.6, VolumeFactor: 0.5})
+
+	assert.Equal(t, "A", exercise.GroupKey)
+	assert.Equal(t, "superset", exercise.GroupType)
+	assert.Equal(t, 2, exercise.GroupRounds)
+	assert.Len(t, exercise.Sets, 3)
+	assert.Equal(t, "warmup", exercise.Sets[0].SetType)
+	assert.Equal(t, 48.0, exercise.Sets[1].Weight)
+}
+
+func TestClonePlan(t *testing.T) {
+	plans := buildProgramPlans("user-1", testProgram())
+	plans[0].Exercises[0].Sets[0].Completed = true
+
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	clone := clonePlan(plans[0], date)
+
+	assert.NotEqual(t, plans[0].ID, clone.ID)
+	assert.Equal(t, date, clone.Date)
+	assert.Equal(t, clone.ID, clone.Exercises[0].PlanID)
+	assert.NotEqual(t, plans[0].Exercises[0].ID, clone.Exercises[0].ID)
+	assert.Equal(t, clone.Exercises[0].ID, clone.Exercises[0].Sets[0].ExerciseID)
+	assert.False(t, clone.Exercises[0].Sets[0].Completed)
+	assert.True(t, plans[0].Exercises[0].Sets[0].Completed)
+}
+
+func TestReflowDates(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	// 周一/三/五训练，跳过周一：其余训练各顺延一个训练日，末尾按周节奏追加下周一
+	slots := []time.Time{day(4), day(6), day(8), day(11), day(13), day(15)}
+	assert.Equal(t, []time.Time{day(6), day(8), day(11), day(13), day(15), day(18)}, reflowDates(slots, 3))
+
+	// 剩余训练不足一周时顺延到最后一个训练的次日
+	assert.Equal(t, []time.Time{day(15), day(16)}, reflowDates([]time.Time{day(13), day(15)}, 3))
+
+	assert.Nil(t, reflowDates(nil, 3))
+}
+
+func TestProgramProgress(t *testing.T) {
+	program := testProgram()
+	plans := buildProgramPlans("user-1", program)
+	plans[0].Status = "completed"
+	plans[1].Status = "skipped"
+	plans[2].Status = "completed"
+
+	progress := programProgress(program, plans, program.StartDate.AddDate(0, 0, 22))
+
+	assert.Equal(t, 12, progress.TotalWorkouts)
+	assert.Equal(t, 2, progress.CompletedCount)
+	assert.Equal(t, 1, progress.SkippedCount)
+	assert.Equal(t, 9, progress.PendingCount)
+	assert.Equal(t, 18.2, progress.ProgressPercent)
+	assert.Equal(t, 4, progress.CurrentWeek)
+	assert.Equal(t, "减载周", progress.CurrentPhase)
+	assert.True(t, progress.IsDeloadWeek)
+	assert.Equal(t, plans[3].Date, *progress.NextWorkoutDate)
+}
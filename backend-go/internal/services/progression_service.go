@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 内置默认渐进规则
+const (
+	defaultWeightIncrement = 2.5
+	defaultMinReps         = 8
+	defaultMaxReps         = 12
+	defaultTargetRPE       = 8.0
+	// defaultDurationIncrement 计时组每次增加的秒数
+	defaultDurationIncrement = 5
+)
+
+// difficultyRPE 将动作反馈的难度映射为近似 RPE
+var difficultyRPE = map[string]float64{
+	"too_easy": 6,
+	"easy":     7,
+	"medium":   8,
+	"hard":     9,
+	"too_hard": 10,
+}
+
+// ProgressionService 渐进超负荷服务
+// 根据上一次完成的组数和动作反馈，建议下一次训练的重量和次数
+type ProgressionService struct {
+	db      *gorm.DB
+	catalog *ExerciseCatalogService
+}
+
+// NewProgressionService 创建渐进超负荷服务
+func NewProgressionService(db *gorm.DB, catalog *ExerciseCatalogService) *ProgressionService {
+	return &ProgressionService{db: db, catalog: catalog}
+}
+
+// Suggest 为指定动作生成下一次训练的建议
+// 动作关联了标准动作（catalogID 不为 0，或名称能匹配到标准动作）时按标准动作查找历史，
+// 不同写法的同一动作共用历史；否则按名称查找。模板中的动作不计入历史
+func (s *ProgressionService) Suggest(userID, exerciseName string, catalogID uint) (*models.ProgressionSuggestion, error) {
+	rule := s.GetRule(userID, exerciseName)
+
+	if catalogID == 0 && s.catalog != nil {
+		if match, err := s.catalog.Match(exerciseName); err == nil && match != nil {
+			catalogID = match.Exercise.ID
+		}
+	}
+	query := s.db.Joins("JOIN training_plans ON training_plans.id = training_exercises.plan_id").
+		Where("training_plans.user_id = ? AND training_plans.is_template = ?", userID, false)
+	if catalogID != 0 {
+		query = query.Where("training_exercises.catalog_id = ?", catalogID)
+	} else {
+		query = query.Where("training_exercises.name = ?", exerciseName)
+	}
+
+	var exercise models.TrainingExercise
+	err := query.
+		Where("EXISTS (SELECT 1 FROM exercise_sets WHERE exercise_sets.exercise_id = training_exercises.id AND exercise_sets.completed = ?)", true).
+		Preload("Sets", "completed = ?", true).
+		Order("training_plans.date DESC, training_exercises.updated_at DESC").
+		First(&exercise).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return suggestProgression(exerciseName, rule, nil, nil), nil
+		}
+		logger.Error.Printf("查询动作历史失败: user_id=%v, exercise=%v, error=%v", userID, exerciseName, err.Error())
+		return nil, err
+	}
+
+	var feedback *models.ExerciseFeedback
+	var fb models.ExerciseFeedback
+	if err := s.db.Where("user_id = ? AND exercise_id = ?", userID, exercise.ID).
+		Order("created_at DESC").
+		First(&fb).Error; err == nil {
+		feedback = &fb
+	}
+
+	return suggestProgression(exerciseName, rule, exercise.Sets, feedback), nil
+}
+
+// SuggestMany 批量生成建议，单个动作失败时跳过
+func (s *ProgressionService) SuggestMany(userID string, exerciseNames []string) []models.ProgressionSuggestion {
+	var suggestions []models.ProgressionSuggestion
+	for _, name := range exerciseNames {
+		suggestion, err := s.Suggest(userID, name, 0)
+		if err != nil {
+			continue
+		}
+		suggestions = append(suggestions, *suggestion)
+	}
+	return suggestions
+}
+
+// GetRule 获取生效的渐进规则：动作专属规则 > 用户默认规则 > 内置默认规则
+func (s *ProgressionService) GetRule(userID, exerciseName string) models.ProgressionRule {
+	var rules []models.ProgressionRule
+	s.db.Where("user_id = ? AND (exercise_name = ? OR exercise_name = ?)", userID, exerciseName, "").
+		Find(&rules)
+
+	rule := models.ProgressionRule{UserID: userID, ExerciseName: exerciseName}
+	for _, r := range rules {
+		if r.ExerciseName == exerciseName || rule.Strategy == "" {
+			rule = r
+		}
+	}
+
+	return withRuleDefaults(rule)
+}
+
+// UpdateRule 创建或更新渐进规则
+func (s *ProgressionService) UpdateRule(userID string, req models.UpdateProgressionRuleRequest) (*models.ProgressionRule, error) {
+	if req.MinReps > 0 && req.MaxReps > 0 && req.MinReps > req.MaxReps {
+		return nil, errors.New("最少次数不能大于最多次数")
+	}
+
+	var rule models.ProgressionRule
+	err := s.db.Where("user_id = ? AND exercise_name = ?", userID, req.ExerciseName).First(&rule).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if rule.ID == "" {
+		rule = models.ProgressionRule{
+			ID:           uuid.New().String(),
+			UserID:       userID,
+			ExerciseName: req.ExerciseName,
+			CreatedAt:    time.Now(),
+		}
+	}
+
+	rule.Strategy = req.Strategy
+	rule.WeightIncrement = req.WeightIncrement
+	rule.MinReps = req.MinReps
+	rule.MaxReps = req.MaxReps
+	rule.TargetRPE = req.TargetRPE
+	rule.UpdatedAt = time.Now()
+
+	if err := s.db.Save(&rule).Error; err != nil {
+		logger.Error.Printf("保存渐进规则失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// withRuleDefaults 补全规则中未设置的参数
+func withRuleDefaults(rule models.ProgressionRule) models.ProgressionRule {
+	if rule.Strategy == "" {
+		rule.Strategy = models.ProgressionDouble
+	}
+	if rule.WeightIncrement <= 0 {
+		rule.WeightIncrement = defaultWeightIncrement
+	}
+	if rule.MinReps <= 0 {
+		rule.MinReps = defaultMinReps
+	}
+	if rule.MaxReps < rule.MinReps {
+		rule.MaxReps = rule.MinReps + (defaultMaxReps - defaultMinReps)
+	}
+	if rule.TargetRPE <= 0 {
+		rule.TargetRPE = defaultTargetRPE
+	}
+	return rule
+}
+
+// suggestProgression 根据规则、上一次完成的组和反馈计算建议
+func suggestProgression(name string, rule models.ProgressionRule, sets []models.ExerciseSet, feedback *models.ExerciseFeedback) *models.ProgressionSuggestion {
+	suggestion := &models.ProgressionSuggestion{
+		ExerciseName: name,
+		Strategy:     rule.Strategy,
+	}
+
+	// 工作组：重量最大的那些组，以其中最少的次数作为本次达成次数；热身组和递减组不参与
+	topWeight, lowestReps := 0.0, 0
+	for _, set := range sets {
+		if !set.Completed || setTypeOf(set) == models.SetTypeWarmup || setTypeOf(set) == models.SetTypeDrop {
+			continue
+		}
+		switch {
+		case set.Weight > topWeight:
+			topWeight, lowestReps = set.Weight, set.Reps
+		case set.Weight == topWeight && (lowestReps == 0 || set.Reps < lowestReps):
+			lowestReps = set.Reps
+		}
+	}
+
+	if lowestReps == 0 {
+		if longest := longestTimedSet(sets); longest > 0 {
+			return suggestDurationProgression(suggestion, longest, feedback)
+		}
+		suggestion.SuggestedReps = rule.MinReps
+		suggestion.Reason = "暂无历史记录，从规则的最少次数开始"
+		return suggestion
+	}
+
+	suggestion.HasHistory = true
+	suggestion.LastWeight = topWeight
+	suggestion.LastReps = lowestReps
+	weight, reps := topWeight, lowestReps
+	bodyweight := topWeight == 0
+
+	switch {
+	case bodyweight:
+		// 自重动作只递增次数
+		reps = lowestReps + 1
+		suggestion.Reason = "自重动作，增加 1 次"
+	case rule.Strategy == models.ProgressionLinear:
+		if lowestReps >= rule.MinReps {
+			weight += rule.WeightIncrement
+			suggestion.Reason = fmt.Sprintf("已完成目标 %d 次，增加 %.1fkg", rule.MinReps, rule.WeightIncrement)
+		} else {
+			suggestion.Reason = fmt.Sprintf("未完成目标 %d 次，保持重量", rule.MinReps)
+		}
+		reps = rule.MinReps
+	case rule.Strategy == models.ProgressionRPE:
+		rpe, ok := feedbackRPE(feedback)
+		if !ok {
+			suggestion.Reason = "缺少难度反馈，保持重量和次数"
+			break
+		}
+		// 每偏离目标 RPE 1 点，调整约 2.5% 的重量
+		weight = topWeight * (1 + 0.025*(rule.TargetRPE-rpe))
+		suggestion.Reason = fmt.Sprintf("上次 RPE 约 %.0f，目标 RPE %.0f", rpe, rule.TargetRPE)
+	default:
+		if lowestReps >= rule.MaxReps {
+			weight += rule.WeightIncrement
+			reps = rule.MinReps
+			suggestion.Reason = fmt.Sprintf("所有组达到 %d 次上限，增加 %.1fkg 并回到 %d 次", rule.MaxReps, rule.WeightIncrement, rule.MinReps)
+		} else {
+			reps = int(math.Min(float64(lowestReps+1), float64(rule.MaxReps)))
+			if reps < rule.MinReps {
+				reps = rule.MinReps
+			}
+			suggestion.Reason = "保持重量，增加次数"
+		}
+	}
+
+	// 反馈修正：疼痛优先于难度
+	if feedback != nil {
+		switch {
+		case feedback.PainLevel >= 7:
+			weight = topWeight * 0.9
+			reps = lowestReps
+			suggestion.Reason = fmt.Sprintf("上次疼痛等级 %d，降低 10%% 重量", feedback.PainLevel)
+		case feedback.PainLevel >= 4:
+			weight = math.Min(weight, topWeight)
+			reps = int(math.Min(float64(reps), float64(lowestReps)))
+			suggestion.Reason = fmt.Sprintf("上次疼痛等级 %d，暂不增加负荷", feedback.PainLevel)
+		case feedback.Difficulty == "too_hard" && rule.Strategy != models.ProgressionRPE:
+			weight = math.Min(weight, topWeight)
+			reps = int(math.Min(float64(reps), float64(lowestReps)))
+			suggestion.Reason = "上次反馈过难，保持负荷"
+		case feedback.Difficulty == "too_easy" && rule.Strategy != models.ProgressionRPE && !bodyweight && weight == topWeight:
+			weight += rule.WeightIncrement
+			suggestion.Reason = "上次反馈过于轻松，增加重量"
+		}
+	}
+
+	if !bodyweight && weight != topWeight {
+		weight = roundToIncrement(weight, rule.WeightIncrement)
+	}
+	suggestion.SuggestedWeight = weight
+	suggestion.SuggestedReps = reps
+
+	return suggestion
+}
+
+// longestTimedSet 已完成计时组中的最长时长（秒）
+func longestTimedSet(sets []models.ExerciseSet) int {
+	longest := 0
+	for _, set := range sets {
+		if set.Completed && setTypeOf(set) == models.SetTypeTimed && set.Duration > longest {
+			longest = set.Duration
+		}
+	}
+	return longest
+}
+
+// suggestDurationProgression 计时动作（如平板支撑）递增时长，疼痛或过难时保持
+func suggestDurationProgression(suggestion *models.ProgressionSuggestion, longest int, feedback *models.ExerciseFeedback) *models.ProgressionSuggestion {
+	suggestion.HasHistory = true
+	suggestion.LastDuration = longest
+	suggestion.SuggestedDuration = longest + defaultDurationIncrement
+	suggestion.Reason = fmt.Sprintf("计时动作，增加 %d 秒", defaultDurationIncrement)
+	if feedback != nil {
+		switch {
+		case feedback.PainLevel >= 7:
+			suggestion.SuggestedDuration = int(float64(longest) * 0.9)
+			suggestion.Reason = fmt.Sprintf("上次疼痛等级 %d，缩短 10%% 时长", feedback.PainLevel)
+		case feedback.PainLevel >= 4:
+			suggestion.SuggestedDuration = longest
+			suggestion.Reason = fmt.Sprintf("上次疼痛等级 %d，暂不增加时长", feedback.PainLevel)
+		case feedback.Difficulty == "too_hard":
+			suggestion.SuggestedDuration = longest
+			suggestion.Reason = "上次反馈过难，保持时长"
+		}
+	}
+	return suggestion
+}
+
+// feedbackRPE 从动作反馈推断 RPE
+func feedbackRPE(feedback *models.ExerciseFeedback) (float64, bool) {
+	if feedback == nil {
+		return 0, false
+	}
+	rpe, ok := difficultyRPE[feedback.Difficulty]
+	return rpe, ok
+}
+
+// roundToIncrement 将重量取整到可加载的增量
+func roundToIncrement(weight, increment float64) float64 {
+	if increment <= 0 {
+		increment = 0.5
+	}
+	return math.Round(weight/increment) * increment
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func completedSets(weight float64, reps ...int) []models.ExerciseSet {
+	var sets []models.ExerciseSet
+	for i, r := range reps {
+		sets = append(sets, models.ExerciseSet{Weight: weight, Reps: r, Completed: true, Order: i + 1})
+	}
+	return sets
+}
+
+func TestSuggestProgression(t *testing.T) {
+	double := withRuleDefaults(models.ProgressionRule{Strategy: models.ProgressionDouble})
+	linear := withRuleDefaults(models.ProgressionRule{Strategy: models.ProgressionLinear, MinReps: 5})
+	rpe := withRuleDefaults(models.ProgressionRule{Strategy: models.ProgressionRPE, WeightIncrement: 2.5})
+
+	tests := []struct {
+		name           string
+		rule           models.ProgressionRule
+		sets           []models.ExerciseSet
+		feedback       *models.ExerciseFeedback
+		expectedWeight float64
+		expectedReps   int
+	}{
+		{
+			name:           "无历史记录",
+			rule:           double,
+			expectedWeight: 0,
+			expectedReps:   8,
+		},
+		{
+			name:           "双重递进：未达上限加次数",
+			rule:           double,
+			sets:           completedSets(60, 10, 10, 9),
+			expectedWeight: 60,
+			expectedReps:   10,
+		},
+		{
+			name:           "双重递进：达到上限加重",
+			rule:           double,
+			sets:           completedSets(60, 12, 12, 12),
+			expectedWeight: 62.5,
+			expectedReps:   8,
+		},
+		{
+			name:           "线性：完成目标加重",
+			rule:           linear,
+			sets:           completedSets(100, 5, 5, 5),
+			expectedWeight: 102.5,
+			expectedReps:   5,
+		},
+		{
+			name:           "线性：未完成目标保持",
+			rule:           linear,
+			sets:           completedSets(100, 5, 4, 3),
+			expectedWeight: 100,
+			expectedReps:   5,
+		},
+		{
+			name:           "RPE：过于轻松加重",
+			rule:           rpe,
+			sets:           completedSets(100, 8, 8),
+			feedback:       &models.ExerciseFeedback{Difficulty: "too_easy", PainLevel: 1},
+			expectedWeight: 105,
+			expectedReps:   8,
+		},
+		{
+			name:           "RPE：过难减重",
+			rule:           rpe,
+			sets:           completedSets(100, 8, 8),
+			feedback:       &models.ExerciseFeedback{Difficulty: "too_hard", PainLevel: 1},
+			expectedWeight: 95,
+			expectedReps:   8,
+		},
+		{
+			name:           "高疼痛等级降低负荷",
+			rule:           double,
+			sets:           completedSets(60, 12, 12, 12),
+			feedback:       &models.ExerciseFeedback{Difficulty: "medium", PainLevel: 8},
+			expectedWeight: 55,
+			expectedReps:   12,
+		},
+		{
+			name:           "过难反馈不加重",
+			rule:           double,
+			sets:           completedSets(60, 12, 12, 12),
+			feedback:       &models.ExerciseFeedback{Difficulty: "too_hard", PainLevel: 1},
+			expectedWeight: 60,
+			expectedReps:   8,
+		},
+		{
+			name: "热身组和递减组不计入工作组",
+			rule: double,
+			sets: append(append([]models.ExerciseSet{{Weight: 100, Reps: 3, Completed: true, SetType: models.SetTypeWarmup}},
+				completedSets(60, 12, 12, 12)...),
+				models.ExerciseSet{Weight: 60, Reps: 6, Completed: true, SetType: models.SetTypeDrop}),
+			expectedWeight: 62.5,
+			expectedReps:   8,
+		},
+		{
+			name:           "自重动作递增次数",
+			rule:           double,
+			sets:           completedSets(0, 15, 15, 12),
+			expectedWeight: 0,
+			expectedReps:   13,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestion := suggestProgression("测试动作", tt.rule, tt.sets, tt.feedback)
+			assert.Equal(t, tt.expectedWeight, suggestion.SuggestedWeight)
+			assert.Equal(t, tt.expectedReps, suggestion.SuggestedReps)
+		})
+	}
+}
+
+func TestSuggestTimedProgression(t *testing.T) {
+	rule := withRuleDefaults(models.ProgressionRule{})
+	sets := []models.ExerciseSet{
+		{Duration: 45, Completed: true, SetType: models.SetTypeTimed},
+		{Duration: 60, Completed: true, SetType: models.SetTypeTimed},
+	}
+
+	suggestion := suggestProgression("平板支撑", rule, sets, nil)
+	assert.True(t, suggestion.HasHistory)
+	assert.Equal(t, 60, suggestion.LastDuration)
+	assert.Equal(t, 65, suggestion.SuggestedDuration)
+
+	painful := suggestProgression("平板支撑", rule, sets, &models.ExerciseFeedback{PainLevel: 5})
+	assert.Equal(t, 60, painful.SuggestedDuration)
+}
+
+func TestProgressedTargetFillsMissingValues(t *testing.T) {
+	suggestion := &models.ProgressionSuggestion{HasHistory: true, SuggestedWeight: 62.5, SuggestedReps: 10}
+
+	weight, reps, duration := progressedTarget(models.SetTypeWorking, 0, 0, 0, suggestion)
+	assert.Equal(t, 62.5, weight)
+	assert.Equal(t, 10, reps)
+	assert.Equal(t, 0, duration)
+
+	// 已指定的值保持不变
+	weight, reps, _ = progressedTarget(models.SetTypeWorking, 70, 0, 0, suggestion)
+	assert.Equal(t, 70.0, weight)
+	assert.Equal(t, 10, reps)
+
+	timed := &models.ProgressionSuggestion{HasHistory: true, SuggestedDuration: 65}
+	_, reps, duration = progressedTarget(models.SetTypeTimed, 0, 0, 0, timed)
+	assert.Equal(t, 0, reps)
+	assert.Equal(t, 65, duration)
+	_, reps, _ = progressedTarget(models.SetTypeWorking, 0, 0, 0, timed)
+	assert.Equal(t, 0, reps)
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxEstimateReps 超过该次数的组不用于估算 1RM（高次数下公式误差过大）
+const maxEstimateReps = 12
+
+// recordFormulas 参与 1RM 记录的公式
+var recordFormulas = []string{models.FormulaEpley, models.FormulaBrzycki}
+
+// RecordService 个人记录（PR）服务
+// 在动作组数完成后检测并保存新的个人记录，刷新记录时发布 EventPersonalRecord 事件
+type RecordService struct {
+	db      *gorm.DB
+	events  *EventBus
+	catalog *ExerciseCatalogService
+}
+
+// NewRecordService 创建个人记录服务
+func NewRecordService(db *gorm.DB, events *EventBus, catalog *ExerciseCatalogService) *RecordService {
+	return &RecordService{db: db, events: events, catalog: catalog}
+}
+
+// ProcessExercise 根据动作已完成的组数更新个人记录，返回本次刷新的记录
+func (s *RecordService) ProcessExercise(userID, exerciseID string) ([]models.PersonalRecord, error) {
+	return s.processExercise(userID, exerciseID, time.Now(), true)
+}
+
+// ProcessImported 按导入训练的实际日期更新个人记录，历史数据刷新的记录不发布事件
+func (s *RecordService) ProcessImported(userID, exerciseID string, at time.Time) ([]models.PersonalRecord, error) {
+	return s.processExercise(userID, exerciseID, at, false)
+}
+
+// processExercise 检测并保存动作的个人记录，at 为记录的达成时间，notify 决定是否发布 EventPersonalRecord
+func (s *RecordService) processExercise(userID, exerciseID string, at time.Time, notify bool) ([]models.PersonalRecord, error) {
+	var exercise models.TrainingExercise
+	err := s.db.Where("id = ?", exerciseID).
+		Preload("Sets", "completed = ?", true).
+		First(&exercise).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 已关联标准动作时按标准名称记录，不同写法的同一动作共享 PR
+	exercise.Name = s.catalog.CanonicalName(exercise.CatalogID, exercise.Name)
+
+	var existing []models.PersonalRecord
+	if err := s.db.Where("user_id = ? AND exercise_name = ?", userID, exercise.Name).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	records := detectRecords(bestRecords(existing), exercise, at)
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	var created []models.PersonalRecord
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range records {
+			if records[i].ID == "" {
+				records[i].ID = uuid.New().String()
+				records[i].UserID = userID
+				records[i].CreatedAt = time.Now()
+				created = append(created, records[i])
+			}
+			if err := tx.Save(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error.Printf("保存个人记录失败: user_id=%v, exercise=%v, error=%v", userID, exercise.Name, err.Error())
+		return nil, err
+	}
+
+	for _, record := range created {
+		if !notify {
+			break
+		}
+		s.events.Publish(EventPersonalRecord, models.PersonalRecordEvent{UserID: userID, Record: record})
+	}
+
+	return records, nil
+}
+
+// GetRecords 获取用户每个动作当前的个人记录
+func (s *RecordService) GetRecords(userID, formula string) ([]models.PersonalRecord, error) {
+	formula, err := normalizeFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.PersonalRecord
+	if err := s.db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		logger.Error.Printf("获取个人记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	var records []models.PersonalRecord
+	for _, record := range bestRecords(rows) {
+		if record.Formula != "" && record.Formula != formula {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].ExerciseName != records[j].ExerciseName {
+			return records[i].ExerciseName < records[j].ExerciseName
+		}
+		if records[i].RecordType != records[j].RecordType {
+			return records[i].RecordType < records[j].RecordType
+		}
+		return records[i].Weight < records[j].Weight
+	})
+
+	return records, nil
+}
+
+// GetTimeline 获取某个动作的 PR 时间线（按达成时间升序）
+func (s *RecordService) GetTimeline(userID, exerciseName, formula string) ([]models.PersonalRecord, error) {
+	formula, err := normalizeFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.PersonalRecord
+	if err := s.db.Where("user_id = ? AND exercise_name = ?", userID, exerciseName).
+		Where("formula = ? OR formula = ?", "", formula).
+		Order("achieved_at ASC").
+		Find(&rows).Error; err != nil {
+		logger.Error.Printf("获取PR时间线失败: user_id=%v, exercise=%v, error=%v", userID, exerciseName, err.Error())
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// normalizeFormula 校验 1RM 公式，为空时使用 Epley
+func normalizeFormula(formula string) (string, error) {
+	switch formula {
+	case "":
+		return models.FormulaEpley, nil
+	case models.FormulaEpley, models.FormulaBrzycki:
+		return formula, nil
+	default:
+		return "", fmt.Errorf("不支持的1RM公式: %s", formula)
+	}
+}
+
+// estimateOneRM 按公式估算 1RM，无法估算时返回 false
+func estimateOneRM(formula string, weight float64, reps int) (float64, bool) {
+	if weight <= 0 || reps <= 0 || reps > maxEstimateReps {
+		return 0, false
+	}
+	if reps == 1 {
+		return weight, true
+	}
+
+	var value float64
+	switch formula {
+	case models.FormulaBrzycki:
+		value = weight * 36 / float64(37-reps)
+	default:
+		value = weight * (1 + float64(reps)/30)
+	}
+	return math.Round(value*10) / 10, true
+}
+
+// recordKey 记录的比较维度：类型 + 公式 + 重量（仅 max_reps 按重量区分）
+func recordKey(record models.PersonalRecord) string {
+	weight := 0.0
+	if record.RecordType == models.RecordMaxReps {
+		weight = record.Weight
+	}
+	return fmt.Sprintf("%s|%s|%g", record.RecordType, record.Formula, weight)
+}
+
+// isBetter 判断候选记录是否优于当前记录（配速越小越好，其余越大越好）
+func isBetter(candidate, current models.PersonalRecord) bool {
+	if candidate.RecordType == models.RecordFastestPace {
+		return candidate.Value < current.Value
+	}
+	return candidate.Value > current.Value
+}
+
+// bestRecords 从历史记录中取每个维度的最好成绩
+func bestRecords(rows []models.PersonalRecord) map[string]models.PersonalRecord {
+	bests := make(map[string]models.PersonalRecord)
+	for _, row := range rows {
+		key := recordKey(row)
+		if current, ok := bests[key]; !ok || isBetter(row, current) {
+			bests[key] = row
+		}
+	}
+	return bests
+}
+
+// detectRecords 对比动作已完成的组与当前最好成绩，返回需要保存的记录，热身组不参与
+//
+// 新记录的 ID 为空；若当前最好成绩来自同一组（或同一动作的容量），
+// 说明是重新提交了刚完成的数据，提高时沿用原记录 ID 原地更新，不视为新的 PR。
+func detectRecords(bests map[string]models.PersonalRecord, exercise models.TrainingExercise, at time.Time) []models.PersonalRecord {
+	candidates := make(map[string]models.PersonalRecord)
+	offer := func(candidate models.PersonalRecord) {
+		key := recordKey(candidate)
+		if current, ok := candidates[key]; !ok || isBetter(candidate, current) {
+			candidates[key] = candidate
+		}
+	}
+
+	base := models.PersonalRecord{
+		ExerciseName: exercise.Name,
+		ExerciseID:   exercise.ID,
+		AchievedAt:   at,
+	}
+
+	volume := 0.0
+	for _, set := range exercise.Sets {
+		if !set.Completed || !countsTowardVolume(set) {
+			continue
+		}
+
+		record := base
+		record.SetID = set.ID
+		record.Weight = set.Weight
+		record.Reps = set.Reps
+		record.Duration = set.Duration
+		record.Distance = set.Distance
+
+		if set.Weight > 0 && set.Reps > 0 {
+			volume += set.Weight * float64(set.Reps)
+
+			maxWeight := record
+			maxWeight.RecordType = models.RecordMaxWeight
+			maxWeight.Value = set.Weight
+			offer(maxWeight)
+
+			maxReps := record
+			maxReps.RecordType = models.RecordMaxReps
+			maxReps.Value = float64(set.Reps)
+			offer(maxReps)
+
+			for _, formula := range recordFormulas {
+				if value, ok := estimateOneRM(formula, set.Weight, set.Reps); ok {
+					oneRM := record
+					oneRM.RecordType = models.RecordEstimated1RM
+					oneRM.Formula = formula
+					oneRM.Value = value
+					offer(oneRM)
+				}
+			}
+		}
+
+		if set.Distance > 0 && set.Duration > 0 {
+			pace := record
+			pace.RecordType = models.RecordFastestPace
+			pace.Value = math.Round(float64(set.Duration) / set.Distance)
+			offer(pace)
+		}
+	}
+
+	if volume > 0 {
+		record := base
+		record.RecordType = models.RecordMaxVolume
+		record.Value = volume
+		offer(record)
+	}
+
+	var records []models.PersonalRecord
+	for key, candidate := range candidates {
+		current, ok := bests[key]
+		sameSource := ok && current.ExerciseID == candidate.ExerciseID &&
+			(candidate.RecordType == models.RecordMaxVolume || current.SetID == candidate.SetID)
+
+		switch {
+		case !ok:
+		case !isBetter(candidate, current):
+			continue
+		case sameSource:
+			candidate.ID = current.ID
+			candidate.UserID = current.UserID
+			candidate.PreviousValue = current.PreviousValue
+			candidate.CreatedAt = current.CreatedAt
+		default:
+			candidate.PreviousValue = current.Value
+		}
+		records = append(records, candidate)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return recordKey(records[i]) < recordKey(records[j]) })
+	return records
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func findRecord(records []models.PersonalRecord, recordType, formula string) *models.PersonalRecord {
+	for i := range records {
+		if records[i].RecordType == recordType && records[i].Formula == formula {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+func TestEstimateOneRM(t *testing.T) {
+	value, ok := estimateOneRM(models.FormulaEpley, 100, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 116.7, value)
+
+	value, ok = estimateOneRM(models.FormulaBrzycki, 100, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 112.5, value)
+
+	value, ok = estimateOneRM(models.FormulaBrzycki, 100, 1)
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, value)
+
+	_, ok = estimateOneRM(models.FormulaEpley, 100, 15)
+	assert.False(t, ok)
+}
+
+func TestDetectRecords(t *testing.T) {
+	at := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	exercise := models.TrainingExercise{
+		ID:   "ex-2",
+		Name: "卧推",
+		Sets: []models.ExerciseSet{
+			{ID: "s1", Weight: 80, Reps: 8, Completed: true},
+			{ID: "s2", Weight: 85, Reps: 5, Completed: true},
+			{ID: "s3", Weight: 90, Reps: 3, Completed: false},
+		},
+	}
+
+	t.Run("首次记录", func(t *testing.T) {
+		records := detectRecords(nil, exercise, at)
+
+		maxWeight := findRecord(records, models.RecordMaxWeight, "")
+		assert.Equal(t, 85.0, maxWeight.Value)
+		assert.Equal(t, "s2", maxWeight.SetID)
+		assert.Empty(t, maxWeight.ID)
+
+		volume := findRecord(records, models.RecordMaxVolume, "")
+		assert.Equal(t, 80.0*8+85*5, volume.Value)
+
+		epley := findRecord(records, models.RecordEstimated1RM, models.FormulaEpley)
+		assert.Equal(t, 101.3, epley.Value)
+		assert.NotNil(t, findRecord(records, models.RecordEstimated1RM, models.FormulaBrzycki))
+
+		// 每个重量各有一条次数记录
+		reps := 0
+		for _, r := range records {
+			if r.RecordType == models.RecordMaxReps {
+				reps++
+			}
+		}
+		assert.Equal(t, 2, reps)
+	})
+
+	t.Run("只有超过历史最好才记录", func(t *testing.T) {
+		bests := bestRecords([]models.PersonalRecord{
+			{ID: "r1", ExerciseID: "ex-1", SetID: "old", RecordType: models.RecordMaxWeight, Value: 90},
+			{ID: "r2", ExerciseID: "ex-1", SetID: "old", RecordType: models.RecordMaxWeight, Value: 80},
+			{ID: "r3", ExerciseID: "ex-1", RecordType: models.RecordMaxVolume, Value: 1000},
+		})
+		records := detectRecords(bests, exercise, at)
+
+		assert.Nil(t, findRecord(records, models.RecordMaxWeight, ""))
+		volume := findRecord(records, models.RecordMaxVolume, "")
+		assert.Equal(t, 1000.0, volume.PreviousValue)
+		assert.Empty(t, volume.ID)
+	})
+
+	t.Run("同一组重新提交时原地更新", func(t *testing.T) {
+		bests := bestRecords([]models.PersonalRecord{
+			{ID: "r1", ExerciseID: "ex-2", SetID: "s2", RecordType: models.RecordMaxWeight, Value: 82.5, PreviousValue: 80},
+		})
+		records := detectRecords(bests, exercise, at)
+
+		maxWeight := findRecord(records, models.RecordMaxWeight, "")
+		assert.Equal(t, "r1", maxWeight.ID)
+		assert.Equal(t, 80.0, maxWeight.PreviousValue)
+		assert.Equal(t, 85.0, maxWeight.Value)
+	})
+
+	t.Run("热身组不计入记录", func(t *testing.T) {
+		warmup := exercise
+		warmup.Sets = append([]models.ExerciseSet{{ID: "w1", Weight: 100, Reps: 1, Completed: true, SetType: models.SetTypeWarmup}}, exercise.Sets...)
+		records := detectRecords(nil, warmup, at)
+
+		assert.Equal(t, 85.0, findRecord(records, models.RecordMaxWeight, "").Value)
+		assert.Equal(t, 80.0*8+85*5, findRecord(records, models.RecordMaxVolume, "").Value)
+	})
+
+	t.Run("有氧配速", func(t *testing.T) {
+		run := models.TrainingExercise{
+			ID:   "run-1",
+			Name: "跑步",
+			Sets: []models.ExerciseSet{
+				{ID: "r1", Distance: 5, Duration: 1500, Completed: true},
+				{ID: "r2", Distance: 2, Duration: 560, Completed: true},
+			},
+		}
+		bests := bestRecords([]models.PersonalRecord{
+			{ID: "p1", ExerciseID: "run-0", RecordType: models.RecordFastestPace, Value: 290},
+		})
+		records := detectRecords(bests, run, at)
+
+		assert.Len(t, records, 1)
+		assert.Equal(t, 280.0, records[0].Value)
+		assert.Equal(t, 290.0, records[0].PreviousValue)
+	})
+}
+
+func TestEventBusPublish(t *testing.T) {
+	logger.Init("error")
+	bus := NewEventBus()
+	var received []models.PersonalRecordEvent
+
+	bus.Subscribe(EventPersonalRecord, func(payload interface{}) { panic("boom") })
+	bus.Subscribe(EventPersonalRecord, func(payload interface{}) {
+		received = append(received, payload.(models.PersonalRecordEvent))
+	})
+
+	bus.Publish(EventPersonalRecord, models.PersonalRecordEvent{UserID: "u1"})
+	bus.Publish("other", nil)
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, "u1", received[0].UserID)
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 设备通过 WebSocket 操作计时器的消息类型
+const (
+	restTimerActionExtend = "rest_timer_extend" // data: {"seconds": 30}
+	restTimerActionSkip   = "rest_timer_skip"
+	restTimerActionEnd    = "rest_timer_end"
+	restTimerActionSync   = "rest_timer_sync" // 设备重连后请求当前状态
+)
+
+const (
+	defaultRestSeconds = 90
+	maxRestSeconds     = 30 * 60
+)
+
+// ErrNoRestTimer 当前没有进行中的休息
+var ErrNoRestTimer = errors.New("当前没有进行中的休息")
+
+// RestNotifier 向用户的所有设备推送消息，由 WebSocketService 实现
+type RestNotifier interface {
+	SendToUser(userID string, message interface{})
+}
+
+// RestTimerService 组间休息计时器
+// 计时器由服务端倒计时，每秒向用户所有设备推送剩余时间，任一设备都可以延长、跳过或结束
+type RestTimerService struct {
+	db       *gorm.DB
+	notifier RestNotifier
+	mu       sync.Mutex
+	timers   map[string]*models.RestTimer // userID -> 进行中的计时器
+}
+
+// NewRestTimerService 创建组间休息计时器服务，完成一组后自动开始休息
+func NewRestTimerService(db *gorm.DB, ws *WebSocketService, events *EventBus) *RestTimerService {
+	s := &RestTimerService{
+		db:       db,
+		notifier: ws,
+		timers:   make(map[string]*models.RestTimer),
+	}
+
+	events.Subscribe(EventSetCompleted, s.onSetCompleted)
+
+	ws.RegisterHandler(restTimerActionExtend, func(msg *WebSocketMessage) {
+		seconds, _ := msg.Data["seconds"].(float64)
+		if _, err := s.Extend(msg.UserID, int(seconds)); err != nil {
+			logger.Warn.Printf("延长休息失败: user_id=%v, error=%v", msg.UserID, err.Error())
+		}
+	})
+	ws.RegisterHandler(restTimerActionSkip, func(msg *WebSocketMessage) {
+		if _, err := s.Skip(msg.UserID); err != nil {
+			logger.Warn.Printf("跳过休息失败: user_id=%v, error=%v", msg.UserID, err.Error())
+		}
+	})
+	ws.RegisterHandler(restTimerActionEnd, func(msg *WebSocketMessage) {
+		if _, err := s.End(msg.UserID); err != nil {
+			logger.Warn.Printf("结束休息失败: user_id=%v, error=%v", msg.UserID, err.Error())
+		}
+	})
+	ws.RegisterHandler(restTimerActionSync, func(msg *WebSocketMessage) {
+		if state := s.Current(msg.UserID); state != nil {
+			s.push(models.RestTimerEventUpdated, *state)
+		}
+	})
+
+	return s
+}
+
+// Start 手动开始休息，指定组时默认使用该组的休息时间，新的计时器会替换进行中的计时器
+func (s *RestTimerService) Start(userID string, req models.StartRestTimerRequest) (*models.RestTimerState, error) {
+	seconds := req.Duration
+	exerciseID := ""
+	if req.SetID != "" {
+		var set models.ExerciseSet
+		err := s.db.Joins("JOIN training_exercises ON training_exercises.id = exercise_sets.exercise_id").
+			Joins("JOIN training_plans ON training_plans.id = training_exercises.plan_id").
+			Where("exercise_sets.id = ? AND training_plans.user_id = ?", req.SetID, userID).
+			First(&set).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("组不存在或无权操作")
+			}
+			logger.Error.Printf("查询组数失败: set_id=%v, error=%v", req.SetID, err.Error())
+			return nil, err
+		}
+		exerciseID = set.ExerciseID
+		if seconds <= 0 {
+			seconds = set.RestTime
+		}
+	}
+	if seconds <= 0 {
+		seconds = defaultRestSeconds
+	}
+
+	return s.start(models.RestTimer{
+		UserID:     userID,
+		ExerciseID: exerciseID,
+		SetID:      req.SetID,
+	}, seconds, time.Now())
+}
+
+// Extend 延长休息，seconds 为负数时缩短，剩余时间不会小于 0
+func (s *RestTimerService) Extend(userID string, seconds int) (*models.RestTimerState, error) {
+	now := time.Now()
+	s.mu.Lock()
+	timer, ok := s.timers[userID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrNoRestTimer
+	}
+	extendRestTimer(timer, seconds, now)
+	state := restTimerState(*timer, now)
+	s.mu.Unlock()
+
+	s.save(state.Timer)
+	s.push(models.RestTimerEventUpdated, state)
+	return &state, nil
+}
+
+// Skip 提前跳过休息
+func (s *RestTimerService) Skip(userID string) (*models.RestTimerState, error) {
+	return s.stop(userID, models.RestTimerSkipped, time.Now())
+}
+
+// End 结束休息
+func (s *RestTimerService) End(userID string) (*models.RestTimerState, error) {
+	return s.stop(userID, models.RestTimerEnded, time.Now())
+}
+
+// Current 进行中的计时器，没有时返回 nil
+func (s *RestTimerService) Current(userID string) *models.RestTimerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timer, ok := s.timers[userID]
+	if !ok {
+		return nil
+	}
+	state := restTimerState(*timer, time.Now())
+	return &state
+}
+
+// StartTicker 恢复重启前未结束的计时器，并启动后台任务按 interval 推送剩余时间，ctx 取消后停止
+func (s *RestTimerService) StartTicker(ctx context.Context, interval time.Duration) {
+	s.restore(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.tick(now)
+			}
+		}
+	}()
+}
+
+// onSetCompleted 完成一组后按该组的休息时间开始休息
+func (s *RestTimerService) onSetCompleted(payload interface{}) {
+	event, ok := payload.(models.SetCompletedEvent)
+	if !ok || event.RestTime <= 0 {
+		return
+	}
+
+	_, err := s.start(models.RestTimer{
+		UserID:     event.UserID,
+		RecordID:   event.RecordID,
+		ExerciseID: event.ExerciseID,
+		SetID:      event.SetID,
+	}, event.RestTime, time.Now())
+	if err != nil {
+		logger.Error.Printf("开始组间休息失败: user_id=%v, set_id=%v, error=%v", event.UserID, event.SetID, err.Error())
+	}
+}
+
+// start 保存并推送新的计时器，替换进行中的计时器
+func (s *RestTimerService) start(timer models.RestTimer, seconds int, now time.Time) (*models.RestTimerState, error) {
+	timer = newRestTimer(timer, seconds, now)
+	if err := s.db.Create(&timer).Error; err != nil {
+		logger.Error.Printf("保存组间休息失败: user_id=%v, error=%v", timer.UserID, err.Error())
+		return nil, err
+	}
+
+	s.mu.Lock()
+	previous, replaced := s.timers[timer.UserID]
+	running := timer
+	s.timers[timer.UserID] = &running
+	s.mu.Unlock()
+
+	if replaced {
+		stopRestTimer(previous, models.RestTimerEnded, now)
+		s.save(*previous)
+	}
+
+	state := restTimerState(timer, now)
+	s.push(models.RestTimerEventStarted, state)
+	return &state, nil
+}
+
+// stop 结束进行中的计时器并通知所有设备
+func (s *RestTimerService) stop(userID, status string, now time.Time) (*models.RestTimerState, error) {
+	s.mu.Lock()
+	timer, ok := s.timers[userID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrNoRestTimer
+	}
+	delete(s.timers, userID)
+	s.mu.Unlock()
+
+	stopRestTimer(timer, status, now)
+	state := restTimerState(*timer, now)
+	s.save(state.Timer)
+	s.push(models.RestTimerEventFinished, state)
+	return &state, nil
+}
+
+// tick 推送所有进行中计时器的剩余时间，倒计时结束的计时器推送结束事件
+func (s *RestTimerService) tick(now time.Time) {
+	var ticking, finished []models.RestTimerState
+
+	s.mu.Lock()
+	for userID, timer := range s.timers {
+		if restRemaining(*timer, now) > 0 {
+			ticking = append(ticking, restTimerState(*timer, now))
+			continue
+		}
+		delete(s.timers, userID)
+		stopRestTimer(timer, models.RestTimerFinished, timer.EndsAt)
+		finished = append(finished, restTimerState(*timer, now))
+	}
+	s.mu.Unlock()
+
+	for _, state := range ticking {
+		s.push(models.RestTimerEventTick, state)
+	}
+	for _, state := range finished {
+		s.save(state.Timer)
+		s.push(models.RestTimerEventFinished, state)
+	}
+}
+
+// restore 加载重启前仍在进行的计时器，已过期的直接标记为结束
+func (s *RestTimerService) restore(now time.Time) {
+	var timers []models.RestTimer
+	err := s.db.Where("status = ?", models.RestTimerRunning).Order("started_at ASC").Find(&timers).Error
+	if err != nil {
+		logger.Error.Printf("加载组间休息失败: error=%v", err.Error())
+		return
+	}
+
+	// 同一用户有多个未结束的计时器时只保留最新的一个
+	s.mu.Lock()
+	var expired []models.RestTimer
+	for i := range timers {
+		timer := &timers[i]
+		if restRemaining(*timer, now) <= 0 {
+			stopRestTimer(timer, models.RestTimerFinished, timer.EndsAt)
+			expired = append(expired, *timer)
+			continue
+		}
+		if previous, ok := s.timers[timer.UserID]; ok {
+			stopRestTimer(previous, models.RestTimerEnded, now)
+			expired = append(expired, *previous)
+		}
+		s.timers[timer.UserID] = timer
+	}
+	s.mu.Unlock()
+
+	for _, timer := range expired {
+		s.save(timer)
+	}
+}
+
+// save 保存计时器状态，失败只记录日志，不影响推送
+func (s *RestTimerService) save(timer models.RestTimer) {
+	if err := s.db.Save(&timer).Error; err != nil {
+		logger.Error.Printf("更新组间休息失败: timer_id=%v, error=%v", timer.ID, err.Error())
+	}
+}
+
+// push 向用户的所有设备推送计时器状态
+func (s *RestTimerService) push(event string, state models.RestTimerState) {
+	s.notifier.SendToUser(state.Timer.UserID, WebSocketMessage{
+		Type:      event,
+		UserID:    state.Timer.UserID,
+		Timestamp: state.ServerTime.Unix(),
+		Data: map[string]interface{}{
+			"rest_timer": state,
+		},
+	})
+}
+
+// newRestTimer 创建计时器，时长限制在 1 秒到 30 分钟之间
+func newRestTimer(timer models.RestTimer, seconds int, now time.Time) models.RestTimer {
+	seconds = min(max(seconds, 1), maxRestSeconds)
+	timer.ID = uuid.New().String()
+	timer.Duration = seconds
+	timer.StartedAt = now
+	timer.EndsAt = now.Add(time.Duration(seconds) * time.Second)
+	timer.Status = models.RestTimerRunning
+	timer.CreatedAt = now
+	timer.UpdatedAt = now
+	return timer
+}
+
+// extendRestTimer 调整结束时间，总时长不超过 30 分钟，剩余时间不小于 0
+func extendRestTimer(timer *models.RestTimer, seconds int, now time.Time) {
+	endsAt := timer.EndsAt.Add(time.Duration(seconds) * time.Second)
+	if endsAt.Before(now) {
+		endsAt = now
+	}
+	if limit := timer.StartedAt.Add(maxRestSeconds * time.Second); endsAt.After(limit) {
+		endsAt = limit
+	}
+
+	delta := int(endsAt.Sub(timer.EndsAt).Round(time.Second) / time.Second)
+	timer.EndsAt = endsAt
+	timer.Duration += delta
+	timer.Extended += delta
+	timer.UpdatedAt = now
+}
+
+// stopRestTimer 标记计时器结束
+func stopRestTimer(timer *models.RestTimer, status string, at time.Time) {
+	timer.Status = status
+	timer.EndedAt = &at
+	timer.UpdatedAt = at
+}
+
+// restRemaining 剩余秒数，不足 1 秒按 1 秒计，与设备上的倒计时显示一致
+func restRemaining(timer models.RestTimer, now time.Time) int {
+	if timer.Status != models.RestTimerRunning || !timer.EndsAt.After(now) {
+		return 0
+	}
+	return int((timer.EndsAt.Sub(now) + time.Second - 1) / time.Second)
+}
+
+// restTimerState 计时器在 now 时刻的状态
+func restTimerState(timer models.RestTimer, now time.Time) models.RestTimerState {
+	return models.RestTimerState{
+		Timer:      timer,
+		Remaining:  restRemaining(timer, now),
+		ServerTime: now,
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRestNotifier struct {
+	messages map[string][]WebSocketMessage
+}
+
+func (n *fakeRestNotifier) SendToUser(userID string, message interface{}) {
+	n.messages[userID] = append(n.messages[userID], message.(WebSocketMessage))
+}
+
+func TestNewRestTimer(t *testing.T) {
+	now := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	timer := newRestTimer(models.RestTimer{UserID: "u1", SetID: "s1"}, 90, now)
+
+	assert.NotEmpty(t, timer.ID)
+	assert.Equal(t, "s1", timer.SetID)
+	assert.Equal(t, 90, timer.Duration)
+	assert.Equal(t, now.Add(90*time.Second), timer.EndsAt)
+	assert.Equal(t, models.RestTimerRunning, timer.Status)
+
+	assert.Equal(t, maxRestSeconds, newRestTimer(models.RestTimer{}, 5000, now).Duration)
+	assert.Equal(t, 1, newRestTimer(models.RestTimer{}, -5, now).Duration)
+}
+
+func TestRestRemaining(t *testing.T) {
+	now := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	timer := newRestTimer(models.RestTimer{}, 60, now)
+
+	assert.Equal(t, 60, restRemaining(timer, now))
+	assert.Equal(t, 30, restRemaining(timer, now.Add(30*time.Second)))
+	// 不足 1 秒按 1 秒计
+	assert.Equal(t, 1, restRemaining(timer, now.Add(59500*time.Millisecond)))
+	assert.Equal(t, 0, restRemaining(timer, now.Add(time.Minute)))
+
+	stopRestTimer(&timer, models.RestTimerSkipped, now)
+	assert.Equal(t, 0, restRemaining(timer, now))
+	assert.Equal(t, now, *timer.EndedAt)
+}
+
+func TestExtendRestTimer(t *testing.T) {
+	now := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	timer := newRestTimer(models.RestTimer{}, 60, now)
+
+	extendRestTimer(&timer, 30, now.Add(10*time.Second))
+	assert.Equal(t, 90, timer.Duration)
+	assert.Equal(t, 30, timer.Extended)
+	assert.Equal(t, now.Add(90*time.Second), timer.EndsAt)
+
+	// 缩短时剩余时间不小于 0
+	extendRestTimer(&timer, -120, now.Add(20*time.Second))
+	assert.Equal(t, now.Add(20*time.Second), timer.EndsAt)
+	assert.Equal(t, 20, timer.Duration)
+	assert.Equal(t, -40, timer.Extended)
+
+	// 总时长不超过上限
+	extendRestTimer(&timer, 2*maxRestSeconds, now.Add(20*time.Second))
+	assert.Equal(t, maxRestSeconds, timer.Duration)
+}
+
+func TestRestTimerTick(t *testing.T) {
+	notifier := &fakeRestNotifier{messages: make(map[string][]WebSocketMessage)}
+	now := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	phone := newRestTimer(models.RestTimer{UserID: "u1"}, 60, now)
+	s := &RestTimerService{
+		notifier: notifier,
+		timers:   map[string]*models.RestTimer{"u1": &phone},
+	}
+
+	s.tick(now.Add(15 * time.Second))
+	require.Len(t, notifier.messages["u1"], 1)
+	msg := notifier.messages["u1"][0]
+	assert.Equal(t, models.RestTimerEventTick, msg.Type)
+	state := msg.Data["rest_timer"].(models.RestTimerState)
+	assert.Equal(t, 45, state.Remaining)
+	assert.Equal(t, phone.EndsAt, state.Timer.EndsAt)
+
+	current := s.Current("u1")
+	require.NotNil(t, current)
+	assert.Equal(t, phone.ID, current.Timer.ID)
+	assert.Nil(t, s.Current("u2"))
+}
@@ -0,0 +1,566 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// riskHistoryDays 估算 1RM 和负荷比时回看的天数
+	riskHistoryDays = 42
+	// riskFeedbackDays 疼痛和难度反馈回看的天数
+	riskFeedbackDays = 28
+	// riskAlertCooldown 同一风险信号两次提醒的最小间隔
+	riskAlertCooldown = 7 * 24 * time.Hour
+
+	// painHighLevel 最近一次疼痛达到该等级直接判为高风险
+	painHighLevel = 7
+	// painModerateLevel 最近一次疼痛达到该等级且较此前上升时判为中风险
+	painModerateLevel = 4
+	// painRiseThreshold 最近一次疼痛比此前平均值高出的等级数
+	painRiseThreshold = 2.0
+
+	// fatigueWindow 判断疲劳时统计的最近反馈条数
+	fatigueWindow = 6
+	// fatigueMinFeedbacks 判断疲劳所需的最少反馈条数
+	fatigueMinFeedbacks = 4
+	// fatigueHardRatio 反馈为"困难/过难"的比例达到该值判为疲劳
+	fatigueHardRatio = 0.6
+
+	// regressionBaselineSessions 表现下降与之前多少次训练的最好成绩比较
+	regressionBaselineSessions = 3
+	// regressionModerateDrop 估算 1RM 下降达到该比例判为中风险
+	regressionModerateDrop = 0.10
+	// regressionHighDrop 估算 1RM 下降达到该比例判为高风险
+	regressionHighDrop = 0.20
+
+	// acwrMinHistoryDays 计算急慢性负荷比所需的最短训练历史
+	acwrMinHistoryDays = 21
+	// acwrModerate 急慢性负荷比达到该值判为中风险
+	acwrModerate = 1.3
+	// acwrHigh 急慢性负荷比达到该值判为高风险
+	acwrHigh = 1.5
+
+	// maxSubstitutes 每条信号最多推荐的替换动作数
+	maxSubstitutes = 3
+)
+
+// RiskService 疲劳与受伤风险监测服务
+// 根据疼痛反馈、估算 1RM 变化和急慢性负荷比（ACWR）识别风险，提交反馈或结束训练后在后台检查并提醒
+type RiskService struct {
+	db        *gorm.DB
+	analytics *AnalyticsService
+	catalog   *ExerciseCatalogService
+	messages  *MessageService
+	ws        *WebSocketService
+	checks    *userTasks
+}
+
+// painEntry 一条带肌群信息的动作反馈
+type painEntry struct {
+	At         time.Time
+	Exercise   string
+	Muscles    []string
+	Pain       int
+	Difficulty string
+}
+
+// NewRiskService 创建风险监测服务，并订阅反馈提交和训练结束事件
+func NewRiskService(db *gorm.DB, analytics *AnalyticsService, catalog *ExerciseCatalogService, messages *MessageService, ws *WebSocketService, events *EventBus) *RiskService {
+	s := &RiskService{
+		db:        db,
+		analytics: analytics,
+		catalog:   catalog,
+		messages:  messages,
+		ws:        ws,
+	}
+	// 风险检查需要加载最近 6 周的组数，在后台运行，不阻塞提交反馈和结束训练的请求
+	s.checks = newUserTasks("risk_check", s.Check)
+	events.Subscribe(EventFeedbackSubmitted, func(payload interface{}) {
+		if event, ok := payload.(models.FeedbackSubmittedEvent); ok {
+			s.checks.schedule(event.UserID)
+		}
+	})
+	events.Subscribe(EventWorkoutEnded, func(payload interface{}) {
+		if event, ok := payload.(models.WorkoutEndedEvent); ok {
+			s.checks.schedule(event.UserID)
+		}
+	})
+	return s
+}
+
+// Report 生成用户当前的风险报告
+func (s *RiskService) Report(userID string) (*models.RiskReport, error) {
+	now := time.Now()
+	loc := s.analytics.streakService.UserLocation(userID)
+
+	sets, err := s.analytics.loadSets(userID, now.AddDate(0, 0, -riskHistoryDays))
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := s.catalog.loadCatalog("")
+	if err != nil {
+		logger.Error.Printf("查询标准动作失败: error=%v", err.Error())
+		return nil, err
+	}
+	catalogMap := make(map[uint]models.Exercise, len(catalog))
+	for _, entry := range catalog {
+		catalogMap[entry.ID] = entry
+	}
+
+	entries, err := s.loadFeedback(userID, now.AddDate(0, 0, -riskFeedbackDays), catalogMap)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildRiskReport(userID, sets, entries, now, loc)
+	for i := range report.Flags {
+		flag := &report.Flags[i]
+		switch flag.Type {
+		case models.RiskPainTrend, models.RiskPerformanceDrop:
+			muscles := []string{flag.MuscleGroup}
+			if flag.Exercise != "" {
+				muscles = musclesOf(flag.Exercise, sets, entries)
+			}
+			flag.Substitutes = substituteExercises(catalog, flag.Exercise, muscles)
+		}
+	}
+	return report, nil
+}
+
+// Check 检查风险并为冷却期外的信号发送提醒
+func (s *RiskService) Check(userID string) {
+	report, err := s.Report(userID)
+	if err != nil {
+		logger.Error.Printf("风险检查失败: user_id=%v, error=%v", userID, err.Error())
+		return
+	}
+
+	for _, flag := range report.Flags {
+		claimed, err := s.claimAlert(userID, flag.Key, time.Now())
+		if err != nil {
+			logger.Error.Printf("查询风险提醒冷却期失败: user_id=%v, key=%v, error=%v", userID, flag.Key, err.Error())
+			continue
+		}
+		if claimed {
+			s.alert(userID, flag)
+		}
+	}
+}
+
+// claimAlert 原子地占用一条风险信号的提醒冷却期，冷却期内已提醒过时返回 false
+// 依赖 (user_id, key) 主键的条件 upsert，并发的检查只有一个能占用成功
+func (s *RiskService) claimAlert(userID, key string, now time.Time) (bool, error) {
+	result := s.db.Exec(`INSERT INTO risk_alert_cooldowns (user_id, key, alerted_at) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, key) DO UPDATE SET alerted_at = EXCLUDED.alerted_at
+		WHERE risk_alert_cooldowns.alerted_at < ?`, userID, key, now, now.Add(-riskAlertCooldown))
+	return result.RowsAffected > 0, result.Error
+}
+
+// alert 为一条风险信号创建通知、记录提醒并实时推送
+func (s *RiskService) alert(userID string, flag models.RiskFlag) {
+	content := flag.Message + "，" + flag.Suggestion
+	if len(flag.Substitutes) > 0 {
+		content += fmt.Sprintf("（可替换为: %s）", strings.Join(flag.Substitutes, "、"))
+	}
+
+	notification, err := s.messages.CreateNotification(models.CreateNotificationRequest{
+		UserID:    userID,
+		Type:      models.NotificationTypeInjuryRisk,
+		Title:     "训练风险提醒",
+		Content:   content,
+		ActionURL: "/training/risk-report",
+	})
+	if err != nil {
+		logger.Error.Printf("创建风险提醒失败: user_id=%v, key=%v, error=%v", userID, flag.Key, err.Error())
+		// 释放冷却期，下次检查时重新提醒
+		s.db.Exec("DELETE FROM risk_alert_cooldowns WHERE user_id = ? AND key = ?", userID, flag.Key)
+		return
+	}
+
+	alert := models.RiskAlert{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Key:            flag.Key,
+		Type:           flag.Type,
+		Severity:       flag.Severity,
+		NotificationID: notification.ID,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.db.Create(&alert).Error; err != nil {
+		logger.Error.Printf("保存风险提醒失败: user_id=%v, key=%v, error=%v", userID, flag.Key, err.Error())
+	}
+
+	s.ws.SendNotification(userID, &models.Notification{
+		ID:        notification.ID,
+		UserID:    userID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Content:   notification.Content,
+		ActionURL: notification.ActionURL,
+		CreatedAt: notification.CreatedAt,
+	})
+	logger.Info.Printf("发送风险提醒: user_id=%v, key=%v, severity=%v", userID, flag.Key, flag.Severity)
+}
+
+// loadFeedback 加载用户自 from 起的动作反馈，按时间升序排列
+func (s *RiskService) loadFeedback(userID string, from time.Time, catalog map[uint]models.Exercise) ([]painEntry, error) {
+	var feedbacks []models.ExerciseFeedback
+	err := s.db.Where("user_id = ? AND created_at >= ?", userID, from).
+		Preload("Exercise").
+		Order("created_at ASC").
+		Find(&feedbacks).Error
+	if err != nil {
+		logger.Error.Printf("查询动作反馈失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	entries := make([]painEntry, 0, len(feedbacks))
+	for _, feedback := range feedbacks {
+		name := feedback.Exercise.Name
+		if entry, ok := catalog[feedback.Exercise.CatalogID]; ok {
+			name = entry.Name
+		}
+		primary, _ := exerciseMuscles(feedback.Exercise, catalog)
+		entries = append(entries, painEntry{
+			At:         feedback.CreatedAt,
+			Exercise:   name,
+			Muscles:    primary,
+			Pain:       feedback.PainLevel,
+			Difficulty: feedback.Difficulty,
+		})
+	}
+	return entries, nil
+}
+
+// buildRiskReport 汇总各类风险信号生成报告
+func buildRiskReport(userID string, sets []volumeSet, entries []painEntry, now time.Time, loc *time.Location) *models.RiskReport {
+	acute, chronic, acwr := workloadRatio(sets, now)
+
+	var flags []models.RiskFlag
+	flags = append(flags, painFlags(entries)...)
+	flags = append(flags, fatigueFlags(entries)...)
+	flags = append(flags, regressionFlags(sets, loc)...)
+	if flag := workloadFlag(acwr); flag != nil {
+		flags = append(flags, *flag)
+	}
+	if flags == nil {
+		flags = []models.RiskFlag{}
+	}
+
+	return &models.RiskReport{
+		UserID:      userID,
+		Level:       riskLevel(flags),
+		AcuteLoad:   roundVolume(acute),
+		ChronicLoad: roundVolume(chronic),
+		ACWR:        math.Round(acwr*100) / 100,
+		Flags:       flags,
+		GeneratedAt: now,
+	}
+}
+
+// painFlags 同一动作或同一肌群疼痛上升的信号
+// 最近一次疼痛达到 7 级判为高风险；达到 4 级且比此前平均高出 2 级判为中风险。
+// 肌群维度只在涉及至少两个不同动作时提示，避免与动作维度重复
+func painFlags(entries []painEntry) []models.RiskFlag {
+	var flags []models.RiskFlag
+
+	byExercise := make(map[string][]int)
+	var exercises []string
+	byMuscle := make(map[string][]int)
+	muscleExercises := make(map[string]map[string]bool)
+	var muscles []string
+	for _, entry := range entries {
+		if entry.Pain <= 0 {
+			continue
+		}
+		if _, ok := byExercise[entry.Exercise]; !ok {
+			exercises = append(exercises, entry.Exercise)
+		}
+		byExercise[entry.Exercise] = append(byExercise[entry.Exercise], entry.Pain)
+		for _, muscle := range entry.Muscles {
+			if _, ok := byMuscle[muscle]; !ok {
+				muscles = append(muscles, muscle)
+				muscleExercises[muscle] = make(map[string]bool)
+			}
+			byMuscle[muscle] = append(byMuscle[muscle], entry.Pain)
+			muscleExercises[muscle][entry.Exercise] = true
+		}
+	}
+
+	for _, exercise := range exercises {
+		severity := painSeverity(byExercise[exercise])
+		if severity == "" {
+			continue
+		}
+		levels := byExercise[exercise]
+		flags = append(flags, models.RiskFlag{
+			Key:        models.RiskPainTrend + ":exercise:" + exercise,
+			Type:       models.RiskPainTrend,
+			Severity:   severity,
+			Exercise:   exercise,
+			Message:    fmt.Sprintf("%s 的疼痛等级升至 %d 级", exercise, levels[len(levels)-1]),
+			Suggestion: "建议暂停该动作或降低重量，改做对关节压力更小的替换动作，疼痛持续请咨询医生",
+		})
+	}
+	for _, muscle := range muscles {
+		if len(muscleExercises[muscle]) < 2 {
+			continue
+		}
+		severity := painSeverity(byMuscle[muscle])
+		if severity == "" {
+			continue
+		}
+		levels := byMuscle[muscle]
+		flags = append(flags, models.RiskFlag{
+			Key:         models.RiskPainTrend + ":muscle:" + muscle,
+			Type:        models.RiskPainTrend,
+			Severity:    severity,
+			MuscleGroup: muscle,
+			Message:     fmt.Sprintf("%s 部位多个动作出现疼痛，最近一次为 %d 级", muscle, levels[len(levels)-1]),
+			Suggestion:  "建议该部位安排一周减量训练（重量和组数减少 40%-50%）",
+		})
+	}
+	return flags
+}
+
+// painSeverity 按时间排列的疼痛等级对应的风险等级，无风险时返回空字符串
+func painSeverity(levels []int) string {
+	if len(levels) == 0 {
+		return ""
+	}
+	latest := levels[len(levels)-1]
+	if latest >= painHighLevel {
+		return models.RiskLevelHigh
+	}
+	if latest < painModerateLevel || len(levels) < 2 {
+		return ""
+	}
+	sum := 0
+	for _, level := range levels[:len(levels)-1] {
+		sum += level
+	}
+	mean := float64(sum) / float64(len(levels)-1)
+	if float64(latest)-mean >= painRiseThreshold {
+		return models.RiskLevelModerate
+	}
+	return ""
+}
+
+// fatigueFlags 最近的反馈多为"困难/过难"时的疲劳信号
+func fatigueFlags(entries []painEntry) []models.RiskFlag {
+	recent := entries
+	if len(recent) > fatigueWindow {
+		recent = recent[len(recent)-fatigueWindow:]
+	}
+	if len(recent) < fatigueMinFeedbacks {
+		return nil
+	}
+	hard := 0
+	for _, entry := range recent {
+		if entry.Difficulty == "hard" || entry.Difficulty == "too_hard" {
+			hard++
+		}
+	}
+	if float64(hard)/float64(len(recent)) < fatigueHardRatio {
+		return nil
+	}
+	return []models.RiskFlag{{
+		Key:        models.RiskFatigue,
+		Type:       models.RiskFatigue,
+		Severity:   models.RiskLevelModerate,
+		Message:    fmt.Sprintf("最近 %d 次反馈中有 %d 次感觉过难", len(recent), hard),
+		Suggestion: "建议安排减量周，保证睡眠和营养后再恢复原有强度",
+	}}
+}
+
+// regressionFlags 同一动作估算 1RM 明显下降的信号
+// 每个动作按用户时区的自然日取当天最好的估算 1RM，最近一次与之前 3 次中的最好成绩比较
+func regressionFlags(sets []volumeSet, loc *time.Location) []models.RiskFlag {
+	best := make(map[string]map[time.Time]float64)
+	for _, set := range sets {
+		oneRM, ok := estimateOneRM(models.FormulaEpley, set.Weight, set.Reps)
+		if !ok {
+			continue
+		}
+		day := localDay(set.At, loc)
+		if best[set.Exercise] == nil {
+			best[set.Exercise] = make(map[time.Time]float64)
+		}
+		best[set.Exercise][day] = max(best[set.Exercise][day], oneRM)
+	}
+
+	exercises := make([]string, 0, len(best))
+	for exercise := range best {
+		exercises = append(exercises, exercise)
+	}
+	sort.Strings(exercises)
+
+	var flags []models.RiskFlag
+	for _, exercise := range exercises {
+		days := make([]time.Time, 0, len(best[exercise]))
+		for day := range best[exercise] {
+			days = append(days, day)
+		}
+		if len(days) < 2 {
+			continue
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+		latest := best[exercise][days[len(days)-1]]
+		baseline := 0.0
+		for _, day := range days[max(0, len(days)-1-regressionBaselineSessions) : len(days)-1] {
+			baseline = max(baseline, best[exercise][day])
+		}
+		drop := (baseline - latest) / baseline
+
+		severity := ""
+		switch {
+		case drop >= regressionHighDrop:
+			severity = models.RiskLevelHigh
+		case drop >= regressionModerateDrop:
+			severity = models.RiskLevelModerate
+		default:
+			continue
+		}
+		flags = append(flags, models.RiskFlag{
+			Key:        models.RiskPerformanceDrop + ":exercise:" + exercise,
+			Type:       models.RiskPerformanceDrop,
+			Severity:   severity,
+			Exercise:   exercise,
+			Message:    fmt.Sprintf("%s 的估算 1RM 较近期最好成绩下降 %.0f%%", exercise, drop*100),
+			Suggestion: "可能是疲劳累积，建议该动作减量一周（重量降低 10%-20%）后再逐步恢复",
+		})
+	}
+	return flags
+}
+
+// workloadRatio 急性负荷（最近 7 天训练量）、慢性负荷（最近 28 天平均每周训练量）和两者之比
+// 训练历史不足 21 天时慢性负荷不可靠，负荷比返回 0
+func workloadRatio(sets []volumeSet, now time.Time) (float64, float64, float64) {
+	acuteFrom := now.AddDate(0, 0, -7)
+	chronicFrom := now.AddDate(0, 0, -28)
+	acute, chronic := 0.0, 0.0
+	var earliest time.Time
+	for _, set := range sets {
+		if earliest.IsZero() || set.At.Before(earliest) {
+			earliest = set.At
+		}
+		if set.At.After(now) || set.At.Before(chronicFrom) {
+			continue
+		}
+		volume := set.Weight * float64(set.Reps)
+		chronic += volume
+		if !set.At.Before(acuteFrom) {
+			acute += volume
+		}
+	}
+	chronic /= 4
+	if earliest.IsZero() || now.Sub(earliest) < acwrMinHistoryDays*24*time.Hour || chronic <= 0 {
+		return acute, chronic, 0
+	}
+	return acute, chronic, acute / chronic
+}
+
+// workloadFlag 急慢性负荷比过高的信号
+func workloadFlag(acwr float64) *models.RiskFlag {
+	severity := ""
+	switch {
+	case acwr >= acwrHigh:
+		severity = models.RiskLevelHigh
+	case acwr >= acwrModerate:
+		severity = models.RiskLevelModerate
+	default:
+		return nil
+	}
+	return &models.RiskFlag{
+		Key:        models.RiskWorkloadSpike,
+		Type:       models.RiskWorkloadSpike,
+		Severity:   severity,
+		Message:    fmt.Sprintf("最近 7 天训练量是此前平均水平的 %.1f 倍", acwr),
+		Suggestion: "训练量增长过快，建议本周减量，每周训练量增幅控制在 10% 以内",
+	}
+}
+
+// riskLevel 报告的总体风险等级，取各信号中最高的等级
+func riskLevel(flags []models.RiskFlag) string {
+	level := models.RiskLevelLow
+	for _, flag := range flags {
+		if flag.Severity == models.RiskLevelHigh {
+			return models.RiskLevelHigh
+		}
+		if flag.Severity == models.RiskLevelModerate {
+			level = models.RiskLevelModerate
+		}
+	}
+	return level
+}
+
+// musclesOf 动作的主要肌群，优先取训练记录中的肌群
+func musclesOf(exercise string, sets []volumeSet, entries []painEntry) []string {
+	for _, set := range sets {
+		if set.Exercise == exercise {
+			return set.Primary
+		}
+	}
+	for _, entry := range entries {
+		if entry.Exercise == exercise {
+			return entry.Muscles
+		}
+	}
+	return nil
+}
+
+// substituteExercises 训练相同主要肌群的替换动作，按难度从低到高排列
+func substituteExercises(catalog []models.Exercise, exclude string, muscles []string) []string {
+	if len(muscles) == 0 {
+		return nil
+	}
+	targets := make(map[string]bool, len(muscles))
+	for _, muscle := range muscles {
+		targets[muscle] = true
+	}
+
+	var candidates []models.Exercise
+	for _, entry := range catalog {
+		if entry.Name == exclude {
+			continue
+		}
+		for _, muscle := range entry.PrimaryMuscleGroups {
+			if targets[muscle] {
+				candidates = append(candidates, entry)
+				break
+			}
+		}
+	}
+
+	rank := map[string]int{"初级": 0, "中级": 1, "高级": 2}
+	difficulty := func(entry models.Exercise) int {
+		if r, ok := rank[entry.Difficulty]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if di, dj := difficulty(candidates[i]), difficulty(candidates[j]); di != dj {
+			return di < dj
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	names := make([]string, 0, maxSubstitutes)
+	for _, entry := range candidates[:min(len(candidates), maxSubstitutes)] {
+		names = append(names, entry.Name)
+	}
+	return names
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPainFlagsExerciseTrend(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []painEntry{
+		{At: base, Exercise: "卧推", Muscles: []string{"胸"}, Pain: 2},
+		{At: base.AddDate(0, 0, 3), Exercise: "卧推", Muscles: []string{"胸"}, Pain: 2},
+		{At: base.AddDate(0, 0, 6), Exercise: "卧推", Muscles: []string{"胸"}, Pain: 5},
+	}
+
+	flags := painFlags(entries)
+
+	require.Len(t, flags, 1)
+	assert.Equal(t, "pain_trend:exercise:卧推", flags[0].Key)
+	assert.Equal(t, models.RiskLevelModerate, flags[0].Severity)
+	assert.Equal(t, "卧推", flags[0].Exercise)
+}
+
+func TestPainFlagsStableOrLow(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []painEntry{
+		{At: base, Exercise: "深蹲", Muscles: []string{"腿"}, Pain: 4},
+		{At: base.AddDate(0, 0, 3), Exercise: "深蹲", Muscles: []string{"腿"}, Pain: 5},
+		{At: base.AddDate(0, 0, 6), Exercise: "硬拉", Muscles: []string{"背"}, Pain: 3},
+	}
+
+	assert.Empty(t, painFlags(entries))
+}
+
+func TestPainFlagsHighAndMuscleGroup(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []painEntry{
+		{At: base, Exercise: "推举", Muscles: []string{"肩"}, Pain: 1},
+		{At: base.AddDate(0, 0, 2), Exercise: "侧平举", Muscles: []string{"肩"}, Pain: 2},
+		{At: base.AddDate(0, 0, 4), Exercise: "推举", Muscles: []string{"肩"}, Pain: 8},
+	}
+
+	flags := painFlags(entries)
+
+	require.Len(t, flags, 2)
+	assert.Equal(t, "pain_trend:exercise:推举", flags[0].Key)
+	assert.Equal(t, models.RiskLevelHigh, flags[0].Severity)
+	assert.Equal(t, "pain_trend:muscle:肩", flags[1].Key)
+	assert.Equal(t, "肩", flags[1].MuscleGroup)
+	assert.Equal(t, models.RiskLevelHigh, flags[1].Severity)
+}
+
+func TestFatigueFlags(t *testing.T) {
+	entries := []painEntry{
+		{Difficulty: "medium"},
+		{Difficulty: "hard"},
+		{Difficulty: "too_hard"},
+		{Difficulty: "hard"},
+		{Difficulty: "easy"},
+	}
+	flags := fatigueFlags(entries)
+	require.Len(t, flags, 1)
+	assert.Equal(t, models.RiskFatigue, flags[0].Type)
+
+	assert.Empty(t, fatigueFlags(entries[:3]), "反馈不足时不判断疲劳")
+	assert.Empty(t, fatigueFlags(append(entries, painEntry{Difficulty: "easy"}, painEntry{Difficulty: "medium"})))
+}
+
+func TestRegressionFlags(t *testing.T) {
+	loc := time.UTC
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, loc)
+	sets := []volumeSet{
+		{At: base, Exercise: "卧推", Weight: 100, Reps: 5},
+		{At: base.AddDate(0, 0, 3), Exercise: "卧推", Weight: 100, Reps: 5},
+		{At: base.AddDate(0, 0, 3).Add(time.Minute), Exercise: "卧推", Weight: 60, Reps: 5},
+		{At: base.AddDate(0, 0, 7), Exercise: "卧推", Weight: 85, Reps: 5},
+		{At: base, Exercise: "深蹲", Weight: 100, Reps: 5},
+		{At: base.AddDate(0, 0, 7), Exercise: "深蹲", Weight: 95, Reps: 5},
+	}
+
+	flags := regressionFlags(sets, loc)
+
+	require.Len(t, flags, 1)
+	assert.Equal(t, "performance_drop:exercise:卧推", flags[0].Key)
+	assert.Equal(t, models.RiskLevelModerate, flags[0].Severity)
+
+	sets = append(sets, volumeSet{At: base.AddDate(0, 0, 8), Exercise: "深蹲", Weight: 70, Reps: 5})
+	flags = regressionFlags(sets, loc)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "深蹲", flags[1].Exercise)
+	assert.Equal(t, models.RiskLevelHigh, flags[1].Severity)
+}
+
+func TestWorkloadRatio(t *testing.T) {
+	now := time.Date(2024, 3, 29, 12, 0, 0, 0, time.UTC)
+	var sets []volumeSet
+	for week := 1; week <= 3; week++ {
+		sets = append(sets, volumeSet{At: now.AddDate(0, 0, -7*week-1), Weight: 100, Reps: 10})
+	}
+	sets = append(sets, volumeSet{At: now.AddDate(0, 0, -1), Weight: 100, Reps: 25})
+
+	acute, chronic, acwr := workloadRatio(sets, now)
+	assert.Equal(t, 2500.0, acute)
+	assert.Equal(t, 1375.0, chronic)
+	assert.InDelta(t, 1.82, acwr, 0.01)
+
+	flag := workloadFlag(acwr)
+	require.NotNil(t, flag)
+	assert.Equal(t, models.RiskLevelHigh, flag.Severity)
+	assert.Nil(t, workloadFlag(1.1))
+	assert.Equal(t, models.RiskLevelModerate, workloadFlag(1.35).Severity)
+
+	_, _, acwr = workloadRatio([]volumeSet{sets[0], sets[3]}, now)
+	assert.Zero(t, acwr, "训练历史不足 21 天时不计算负荷比")
+}
+
+func TestRiskLevel(t *testing.T) {
+	assert.Equal(t, models.RiskLevelLow, riskLevel(nil))
+	assert.Equal(t, models.RiskLevelModerate, riskLevel([]models.RiskFlag{{Severity: models.RiskLevelModerate}}))
+	assert.Equal(t, models.RiskLevelHigh, riskLevel([]models.RiskFlag{
+		{Severity: models.RiskLevelModerate},
+		{Severity: models.RiskLevelHigh},
+	}))
+}
+
+func TestSubstituteExercises(t *testing.T) {
+	catalog := []models.Exercise{
+		{Name: "杠铃卧推", Difficulty: "中级", PrimaryMuscleGroups: []string{"胸"}},
+		{Name: "哑铃卧推", Difficulty: "中级", PrimaryMuscleGroups: []string{"胸"}},
+		{Name: "俯卧撑", Difficulty: "初级", PrimaryMuscleGroups: []string{"胸"}},
+		{Name: "双杠臂屈伸", Difficulty: "高级", PrimaryMuscleGroups: []string{"胸", "三头"}},
+		{Name: "器械推胸", Difficulty: "初级", PrimaryMuscleGroups: []string{"胸"}},
+		{Name: "深蹲", Difficulty: "中级", PrimaryMuscleGroups: []string{"腿"}},
+	}
+
+	names := substituteExercises(catalog, "杠铃卧推", []string{"胸"})
+
+	assert.Equal(t, []string{"俯卧撑", "器械推胸", "哑铃卧推"}, names)
+	assert.Empty(t, substituteExercises(catalog, "深蹲", nil))
+}
@@ -0,0 +1,576 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxRoutePoints 一条轨迹最多保存的点数（1 秒一个点约 14 小时）
+	maxRoutePoints = 50000
+	// routePointMargin 允许轨迹点早于训练开始或晚于训练结束的范围
+	routePointMargin = time.Minute
+	// maxRouteSpeed 相邻两点之间超过该速度（米/秒）视为 GPS 漂移，丢弃后一个点
+	maxRouteSpeed = 40.0
+	// maxRouteGap 相邻两点间隔超过该值视为中断（暂停或信号丢失），中断期间的距离和时长都不计入
+	maxRouteGap = 2 * time.Minute
+	// routeElevationThreshold 海拔变化累计超过该值（米）才计入爬升/下降，过滤 GPS 海拔噪声
+	routeElevationThreshold = 3.0
+	// routeMinSplitMeters 最后不足 1 公里的分段短于该值时不单独列出
+	routeMinSplitMeters = 10.0
+	// routePolylinePoints 返回的地图轨迹最多包含的点数
+	routePolylinePoints = 2000
+	// earthRadiusMeters 地球平均半径
+	earthRadiusMeters = 6371008.8
+)
+
+var (
+	// ErrNoRoutePoints 上传的轨迹点都无效
+	ErrNoRoutePoints = errors.New("没有有效的轨迹点")
+	// ErrRouteTooLarge 轨迹点数超过上限
+	ErrRouteTooLarge = fmt.Errorf("一条轨迹最多 %d 个点", maxRoutePoints)
+	// ErrRouteSetNotFound 指定的组不属于该训练的计划
+	ErrRouteSetNotFound = errors.New("训练组不存在或不属于该训练")
+	// ErrInvalidGPX GPX 文件无法解析或没有轨迹点
+	ErrInvalidGPX = errors.New("GPX 文件格式错误或没有轨迹点")
+)
+
+// RouteService GPS 轨迹服务
+// 跑步、骑行等有氧训练的轨迹可以在训练中分批上传，也可以训练后导入 GPX。每个有氧组对应一条轨迹，
+// 每次上传后重新计算距离、用时、爬升和每公里分段，并自动填写对应组的距离和时长。同时为导出提供训练的 GPS 轨迹
+type RouteService struct {
+	db      *gorm.DB
+	records *RecordService
+}
+
+// routeStats 轨迹分析结果
+type routeStats struct {
+	Meters        float64
+	Seconds       float64
+	ElevationGain float64
+	ElevationLoss float64
+	Splits        []models.RouteSplit
+	Points        []models.TrackPoint // 去除漂移点后的轨迹
+}
+
+// gpxFile GPX 文件中导入用到的部分，轨迹结构与导出共用
+type gpxFile struct {
+	Tracks []gpxTrack `xml:"trk"`
+}
+
+// NewRouteService 创建 GPS 轨迹服务
+func NewRouteService(db *gorm.DB, records *RecordService) *RouteService {
+	return &RouteService{db: db, records: records}
+}
+
+// AppendPoints 训练中分批上传轨迹点
+func (s *RouteService) AppendPoints(userID string, req models.AppendRoutePointsRequest) (*models.RouteResponse, error) {
+	return s.append(userID, req.RecordID, req.SetID, req.Points)
+}
+
+// ImportGPX 导入 GPX 文件中的轨迹，多个 trk/trkseg 合并为一条轨迹，没有时间的点被丢弃
+func (s *RouteService) ImportGPX(userID, recordID, setID string, r io.Reader) (*models.RouteResponse, error) {
+	points, err := parseGPX(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.append(userID, recordID, setID, points)
+}
+
+// Routes 获取训练的全部轨迹
+func (s *RouteService) Routes(userID, recordID string) ([]models.RouteResponse, error) {
+	record, err := userWorkoutRecord(s.db, userID, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []models.WorkoutRoute
+	if err := s.db.Where("record_id = ?", record.ID).Order("start_at ASC").Find(&routes).Error; err != nil {
+		logger.Error.Printf("查询 GPS 轨迹失败: record_id=%v, error=%v", record.ID, err.Error())
+		return nil, err
+	}
+
+	responses := make([]models.RouteResponse, 0, len(routes))
+	for _, route := range routes {
+		points, err := decodeRoute(route.StartAt, route.Data, route.PointCount)
+		if err != nil {
+			logger.Error.Printf("解码 GPS 轨迹失败: route_id=%v, error=%v", route.ID, err.Error())
+			continue
+		}
+		responses = append(responses, routeResponse(route, analyzeRoute(points).Points))
+	}
+	return responses, nil
+}
+
+// Tracks 训练记录的 GPS 轨迹，同一训练的多条轨迹按时间合并，供导出 TCX/GPX 使用
+func (s *RouteService) Tracks(recordIDs []string) (map[string][]models.TrackPoint, error) {
+	tracks := make(map[string][]models.TrackPoint)
+	if len(recordIDs) == 0 {
+		return tracks, nil
+	}
+
+	var routes []models.WorkoutRoute
+	if err := s.db.Where("record_id IN ?", recordIDs).Order("start_at ASC").Find(&routes).Error; err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		points, err := decodeRoute(route.StartAt, route.Data, route.PointCount)
+		if err != nil {
+			logger.Error.Printf("解码 GPS 轨迹失败: route_id=%v, error=%v", route.ID, err.Error())
+			continue
+		}
+		tracks[route.RecordID] = append(tracks[route.RecordID], analyzeRoute(points).Points...)
+	}
+	for id := range tracks {
+		track := tracks[id]
+		sort.SliceStable(track, func(i, j int) bool { return track[i].Time.Before(track[j].Time) })
+	}
+	return tracks, nil
+}
+
+// append 把轨迹点合并到对应的轨迹，重新分析并填写组的距离和时长
+// 锁住训练记录后读取、合并和保存轨迹，同一训练并发上传时不会各自新建轨迹或丢失轨迹点
+func (s *RouteService) append(userID, recordID, setID string, points []models.TrackPoint) (*models.RouteResponse, error) {
+	var resp models.RouteResponse
+	var filled *models.ExerciseSet
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := userWorkoutRecord(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, recordID)
+		if err != nil {
+			return err
+		}
+
+		end := time.Now()
+		if record.Status == models.WorkoutStatusCompleted && !record.EndTime.IsZero() {
+			end = record.EndTime
+		}
+		kept, dropped := filterRoutePoints(points, record.StartTime.Add(-routePointMargin), end.Add(routePointMargin))
+		if len(kept) == 0 {
+			return ErrNoRoutePoints
+		}
+
+		route, err := s.route(tx, record, setID)
+		if err != nil {
+			return err
+		}
+		existing, err := decodeRoute(route.StartAt, route.Data, route.PointCount)
+		if err != nil {
+			logger.Error.Printf("解码 GPS 轨迹失败: route_id=%v, error=%v", route.ID, err.Error())
+			return err
+		}
+		merged := mergeTrackPoints(existing, kept)
+		if len(merged) > maxRoutePoints {
+			return ErrRouteTooLarge
+		}
+
+		stats := analyzeRoute(merged)
+		route.StartAt, route.Data = encodeRoute(merged)
+		route.EndAt = merged[len(merged)-1].Time
+		route.PointCount = len(merged)
+		route.Distance = roundTo(stats.Meters/1000, 3)
+		route.Duration = int(math.Round(stats.Seconds))
+		route.ElevationGain = roundTo(stats.ElevationGain, 1)
+		route.ElevationLoss = roundTo(stats.ElevationLoss, 1)
+		route.Splits = stats.Splits
+		route.UpdatedAt = time.Now()
+		if err := tx.Save(route).Error; err != nil {
+			logger.Error.Printf("保存 GPS 轨迹失败: record_id=%v, error=%v", record.ID, err.Error())
+			return err
+		}
+
+		if route.SetID != "" {
+			if filled, err = fillRouteSet(tx, record, route); err != nil {
+				logger.Error.Printf("填写组的距离和时长失败: set_id=%v, error=%v", route.SetID, err.Error())
+				return err
+			}
+		}
+
+		resp = routeResponse(*route, stats.Points)
+		resp.Accepted = len(kept)
+		resp.Dropped = dropped
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 已完成的组距离和时长变化后重新检查个人记录（最快配速等），未完成的组在完成时检查
+	if filled != nil && filled.Completed {
+		if _, err := s.records.ProcessExercise(userID, filled.ExerciseID); err != nil {
+			logger.Error.Printf("更新个人记录失败: user_id=%v, exercise_id=%v, error=%v", userID, filled.ExerciseID, err.Error())
+		}
+	}
+	return &resp, nil
+}
+
+// fillRouteSet 用轨迹的距离和时长填写关联的组
+// 未完成的组先把计划值保存为目标，完成时不会把 GPS 实际值当作目标；已完成但没有关联训练的组关联到轨迹所在的训练
+func fillRouteSet(tx *gorm.DB, record *models.WorkoutRecord, route *models.WorkoutRoute) (*models.ExerciseSet, error) {
+	var set models.ExerciseSet
+	if err := tx.Where("id = ?", route.SetID).First(&set).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !set.Completed {
+		captureTarget(&set)
+	} else if set.RecordID == "" {
+		set.RecordID = record.ID
+	}
+	set.Distance = roundTo(route.Distance, 2)
+	set.Duration = route.Duration
+	set.UpdatedAt = time.Now()
+	if err := tx.Save(&set).Error; err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// route 找到轨迹点所属的轨迹，没有时新建
+// 指定组时使用该组的轨迹；未指定时沿用该训练最近更新的轨迹，训练还没有轨迹时关联第一个未完成的有氧组
+func (s *RouteService) route(tx *gorm.DB, record *models.WorkoutRecord, setID string) (*models.WorkoutRoute, error) {
+	var route models.WorkoutRoute
+	query := tx.Where("record_id = ?", record.ID)
+	if setID != "" {
+		if err := checkRouteSet(tx, record, setID); err != nil {
+			return nil, err
+		}
+		query = query.Where("set_id = ?", setID)
+	}
+	err := query.Order("updated_at DESC").First(&route).Error
+	if err == nil {
+		return &route, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error.Printf("查询 GPS 轨迹失败: record_id=%v, error=%v", record.ID, err.Error())
+		return nil, err
+	}
+
+	if setID == "" {
+		setID = defaultRouteSet(tx, record)
+	}
+	return &models.WorkoutRoute{
+		ID:        uuid.New().String(),
+		RecordID:  record.ID,
+		UserID:    record.UserID,
+		SetID:     setID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// checkRouteSet 确认组属于训练关联的计划
+func checkRouteSet(db *gorm.DB, record *models.WorkoutRecord, setID string) error {
+	var count int64
+	err := db.Model(&models.ExerciseSet{}).
+		Joins("JOIN training_exercises ON training_exercises.id = exercise_sets.exercise_id").
+		Where("exercise_sets.id = ? AND training_exercises.plan_id = ?", setID, record.PlanID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrRouteSetNotFound
+	}
+	return nil
+}
+
+// defaultRouteSet 训练计划中第一个未完成的有氧组，没有时返回空
+func defaultRouteSet(db *gorm.DB, record *models.WorkoutRecord) string {
+	if record.PlanID == "" {
+		return ""
+	}
+	var exercises []models.TrainingExercise
+	err := db.Where("plan_id = ?", record.PlanID).
+		Preload("Sets", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+		Order(`"order" ASC`).
+		Find(&exercises).Error
+	if err != nil {
+		logger.Error.Printf("查询训练计划失败: plan_id=%v, error=%v", record.PlanID, err.Error())
+		return ""
+	}
+	return firstCardioSet(exercises)
+}
+
+// firstCardioSet 第一个未完成的有氧组
+func firstCardioSet(exercises []models.TrainingExercise) string {
+	for _, exercise := range exercises {
+		if metCategory(exercise.Category) != "cardio" && cardioSport(exercise.Name) == "Other" {
+			continue
+		}
+		for _, set := range exercise.Sets {
+			if !set.Completed {
+				return set.ID
+			}
+		}
+	}
+	return ""
+}
+
+// routeResponse 轨迹的响应，points 为去除漂移点后的轨迹
+func routeResponse(route models.WorkoutRoute, points []models.TrackPoint) models.RouteResponse {
+	resp := models.RouteResponse{
+		ID:            route.ID,
+		RecordID:      route.RecordID,
+		SetID:         route.SetID,
+		Points:        route.PointCount,
+		StartAt:       route.StartAt,
+		EndAt:         route.EndAt,
+		Distance:      roundTo(route.Distance, 2),
+		Duration:      route.Duration,
+		ElevationGain: route.ElevationGain,
+		ElevationLoss: route.ElevationLoss,
+		Splits:        route.Splits,
+		Polyline:      encodePolyline(points, routePolylinePoints),
+	}
+	if route.Distance > 0 {
+		resp.Pace = int(math.Round(float64(route.Duration) / route.Distance))
+	}
+	return resp
+}
+
+// parseGPX 读取 GPX 文件中所有 trkpt
+func parseGPX(r io.Reader) ([]models.TrackPoint, error) {
+	var file gpxFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGPX, err)
+	}
+
+	var points []models.TrackPoint
+	for _, track := range file.Tracks {
+		for _, segment := range track.Segments {
+			for _, p := range segment.Points {
+				point := models.TrackPoint{Latitude: p.Latitude, Longitude: p.Longitude, Elevation: p.Elevation}
+				if at, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time)); err == nil {
+					point.Time = at
+				}
+				points = append(points, point)
+			}
+		}
+	}
+	if len(points) == 0 {
+		return nil, ErrInvalidGPX
+	}
+	return points, nil
+}
+
+// filterRoutePoints 丢弃坐标无效、没有时间或时间不在 [from, to] 内的点，结果按时间排序
+func filterRoutePoints(points []models.TrackPoint, from, to time.Time) ([]models.TrackPoint, int) {
+	kept := make([]models.TrackPoint, 0, len(points))
+	for _, p := range points {
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			continue
+		}
+		// (0, 0) 通常是设备尚未定位时的占位值
+		if p.Latitude == 0 && p.Longitude == 0 {
+			continue
+		}
+		if p.Time.IsZero() || p.Time.Before(from) || p.Time.After(to) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	return kept, len(points) - len(kept)
+}
+
+// mergeTrackPoints 合并已有轨迹和新上传的点，同一时间（毫秒）的点以新上传的为准
+func mergeTrackPoints(existing, added []models.TrackPoint) []models.TrackPoint {
+	byTime := make(map[int64]models.TrackPoint, len(existing)+len(added))
+	for _, points := range [][]models.TrackPoint{existing, added} {
+		for _, p := range points {
+			byTime[p.Time.UnixMilli()] = p
+		}
+	}
+	merged := make([]models.TrackPoint, 0, len(byTime))
+	for _, p := range byTime {
+		merged = append(merged, p)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
+
+// encodeRoute 编码按时间排序的轨迹点，每个值都是与上一点的差值：
+// 时间（毫秒，uvarint）、纬度和经度（1e-6 度，varint）、海拔（0.1 米，varint）
+func encodeRoute(points []models.TrackPoint) (time.Time, []byte) {
+	if len(points) == 0 {
+		return time.Time{}, nil
+	}
+	start := points[0].Time.Truncate(time.Millisecond)
+	data := make([]byte, 0, len(points)*8)
+	buf := make([]byte, binary.MaxVarintLen64)
+	prevAt := start
+	var prevLat, prevLon, prevEle int64
+	for _, p := range points {
+		at := p.Time.Truncate(time.Millisecond)
+		lat, lon := int64(math.Round(p.Latitude*1e6)), int64(math.Round(p.Longitude*1e6))
+		ele := int64(math.Round(p.Elevation * 10))
+
+		data = append(data, buf[:binary.PutUvarint(buf, uint64(at.Sub(prevAt).Milliseconds()))]...)
+		data = append(data, buf[:binary.PutVarint(buf, lat-prevLat)]...)
+		data = append(data, buf[:binary.PutVarint(buf, lon-prevLon)]...)
+		data = append(data, buf[:binary.PutVarint(buf, ele-prevEle)]...)
+		prevAt, prevLat, prevLon, prevEle = at, lat, lon, ele
+	}
+	return start, data
+}
+
+// decodeRoute 解码 encodeRoute 编码的轨迹点
+func decodeRoute(start time.Time, data []byte, count int) ([]models.TrackPoint, error) {
+	points := make([]models.TrackPoint, 0, count)
+	at := start
+	var lat, lon, ele int64
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("GPS 轨迹数据已损坏")
+		}
+		data = data[n:]
+		var values [3]int64
+		for i := range values {
+			v, n := binary.Varint(data)
+			if n <= 0 {
+				return nil, errors.New("GPS 轨迹数据已损坏")
+			}
+			values[i] = v
+			data = data[n:]
+		}
+		at = at.Add(time.Duration(delta) * time.Millisecond)
+		lat, lon, ele = lat+values[0], lon+values[1], ele+values[2]
+		points = append(points, models.TrackPoint{
+			Latitude:  float64(lat) / 1e6,
+			Longitude: float64(lon) / 1e6,
+			Elevation: float64(ele) / 10,
+			Time:      at,
+		})
+	}
+	return points, nil
+}
+
+// analyzeRoute 计算距离、用时、爬升/下降和每公里分段
+// 与上一个有效点之间速度超过 maxRouteSpeed 的点视为漂移并丢弃；间隔超过 maxRouteGap 的两点之间视为中断，不计距离和用时；
+// 海拔变化累计超过 routeElevationThreshold 才计入爬升或下降
+func analyzeRoute(points []models.TrackPoint) routeStats {
+	var stats routeStats
+	if len(points) == 0 {
+		return stats
+	}
+
+	prev := points[0]
+	stats.Points = append(stats.Points, prev)
+	refElevation := prev.Elevation
+	var splitMeters, splitSeconds, splitGain float64
+
+	for _, p := range points[1:] {
+		dt := p.Time.Sub(prev.Time).Seconds()
+		if dt <= 0 {
+			continue
+		}
+		d := haversineMeters(prev, p)
+		if d/dt > maxRouteSpeed {
+			continue
+		}
+		stats.Points = append(stats.Points, p)
+
+		switch climb := p.Elevation - refElevation; {
+		case climb >= routeElevationThreshold:
+			stats.ElevationGain += climb
+			splitGain += climb
+			refElevation = p.Elevation
+		case climb <= -routeElevationThreshold:
+			stats.ElevationLoss -= climb
+			refElevation = p.Elevation
+		}
+
+		if dt <= maxRouteGap.Seconds() {
+			stats.Meters += d
+			stats.Seconds += dt
+			// 跨过整公里时按距离比例拆分该段用时
+			for splitMeters+d >= 1000 {
+				ratio := (1000 - splitMeters) / d
+				splitSeconds += dt * ratio
+				stats.Splits = append(stats.Splits, routeSplit(len(stats.Splits)+1, 1000, splitSeconds, splitGain))
+				d -= 1000 - splitMeters
+				dt -= dt * ratio
+				splitMeters, splitSeconds, splitGain = 0, 0, 0
+			}
+			splitMeters += d
+			splitSeconds += dt
+		}
+		prev = p
+	}
+
+	if splitMeters >= routeMinSplitMeters {
+		stats.Splits = append(stats.Splits, routeSplit(len(stats.Splits)+1, splitMeters, splitSeconds, splitGain))
+	}
+	return stats
+}
+
+// routeSplit 生成一个分段，配速按分段实际距离折算为秒/公里
+func routeSplit(index int, meters, seconds, gain float64) models.RouteSplit {
+	return models.RouteSplit{
+		Index:         index,
+		Distance:      roundTo(meters/1000, 2),
+		Seconds:       int(math.Round(seconds)),
+		Pace:          int(math.Round(seconds / meters * 1000)),
+		ElevationGain: roundTo(gain, 1),
+	}
+}
+
+// haversineMeters 两点之间的球面距离
+func haversineMeters(a, b models.TrackPoint) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// encodePolyline 按 Google Encoded Polyline 算法（精度 1e-5）编码轨迹，点数超过 limit 时等间隔抽取，保留起点和终点
+func encodePolyline(points []models.TrackPoint, limit int) string {
+	if len(points) > limit && limit > 1 {
+		step := float64(len(points)-1) / float64(limit-1)
+		sampled := make([]models.TrackPoint, limit)
+		for i := range sampled {
+			sampled[i] = points[int(math.Round(float64(i)*step))]
+		}
+		points = sampled
+	}
+
+	var sb strings.Builder
+	var prevLat, prevLon int64
+	for _, p := range points {
+		lat, lon := int64(math.Round(p.Latitude*1e5)), int64(math.Round(p.Longitude*1e5))
+		writePolylineValue(&sb, lat-prevLat)
+		writePolylineValue(&sb, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return sb.String()
+}
+
+// writePolylineValue 写入一个差值：左移一位（负数取反），按 5 位一组从低到高输出，每个字符加 63
+func writePolylineValue(sb *strings.Builder, v int64) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte((0x20 | (u & 0x1f)) + 63))
+		u >>= 5
+	}
+	sb.WriteByte(byte(u + 63))
+}
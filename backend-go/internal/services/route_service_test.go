@@ -0,0 +1,197 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// northRoute 从 (31, 121) 向正北每 step 前进 0.001 度纬度（约 111.2 米）
+func northRoute(start time.Time, step time.Duration, n int) []models.TrackPoint {
+	points := make([]models.TrackPoint, n)
+	for i := range points {
+		points[i] = models.TrackPoint{
+			Latitude:  31 + float64(i)*0.001,
+			Longitude: 121,
+			Elevation: 10,
+			Time:      start.Add(time.Duration(i) * step),
+		}
+	}
+	return points
+}
+
+func TestEncodeDecodeRoute(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	points := []models.TrackPoint{
+		{Latitude: 31.230416, Longitude: 121.473701, Elevation: 4.5, Time: start},
+		{Latitude: 31.230501, Longitude: 121.473622, Elevation: 4.2, Time: start.Add(1200 * time.Millisecond)},
+		{Latitude: -33.856784, Longitude: -151.215297, Elevation: -12.3, Time: start.Add(time.Hour)},
+	}
+
+	at, data := encodeRoute(points)
+	assert.Equal(t, start, at)
+
+	decoded, err := decodeRoute(at, data, len(points))
+	require.NoError(t, err)
+	require.Len(t, decoded, len(points))
+	for i := range points {
+		assert.InDelta(t, points[i].Latitude, decoded[i].Latitude, 1e-9)
+		assert.InDelta(t, points[i].Longitude, decoded[i].Longitude, 1e-9)
+		assert.InDelta(t, points[i].Elevation, decoded[i].Elevation, 1e-9)
+		assert.True(t, points[i].Time.Equal(decoded[i].Time))
+	}
+
+	// 相邻点距离较近时每个点只需几个字节
+	_, data = encodeRoute(northRoute(start, time.Second, 100))
+	assert.Less(t, len(data), 100*8)
+
+	_, err = decodeRoute(at, data[:len(data)-1], 0)
+	assert.Error(t, err)
+}
+
+func TestFilterRoutePoints(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	points := []models.TrackPoint{
+		{Latitude: 31.001, Longitude: 121, Time: start.Add(2 * time.Second)},
+		{Latitude: 31, Longitude: 121, Time: start.Add(time.Second)},
+		{Latitude: 0, Longitude: 0, Time: start.Add(3 * time.Second)},    // 尚未定位
+		{Latitude: 91, Longitude: 121, Time: start.Add(4 * time.Second)}, // 坐标无效
+		{Latitude: 31, Longitude: 121},                                   // 没有时间
+		{Latitude: 31, Longitude: 121, Time: start.Add(-time.Hour)},      // 早于训练开始
+	}
+
+	kept, dropped := filterRoutePoints(points, start, start.Add(time.Hour))
+	assert.Equal(t, 4, dropped)
+	require.Len(t, kept, 2)
+	assert.Equal(t, 31.0, kept[0].Latitude)
+	assert.Equal(t, 31.001, kept[1].Latitude)
+}
+
+func TestMergeTrackPoints(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	existing := northRoute(start, time.Second, 3)
+	added := []models.TrackPoint{
+		{Latitude: 32, Longitude: 121, Time: start.Add(2 * time.Second)},
+		{Latitude: 33, Longitude: 121, Time: start.Add(3 * time.Second)},
+	}
+
+	merged := mergeTrackPoints(existing, added)
+	require.Len(t, merged, 4)
+	assert.Equal(t, 31.001, merged[1].Latitude)
+	assert.Equal(t, 32.0, merged[2].Latitude)
+	assert.Equal(t, 33.0, merged[3].Latitude)
+}
+
+func TestAnalyzeRoute(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	// 19 段 × 111.2 米，每段 30 秒
+	points := northRoute(start, 30*time.Second, 20)
+
+	stats := analyzeRoute(points)
+	assert.InDelta(t, 2112.7, stats.Meters, 0.5)
+	assert.InDelta(t, 570, stats.Seconds, 0.001)
+	require.Len(t, stats.Splits, 3)
+	assert.Equal(t, 1, stats.Splits[0].Index)
+	assert.Equal(t, 1.0, stats.Splits[0].Distance)
+	assert.Equal(t, 270, stats.Splits[0].Seconds)
+	assert.Equal(t, 270, stats.Splits[0].Pace)
+	assert.Equal(t, 270, stats.Splits[1].Seconds)
+	assert.Equal(t, 0.11, stats.Splits[2].Distance)
+	assert.Equal(t, 30, stats.Splits[2].Seconds)
+	assert.Equal(t, 270, stats.Splits[2].Pace)
+}
+
+func TestAnalyzeRouteGapsAndDrift(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	points := northRoute(start, 30*time.Second, 3)
+	// 漂移到 10 公里外的点被丢弃
+	points = append(points, models.TrackPoint{Latitude: 31.1, Longitude: 121, Elevation: 10, Time: start.Add(65 * time.Second)})
+	// 暂停 10 分钟后在 1 公里外继续，中断期间的距离和时长不计入
+	resumed := northRoute(start.Add(11*time.Minute), 30*time.Second, 2)
+	for i := range resumed {
+		resumed[i].Latitude += 0.01
+	}
+	points = append(points, resumed...)
+
+	stats := analyzeRoute(points)
+	assert.Len(t, stats.Points, 5)
+	assert.InDelta(t, 3*111.195, stats.Meters, 0.5)
+	assert.InDelta(t, 90, stats.Seconds, 0.001)
+	require.Len(t, stats.Splits, 1)
+	assert.Equal(t, 0.33, stats.Splits[0].Distance)
+}
+
+func TestAnalyzeRouteElevation(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	points := northRoute(start, 30*time.Second, 8)
+	// 1 米的抖动不计入；爬升 12 米后下降 3 米，最后 2 米的下降未达到阈值不计入
+	for i, ele := range []float64{10, 11, 10, 11, 16, 22, 19, 17} {
+		points[i].Elevation = ele
+	}
+
+	stats := analyzeRoute(points)
+	assert.InDelta(t, 12, stats.ElevationGain, 0.001)
+	assert.InDelta(t, 3, stats.ElevationLoss, 0.001)
+	assert.InDelta(t, 12, stats.Splits[0].ElevationGain, 0.001)
+}
+
+func TestEncodePolyline(t *testing.T) {
+	// Google Encoded Polyline 文档中的示例
+	points := []models.TrackPoint{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	assert.Equal(t, "_p~iF~ps|U_ulLnnqC_mqNvxq`@", encodePolyline(points, routePolylinePoints))
+	assert.Equal(t, "", encodePolyline(nil, routePolylinePoints))
+
+	// 超过上限时等间隔抽取，保留起点和终点
+	assert.Equal(t, `_p~iF~ps|U_c_\fhde@`, encodePolyline(points, 2))
+}
+
+func TestParseGPX(t *testing.T) {
+	gpx := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="Watch" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk><name>晨跑</name>
+    <trkseg>
+      <trkpt lat="31.2304" lon="121.4737"><ele>4.5</ele><time>2024-03-01T07:00:00Z</time></trkpt>
+      <trkpt lat="31.2310" lon="121.4740"><ele>5.0</ele><time>2024-03-01T07:00:05.500Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="31.2320" lon="121.4750"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+	points, err := parseGPX(strings.NewReader(gpx))
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, 31.2304, points[0].Latitude)
+	assert.Equal(t, 4.5, points[0].Elevation)
+	assert.Equal(t, time.Date(2024, 3, 1, 7, 0, 5, 500e6, time.UTC), points[1].Time)
+	// 没有时间的点保留零值，由 filterRoutePoints 丢弃
+	assert.True(t, points[2].Time.IsZero())
+
+	_, err = parseGPX(strings.NewReader(`<gpx></gpx>`))
+	assert.ErrorIs(t, err, ErrInvalidGPX)
+	_, err = parseGPX(strings.NewReader(`not xml`))
+	assert.ErrorIs(t, err, ErrInvalidGPX)
+}
+
+func TestFirstCardioSet(t *testing.T) {
+	exercises := []models.TrainingExercise{
+		{Name: "深蹲", Category: "腿", Sets: []models.ExerciseSet{{ID: "squat-1"}}},
+		{Name: "跑步机", Category: "有氧", Sets: []models.ExerciseSet{{ID: "run-1", Completed: true}, {ID: "run-2"}}},
+		{Name: "Cycling", Category: "other", Sets: []models.ExerciseSet{{ID: "bike-1"}}},
+	}
+	assert.Equal(t, "run-2", firstCardioSet(exercises))
+
+	exercises[1].Sets[1].Completed = true
+	assert.Equal(t, "bike-1", firstCardioSet(exercises))
+	assert.Equal(t, "", firstCardioSet(exercises[:1]))
+}
@@ -13,31 +13,102 @@ type Services struct {
 	AuthService        *AuthService
 	AIService          *AIService
 	TrainingService    *TrainingService
+	StreakService      *StreakService
+	RecordService      *RecordService
+	CatalogService     *ExerciseCatalogService
+	SessionService     *SessionService
+	CalorieService     *CalorieService
+	AnalyticsService   *AnalyticsService
+	AdherenceService   *AdherenceService
+	SyncService        *SyncService
+	UnitService        *UnitService
+	HeartRateService   *HeartRateService
+	RouteService       *RouteService
+	AIChatService      *AIChatService
+	AIToolService      *AIToolService
+	NutritionService   *NutritionService
+	HealthService      *HealthService
+	TemplateService    *TemplateService
+	ImportService      *ImportService
+	ExportService      *ExportService
+	RestTimerService   *RestTimerService
+	RiskService        *RiskService
+	ProgramService     *ProgramService
+	WebSocketService   *WebSocketService
 	MessageService     *MessageService
 	BuddyService       *BuddyService
 	CommunityService   *CommunityService
 	UserProfileService *UserProfileService
+	Events             *EventBus
 }
 
 // NewServices 创建服务容器
 func NewServices(cfg *config.Config, db *gorm.DB, redisClient *redis.Client) *Services {
 	userService := NewUserService(db, redisClient)
 	authService := NewAuthService(cfg, userService)
-	aiService := NewAIService(cfg)
-	trainingService := NewTrainingService(db, aiService, userService)
+	catalogService := NewExerciseCatalogService(NewExerciseRepository(db))
+	aiService := NewAIService(cfg, catalogService, redisClient)
+	events := NewEventBus()
+	webSocketService := NewWebSocketService()
+	restTimerService := NewRestTimerService(db, webSocketService, events)
+	streakService := NewStreakService(db)
+	progressionService := NewProgressionService(db, catalogService)
+	calorieService := NewCalorieService(db)
+	adherenceService := NewAdherenceService(db, streakService)
+	analyticsService := NewAnalyticsService(db, streakService)
+	sessionService := NewSessionService(db, streakService, calorieService, adherenceService, analyticsService, events)
+	recordService := NewRecordService(db, events, catalogService)
+	programService := NewProgramService(db, catalogService)
+	trainingService := NewTrainingService(db, aiService, userService, streakService, progressionService, recordService, catalogService, sessionService, analyticsService, adherenceService, programService, events)
+	workoutService := NewWorkoutService(db, redisClient, streakService, calorieService)
+	syncService := NewSyncService(db, trainingService, workoutService)
+	unitService := NewUnitService(db)
+	heartRateService := NewHeartRateService(db)
+	routeService := NewRouteService(db, recordService)
+	userProfileService := NewUserProfileService(db, streakService)
+	nutritionService := NewNutritionService(db)
+	healthService := NewHealthService(db)
+	aiToolService := NewAIToolService(db, trainingService, nutritionService, userProfileService, streakService)
+	aiChatService := NewAIChatService(db, aiService, aiToolService)
 	messageService := NewMessageService(db)
+	riskService := NewRiskService(db, analyticsService, catalogService, messageService, webSocketService, events)
 	buddyService := NewBuddyService(db)
+	templateService := NewTemplateService(db, buddyService)
+	importService := NewImportService(db, catalogService, streakService, recordService, adherenceService, analyticsService)
+	exportService := NewExportService(db, routeService)
 	communityService := NewCommunityService(db)
-	userProfileService := NewUserProfileService(db)
 
 	return &Services{
 		UserService:        userService,
 		AuthService:        authService,
 		AIService:          aiService,
 		TrainingService:    trainingService,
+		StreakService:      streakService,
+		RecordService:      recordService,
+		CatalogService:     catalogService,
+		SessionService:     sessionService,
+		CalorieService:     calorieService,
+		AnalyticsService:   analyticsService,
+		AdherenceService:   adherenceService,
+		SyncService:        syncService,
+		UnitService:        unitService,
+		HeartRateService:   heartRateService,
+		RouteService:       routeService,
+		AIChatService:      aiChatService,
+		AIToolService:      aiToolService,
+		NutritionService:   nutritionService,
+		HealthService:      healthService,
+		TemplateService:    templateService,
+		ImportService:      importService,
+		ExportService:      exportService,
+		RestTimerService:   restTimerService,
+		RiskService:        riskService,
+		ProgramService:     programService,
+		WebSocketService:   webSocketService,
 		MessageService:     messageService,
 		BuddyService:       buddyService,
 		CommunityService:   communityService,
 		UserProfileService: userProfileService,
+		Events:             events,
 	}
 }
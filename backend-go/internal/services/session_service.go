@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionAbandonTimeout 超过该时长没有任何操作的训练视为已放弃，会被自动结束
+const sessionAbandonTimeout = 4 * time.Hour
+
+// 训练操作
+const (
+	workoutActionPause  = "pause"
+	workoutActionResume = "resume"
+	workoutActionEnd    = "end"
+)
+
+// workoutTransitions 合法的状态转换：当前状态 -> 操作 -> 新状态
+var workoutTransitions = map[string]map[string]string{
+	models.WorkoutStatusInProgress: {
+		workoutActionPause: models.WorkoutStatusPaused,
+		workoutActionEnd:   models.WorkoutStatusCompleted,
+	},
+	models.WorkoutStatusPaused: {
+		workoutActionResume: models.WorkoutStatusInProgress,
+		workoutActionEnd:    models.WorkoutStatusCompleted,
+	},
+}
+
+var (
+	// ErrWorkoutInProgress 用户已有未结束的训练
+	ErrWorkoutInProgress = errors.New("已有进行中的训练，请先结束当前训练")
+	// ErrInvalidWorkoutTransition 当前状态不允许该操作
+	ErrInvalidWorkoutTransition = errors.New("当前训练状态不允许该操作")
+)
+
+// SessionService 训练会话服务
+//
+// 负责训练记录的生命周期：开始 -> (暂停 <-> 恢复)* -> 结束。
+// 每个用户同时只能有一个未结束的训练，暂停区间不计入训练时长，
+// 长时间没有操作的训练会按最后一次操作时间自动结束。
+type SessionService struct {
+	db               *gorm.DB
+	streakService    *StreakService
+	calorieService   *CalorieService
+	adherenceService *AdherenceService
+	analyticsService *AnalyticsService
+	events           *EventBus
+}
+
+// NewSessionService 创建训练会话服务
+func NewSessionService(db *gorm.DB, streakService *StreakService, calorieService *CalorieService, adherenceService *AdherenceService, analyticsService *AnalyticsService, events *EventBus) *SessionService {
+	return &SessionService{db: db, streakService: streakService, calorieService: calorieService, adherenceService: adherenceService, analyticsService: analyticsService, events: events}
+}
+
+// Start 开始训练，entityID 决定是否沿用请求中的ID
+func (s *SessionService) Start(userID string, req models.StartWorkoutRequest, entityID func(string) string) (*models.WorkoutRecord, error) {
+	s.closeAbandoned(s.db.Where("user_id = ?", userID), time.Now())
+
+	active, err := s.Active(userID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, ErrWorkoutInProgress
+	}
+
+	// 开始时间可以是客户端离线时的时间；最后活动、创建和更新时间始终取服务端时间，
+	// 否则离线开始的训练会立即被当作超时未结束的训练自动关闭，同步游标也依赖 updated_at
+	now := time.Now()
+	startedAt := clientTime(req.StartedAt, now)
+	record := models.WorkoutRecord{
+		ID:             entityID(req.ID),
+		UserID:         userID,
+		PlanID:         req.PlanID,
+		StartTime:      startedAt,
+		Notes:          req.Notes,
+		Status:         models.WorkoutStatusInProgress,
+		LastActivityAt: now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := s.db.Create(&record).Error; err != nil {
+		// 同时开始的两个请求，后写入的违反“每个用户只有一个未结束训练”的唯一索引；
+		// 没有未结束的训练时是客户端ID与已有记录冲突
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if active, _ := s.Active(userID); active != nil {
+				return active, ErrWorkoutInProgress
+			}
+		}
+		logger.Error.Printf("开始训练失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Pause 暂停训练
+func (s *SessionService) Pause(userID string, req models.WorkoutActionRequest) (*models.WorkoutRecord, error) {
+	return s.apply(userID, req.RecordID, workoutActionPause, req.At, nil)
+}
+
+// Resume 恢复训练
+func (s *SessionService) Resume(userID string, req models.WorkoutActionRequest) (*models.WorkoutRecord, error) {
+	return s.apply(userID, req.RecordID, workoutActionResume, req.At, nil)
+}
+
+// End 结束训练，暂停中的训练会先结束当前暂停区间
+// 客户端未标明卡路里为设备实测时，按实际训练时长估算
+func (s *SessionService) End(userID string, req models.EndWorkoutRequest) (*models.WorkoutRecord, error) {
+	return s.apply(userID, req.RecordID, workoutActionEnd, req.EndedAt, func(record *models.WorkoutRecord) {
+		s.calorieService.ApplyWorkoutCalories(record, req.Calories, req.CaloriesMeasured)
+		s.adherenceService.ApplyWorkoutAdherence(record)
+		if req.Notes != "" {
+			record.Notes = req.Notes
+		}
+	})
+}
+
+// Active 获取用户未结束的训练，没有时返回 nil
+func (s *SessionService) Active(userID string) (*models.WorkoutRecord, error) {
+	var record models.WorkoutRecord
+	err := s.db.Where("user_id = ? AND status IN ?", userID, []string{models.WorkoutStatusInProgress, models.WorkoutStatusPaused}).
+		Preload("Pauses").
+		Order("start_time DESC").
+		First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Touch 记录一次训练操作（如完成一组），返回进行中训练的ID，没有进行中的训练时返回空字符串
+func (s *SessionService) Touch(userID string, at time.Time) string {
+	active, err := s.Active(userID)
+	if err != nil || active == nil || active.Status != models.WorkoutStatusInProgress {
+		return ""
+	}
+	s.db.Model(&models.WorkoutRecord{}).Where("id = ?", active.ID).
+		Updates(map[string]interface{}{"last_activity_at": at, "updated_at": time.Now()})
+	return active.ID
+}
+
+// CloseAbandoned 自动结束所有长时间未活动的训练，返回结束的数量
+func (s *SessionService) CloseAbandoned(now time.Time) int {
+	return s.closeAbandoned(s.db, now)
+}
+
+// StartJanitor 启动后台任务，定期自动结束长时间未活动的训练，ctx 取消后停止
+func (s *SessionService) StartJanitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if closed := s.CloseAbandoned(time.Now()); closed > 0 {
+					logger.Info.Printf("自动结束未活动的训练: count=%d", closed)
+				}
+			}
+		}
+	}()
+}
+
+// apply 对训练记录执行一次状态转换并持久化
+// at 为离线记录的操作时间，不早于训练开始时间、不晚于当前时间，为空时使用当前时间
+func (s *SessionService) apply(userID, recordID, action string, at *time.Time, mutate func(record *models.WorkoutRecord)) (*models.WorkoutRecord, error) {
+	var record models.WorkoutRecord
+	err := s.db.Where("id = ? AND user_id = ?", recordID, userID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("训练记录不存在或无权操作")
+		}
+		logger.Error.Printf("查询训练记录失败: record_id=%v, user_id=%v, error=%v", recordID, userID, err.Error())
+		return nil, err
+	}
+
+	now := clientTime(at, time.Now())
+	if now.Before(record.StartTime) {
+		now = record.StartTime
+	}
+	if err := s.transition(&record, action, now, mutate); err != nil {
+		return nil, err
+	}
+
+	if action == workoutActionEnd {
+		s.afterEnd(record)
+	}
+
+	s.db.Where("record_id = ?", record.ID).Order("started_at ASC").Find(&record.Pauses)
+	return &record, nil
+}
+
+// transition 在事务中更新训练记录和暂停区间
+func (s *SessionService) transition(record *models.WorkoutRecord, action string, now time.Time, mutate func(record *models.WorkoutRecord)) error {
+	status := record.Status
+	if err := applyWorkoutAction(record, action, now); err != nil {
+		return err
+	}
+	if mutate != nil {
+		mutate(record)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 以原状态为条件更新，防止并发请求重复转换
+		result := tx.Model(&models.WorkoutRecord{}).
+			Where("id = ? AND status = ?", record.ID, status).
+			Select("status", "end_time", "duration", "calories", "calories_source", "notes", "paused_at", "paused_seconds", "last_activity_at", "auto_closed", "adherence_score", "adherence", "updated_at").
+			Updates(record)
+		if result.Error != nil {
+			logger.Error.Printf("更新训练状态失败: record_id=%v, action=%v, error=%v", record.ID, action, result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidWorkoutTransition
+		}
+
+		if action == workoutActionPause {
+			return tx.Create(&models.WorkoutPause{
+				ID:        uuid.New().String(),
+				RecordID:  record.ID,
+				StartedAt: now,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}).Error
+		}
+		if status == models.WorkoutStatusPaused {
+			return tx.Model(&models.WorkoutPause{}).
+				Where("record_id = ? AND ended_at IS NULL", record.ID).
+				Updates(map[string]interface{}{"ended_at": now, "updated_at": time.Now()}).Error
+		}
+		return nil
+	})
+}
+
+// closeAbandoned 按最后一次操作时间结束符合条件的未活动训练
+func (s *SessionService) closeAbandoned(scope *gorm.DB, now time.Time) int {
+	var records []models.WorkoutRecord
+	err := scope.Where("status IN ? AND last_activity_at < ?",
+		[]string{models.WorkoutStatusInProgress, models.WorkoutStatusPaused}, now.Add(-sessionAbandonTimeout)).
+		Find(&records).Error
+	if err != nil {
+		logger.Error.Printf("查询未活动训练失败: error=%v", err.Error())
+		return 0
+	}
+
+	closed := 0
+	for i := range records {
+		record := &records[i]
+		err := s.transition(record, workoutActionEnd, abandonedEndTime(record), func(r *models.WorkoutRecord) {
+			r.AutoClosed = true
+			s.calorieService.ApplyWorkoutCalories(r, 0, false)
+			s.adherenceService.ApplyWorkoutAdherence(r)
+		})
+		if err != nil {
+			logger.Error.Printf("自动结束训练失败: record_id=%v, error=%v", record.ID, err.Error())
+			continue
+		}
+		s.afterEnd(*record)
+		closed++
+	}
+	return closed
+}
+
+// afterEnd 训练结束（包括自动结束）后更新连续训练天数、计划完成度和最常训练动作，并发布 EventWorkoutEnded，失败不影响结束训练
+func (s *SessionService) afterEnd(record models.WorkoutRecord) {
+	if _, err := s.streakService.RecordActivity(record.UserID, record.StartTime); err != nil {
+		logger.Error.Printf("更新连续训练天数失败: user_id=%v, error=%v", record.UserID, err.Error())
+	}
+	s.adherenceService.ScheduleRefresh(record.UserID)
+	s.analyticsService.RefreshFavorite(record.UserID)
+	s.events.Publish(EventWorkoutEnded, models.WorkoutEndedEvent{UserID: record.UserID, Record: record})
+}
+
+// clientTime 客户端上报的离线操作时间，为空或晚于当前时间时使用当前时间
+func clientTime(at *time.Time, now time.Time) time.Time {
+	if at == nil || at.IsZero() || at.After(now) {
+		return now
+	}
+	return *at
+}
+
+// applyWorkoutAction 校验并应用状态转换，更新暂停累计时长和训练时长
+func applyWorkoutAction(record *models.WorkoutRecord, action string, now time.Time) error {
+	next, ok := workoutTransitions[record.Status][action]
+	if !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidWorkoutTransition, record.Status, action)
+	}
+
+	switch action {
+	case workoutActionPause:
+		record.PausedAt = &now
+	case workoutActionResume, workoutActionEnd:
+		if record.PausedAt != nil {
+			if paused := now.Sub(*record.PausedAt); paused > 0 {
+				record.PausedSeconds += int(paused.Seconds())
+			}
+			record.PausedAt = nil
+		}
+	}
+
+	if action == workoutActionEnd {
+		record.EndTime = now
+		record.Duration = workoutDurationMinutes(record)
+	}
+
+	record.Status = next
+	if now.After(record.LastActivityAt) {
+		record.LastActivityAt = now
+	}
+	record.UpdatedAt = time.Now()
+	return nil
+}
+
+// workoutDurationMinutes 训练时长（分钟），不含暂停时间
+func workoutDurationMinutes(record *models.WorkoutRecord) int {
+	active := record.EndTime.Sub(record.StartTime) - time.Duration(record.PausedSeconds)*time.Second
+	if active < 0 {
+		return 0
+	}
+	return int(active.Minutes())
+}
+
+// abandonedEndTime 自动结束的训练以最后一次操作时间作为结束时间
+// （暂停中的训练以暂停开始时间为准，暂停区间不计入时长）
+func abandonedEndTime(record *models.WorkoutRecord) time.Time {
+	if record.PausedAt != nil {
+		return *record.PausedAt
+	}
+	if record.LastActivityAt.IsZero() {
+		return record.StartTime
+	}
+	return record.LastActivityAt
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyWorkoutAction(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	record := &models.WorkoutRecord{
+		StartTime:      start,
+		Status:         models.WorkoutStatusInProgress,
+		LastActivityAt: start,
+	}
+
+	assert.NoError(t, applyWorkoutAction(record, workoutActionPause, start.Add(20*time.Minute)))
+	assert.Equal(t, models.WorkoutStatusPaused, record.Status)
+	assert.Equal(t, start.Add(20*time.Minute), *record.PausedAt)
+
+	// 暂停中不能再次暂停
+	err := applyWorkoutAction(record, workoutActionPause, start.Add(25*time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidWorkoutTransition)
+
+	assert.NoError(t, applyWorkoutAction(record, workoutActionResume, start.Add(30*time.Minute)))
+	assert.Equal(t, models.WorkoutStatusInProgress, record.Status)
+	assert.Nil(t, record.PausedAt)
+	assert.Equal(t, 600, record.PausedSeconds)
+
+	assert.NoError(t, applyWorkoutAction(record, workoutActionPause, start.Add(40*time.Minute)))
+	assert.NoError(t, applyWorkoutAction(record, workoutActionEnd, start.Add(60*time.Minute)))
+	assert.Equal(t, models.WorkoutStatusCompleted, record.Status)
+	assert.Equal(t, 1800, record.PausedSeconds)
+	assert.Equal(t, 30, record.Duration)
+	assert.Equal(t, start.Add(60*time.Minute), record.EndTime)
+
+	// 已结束的训练不能再操作
+	for _, action := range []string{workoutActionPause, workoutActionResume, workoutActionEnd} {
+		assert.ErrorIs(t, applyWorkoutAction(record, action, start.Add(90*time.Minute)), ErrInvalidWorkoutTransition)
+	}
+}
+
+func TestAbandonedEndTime(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	lastActivity := start.Add(45 * time.Minute)
+
+	record := &models.WorkoutRecord{StartTime: start, Status: models.WorkoutStatusInProgress, LastActivityAt: lastActivity}
+	assert.Equal(t, lastActivity, abandonedEndTime(record))
+
+	assert.NoError(t, applyWorkoutAction(record, workoutActionEnd, abandonedEndTime(record)))
+	assert.Equal(t, 45, record.Duration)
+
+	// 暂停中被放弃的训练以暂停开始时间结束，暂停区间不计入时长
+	pausedAt := start.Add(50 * time.Minute)
+	paused := &models.WorkoutRecord{StartTime: start, Status: models.WorkoutStatusPaused, PausedAt: &pausedAt, LastActivityAt: pausedAt}
+	assert.NoError(t, applyWorkoutAction(paused, workoutActionEnd, abandonedEndTime(paused)))
+	assert.Equal(t, 50, paused.Duration)
+	assert.Equal(t, 0, paused.PausedSeconds)
+
+	legacy := &models.WorkoutRecord{StartTime: start, Status: models.WorkoutStatusInProgress}
+	assert.Equal(t, start, abandonedEndTime(legacy))
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+	_ "time/tzdata" // 内嵌时区数据库，保证精简镜像中也能解析用户时区
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultTimezone 用户未设置时区时使用的默认时区（与注册时的默认设置一致）
+const defaultTimezone = "Asia/Shanghai"
+
+// dayLayout 日期键格式
+const dayLayout = "2006-01-02"
+
+// StreakService 连续打卡/训练天数服务
+//
+// 训练记录（WorkoutRecord）和签到（CheckIn）都算作"活跃日"，
+// 日期边界按用户设置的时区（UserSettings.Timezone）划分。
+type StreakService struct {
+	db *gorm.DB
+}
+
+// StreakResult 连续天数计算结果
+type StreakResult struct {
+	CurrentStreak  int    `json:"current_streak"`
+	LongestStreak  int    `json:"longest_streak"`
+	ActiveDays     int    `json:"active_days"`
+	LastActiveDate string `json:"last_active_date"`
+	Timezone       string `json:"timezone"`
+}
+
+// NewStreakService 创建连续天数服务
+func NewStreakService(db *gorm.DB) *StreakService {
+	return &StreakService{db: db}
+}
+
+// UserLocation 获取用户时区，无效或未设置时回退到默认时区
+func (s *StreakService) UserLocation(userID string) *time.Location {
+	var settings models.UserSettings
+	tz := defaultTimezone
+	if err := s.db.Where("user_id = ?", userID).First(&settings).Error; err == nil && settings.Timezone != "" {
+		tz = settings.Timezone
+	}
+	return loadLocation(tz)
+}
+
+// GetStreaks 根据完整的训练和签到历史计算连续天数
+func (s *StreakService) GetStreaks(userID string) (*StreakResult, error) {
+	loc := s.UserLocation(userID)
+
+	days, err := s.activeDays(userID, loc)
+	if err != nil {
+		logger.Error.Printf("获取活跃日失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	current, longest := computeStreaks(days, localDay(time.Now(), loc))
+	result := &StreakResult{
+		CurrentStreak: current,
+		LongestStreak: longest,
+		ActiveDays:    len(days),
+		Timezone:      loc.String(),
+	}
+	if len(days) > 0 {
+		result.LastActiveDate = days[len(days)-1].Format(dayLayout)
+	}
+
+	return result, nil
+}
+
+// RecordActivity 在训练结束或签到后增量更新 UserStats 中的连续天数
+//
+// 活动日与上次活跃日相同则不变；恰好是下一天则 +1；间隔超过一天则重置为 1。
+// 如果活动日早于上次活跃日（补录历史数据），则回退为全量重算。
+func (s *StreakService) RecordActivity(userID string, at time.Time) (*models.UserStats, error) {
+	loc := s.UserLocation(userID)
+	day := localDay(at, loc)
+
+	var stats models.UserStats
+	err := s.db.Where("user_id = ?", userID).First(&stats).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error.Printf("查询用户统计失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || stats.LastActiveDate == "" {
+		return s.rebuild(userID, stats)
+	}
+
+	last, err := time.Parse(dayLayout, stats.LastActiveDate)
+	if err != nil {
+		return s.rebuild(userID, stats)
+	}
+
+	switch gap := daysBetween(last, day); {
+	case gap == 0:
+		return &stats, nil
+	case gap == 1:
+		stats.CurrentStreak++
+	case gap > 1:
+		stats.CurrentStreak = 1
+	default:
+		return s.rebuild(userID, stats)
+	}
+
+	if stats.CurrentStreak > stats.LongestStreak {
+		stats.LongestStreak = stats.CurrentStreak
+	}
+	stats.LastActiveDate = day.Format(dayLayout)
+	stats.UpdatedAt = time.Now()
+
+	if err := s.db.Save(&stats).Error; err != nil {
+		logger.Error.Printf("更新连续天数失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// Rebuild 按完整的训练和签到历史重算 UserStats 中的连续天数，用于批量导入历史训练后
+func (s *StreakService) Rebuild(userID string) (*models.UserStats, error) {
+	var stats models.UserStats
+	err := s.db.Where("user_id = ?", userID).First(&stats).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error.Printf("查询用户统计失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return s.rebuild(userID, stats)
+}
+
+// EffectiveCurrentStreak 返回截至当前仍然有效的连续天数
+//
+// UserStats 只在有活动时增量更新，超过一天没有活动的连续天数在读取时视为已中断。
+func (s *StreakService) EffectiveCurrentStreak(stats *models.UserStats, now time.Time) int {
+	if stats.LastActiveDate == "" {
+		return stats.CurrentStreak
+	}
+	last, err := time.Parse(dayLayout, stats.LastActiveDate)
+	if err != nil {
+		return stats.CurrentStreak
+	}
+	if daysBetween(last, localDay(now, s.UserLocation(stats.UserID))) > 1 {
+		return 0
+	}
+	return stats.CurrentStreak
+}
+
+// LocalDayRange 返回某一时刻所在用户本地日的 [开始, 结束) 时间范围
+func (s *StreakService) LocalDayRange(userID string, at time.Time) (time.Time, time.Time) {
+	loc := s.UserLocation(userID)
+	local := at.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// rebuild 全量重算连续天数并写回 UserStats
+func (s *StreakService) rebuild(userID string, stats models.UserStats) (*models.UserStats, error) {
+	result, err := s.GetStreaks(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.ID == "" {
+		stats.ID = uuid.New().String()
+		stats.UserID = userID
+		stats.CreatedAt = time.Now()
+	}
+	stats.CurrentStreak = result.CurrentStreak
+	stats.LongestStreak = result.LongestStreak
+	stats.LastActiveDate = result.LastActiveDate
+	stats.UpdatedAt = time.Now()
+
+	if err := s.db.Save(&stats).Error; err != nil {
+		logger.Error.Printf("重算连续天数失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// activeDays 返回用户所有活跃日（按用户时区划分，升序去重）
+func (s *StreakService) activeDays(userID string, loc *time.Location) ([]time.Time, error) {
+	var workoutTimes []time.Time
+	if err := s.db.Model(&models.WorkoutRecord{}).
+		Where("user_id = ? AND status = ?", userID, "completed").
+		Pluck("start_time", &workoutTimes).Error; err != nil {
+		return nil, err
+	}
+
+	var checkinTimes []time.Time
+	if err := s.db.Model(&models.CheckIn{}).
+		Where("user_id = ?", userID).
+		Pluck("date", &checkinTimes).Error; err != nil {
+		return nil, err
+	}
+
+	return uniqueDays(append(workoutTimes, checkinTimes...), loc), nil
+}
+
+// loadLocation 解析时区名称，失败时回退到默认时区
+func loadLocation(name string) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(defaultTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// localDay 将时刻换算为用户本地日期，并以 UTC 零点表示，便于按天做差
+func localDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween 计算两个本地日期相差的天数
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// uniqueDays 将时刻列表换算为升序去重的本地日期
+func uniqueDays(times []time.Time, loc *time.Location) []time.Time {
+	seen := make(map[time.Time]bool)
+	var days []time.Time
+	for _, t := range times {
+		if t.IsZero() {
+			continue
+		}
+		day := localDay(t, loc)
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// computeStreaks 根据升序去重的活跃日计算当前和最长连续天数
+//
+// 当天尚未活跃时，截至昨天的连续天数仍然有效。
+func computeStreaks(days []time.Time, today time.Time) (current, longest int) {
+	run := 0
+	for i, day := range days {
+		if i > 0 && daysBetween(days[i-1], day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if len(days) > 0 && daysBetween(days[len(days)-1], today) <= 1 {
+		current = run
+	}
+
+	return current, longest
+}
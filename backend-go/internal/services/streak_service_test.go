@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse(dayLayout, s)
+	return t
+}
+
+func TestComputeStreaks(t *testing.T) {
+	tests := []struct {
+		name            string
+		days            []string
+		today           string
+		expectedCurrent int
+		expectedLongest int
+	}{
+		{
+			name:            "无活跃记录",
+			days:            nil,
+			today:           "2024-03-10",
+			expectedCurrent: 0,
+			expectedLongest: 0,
+		},
+		{
+			name:            "连续到今天",
+			days:            []string{"2024-03-08", "2024-03-09", "2024-03-10"},
+			today:           "2024-03-10",
+			expectedCurrent: 3,
+			expectedLongest: 3,
+		},
+		{
+			name:            "今天尚未训练，昨天的连续仍有效",
+			days:            []string{"2024-03-08", "2024-03-09"},
+			today:           "2024-03-10",
+			expectedCurrent: 2,
+			expectedLongest: 2,
+		},
+		{
+			name:            "中断后重新开始",
+			days:            []string{"2024-03-01", "2024-03-02", "2024-03-03", "2024-03-04", "2024-03-09", "2024-03-10"},
+			today:           "2024-03-10",
+			expectedCurrent: 2,
+			expectedLongest: 4,
+		},
+		{
+			name:            "已中断超过一天",
+			days:            []string{"2024-03-05", "2024-03-06"},
+			today:           "2024-03-10",
+			expectedCurrent: 0,
+			expectedLongest: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var days []time.Time
+			for _, d := range tt.days {
+				days = append(days, day(d))
+			}
+
+			current, longest := computeStreaks(days, day(tt.today))
+			assert.Equal(t, tt.expectedCurrent, current)
+			assert.Equal(t, tt.expectedLongest, longest)
+		})
+	}
+}
+
+func TestUniqueDaysUsesUserTimezone(t *testing.T) {
+	shanghai := loadLocation("Asia/Shanghai")
+	newYork := loadLocation("America/New_York")
+
+	// 两次训练间隔 20 小时：UTC 下跨越零点，上海时间同为 3 月 10 日
+	times := []time.Time{
+		time.Date(2024, 3, 9, 17, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC),
+	}
+
+	assert.Len(t, uniqueDays(times, time.UTC), 2)
+	assert.Equal(t, []time.Time{day("2024-03-10")}, uniqueDays(times, shanghai))
+	assert.Equal(t, []time.Time{day("2024-03-09"), day("2024-03-10")}, uniqueDays(times, newYork))
+}
+
+func TestLoadLocationFallback(t *testing.T) {
+	assert.Equal(t, "Europe/Berlin", loadLocation("Europe/Berlin").String())
+	assert.Equal(t, defaultTimezone, loadLocation("Not/AZone").String())
+}
@@ -0,0 +1,626 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxSyncMutations 单次同步最多提交的变更数
+	maxSyncMutations = 500
+	// syncPageSize 变更流每类实体每次最多返回的条数
+	syncPageSize = 200
+	// syncStatusPending 变更正在处理，占住客户端ID防止并发重复应用
+	syncStatusPending = "pending"
+	// syncPendingLease 处理中的变更超过该时间未完成（如处理时进程退出）视为已中断，重试的请求可以接管
+	syncPendingLease = 2 * time.Minute
+)
+
+var (
+	// ErrTooManyMutations 单次同步提交的变更过多
+	ErrTooManyMutations = fmt.Errorf("单次同步最多提交 %d 条变更", maxSyncMutations)
+	// ErrInvalidSyncCursor 同步游标不是上次同步返回的值
+	ErrInvalidSyncCursor = errors.New("同步游标无效")
+
+	// errSyncConflict 变更与服务端数据冲突
+	errSyncConflict = errors.New("与服务端数据冲突")
+	// errSyncRejected 变更格式或内容无效
+	errSyncRejected = errors.New("变更无效")
+)
+
+// SyncService 离线同步服务
+//
+// 客户端在离线期间把计划、组、训练记录和打卡的变更排队，联网后批量提交。
+// 变更按客户端时间依次通过 TrainingService 和 WorkoutService 应用，与在线接口走相同的校验；
+// 同一客户端ID只应用一次，重复提交返回首次处理的结果。冲突规则：
+//   - 更新计划：服务端计划在变更时间之后被修改过时保留服务端版本（以时间为准，后写入者胜）
+//   - 删除计划：删除优先，计划已不存在时视为成功
+//   - 完成组：同一组已在其他设备上完成且更新时间晚于变更时间时保留服务端结果
+//   - 开始训练：已有其他未结束的训练时保留服务端训练；暂停/恢复/结束与服务端状态不符时保留服务端状态
+//   - 打卡：同一天只保留最先到达服务端的打卡
+//
+// 同一批次中先应用的变更不参与后续变更的时间比较，离线创建后又修改的计划不会被判为冲突。
+// 应用完成后返回游标之后服务端的所有变更（包括本次应用的结果），客户端以此为准覆盖本地数据。
+// 同步数据与数据库一致，始终使用公制，不按用户的单位制换算。
+type SyncService struct {
+	db       *gorm.DB
+	training *TrainingService
+	workouts *WorkoutService
+}
+
+// NewSyncService 创建离线同步服务
+func NewSyncService(db *gorm.DB, training *TrainingService, workouts *WorkoutService) *SyncService {
+	return &SyncService{db: db, training: training, workouts: workouts}
+}
+
+// Sync 应用客户端提交的离线变更，并返回游标之后的服务端变更
+func (s *SyncService) Sync(userID string, req models.SyncRequest) (*models.SyncResponse, error) {
+	if len(req.Mutations) > maxSyncMutations {
+		return nil, ErrTooManyMutations
+	}
+	since, err := parseSyncCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	touched := make(map[string]bool)
+	results := make([]models.SyncResult, 0, len(req.Mutations))
+	for _, mutation := range orderMutations(req.Mutations) {
+		result := s.applyOnce(userID, mutation, touched)
+		if result.Status == models.SyncStatusApplied && result.EntityID != "" {
+			touched[result.EntityID] = true
+		}
+		results = append(results, result)
+	}
+
+	changes, hasMore, err := s.changes(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	cursor := req.Cursor
+	if len(changes) > 0 {
+		cursor = formatSyncCursor(changes[len(changes)-1])
+	}
+
+	return &models.SyncResponse{
+		Results: results,
+		Changes: changes,
+		Cursor:  cursor,
+		HasMore: hasMore,
+	}, nil
+}
+
+// applyOnce 以客户端ID去重应用一条变更
+// 先写入处理中的日志占住客户端ID，服务端错误时删除日志以便客户端重试；处理中的日志超过租期后可被重试的请求接管
+func (s *SyncService) applyOnce(userID string, mutation models.SyncMutation, touched map[string]bool) models.SyncResult {
+	entry := models.SyncMutationLog{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ClientID:  mutation.ClientID,
+		Entity:    mutation.Entity,
+		Op:        mutation.Op,
+		EntityID:  mutation.EntityID,
+		Status:    syncStatusPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		var existing models.SyncMutationLog
+		if s.db.Where("user_id = ? AND client_id = ?", userID, mutation.ClientID).First(&existing).Error != nil {
+			logger.Error.Printf("记录同步变更失败: user_id=%v, client_id=%v, error=%v", userID, mutation.ClientID, err.Error())
+			return models.SyncResult{ClientID: mutation.ClientID, Status: models.SyncStatusFailed, Message: err.Error()}
+		}
+		if existing.Status != syncStatusPending {
+			return models.SyncResult{
+				ClientID: mutation.ClientID,
+				Status:   existing.Status,
+				EntityID: existing.EntityID,
+				Message:  existing.Message,
+				Replayed: true,
+			}
+		}
+		now := time.Now()
+		lease := s.db.Model(&models.SyncMutationLog{}).
+			Where("id = ? AND status = ? AND updated_at < ?", existing.ID, syncStatusPending, now.Add(-syncPendingLease)).
+			Update("updated_at", now)
+		if lease.Error != nil || lease.RowsAffected == 0 {
+			return models.SyncResult{ClientID: mutation.ClientID, Status: models.SyncStatusFailed, Message: "变更正在处理中"}
+		}
+		entry = existing
+	}
+
+	result := s.apply(userID, mutation, touched)
+	if result.Status == models.SyncStatusFailed {
+		s.db.Delete(&entry)
+		return result
+	}
+
+	err := s.db.Model(&entry).Updates(map[string]interface{}{
+		"status":     result.Status,
+		"entity_id":  result.EntityID,
+		"message":    result.Message,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		logger.Error.Printf("更新同步变更状态失败: user_id=%v, client_id=%v, error=%v", userID, mutation.ClientID, err.Error())
+	}
+	return result
+}
+
+// apply 应用一条变更并按错误类型归类结果
+func (s *SyncService) apply(userID string, mutation models.SyncMutation, touched map[string]bool) models.SyncResult {
+	result := models.SyncResult{ClientID: mutation.ClientID, Status: models.SyncStatusApplied}
+
+	var entityID string
+	var err error
+	switch mutation.Entity {
+	case models.SyncEntityPlan:
+		entityID, err = s.applyPlan(userID, mutation, touched)
+	case models.SyncEntitySet:
+		entityID, err = s.applySets(userID, mutation, touched)
+	case models.SyncEntityWorkout:
+		entityID, err = s.applyWorkout(userID, mutation)
+	case models.SyncEntityCheckIn:
+		entityID, err = s.applyCheckIn(userID, mutation)
+	default:
+		err = fmt.Errorf("%w: 未知的实体类型 %s", errSyncRejected, mutation.Entity)
+	}
+
+	result.EntityID = entityID
+	if err != nil {
+		result.Status = syncErrorStatus(err)
+		result.Message = err.Error()
+		if result.Status == models.SyncStatusFailed {
+			logger.Error.Printf("应用同步变更失败: user_id=%v, client_id=%v, entity=%v, op=%v, error=%v",
+				userID, mutation.ClientID, mutation.Entity, mutation.Op, err.Error())
+		}
+	}
+	return result
+}
+
+// applyPlan 创建、更新或删除计划
+func (s *SyncService) applyPlan(userID string, mutation models.SyncMutation, touched map[string]bool) (string, error) {
+	switch mutation.Op {
+	case models.SyncOpCreate:
+		var req models.CreatePlanRequest
+		if err := decodeSyncData(mutation, &req, true); err != nil {
+			return "", err
+		}
+		if req.ID == "" {
+			req.ID = mutation.EntityID
+		}
+		// 只检查自己的计划；与其他用户的计划、动作或组的ID冲突时写入失败，按冲突返回
+		if req.ID != "" {
+			var count int64
+			if err := s.db.Model(&models.TrainingPlan{}).Where("id = ? AND user_id = ?", req.ID, userID).Count(&count).Error; err != nil {
+				return "", err
+			}
+			if count > 0 {
+				return req.ID, fmt.Errorf("%w: 计划已存在", errSyncConflict)
+			}
+		}
+		if err := validateSyncData(&req); err != nil {
+			return "", err
+		}
+		plan, err := s.training.CreateSyncedPlan(userID, req)
+		if err != nil {
+			return "", err
+		}
+		return plan.ID, nil
+
+	case models.SyncOpUpdate, models.SyncOpDelete:
+		var plan models.TrainingPlan
+		err := s.db.Where("id = ? AND user_id = ?", mutation.EntityID, userID).First(&plan).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if mutation.Op == models.SyncOpDelete {
+				return mutation.EntityID, nil
+			}
+			return mutation.EntityID, fmt.Errorf("%w: 计划已删除", errSyncConflict)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if mutation.Op == models.SyncOpDelete {
+			return plan.ID, s.training.DeletePlan(userID, plan.ID)
+		}
+		if !touched[plan.ID] && plan.UpdatedAt.After(mutation.Timestamp) {
+			return plan.ID, fmt.Errorf("%w: 计划在其他设备上有更新的修改", errSyncConflict)
+		}
+		var req models.UpdatePlanRequest
+		if err := decodeSyncData(mutation, &req, true); err != nil {
+			return "", err
+		}
+		if _, err := s.training.UpdateSyncedPlan(userID, plan.ID, req); err != nil {
+			return "", err
+		}
+		return plan.ID, nil
+
+	default:
+		return "", fmt.Errorf("%w: 计划不支持操作 %s", errSyncRejected, mutation.Op)
+	}
+}
+
+// applySets 完成动作中的组
+func (s *SyncService) applySets(userID string, mutation models.SyncMutation, touched map[string]bool) (string, error) {
+	if mutation.Op != models.SyncOpComplete {
+		return "", fmt.Errorf("%w: 组不支持操作 %s", errSyncRejected, mutation.Op)
+	}
+	var req models.CompleteExerciseRequest
+	if err := decodeSyncData(mutation, &req, true); err != nil {
+		return "", err
+	}
+	if err := validateSyncData(&req); err != nil {
+		return "", err
+	}
+	if req.CompletedAt == nil {
+		req.CompletedAt = &mutation.Timestamp
+	}
+
+	var exercise models.TrainingExercise
+	err := s.db.Joins("JOIN training_plans ON training_plans.id = training_exercises.plan_id").
+		Where("training_exercises.id = ? AND training_plans.user_id = ?", req.ExerciseID, userID).
+		Preload("Sets").
+		First(&exercise).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return req.ExerciseID, fmt.Errorf("%w: 动作已删除", errSyncConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	current := make(map[string]models.ExerciseSet, len(exercise.Sets))
+	for _, set := range exercise.Sets {
+		current[set.ID] = set
+	}
+	for _, setReq := range req.Sets {
+		set, ok := current[setReq.SetID]
+		if ok && !touched[set.ID] && set.Completed && set.UpdatedAt.After(mutation.Timestamp) {
+			return exercise.ID, fmt.Errorf("%w: 组已在其他设备上完成", errSyncConflict)
+		}
+	}
+
+	if _, err := s.training.CompleteExercise(userID, req); err != nil {
+		return "", err
+	}
+	return exercise.ID, nil
+}
+
+// applyWorkout 开始、暂停、恢复或结束训练，操作时间取变更时间
+func (s *SyncService) applyWorkout(userID string, mutation models.SyncMutation) (string, error) {
+	at := mutation.Timestamp
+	if mutation.Op == models.SyncOpStart {
+		var req models.StartWorkoutRequest
+		if err := decodeSyncData(mutation, &req, false); err != nil {
+			return "", err
+		}
+		if req.ID == "" {
+			req.ID = mutation.EntityID
+		}
+		if req.StartedAt == nil {
+			req.StartedAt = &at
+		}
+		if req.ID != "" {
+			var count int64
+			if err := s.db.Model(&models.WorkoutRecord{}).Where("id = ? AND user_id = ?", req.ID, userID).Count(&count).Error; err != nil {
+				return "", err
+			}
+			if count > 0 {
+				return req.ID, fmt.Errorf("%w: 训练记录已存在", errSyncConflict)
+			}
+		}
+		record, err := s.training.StartSyncedWorkout(userID, req)
+		if err != nil {
+			if record != nil {
+				return record.ID, err
+			}
+			return "", err
+		}
+		return record.ID, nil
+	}
+
+	var count int64
+	s.db.Model(&models.WorkoutRecord{}).Where("id = ? AND user_id = ?", mutation.EntityID, userID).Count(&count)
+	if count == 0 {
+		return mutation.EntityID, fmt.Errorf("%w: 训练记录不存在", errSyncConflict)
+	}
+
+	var err error
+	switch mutation.Op {
+	case models.SyncOpPause:
+		_, err = s.training.PauseWorkout(userID, models.WorkoutActionRequest{RecordID: mutation.EntityID, At: &at})
+	case models.SyncOpResume:
+		_, err = s.training.ResumeWorkout(userID, models.WorkoutActionRequest{RecordID: mutation.EntityID, At: &at})
+	case models.SyncOpEnd:
+		var req models.EndWorkoutRequest
+		if err := decodeSyncData(mutation, &req, false); err != nil {
+			return "", err
+		}
+		req.RecordID = mutation.EntityID
+		if req.EndedAt == nil {
+			req.EndedAt = &at
+		}
+		_, err = s.training.EndWorkout(userID, req)
+	default:
+		return "", fmt.Errorf("%w: 训练不支持操作 %s", errSyncRejected, mutation.Op)
+	}
+	return mutation.EntityID, err
+}
+
+// applyCheckIn 按变更时间打卡
+func (s *SyncService) applyCheckIn(userID string, mutation models.SyncMutation) (string, error) {
+	if mutation.Op != models.SyncOpCreate {
+		return "", fmt.Errorf("%w: 打卡不支持操作 %s", errSyncRejected, mutation.Op)
+	}
+	var req models.CreateCheckInRequest
+	if err := decodeSyncData(mutation, &req, true); err != nil {
+		return "", err
+	}
+	if err := validateSyncData(&req); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	checkIn := models.CheckIn{
+		ID:         clientEntityID(mutation.EntityID),
+		UserID:     userID,
+		Date:       clientTime(&mutation.Timestamp, now),
+		Type:       req.Type,
+		Notes:      req.Notes,
+		Mood:       req.Mood,
+		Energy:     req.Energy,
+		Motivation: req.Motivation,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.workouts.CreateCheckIn(&checkIn); err != nil {
+		return "", err
+	}
+	return checkIn.ID, nil
+}
+
+// changes 游标之后服务端的变更：计划（含动作和组）、组、训练记录、打卡和删除记录
+// 按 (更新时间, ID) 翻页，同一时刻更新的多条记录跨页时不会遗漏
+func (s *SyncService) changes(userID string, since syncCursor) ([]models.SyncChange, bool, error) {
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND (updated_at, id) > (?, ?)", userID, since.At, since.ID).
+		Preload("Exercises", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+		Preload("Exercises.Sets", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+		Order("updated_at ASC, id ASC").
+		Limit(syncPageSize).
+		Find(&plans).Error
+	if err != nil {
+		logger.Error.Printf("查询计划变更失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, false, err
+	}
+
+	var sets []models.ExerciseSet
+	err = s.db.Joins("JOIN training_exercises ON training_exercises.id = exercise_sets.exercise_id").
+		Joins("JOIN training_plans ON training_plans.id = training_exercises.plan_id").
+		Where("training_plans.user_id = ? AND (exercise_sets.updated_at, exercise_sets.id) > (?, ?)", userID, since.At, since.ID).
+		Order("exercise_sets.updated_at ASC, exercise_sets.id ASC").
+		Limit(syncPageSize).
+		Find(&sets).Error
+	if err != nil {
+		logger.Error.Printf("查询组变更失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, false, err
+	}
+
+	var records []models.WorkoutRecord
+	err = s.db.Where("user_id = ? AND (updated_at, id) > (?, ?)", userID, since.At, since.ID).
+		Preload("Pauses").
+		Order("updated_at ASC, id ASC").
+		Limit(syncPageSize).
+		Find(&records).Error
+	if err != nil {
+		logger.Error.Printf("查询训练记录变更失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, false, err
+	}
+
+	var checkIns []models.CheckIn
+	err = s.db.Where("user_id = ? AND (updated_at, id) > (?, ?)", userID, since.At, since.ID).
+		Order("updated_at ASC, id ASC").
+		Limit(syncPageSize).
+		Find(&checkIns).Error
+	if err != nil {
+		logger.Error.Printf("查询打卡变更失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, false, err
+	}
+
+	var tombstones []models.SyncTombstone
+	err = s.db.Where("user_id = ? AND (deleted_at, entity_id) > (?, ?)", userID, since.At, since.ID).
+		Order("deleted_at ASC, entity_id ASC").
+		Limit(syncPageSize).
+		Find(&tombstones).Error
+	if err != nil {
+		logger.Error.Printf("查询删除记录失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, false, err
+	}
+
+	groups := make([][]models.SyncChange, 5)
+	for _, plan := range plans {
+		groups[0] = append(groups[0], models.SyncChange{
+			Entity: models.SyncEntityPlan, EntityID: plan.ID, Data: s.training.convertToPlanResponse(plan), ChangedAt: plan.UpdatedAt,
+		})
+	}
+	for _, set := range sets {
+		groups[1] = append(groups[1], models.SyncChange{
+			Entity: models.SyncEntitySet, EntityID: set.ID, Data: set, ChangedAt: set.UpdatedAt,
+		})
+	}
+	for _, record := range records {
+		groups[2] = append(groups[2], models.SyncChange{
+			Entity: models.SyncEntityWorkout, EntityID: record.ID, Data: s.training.convertToRecordResponse(record), ChangedAt: record.UpdatedAt,
+		})
+	}
+	for _, checkIn := range checkIns {
+		groups[3] = append(groups[3], models.SyncChange{
+			Entity: models.SyncEntityCheckIn, EntityID: checkIn.ID, Data: checkIn, ChangedAt: checkIn.UpdatedAt,
+		})
+	}
+	for _, tombstone := range tombstones {
+		groups[4] = append(groups[4], models.SyncChange{
+			Entity: tombstone.Entity, EntityID: tombstone.EntityID, Deleted: true, ChangedAt: tombstone.DeletedAt,
+		})
+	}
+
+	changes, hasMore := pageChanges(groups, syncPageSize)
+	return changes, hasMore, nil
+}
+
+// recordTombstone 记录删除的实体，失败不影响删除
+func recordTombstone(db *gorm.DB, userID, entity, entityID string) {
+	tombstone := models.SyncTombstone{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Entity:    entity,
+		EntityID:  entityID,
+		DeletedAt: time.Now(),
+	}
+	if err := db.Create(&tombstone).Error; err != nil {
+		logger.Error.Printf("记录删除失败: entity=%v, entity_id=%v, error=%v", entity, entityID, err.Error())
+	}
+}
+
+// decodeSyncData 解析变更携带的数据，required 为 false 时允许为空
+func decodeSyncData(mutation models.SyncMutation, v interface{}, required bool) error {
+	if len(mutation.Data) == 0 || string(mutation.Data) == "null" {
+		if required {
+			return fmt.Errorf("%w: 缺少变更数据", errSyncRejected)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(mutation.Data, v); err != nil {
+		return fmt.Errorf("%w: %v", errSyncRejected, err)
+	}
+	return nil
+}
+
+// validateSyncData 按在线接口的 binding 规则校验变更数据
+func validateSyncData(v interface{}) error {
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return fmt.Errorf("%w: %v", errSyncRejected, err)
+	}
+	return nil
+}
+
+// syncErrorStatus 应用变更的错误对应的处理结果
+func syncErrorStatus(err error) string {
+	switch {
+	case errors.Is(err, errSyncConflict),
+		errors.Is(err, gorm.ErrDuplicatedKey),
+		errors.Is(err, ErrWorkoutInProgress),
+		errors.Is(err, ErrInvalidWorkoutTransition),
+		errors.Is(err, ErrAlreadyCheckedIn):
+		return models.SyncStatusConflict
+	case errors.Is(err, errSyncRejected),
+		errors.Is(err, ErrInvalidPlanStructure),
+		errors.Is(err, ErrInvalidSetData),
+		errors.Is(err, ErrInvalidPlanDate):
+		return models.SyncStatusRejected
+	default:
+		return models.SyncStatusFailed
+	}
+}
+
+// orderMutations 按客户端时间排序，时间相同时保持提交顺序
+func orderMutations(mutations []models.SyncMutation) []models.SyncMutation {
+	ordered := make([]models.SyncMutation, len(mutations))
+	copy(ordered, mutations)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+	return ordered
+}
+
+// pageChanges 合并各类实体的变更并按 (时间, ID) 升序排列
+// 某类变更取满一页时，只返回不晚于该类最后一项的变更，其余留到下一次同步
+func pageChanges(groups [][]models.SyncChange, limit int) ([]models.SyncChange, bool) {
+	var boundary models.SyncChange
+	truncated := false
+	for _, group := range groups {
+		if len(group) < limit {
+			continue
+		}
+		last := group[len(group)-1]
+		if !truncated || changeBefore(last, boundary) {
+			boundary = last
+			truncated = true
+		}
+	}
+
+	changes := []models.SyncChange{}
+	for _, group := range groups {
+		for _, change := range group {
+			if truncated && changeBefore(boundary, change) {
+				continue
+			}
+			changes = append(changes, change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changeBefore(changes[i], changes[j])
+	})
+	return changes, truncated
+}
+
+// changeBefore 变更 a 是否排在 b 之前：先比较时间，时间相同时比较实体ID，与查询的排序一致
+func changeBefore(a, b models.SyncChange) bool {
+	if !a.ChangedAt.Equal(b.ChangedAt) {
+		return a.ChangedAt.Before(b.ChangedAt)
+	}
+	return a.EntityID < b.EntityID
+}
+
+// syncCursor 同步游标，上次同步返回的最后一项变更的时间和实体ID
+type syncCursor struct {
+	At time.Time
+	ID string
+}
+
+// parseSyncCursor 解析同步游标，空游标表示从头同步
+// 游标格式为 "时间|实体ID"；只有时间的旧游标仍然接受，视为该时刻之后的变更
+func parseSyncCursor(cursor string) (syncCursor, error) {
+	if cursor == "" {
+		return syncCursor{}, nil
+	}
+	at, id, _ := strings.Cut(cursor, "|")
+	since, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return syncCursor{}, ErrInvalidSyncCursor
+	}
+	if id == "" {
+		// 空ID排在所有ID之前，旧游标需要跳过该时刻的全部变更（数据库时间精度为微秒）
+		return syncCursor{At: since.Add(time.Microsecond)}, nil
+	}
+	return syncCursor{At: since, ID: id}, nil
+}
+
+// formatSyncCursor 以最后一项变更的时间和实体ID作为游标
+func formatSyncCursor(change models.SyncChange) string {
+	return change.ChangedAt.UTC().Format(time.RFC3339Nano) + "|" + change.EntityID
+}
+
+// clientEntityID 客户端离线生成的 UUID 合法时沿用，否则由服务端生成；只用于同步
+func clientEntityID(id string) string {
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String()
+	}
+	return uuid.New().String()
+}
+
+// newEntityID 忽略请求中的ID，始终由服务端生成
+func newEntityID(string) string {
+	return uuid.New().String()
+}
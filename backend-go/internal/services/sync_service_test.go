@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func syncChanges(entity string, base time.Time, minutes ...int) []models.SyncChange {
+	changes := make([]models.SyncChange, 0, len(minutes))
+	for _, m := range minutes {
+		changes = append(changes, models.SyncChange{
+			Entity:    entity,
+			EntityID:  fmt.Sprintf("%s-%d", entity, m),
+			ChangedAt: base.Add(time.Duration(m) * time.Minute),
+		})
+	}
+	return changes
+}
+
+func TestPageChangesMergesInOrder(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	groups := [][]models.SyncChange{
+		syncChanges(models.SyncEntityPlan, base, 1, 5),
+		syncChanges(models.SyncEntityCheckIn, base, 3),
+		nil,
+	}
+
+	changes, hasMore := pageChanges(groups, 10)
+
+	assert.False(t, hasMore)
+	require.Len(t, changes, 3)
+	assert.Equal(t, "plan-1", changes[0].EntityID)
+	assert.Equal(t, "checkin-3", changes[1].EntityID)
+	assert.Equal(t, "plan-5", changes[2].EntityID)
+}
+
+func TestPageChangesStopsAtFullGroup(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	groups := [][]models.SyncChange{
+		syncChanges(models.SyncEntityPlan, base, 1, 2, 3),
+		syncChanges(models.SyncEntityWorkout, base, 2, 4),
+		syncChanges(models.SyncEntitySet, base, 1, 6, 8),
+	}
+
+	changes, hasMore := pageChanges(groups, 3)
+
+	// 计划取满一页，最后一项在第 3 分钟，之后的变更留到下一次同步
+	assert.True(t, hasMore)
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.EntityID)
+	}
+	assert.ElementsMatch(t, []string{"plan-1", "plan-2", "plan-3", "workout-2", "set-1"}, ids)
+	assert.Equal(t, base.Add(3*time.Minute), changes[len(changes)-1].ChangedAt)
+}
+
+func TestPageChangesOrdersSameTimeByID(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	groups := [][]models.SyncChange{
+		{{EntityID: "a", ChangedAt: at}, {EntityID: "c", ChangedAt: at}},
+		{{EntityID: "b", ChangedAt: at}, {EntityID: "d", ChangedAt: at}},
+	}
+
+	changes, hasMore := pageChanges(groups, 2)
+
+	// 同一时刻的变更按ID排序，边界为 (at, "c")，"d" 留到下一次同步
+	assert.True(t, hasMore)
+	ids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.EntityID)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+}
+
+func TestSyncCursorRoundTrip(t *testing.T) {
+	since, err := parseSyncCursor("")
+	require.NoError(t, err)
+	assert.True(t, since.At.IsZero())
+	assert.Empty(t, since.ID)
+
+	at := time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.FixedZone("CST", 8*3600))
+	since, err = parseSyncCursor(formatSyncCursor(models.SyncChange{EntityID: "plan-1", ChangedAt: at}))
+	require.NoError(t, err)
+	assert.True(t, since.At.Equal(at))
+	assert.Equal(t, "plan-1", since.ID)
+
+	// 只有时间的旧游标跳过该时刻的全部变更
+	since, err = parseSyncCursor(at.UTC().Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	assert.True(t, since.At.After(at))
+	assert.Empty(t, since.ID)
+
+	_, err = parseSyncCursor("yesterday")
+	assert.ErrorIs(t, err, ErrInvalidSyncCursor)
+}
+
+func TestOrderMutationsByTimestamp(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	mutations := []models.SyncMutation{
+		{ClientID: "c", Timestamp: base.Add(2 * time.Minute)},
+		{ClientID: "a", Timestamp: base},
+		{ClientID: "b", Timestamp: base},
+	}
+
+	ordered := orderMutations(mutations)
+
+	assert.Equal(t, []string{"a", "b", "c"}, []string{ordered[0].ClientID, ordered[1].ClientID, ordered[2].ClientID})
+	assert.Equal(t, "c", mutations[0].ClientID)
+}
+
+func TestClientEntityID(t *testing.T) {
+	id := "9B2C7A1E-4F3D-4C1B-8A2E-1D2C3B4A5F60"
+	assert.Equal(t, "9b2c7a1e-4f3d-4c1b-8a2e-1d2c3b4a5f60", clientEntityID(id))
+
+	generated := clientEntityID("local-1")
+	assert.NotEqual(t, "local-1", generated)
+	assert.Len(t, generated, 36)
+	assert.Len(t, clientEntityID(""), 36)
+
+	// 直接调用接口时不沿用客户端提交的ID
+	assert.NotEqual(t, "9b2c7a1e-4f3d-4c1b-8a2e-1d2c3b4a5f60", newEntityID(id))
+	assert.Len(t, newEntityID(id), 36)
+}
+
+func TestClientTimeClampsFuture(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	assert.Equal(t, now, clientTime(nil, now))
+	assert.Equal(t, earlier, clientTime(&earlier, now))
+	assert.Equal(t, now, clientTime(&later, now))
+}
+
+func TestSyncErrorStatus(t *testing.T) {
+	assert.Equal(t, models.SyncStatusConflict, syncErrorStatus(fmt.Errorf("%w: 计划已删除", errSyncConflict)))
+	assert.Equal(t, models.SyncStatusConflict, syncErrorStatus(ErrWorkoutInProgress))
+	assert.Equal(t, models.SyncStatusConflict, syncErrorStatus(ErrAlreadyCheckedIn))
+	assert.Equal(t, models.SyncStatusConflict, syncErrorStatus(fmt.Errorf("创建计划失败: %w", gorm.ErrDuplicatedKey)))
+	assert.Equal(t, models.SyncStatusRejected, syncErrorStatus(fmt.Errorf("%w: 缺少变更数据", errSyncRejected)))
+	assert.Equal(t, models.SyncStatusRejected, syncErrorStatus(ErrInvalidPlanDate))
+	assert.Equal(t, models.SyncStatusFailed, syncErrorStatus(errors.New("connection reset")))
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gymates/internal/models"
+	"gymates/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateService 训练计划模板服务
+//
+// 模板本身也是一条 TrainingPlan（IsTemplate = true），动作和组数沿用原有的表结构。
+// 模板可以克隆到具体日期成为新的计划、分享给搭子，或发布到公开的模板广场供其他用户派生和评价。
+type TemplateService struct {
+	db           *gorm.DB
+	buddyService *BuddyService
+}
+
+// NewTemplateService 创建训练计划模板服务
+func NewTemplateService(db *gorm.DB, buddyService *BuddyService) *TemplateService {
+	return &TemplateService{db: db, buddyService: buddyService}
+}
+
+// SaveAsTemplate 将自己的训练计划保存为模板
+func (s *TemplateService) SaveAsTemplate(userID string, req models.SaveTemplateRequest) (*models.TrainingPlan, error) {
+	var plan models.TrainingPlan
+	err := s.db.Where("id = ? AND user_id = ?", req.PlanID, userID).
+		Preload("Exercises.Sets").
+		First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("训练计划不存在")
+		}
+		return nil, err
+	}
+	if plan.IsTemplate {
+		return nil, errors.New("该计划已经是模板")
+	}
+
+	template := copyTemplate(plan, userID, plan.Date, true)
+	template.SourceTemplateID = plan.SourceTemplateID
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+	if req.Description != "" {
+		template.Description = req.Description
+	}
+
+	if err := s.db.Create(&template).Error; err != nil {
+		logger.Error.Printf("保存训练模板失败: plan_id=%v, error=%v", req.PlanID, err.Error())
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTemplates 获取模板列表：scope 为 shared 时返回搭子分享给我的模板，否则返回我的模板
+func (s *TemplateService) GetTemplates(userID, scope string) ([]models.TrainingPlan, error) {
+	query := s.db.Where("is_template = ?", true)
+	if scope == "shared" {
+		query = query.Where("id IN (?)", s.db.Model(&models.TrainingPlanShare{}).
+			Select("template_id").
+			Where("recipient_id = ?", userID))
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var templates []models.TrainingPlan
+	if err := query.Preload("Exercises.Sets").Order("updated_at DESC").Find(&templates).Error; err != nil {
+		logger.Error.Printf("获取训练模板失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Gallery 模板广场：公开发布的模板，支持按名称搜索和排序
+func (s *TemplateService) Gallery(keyword, sort string, limit, offset int) (*models.SearchTrainingPlansResponse, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := s.db.Model(&models.TrainingPlan{}).
+		Where("is_template = ? AND visibility = ?", true, models.PlanVisibilityPublic)
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("name ILIKE ? OR description ILIKE ?", like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var templates []models.TrainingPlan
+	if err := query.Preload("Exercises.Sets").
+		Order(galleryOrder(sort)).
+		Limit(limit).
+		Offset(offset).
+		Find(&templates).Error; err != nil {
+		logger.Error.Printf("获取模板广场失败: error=%v", err.Error())
+		return nil, err
+	}
+
+	return &models.SearchTrainingPlansResponse{
+		Plans:  templates,
+		Total:  int(total),
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Clone 将可见的模板克隆到指定日期，成为自己的训练计划
+func (s *TemplateService) Clone(userID string, req models.CreatePlanFromTemplateRequest) (*models.TrainingPlan, error) {
+	date, err := time.Parse(dayLayout, req.Date)
+	if err != nil {
+		return nil, errors.New("日期格式错误，应为 YYYY-MM-DD")
+	}
+
+	template, err := s.visibleTemplate(userID, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := copyTemplate(*template, userID, date, false)
+	if req.Name != "" {
+		plan.Name = req.Name
+	}
+
+	if err := s.createCopy(&plan, template, userID); err != nil {
+		logger.Error.Printf("克隆训练模板失败: template_id=%v, error=%v", req.TemplateID, err.Error())
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Fork 将他人分享或公开的模板派生到自己的模板库
+func (s *TemplateService) Fork(userID string, req models.ForkTemplateRequest) (*models.TrainingPlan, error) {
+	template, err := s.visibleTemplate(userID, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID == userID {
+		return nil, errors.New("不能派生自己的模板")
+	}
+
+	fork := copyTemplate(*template, userID, template.Date, true)
+	if req.Name != "" {
+		fork.Name = req.Name
+	}
+
+	if err := s.createCopy(&fork, template, userID); err != nil {
+		logger.Error.Printf("派生训练模板失败: template_id=%v, error=%v", req.TemplateID, err.Error())
+		return nil, err
+	}
+	return &fork, nil
+}
+
+// Share 将自己的模板分享给搭子
+func (s *TemplateService) Share(userID string, req models.ShareTemplateRequest) (*models.TrainingPlanShare, error) {
+	if _, err := s.ownTemplate(userID, req.TemplateID); err != nil {
+		return nil, err
+	}
+	if !s.buddyService.IsBuddy(userID, req.BuddyID) {
+		return nil, errors.New("只能分享给搭子")
+	}
+
+	var share models.TrainingPlanShare
+	err := s.db.Where("template_id = ? AND recipient_id = ?", req.TemplateID, req.BuddyID).First(&share).Error
+	if err == nil {
+		return &share, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	share = models.TrainingPlanShare{
+		ID:          uuid.New().String(),
+		TemplateID:  req.TemplateID,
+		OwnerID:     userID,
+		RecipientID: req.BuddyID,
+		Message:     req.Message,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.Create(&share).Error; err != nil {
+		logger.Error.Printf("分享训练模板失败: template_id=%v, error=%v", req.TemplateID, err.Error())
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Publish 发布模板到模板广场，或撤回为私有
+func (s *TemplateService) Publish(userID string, req models.PublishTemplateRequest) (*models.TrainingPlan, error) {
+	template, err := s.ownTemplate(userID, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template.Visibility = models.PlanVisibilityPrivate
+	if req.Public {
+		template.Visibility = models.PlanVisibilityPublic
+		if template.PublishedAt == nil {
+			template.PublishedAt = &now
+		}
+	}
+	template.UpdatedAt = now
+
+	if err := s.db.Model(template).Select("visibility", "published_at", "updated_at").Updates(template).Error; err != nil {
+		logger.Error.Printf("发布训练模板失败: template_id=%v, error=%v", req.TemplateID, err.Error())
+		return nil, err
+	}
+	return template, nil
+}
+
+// Rate 评价他人的模板，每个用户对同一模板只保留一条评价
+func (s *TemplateService) Rate(userID string, req models.RateTrainingPlanRequest) (*models.RateTrainingPlanResponse, error) {
+	template, err := s.visibleTemplate(userID, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID == userID {
+		return nil, errors.New("不能评价自己的模板")
+	}
+
+	var rating models.TrainingPlanRating
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("plan_id = ? AND user_id = ?", template.ID, userID).First(&rating).Error
+		switch {
+		case err == nil:
+			rating.Rating = req.Rating
+			rating.Review = req.Review
+			rating.UpdatedAt = time.Now()
+			if err := tx.Save(&rating).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			rating = models.TrainingPlanRating{
+				ID:        uuid.New().String(),
+				PlanID:    template.ID,
+				UserID:    userID,
+				Rating:    req.Rating,
+				Review:    req.Review,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			if err := tx.Create(&rating).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		// 重新汇总平均分和评价人数
+		var summary struct {
+			Average float64
+			Count   int
+		}
+		if err := tx.Model(&models.TrainingPlanRating{}).
+			Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+			Where("plan_id = ?", template.ID).
+			Scan(&summary).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.TrainingPlan{}).Where("id = ?", template.ID).
+			Updates(map[string]interface{}{"rating_average": summary.Average, "rating_count": summary.Count}).Error
+	})
+	if err != nil {
+		logger.Error.Printf("评价训练模板失败: template_id=%v, error=%v", req.PlanID, err.Error())
+		return nil, err
+	}
+
+	return &models.RateTrainingPlanResponse{RatingID: rating.ID, Message: "评价成功"}, nil
+}
+
+// createCopy 保存克隆出的计划或模板，克隆他人的模板时累计派生次数
+func (s *TemplateService) createCopy(plan *models.TrainingPlan, template *models.TrainingPlan, userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(plan).Error; err != nil {
+			return err
+		}
+		if template.UserID == userID {
+			return nil
+		}
+		return tx.Model(&models.TrainingPlan{}).Where("id = ?", template.ID).
+			UpdateColumn("fork_count", gorm.Expr("fork_count + 1")).Error
+	})
+}
+
+// ownTemplate 获取自己的模板
+func (s *TemplateService) ownTemplate(userID, templateID string) (*models.TrainingPlan, error) {
+	var template models.TrainingPlan
+	err := s.db.Where("id = ? AND user_id = ? AND is_template = ?", templateID, userID, true).First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("模板不存在或无权操作")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// visibleTemplate 获取用户可见的模板：自己的、公开发布的或搭子分享给自己的
+func (s *TemplateService) visibleTemplate(userID, templateID string) (*models.TrainingPlan, error) {
+	var template models.TrainingPlan
+	err := s.db.Where("id = ? AND is_template = ?", templateID, true).
+		Preload("Exercises.Sets").
+		First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("模板不存在")
+		}
+		return nil, err
+	}
+
+	if template.UserID == userID || template.Visibility == models.PlanVisibilityPublic {
+		return &template, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.TrainingPlanShare{}).
+		Where("template_id = ? AND recipient_id = ?", templateID, userID).
+		Count(&count).Error; err != nil {
+		logger.Error.Printf("查询模板分享失败: template_id=%v, user_id=%v, error=%v", templateID, userID, err.Error())
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("模板不存在")
+	}
+	return &template, nil
+}
+
+// copyTemplate 深拷贝计划或模板给指定用户，asTemplate 决定拷贝结果是模板还是待训练的计划
+// 来源、派生次数、评分和发布状态不会被拷贝
+func copyTemplate(source models.TrainingPlan, userID string, date time.Time, asTemplate bool) models.TrainingPlan {
+	plan := clonePlan(source, date)
+	plan.UserID = userID
+	plan.IsTemplate = asTemplate
+	plan.SourceTemplateID = source.ID
+	plan.ProgramID = ""
+	plan.ProgramWeek = 0
+	plan.ForkCount = 0
+	plan.RatingAverage = 0
+	plan.RatingCount = 0
+	plan.PublishedAt = nil
+
+	if asTemplate {
+		plan.Status = ""
+		plan.Visibility = models.PlanVisibilityPrivate
+	} else {
+		plan.Status = "pending"
+		plan.Visibility = ""
+	}
+	return plan
+}
+
+// galleryOrder 模板广场的排序语句
+func galleryOrder(sort string) string {
+	switch sort {
+	case models.GallerySortRating:
+		return "rating_average DESC, rating_count DESC, published_at DESC"
+	case models.GallerySortRecent:
+		return "published_at DESC"
+	default:
+		return "fork_count DESC, rating_average DESC, published_at DESC"
+	}
+}
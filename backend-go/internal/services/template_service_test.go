@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyTemplate(t *testing.T) {
+	completedAt := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	published := completedAt
+	template := models.TrainingPlan{
+		ID:            "tpl-1",
+		UserID:        "owner",
+		Name:          "推日",
+		IsTemplate:    true,
+		Visibility:    models.PlanVisibilityPublic,
+		ForkCount:     12,
+		RatingAverage: 4.5,
+		RatingCount:   4,
+		PublishedAt:   &published,
+		ProgramID:     "program-1",
+		Exercises: []models.TrainingExercise{
+			{ID: "ex-1", PlanID: "tpl-1", Name: "卧推", CatalogID: 1, Sets: []models.ExerciseSet{
+				{ID: "set-1", ExerciseID: "ex-1", Reps: 8, Weight: 60, Completed: true, CompletedAt: &completedAt, RecordID: "rec-1"},
+			}},
+		},
+	}
+	date := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	plan := copyTemplate(template, "user-2", date, false)
+
+	assert.NotEqual(t, template.ID, plan.ID)
+	assert.Equal(t, "user-2", plan.UserID)
+	assert.Equal(t, date, plan.Date)
+	assert.False(t, plan.IsTemplate)
+	assert.Equal(t, "pending", plan.Status)
+	assert.Equal(t, "tpl-1", plan.SourceTemplateID)
+	assert.Empty(t, plan.Visibility)
+	assert.Empty(t, plan.ProgramID)
+	assert.Zero(t, plan.ForkCount)
+	assert.Zero(t, plan.RatingCount)
+	assert.Nil(t, plan.PublishedAt)
+
+	// 动作和组数深拷贝为新的行
+	exercise := plan.Exercises[0]
+	assert.NotEqual(t, "ex-1", exercise.ID)
+	assert.Equal(t, plan.ID, exercise.PlanID)
+	assert.Equal(t, uint(1), exercise.CatalogID)
+	set := exercise.Sets[0]
+	assert.NotEqual(t, "set-1", set.ID)
+	assert.Equal(t, exercise.ID, set.ExerciseID)
+	assert.Equal(t, 60.0, set.Weight)
+	assert.False(t, set.Completed)
+	assert.Nil(t, set.CompletedAt)
+	assert.Empty(t, set.RecordID)
+
+	// 原模板不受影响
+	assert.Equal(t, "set-1", template.Exercises[0].Sets[0].ID)
+
+	fork := copyTemplate(template, "user-2", template.Date, true)
+	assert.True(t, fork.IsTemplate)
+	assert.Equal(t, models.PlanVisibilityPrivate, fork.Visibility)
+	assert.Empty(t, fork.Status)
+}
+
+func TestGalleryOrder(t *testing.T) {
+	assert.Equal(t, "published_at DESC", galleryOrder(models.GallerySortRecent))
+	assert.Contains(t, galleryOrder(models.GallerySortRating), "rating_average DESC")
+	assert.Contains(t, galleryOrder("unknown"), "fork_count DESC")
+}
@@ -1,29 +1,50 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"gymates/internal/models"
 	"gymates/pkg/logger"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // TrainingService 训练服务
 type TrainingService struct {
-	db          *gorm.DB
-	aiService   *AIService
-	userService *UserService
+	db                 *gorm.DB
+	aiService          *AIService
+	userService        *UserService
+	streakService      *StreakService
+	progressionService *ProgressionService
+	recordService      *RecordService
+	catalogService     *ExerciseCatalogService
+	sessionService     *SessionService
+	analyticsService   *AnalyticsService
+	adherenceService   *AdherenceService
+	programService     *ProgramService
+	events             *EventBus
 }
 
 // NewTrainingService 创建训练服务
-func NewTrainingService(db *gorm.DB, aiService *AIService, userService *UserService) *TrainingService {
+func NewTrainingService(db *gorm.DB, aiService *AIService, userService *UserService, streakService *StreakService, progressionService *ProgressionService, recordService *RecordService, catalogService *ExerciseCatalogService, sessionService *SessionService, analyticsService *AnalyticsService, adherenceService *AdherenceService, programService *ProgramService, events *EventBus) *TrainingService {
 	return &TrainingService{
-		db:          db,
-		aiService:   aiService,
-		userService: userService,
+		db:                 db,
+		aiService:          aiService,
+		userService:        userService,
+		streakService:      streakService,
+		progressionService: progressionService,
+		recordService:      recordService,
+		catalogService:     catalogService,
+		sessionService:     sessionService,
+		analyticsService:   analyticsService,
+		adherenceService:   adherenceService,
+		programService:     programService,
+		events:             events,
 	}
 }
 
@@ -32,7 +53,7 @@ func (s *TrainingService) GetTodayPlan(userID string) (*models.TrainingPlanRespo
 	today := time.Now().Format("2006-01-02")
 
 	var plan models.TrainingPlan
-	err := s.db.Where("user_id = ? AND DATE(date) = ?", userID, today).
+	err := s.db.Where("user_id = ? AND DATE(date) = ? AND is_template = ?", userID, today, false).
 		Preload("Exercises.Sets").
 		First(&plan).Error
 
@@ -51,7 +72,7 @@ func (s *TrainingService) GetTodayPlan(userID string) (*models.TrainingPlanRespo
 // GetHistoryPlans 获取历史训练计划
 func (s *TrainingService) GetHistoryPlans(userID string, skip, limit int) ([]models.TrainingPlanResponse, error) {
 	var plans []models.TrainingPlan
-	err := s.db.Where("user_id = ?", userID).
+	err := s.db.Where("user_id = ? AND is_template = ?", userID, false).
 		Preload("Exercises.Sets").
 		Order("date DESC").
 		Limit(limit).
@@ -71,16 +92,46 @@ func (s *TrainingService) GetHistoryPlans(userID string, skip, limit int) ([]mod
 	return responses, nil
 }
 
-// CreatePlan 创建训练计划
+// GetPlansBetween 获取 [from, to] 日期范围内的训练计划，按日期升序
+func (s *TrainingService) GetPlansBetween(userID string, from, to time.Time) ([]models.TrainingPlanResponse, error) {
+	var plans []models.TrainingPlan
+	err := s.db.Where("user_id = ? AND is_template = ? AND DATE(date) BETWEEN ? AND ?", userID, false, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Preload("Exercises.Sets").
+		Order("date ASC").
+		Find(&plans).Error
+	if err != nil {
+		logger.Error.Printf("获取训练计划失败: user_id=%v, error=%v", userID, err.Error())
+		return nil, err
+	}
+
+	responses := make([]models.TrainingPlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		responses = append(responses, *s.convertToPlanResponse(plan))
+	}
+	return responses, nil
+}
+
+// CreatePlan 创建训练计划，计划、动作和组的ID由服务端生成
 func (s *TrainingService) CreatePlan(userID string, req models.CreatePlanRequest) (*models.TrainingPlanResponse, error) {
+	return s.createPlan(userID, req, newEntityID)
+}
+
+// CreateSyncedPlan 创建离线同步的训练计划，沿用客户端生成的ID
+func (s *TrainingService) CreateSyncedPlan(userID string, req models.CreatePlanRequest) (*models.TrainingPlanResponse, error) {
+	return s.createPlan(userID, req, clientEntityID)
+}
+
+// createPlan 创建训练计划，entityID 决定是否沿用请求中的ID
+func (s *TrainingService) createPlan(userID string, req models.CreatePlanRequest, entityID func(string) string) (*models.TrainingPlanResponse, error) {
 	// 解析日期
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return nil, errors.New("日期格式错误")
+		return nil, ErrInvalidPlanDate
 	}
 
 	// 创建训练计划
 	plan := models.TrainingPlan{
+		ID:            entityID(req.ID),
 		UserID:        userID,
 		Name:          req.Name,
 		Description:   req.Description,
@@ -91,6 +142,12 @@ func (s *TrainingService) CreatePlan(userID string, req models.CreatePlanRequest
 		UpdatedAt:     time.Now(),
 	}
 
+	// 构建动作和组数，并关联标准动作库
+	exercises, err := s.buildPlanExercises(userID, plan.ID, req.Exercises, entityID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 开始事务
 	tx := s.db.Begin()
 	defer func() {
@@ -106,10 +163,34 @@ func (s *TrainingService) CreatePlan(userID string, req models.CreatePlanRequest
 		return nil, err
 	}
 
-	// 创建动作
-	for _, exerciseReq := range req.Exercises {
-		exercise := models.TrainingExercise{
-			PlanID:       plan.ID,
+	// 创建动作（组数随动作一起保存）
+	for i := range exercises {
+		if err := tx.Create(&exercises[i]).Error; err != nil {
+			tx.Rollback()
+			logger.Error.Printf("创建训练动作失败: plan_id=%v, error=%v", plan.ID, err.Error())
+			return nil, err
+		}
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		logger.Error.Printf("提交训练计划事务失败: error=%v", err.Error())
+		return nil, err
+	}
+
+	// 重新加载完整数据
+	s.db.Preload("Exercises.Sets").Where("id = ?", plan.ID).First(&plan)
+	return s.convertToPlanResponse(plan), nil
+}
+
+// buildPlanExercises 按请求构建计划的动作和组数，未指定目标的组使用渐进超负荷建议，并关联标准动作库
+func (s *TrainingService) buildPlanExercises(userID, planID string, reqs []models.CreateExerciseRequest, entityID func(string) string) ([]models.TrainingExercise, error) {
+	exercises := make([]models.TrainingExercise, len(reqs))
+	for i, exerciseReq := range reqs {
+		exercises[i] = models.TrainingExercise{
+			ID:           entityID(exerciseReq.ID),
+			PlanID:       planID,
+			CatalogID:    exerciseReq.CatalogID,
 			Name:         exerciseReq.Name,
 			Description:  exerciseReq.Description,
 			Category:     exerciseReq.Category,
@@ -120,61 +201,84 @@ func (s *TrainingService) CreatePlan(userID string, req models.CreatePlanRequest
 			ImageURL:     exerciseReq.ImageURL,
 			Instructions: exerciseReq.Instructions,
 			Order:        exerciseReq.Order,
+			GroupKey:     exerciseReq.GroupKey,
+			GroupType:    exerciseReq.GroupType,
+			GroupRounds:  exerciseReq.GroupRounds,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
 		}
 
-		if err := tx.Create(&exercise).Error; err != nil {
-			tx.Rollback()
-			logger.Error.Printf("创建训练动作失败: plan_id=%v, error=%v", plan.ID, err.Error())
-			return nil, err
-		}
-
-		// 创建组数
+		// 未指定重量、次数或时长的组使用渐进超负荷建议（热身组除外）
+		suggestion, _ := s.progressionService.Suggest(userID, exerciseReq.Name, exerciseReq.CatalogID)
 		for _, setReq := range exerciseReq.Sets {
-			set := models.ExerciseSet{
-				ExerciseID: exercise.ID,
-				Reps:       setReq.Reps,
-				Weight:     setReq.Weight,
-				Duration:   setReq.Duration,
+			weight, reps, duration := setReq.Weight, setReq.Reps, setReq.Duration
+			setType, _ := normalizeSetType(setReq.SetType)
+			if suggestion != nil && suggestion.HasHistory && setType != models.SetTypeWarmup {
+				weight, reps, duration = progressedTarget(setType, weight, reps, duration, suggestion)
+			}
+
+			exercises[i].Sets = append(exercises[i].Sets, models.ExerciseSet{
+				ID:         entityID(setReq.ID),
+				ExerciseID: exercises[i].ID,
+				Reps:       reps,
+				Weight:     weight,
+				Duration:   duration,
 				Distance:   setReq.Distance,
 				RestTime:   setReq.RestTime,
 				Order:      setReq.Order,
+				SetType:    setReq.SetType,
 				CreatedAt:  time.Now(),
 				UpdatedAt:  time.Now(),
-			}
-
-			if err := tx.Create(&set).Error; err != nil {
-				tx.Rollback()
-				logger.Error.Printf("创建动作组数失败: exercise_id=%v, error=%v", exercise.ID, err.Error())
-				return nil, err
-			}
+			})
 		}
 	}
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		logger.Error.Printf("提交训练计划事务失败: error=%v", err.Error())
+	if err := validatePlanStructure(exercises); err != nil {
 		return nil, err
 	}
+	s.catalogService.LinkExercises(exercises)
+	return exercises, nil
+}
 
-	// 重新加载完整数据
-	s.db.Preload("Exercises.Sets").First(&plan, plan.ID)
-	return s.convertToPlanResponse(plan), nil
+// UpdatePlan 更新训练计划，模板不能通过该接口修改；替换的动作和组由服务端生成ID
+func (s *TrainingService) UpdatePlan(userID, planID string, req models.UpdatePlanRequest) (*models.TrainingPlanResponse, error) {
+	return s.updatePlan(userID, planID, req, newEntityID)
 }
 
-// UpdatePlan 更新训练计划
-func (s *TrainingService) UpdatePlan(userID string, planID uint, req models.UpdatePlanRequest) (*models.TrainingPlanResponse, error) {
+// UpdateSyncedPlan 更新离线同步的训练计划，替换的动作和组沿用客户端生成的ID
+func (s *TrainingService) UpdateSyncedPlan(userID, planID string, req models.UpdatePlanRequest) (*models.TrainingPlanResponse, error) {
+	return s.updatePlan(userID, planID, req, clientEntityID)
+}
+
+// updatePlan 更新训练计划，entityID 决定替换的动作和组是否沿用请求中的ID
+func (s *TrainingService) updatePlan(userID, planID string, req models.UpdatePlanRequest, entityID func(string) string) (*models.TrainingPlanResponse, error) {
 	var plan models.TrainingPlan
-	err := s.db.Where("id = ? AND user_id = ?", planID, userID).First(&plan).Error
+	err := s.db.Where("id = ? AND user_id = ? AND is_template = ?", planID, userID, false).First(&plan).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("训练计划不存在或无权操作")
 		}
-		logger.Error.Printf("查询训练计划失败", map[string]interface{}{"plan_id": planID, "user_id": userID, "error": err.Error()})
+		logger.Error.Printf("查询训练计划失败: plan_id=%v, user_id=%v, error=%v", planID, userID, err.Error())
 		return nil, err
 	}
 
+	if req.Status != "" && req.Status != "skipped" {
+		return nil, fmt.Errorf("不支持的状态: %s", req.Status)
+	}
+	// 训练周期中的计划跳过后需要顺延后续训练，与 SkipDay 相同
+	if req.Status == "skipped" && plan.ProgramID != "" {
+		if _, err := s.programService.SkipDay(userID, planID); err != nil {
+			return nil, err
+		}
+		s.db.Preload("Exercises.Sets").Where("id = ?", plan.ID).First(&plan)
+		return s.convertToPlanResponse(plan), nil
+	}
+	if req.Status == "skipped" {
+		if plan.Status != "pending" {
+			return nil, fmt.Errorf("只能跳过未开始的训练，当前状态: %s", plan.Status)
+		}
+		plan.Status = "skipped"
+	}
+
 	// 更新基本信息
 	if req.Name != "" {
 		plan.Name = req.Name
@@ -182,65 +286,134 @@ func (s *TrainingService) UpdatePlan(userID string, planID uint, req models.Upda
 	if req.Description != "" {
 		plan.Description = req.Description
 	}
+	// 调整日期时保留原来的时间
+	if req.Date != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.Date, plan.Date.Location())
+		if err != nil {
+			return nil, errors.New("日期格式错误，应为 YYYY-MM-DD")
+		}
+		plan.Date = time.Date(date.Year(), date.Month(), date.Day(), plan.Date.Hour(), plan.Date.Minute(), plan.Date.Second(), 0, plan.Date.Location())
+	}
 	plan.UpdatedAt = time.Now()
 
-	if err := s.db.Save(&plan).Error; err != nil {
+	// 提交了动作列表时整体替换动作和组数（包括分组设置），已有完成的组时不能替换
+	var exercises []models.TrainingExercise
+	if req.Exercises != nil {
+		var completed int64
+		if err := s.db.Model(&models.ExerciseSet{}).
+			Joins("JOIN training_exercises ON training_exercises.id = exercise_sets.exercise_id").
+			Where("training_exercises.plan_id = ? AND exercise_sets.completed = ?", plan.ID, true).
+			Count(&completed).Error; err != nil {
+			logger.Error.Printf("查询已完成的组失败: plan_id=%v, error=%v", planID, err.Error())
+			return nil, err
+		}
+		if completed > 0 {
+			return nil, errors.New("训练计划已有完成的组，不能修改动作")
+		}
+		if len(req.Exercises) == 0 {
+			return nil, errors.New("训练计划至少需要一个动作")
+		}
+		if exercises, err = s.buildPlanExercises(userID, plan.ID, req.Exercises, entityID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&plan).Error; err != nil {
+			return err
+		}
+		if req.Exercises == nil {
+			return nil
+		}
+		if err := tx.Where("exercise_id IN (?)", tx.Model(&models.TrainingExercise{}).Select("id").Where("plan_id = ?", plan.ID)).
+			Delete(&models.ExerciseSet{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.TrainingExercise{}).Error; err != nil {
+			return err
+		}
+		for i := range exercises {
+			if err := tx.Create(&exercises[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		logger.Error.Printf("更新训练计划失败: plan_id=%v, error=%v", planID, err.Error())
 		return nil, err
 	}
 
 	// 重新加载完整数据
-	s.db.Preload("Exercises.Sets").First(&plan, plan.ID)
+	s.db.Preload("Exercises.Sets").Where("id = ?", plan.ID).First(&plan)
 	return s.convertToPlanResponse(plan), nil
 }
 
 // DeletePlan 删除训练计划
-func (s *TrainingService) DeletePlan(userID string, planID uint) error {
+func (s *TrainingService) DeletePlan(userID, planID string) error {
 	var plan models.TrainingPlan
 	err := s.db.Where("id = ? AND user_id = ?", planID, userID).First(&plan).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("训练计划不存在或无权操作")
 		}
-		logger.Error.Printf("查询训练计划失败", map[string]interface{}{"plan_id": planID, "user_id": userID, "error": err.Error()})
+		logger.Error.Printf("查询训练计划失败: plan_id=%v, user_id=%v, error=%v", planID, userID, err.Error())
 		return err
 	}
 
-	// 删除训练计划（级联删除相关数据）
-	if err := s.db.Delete(&plan).Error; err != nil {
+	// 删除训练计划（级联删除相关数据），模板同时删除分享和评分
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if plan.IsTemplate {
+			if err := tx.Where("template_id = ?", plan.ID).Delete(&models.TrainingPlanShare{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.TrainingPlanRating{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&plan).Error
+	})
+	if err != nil {
 		logger.Error.Printf("删除训练计划失败: plan_id=%v, error=%v", planID, err.Error())
 		return err
 	}
 
+	// 记录删除，离线设备同步时从变更流中移除该计划
+	recordTombstone(s.db, userID, models.SyncEntityPlan, plan.ID)
 	return nil
 }
 
 // GenerateAIPlan 生成AI训练计划
-func (s *TrainingService) GenerateAIPlan(userID string, req models.GenerateAIPlanRequest) (*models.TrainingPlanResponse, error) {
-	// 获取用户资料
-	// profile, err := s.userService.GetProfile(userID)
-	// if err != nil {
-	// 	return nil, errors.New("请先完善个人资料")
-	// }
-
-	// 构建AI请求
+func (s *TrainingService) GenerateAIPlan(ctx context.Context, userID string, req models.GenerateAIPlanRequest) (*models.TrainingPlanResponse, error) {
+	// 构建AI请求，附带用户资料和最近的训练历史
 	aiReq := &models.GenerateTrainingPlanRequest{
 		Goal:       req.Goal,
 		Duration:   req.Duration,
 		Difficulty: req.Difficulty,
 		Equipment:  req.Equipment,
 		FocusAreas: req.FocusAreas,
+		Context:    s.loadAIPlanContext(userID, time.Now()),
+	}
+
+	// 参考最近的计划完成度调整训练量，查询失败不影响生成
+	if report, err := s.adherenceService.Report(userID, defaultAdherenceWeeks); err == nil {
+		aiReq.Adherence = report.Score
+		aiReq.SkippedExercises = report.SkippedExercises
 	}
 
 	// 调用AI服务生成计划
-	aiPlan, err := s.aiService.GenerateTrainingPlan(aiReq)
+	aiPlan, err := s.aiService.GenerateTrainingPlan(ctx, aiReq)
 	if err != nil {
 		logger.Error.Printf("AI生成训练计划失败: user_id=%v, error=%v", userID, err.Error())
+		if errors.Is(err, ErrNoLLMProvider) {
+			return nil, err
+		}
 		return nil, errors.New("AI训练计划生成失败")
 	}
 
 	// 转换为数据库模型并保存
 	plan := models.TrainingPlan{
+		ID:            uuid.New().String(),
 		UserID:        userID,
 		Name:          aiPlan.Name,
 		Description:   aiPlan.Description,
@@ -250,10 +423,29 @@ func (s *TrainingService) GenerateAIPlan(userID string, req models.GenerateAIPla
 		Status:        "pending",
 		IsAIGenerated: true,
 		AIReason:      fmt.Sprintf("基于目标：%s，难度：%s，时长：%d分钟", req.Goal, req.Difficulty, req.Duration),
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+
+		AISource:           aiPlan.AISource,
+		AIProvider:         aiPlan.AIProvider,
+		AIModel:            aiPlan.AIModel,
+		AIAttempts:         aiPlan.AIAttempts,
+		AIValidationErrors: aiPlan.AIValidationErrors,
+
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
+	// AI 生成的动作名称同样关联到标准动作库
+	for _, exercise := range aiPlan.Exercises {
+		exercise.ID = uuid.New().String()
+		exercise.PlanID = plan.ID
+		for j := range exercise.Sets {
+			exercise.Sets[j].ID = uuid.New().String()
+			exercise.Sets[j].ExerciseID = exercise.ID
+		}
+		plan.Exercises = append(plan.Exercises, exercise)
+	}
+	s.catalogService.LinkExercises(plan.Exercises)
+
 	// 保存AI生成的计划
 	if err := s.db.Create(&plan).Error; err != nil {
 		logger.Error.Printf("保存AI训练计划失败: user_id=%v, error=%v", userID, err.Error())
@@ -261,92 +453,223 @@ func (s *TrainingService) GenerateAIPlan(userID string, req models.GenerateAIPla
 	}
 
 	// 重新加载完整数据
-	s.db.Preload("Exercises.Sets").First(&plan, plan.ID)
+	s.db.Preload("Exercises.Sets").Where("id = ?", plan.ID).First(&plan)
 	return s.convertToPlanResponse(plan), nil
 }
 
-// StartWorkout 开始训练
+// StartWorkout 开始训练，训练记录的ID由服务端生成
+// 已有未结束的训练时返回 ErrWorkoutInProgress，同时返回该训练记录
 func (s *TrainingService) StartWorkout(userID string, req models.StartWorkoutRequest) (*models.WorkoutRecordResponse, error) {
-	record := models.WorkoutRecord{
-		UserID:    userID,
-		PlanID:    req.PlanID,
-		StartTime: time.Now(),
-		Status:    "in_progress",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
+	return s.startWorkout(userID, req, newEntityID)
+}
+
+// StartSyncedWorkout 开始离线同步的训练，沿用客户端生成的ID
+func (s *TrainingService) StartSyncedWorkout(userID string, req models.StartWorkoutRequest) (*models.WorkoutRecordResponse, error) {
+	return s.startWorkout(userID, req, clientEntityID)
+}
 
-	if err := s.db.Create(&record).Error; err != nil {
-		logger.Error.Printf("开始训练失败: user_id=%v, error=%v", userID, err.Error())
+// startWorkout 开始训练，entityID 决定是否沿用请求中的ID
+func (s *TrainingService) startWorkout(userID string, req models.StartWorkoutRequest, entityID func(string) string) (*models.WorkoutRecordResponse, error) {
+	record, err := s.sessionService.Start(userID, req, entityID)
+	if record == nil {
 		return nil, err
 	}
+	return s.convertToRecordResponse(*record), err
+}
 
-	return s.convertToRecordResponse(record), nil
+// PauseWorkout 暂停训练
+func (s *TrainingService) PauseWorkout(userID string, req models.WorkoutActionRequest) (*models.WorkoutRecordResponse, error) {
+	record, err := s.sessionService.Pause(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.convertToRecordResponse(*record), nil
 }
 
-// EndWorkout 结束训练
-func (s *TrainingService) EndWorkout(userID string, req models.EndWorkoutRequest) (*models.WorkoutRecordResponse, error) {
-	var record models.WorkoutRecord
-	err := s.db.Where("id = ? AND user_id = ?", req.RecordID, userID).First(&record).Error
+// ResumeWorkout 恢复训练
+func (s *TrainingService) ResumeWorkout(userID string, req models.WorkoutActionRequest) (*models.WorkoutRecordResponse, error) {
+	record, err := s.sessionService.Resume(userID, req)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("训练记录不存在或无权操作")
-		}
-		logger.Error.Printf("查询训练记录失败: record_id=%v, user_id=%v, error=%v", req.RecordID, userID, err.Error())
 		return nil, err
 	}
+	return s.convertToRecordResponse(*record), nil
+}
 
-	record.EndTime = time.Now()
-	record.Duration = int(record.EndTime.Sub(record.StartTime).Minutes())
-	record.Calories = req.Calories
-	record.Notes = req.Notes
-	record.Status = "completed"
-	record.UpdatedAt = time.Now()
+// GetActiveWorkout 获取进行中的训练，没有时返回 nil
+func (s *TrainingService) GetActiveWorkout(userID string) (*models.WorkoutRecordResponse, error) {
+	s.sessionService.closeAbandoned(s.db.Where("user_id = ?", userID), time.Now())
 
-	if err := s.db.Save(&record).Error; err != nil {
-		logger.Error.Printf("结束训练失败: record_id=%v, error=%v", req.RecordID, err.Error())
+	record, err := s.sessionService.Active(userID)
+	if err != nil || record == nil {
 		return nil, err
 	}
+	return s.convertToRecordResponse(*record), nil
+}
 
-	return s.convertToRecordResponse(record), nil
+// EndWorkout 结束训练
+func (s *TrainingService) EndWorkout(userID string, req models.EndWorkoutRequest) (*models.WorkoutRecordResponse, error) {
+	record, err := s.sessionService.End(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.convertToRecordResponse(*record), nil
 }
 
-// CompleteExercise 完成动作
-func (s *TrainingService) CompleteExercise(userID string, req models.CompleteExerciseRequest) error {
-	// 验证动作是否存在
+// CompleteExercise 完成动作，返回本次刷新的个人记录；动作属于分组时同时返回分组进度
+func (s *TrainingService) CompleteExercise(userID string, req models.CompleteExerciseRequest) (*models.CompleteExerciseResult, error) {
+	// 验证动作是否存在，且所在的计划属于当前用户
 	var exercise models.TrainingExercise
-	err := s.db.First(&exercise, req.ExerciseID).Error
+	err := s.db.Joins("JOIN training_plans ON training_plans.id = training_exercises.plan_id").
+		Where("training_exercises.id = ? AND training_plans.user_id = ? AND training_plans.is_template = ?", req.ExerciseID, userID, false).
+		First(&exercise).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("动作不存在")
+			return nil, errors.New("动作不存在")
 		}
 		logger.Error.Printf("查询动作失败: exercise_id=%v, error=%v", req.ExerciseID, err.Error())
-		return err
+		return nil, err
 	}
 
-	// 更新组数完成状态
-	for _, setReq := range req.Sets {
-		var set models.ExerciseSet
-		err := s.db.Where("id = ? AND exercise_id = ?", setReq.SetID, req.ExerciseID).First(&set).Error
-		if err != nil {
-			logger.Error.Printf("查询组数失败: set_id=%v, error=%v", setReq.SetID, err.Error())
-			continue
+	var existing []models.ExerciseSet
+	if err := s.db.Where("exercise_id = ?", req.ExerciseID).Order(`"order" ASC`).Find(&existing).Error; err != nil {
+		logger.Error.Printf("查询组数失败: exercise_id=%v, error=%v", req.ExerciseID, err.Error())
+		return nil, err
+	}
+	setsByID := make(map[string]models.ExerciseSet, len(existing))
+	nextOrder := 1
+	for _, set := range existing {
+		setsByID[set.ID] = set
+		nextOrder = max(nextOrder, set.Order+1)
+	}
+
+	// 先按组类型校验提交的数据，避免只保存了一部分
+	for i, setReq := range req.Sets {
+		setType := models.SetTypeWorking
+		if set, ok := setsByID[setReq.SetID]; ok {
+			setType = setTypeOf(set)
+		} else if setReq.SetID != "" {
+			return nil, fmt.Errorf("%w: 组不存在或不属于该动作: %s", ErrInvalidSetData, setReq.SetID)
+		} else {
+			normalized, err := normalizeSetType(setReq.SetType)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSetData, err)
+			}
+			if normalized == models.SetTypeDrop && len(existing) == 0 {
+				return nil, fmt.Errorf("%w: 递减组需要跟在正式组之后", ErrInvalidSetData)
+			}
+			req.Sets[i].SetType = normalized
+			setType = normalized
+		}
+		if err := validateCompletedSet(setType, setReq); err != nil {
+			return nil, err
 		}
+	}
 
-		set.Reps = setReq.Reps
-		set.Weight = setReq.Weight
-		set.Duration = setReq.Duration
-		set.Distance = setReq.Distance
-		set.Completed = setReq.Completed
-		set.UpdatedAt = time.Now()
+	completedAt := clientTime(req.CompletedAt, time.Now())
+	recordID := s.sessionService.Touch(userID, completedAt)
+
+	// 在一个事务中更新组数完成状态，记录本次新完成的最后一组用于开始组间休息
+	var justCompleted *models.ExerciseSet
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, setReq := range req.Sets {
+			set, ok := setsByID[setReq.SetID]
+			if !ok {
+				// 计划外追加的组排在最后
+				set = models.ExerciseSet{
+					ID:         uuid.New().String(),
+					ExerciseID: req.ExerciseID,
+					Order:      nextOrder,
+					SetType:    setReq.SetType,
+					Extra:      true,
+					CreatedAt:  time.Now(),
+				}
+				nextOrder++
+			}
 
-		if err := s.db.Save(&set).Error; err != nil {
-			logger.Error.Printf("更新组数失败: set_id=%v, error=%v", setReq.SetID, err.Error())
-			return err
+			// 记录每组的完成时间和所在的训练
+			now := time.Now()
+			switch {
+			case setReq.Completed && !set.Completed:
+				if ok {
+					captureTarget(&set)
+				}
+				at := completedAt
+				set.CompletedAt = &at
+				set.RecordID = recordID
+				if justCompleted == nil || set.Order >= justCompleted.Order {
+					completed := set
+					justCompleted = &completed
+				}
+			case !setReq.Completed:
+				set.CompletedAt = nil
+				set.RecordID = ""
+			}
+
+			set.Reps = setReq.Reps
+			set.Weight = setReq.Weight
+			set.Duration = setReq.Duration
+			set.Distance = setReq.Distance
+			set.Completed = setReq.Completed
+			set.UpdatedAt = now
+
+			save := tx.Save
+			if !ok {
+				save = tx.Create
+			}
+			if err := save(&set).Error; err != nil {
+				logger.Error.Printf("更新组数失败: set_id=%v, error=%v", set.ID, err.Error())
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// 记录替换动作，用于计算计划完成度
+	if substitute := strings.TrimSpace(req.Substitute); substitute != "" && substitute != exercise.SubstitutedWith {
+		exercise.SubstitutedWith = substitute
+		if err := s.db.Model(&exercise).Update("substituted_with", substitute).Error; err != nil {
+			logger.Error.Printf("记录替换动作失败: exercise_id=%v, error=%v", exercise.ID, err.Error())
+		}
+	}
+
+	// 个人记录更新失败不影响组数保存
+	records, err := s.recordService.ProcessExercise(userID, req.ExerciseID)
+	if err != nil {
+		logger.Error.Printf("更新个人记录失败: user_id=%v, exercise_id=%v, error=%v", userID, req.ExerciseID, err.Error())
+	}
+
+	result := &models.CompleteExerciseResult{PersonalRecords: records}
+	if exercise.GroupKey != "" {
+		var members []models.TrainingExercise
+		err := s.db.Where("plan_id = ? AND group_key = ?", exercise.PlanID, exercise.GroupKey).
+			Preload("Sets", func(db *gorm.DB) *gorm.DB { return db.Order(`"order" ASC`) }).
+			Find(&members).Error
+		if err != nil {
+			logger.Error.Printf("查询分组动作失败: plan_id=%v, group_key=%v, error=%v", exercise.PlanID, exercise.GroupKey, err.Error())
+		} else {
+			result.Group = groupProgress(members, exercise.ID)
+		}
+	}
+
+	// 分组内同一轮的动作之间不休息，整个分组完成后按该组的休息时间休息；离线补录的组不开始休息
+	if justCompleted != nil && req.CompletedAt == nil {
+		restTime := justCompleted.RestTime
+		if result.Group != nil && !result.Group.Done {
+			restTime = result.Group.RestTime
+		}
+		s.events.Publish(EventSetCompleted, models.SetCompletedEvent{
+			UserID:     userID,
+			RecordID:   recordID,
+			ExerciseID: req.ExerciseID,
+			SetID:      justCompleted.ID,
+			RestTime:   restTime,
+		})
+	}
+
+	return result, nil
 }
 
 // SubmitFeedback 提交动作反馈
@@ -368,6 +691,7 @@ func (s *TrainingService) SubmitFeedback(userID string, req models.SubmitFeedbac
 		return nil, err
 	}
 
+	s.events.Publish(EventFeedbackSubmitted, models.FeedbackSubmittedEvent{UserID: userID, Feedback: feedback})
 	return s.convertToFeedbackResponse(feedback), nil
 }
 
@@ -398,19 +722,26 @@ func (s *TrainingService) GetWorkoutHistory(userID string, skip, limit int) ([]m
 func (s *TrainingService) GetTrainingStats(userID string) (*models.TrainingStatsResponse, error) {
 	var stats models.TrainingStatsResponse
 
-	// 基础统计
+	// 基础统计（只统计已结束的训练，卡路里按实测/估算分开汇总；来源未知的历史记录只计入总数）
 	var totalWorkouts int64
 	var totalDuration int64
 	var totalCalories int64
+	var measuredCalories int64
+	var estimatedCalories int64
 
 	s.db.Model(&models.WorkoutRecord{}).
-		Where("user_id = ?", userID).
-		Select("COUNT(*), COALESCE(SUM(duration), 0), COALESCE(SUM(calories_burned), 0)").
-		Row().Scan(&totalWorkouts, &totalDuration, &totalCalories)
+		Where("user_id = ? AND status = ?", userID, models.WorkoutStatusCompleted).
+		Select("COUNT(*), COALESCE(SUM(duration), 0), COALESCE(SUM(calories), 0), "+
+			"COALESCE(SUM(CASE WHEN calories_source = ? THEN calories ELSE 0 END), 0), "+
+			"COALESCE(SUM(CASE WHEN calories_source IN ? THEN calories ELSE 0 END), 0)",
+			models.CaloriesSourceMeasured, []string{models.CaloriesSourceHeartRate, models.CaloriesSourceEstimated}).
+		Row().Scan(&totalWorkouts, &totalDuration, &totalCalories, &measuredCalories, &estimatedCalories)
 
 	stats.TotalWorkouts = int(totalWorkouts)
 	stats.TotalDuration = int(totalDuration)
 	stats.TotalCalories = int(totalCalories)
+	stats.MeasuredCalories = int(measuredCalories)
+	stats.EstimatedCalories = int(estimatedCalories)
 
 	if totalWorkouts > 0 {
 		stats.AverageDuration = float64(totalDuration) / float64(totalWorkouts)
@@ -418,14 +749,31 @@ func (s *TrainingService) GetTrainingStats(userID string) (*models.TrainingStats
 	}
 
 	// 连续训练天数
-	stats.StreakDays = s.calculateCurrentStreak(userID)
-	stats.LongestStreak = s.calculateLongestStreak(userID)
+	stats.StreakDays, stats.LongestStreak = s.calculateStreaks(userID)
 
 	// 最喜欢的训练部位
-	stats.FavoriteExercise = s.getFavoriteCategory(userID)
+	stats.FavoriteExercise = s.getFavoriteExercise(userID)
 
 	// 周统计
-	// stats.WeeklyStats = s.getWeeklyStats(userID)
+	stats.WeeklyStats = s.getWeeklyStats(userID)
+	if len(stats.WeeklyStats) > 0 {
+		stats.WeeklyWorkouts = stats.WeeklyStats[len(stats.WeeklyStats)-1].Workouts
+	}
+
+	// 本月训练次数，月份边界按用户时区划分
+	now := time.Now().In(s.streakService.UserLocation(userID))
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var monthlyWorkouts int64
+	s.db.Model(&models.WorkoutRecord{}).
+		Where("user_id = ? AND status = ? AND start_time >= ?", userID, models.WorkoutStatusCompleted, monthStart).
+		Count(&monthlyWorkouts)
+	stats.MonthlyWorkouts = int(monthlyWorkouts)
+
+	var userStats models.UserStats
+	if err := s.db.Select("adherence_score", "weekly_adherence").Where("user_id = ?", userID).First(&userStats).Error; err == nil {
+		stats.AdherenceScore = userStats.AdherenceScore
+		stats.WeeklyAdherence = userStats.WeeklyAdherence
+	}
 
 	return &stats, nil
 }
@@ -478,9 +826,84 @@ func (s *TrainingService) generateDefaultPlan(userID string) (*models.TrainingPl
 		},
 	}
 
+	s.catalogService.LinkExercises(plan.Exercises)
+	s.applyProgression(userID, plan.Exercises)
 	return s.convertToPlanResponse(plan), nil
 }
 
+// applyProgression 用渐进超负荷建议补全计划中各组未指定的目标，与创建计划时相同
+func (s *TrainingService) applyProgression(userID string, exercises []models.TrainingExercise) {
+	for i := range exercises {
+		suggestion, err := s.progressionService.Suggest(userID, exercises[i].Name, exercises[i].CatalogID)
+		if err != nil || !suggestion.HasHistory {
+			continue
+		}
+		for j := range exercises[i].Sets {
+			set := &exercises[i].Sets[j]
+			if setType := setTypeOf(*set); setType != models.SetTypeWarmup {
+				set.Weight, set.Reps, set.Duration = progressedTarget(setType, set.Weight, set.Reps, set.Duration, suggestion)
+			}
+		}
+	}
+}
+
+// progressedTarget 用建议补全一组中未指定（为 0）的重量、次数和时长；计时组只补全时长
+func progressedTarget(setType string, weight float64, reps, duration int, suggestion *models.ProgressionSuggestion) (float64, int, int) {
+	if setType == models.SetTypeTimed {
+		if duration == 0 {
+			duration = suggestion.SuggestedDuration
+		}
+		return weight, reps, duration
+	}
+	if suggestion.SuggestedReps == 0 {
+		// 建议来自计时组历史，不适用于按次数的组
+		return weight, reps, duration
+	}
+	if weight == 0 {
+		weight = suggestion.SuggestedWeight
+	}
+	if reps == 0 {
+		reps = suggestion.SuggestedReps
+	}
+	return weight, reps, duration
+}
+
+// GetProgressionSuggestions 获取动作的渐进超负荷建议
+// 未指定动作时，为今日计划中的所有动作生成建议
+func (s *TrainingService) GetProgressionSuggestions(userID string, exerciseNames []string) ([]models.ProgressionSuggestion, error) {
+	if len(exerciseNames) == 0 {
+		plan, err := s.GetTodayPlan(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, exercise := range plan.Exercises {
+			exerciseNames = append(exerciseNames, exercise.Name)
+		}
+	}
+
+	return s.progressionService.SuggestMany(userID, exerciseNames), nil
+}
+
+// UpdateProgressionRule 更新渐进超负荷规则
+func (s *TrainingService) UpdateProgressionRule(userID string, req models.UpdateProgressionRuleRequest) (*models.ProgressionRule, error) {
+	return s.progressionService.UpdateRule(userID, req)
+}
+
+// GetVolumeAnalytics 获取最近若干周按肌群和动作的训练量
+func (s *TrainingService) GetVolumeAnalytics(userID string, weeks int) (*models.VolumeAnalyticsResponse, error) {
+	return s.analyticsService.GetVolume(userID, weeks)
+}
+
+// GetPersonalRecords 获取各动作当前的个人记录
+func (s *TrainingService) GetPersonalRecords(userID, formula string) ([]models.PersonalRecord, error) {
+	return s.recordService.GetRecords(userID, formula)
+}
+
+// GetPersonalRecordTimeline 获取某个动作的 PR 时间线
+func (s *TrainingService) GetPersonalRecordTimeline(userID, exerciseName, formula string) ([]models.PersonalRecord, error) {
+	return s.recordService.GetTimeline(userID, exerciseName, formula)
+}
+
 // convertToPlanResponse 转换为计划响应
 func (s *TrainingService) convertToPlanResponse(plan models.TrainingPlan) *models.TrainingPlanResponse {
 	var exercises []models.TrainingExerciseResponse
@@ -488,36 +911,47 @@ func (s *TrainingService) convertToPlanResponse(plan models.TrainingPlan) *model
 		var sets []models.ExerciseSetResponse
 		for _, set := range exercise.Sets {
 			sets = append(sets, models.ExerciseSetResponse{
-				ID:         set.ID,
-				ExerciseID: set.ExerciseID,
-				Reps:       set.Reps,
-				Weight:     set.Weight,
-				Duration:   set.Duration,
-				Distance:   set.Distance,
-				RestTime:   set.RestTime,
-				Completed:  set.Completed,
-				Order:      set.Order,
-				CreatedAt:  set.CreatedAt,
-				UpdatedAt:  set.UpdatedAt,
+				ID:             set.ID,
+				ExerciseID:     set.ExerciseID,
+				Reps:           set.Reps,
+				Weight:         set.Weight,
+				Duration:       set.Duration,
+				Distance:       set.Distance,
+				RestTime:       set.RestTime,
+				Completed:      set.Completed,
+				Order:          set.Order,
+				SetType:        setTypeOf(set),
+				TargetReps:     set.TargetReps,
+				TargetWeight:   set.TargetWeight,
+				TargetDuration: set.TargetDuration,
+				TargetDistance: set.TargetDistance,
+				Extra:          set.Extra,
+				CreatedAt:      set.CreatedAt,
+				UpdatedAt:      set.UpdatedAt,
 			})
 		}
 
 		exercises = append(exercises, models.TrainingExerciseResponse{
-			ID:           exercise.ID,
-			PlanID:       exercise.PlanID,
-			Name:         exercise.Name,
-			Description:  exercise.Description,
-			Category:     exercise.Category,
-			Difficulty:   exercise.Difficulty,
-			MuscleGroups: exercise.MuscleGroups,
-			Equipment:    exercise.Equipment,
-			Sets:         sets,
-			VideoURL:     exercise.VideoURL,
-			ImageURL:     exercise.ImageURL,
-			Instructions: exercise.Instructions,
-			Order:        exercise.Order,
-			CreatedAt:    exercise.CreatedAt,
-			UpdatedAt:    exercise.UpdatedAt,
+			ID:              exercise.ID,
+			PlanID:          exercise.PlanID,
+			CatalogID:       exercise.CatalogID,
+			Name:            exercise.Name,
+			Description:     exercise.Description,
+			Category:        exercise.Category,
+			Difficulty:      exercise.Difficulty,
+			MuscleGroups:    exercise.MuscleGroups,
+			Equipment:       exercise.Equipment,
+			Sets:            sets,
+			VideoURL:        exercise.VideoURL,
+			ImageURL:        exercise.ImageURL,
+			Instructions:    exercise.Instructions,
+			Order:           exercise.Order,
+			GroupKey:        exercise.GroupKey,
+			GroupType:       exercise.GroupType,
+			GroupRounds:     exercise.GroupRounds,
+			SubstitutedWith: exercise.SubstitutedWith,
+			CreatedAt:       exercise.CreatedAt,
+			UpdatedAt:       exercise.UpdatedAt,
 		})
 	}
 
@@ -533,27 +967,47 @@ func (s *TrainingService) convertToPlanResponse(plan models.TrainingPlan) *model
 		Status:        plan.Status,
 		IsAIGenerated: plan.IsAIGenerated,
 		AIReason:      plan.AIReason,
-		CreatedAt:     plan.CreatedAt,
-		UpdatedAt:     plan.UpdatedAt,
+		ProgramID:     plan.ProgramID,
+		ProgramWeek:   plan.ProgramWeek,
+
+		AISource:           plan.AISource,
+		AIProvider:         plan.AIProvider,
+		AIModel:            plan.AIModel,
+		AIAttempts:         plan.AIAttempts,
+		AIValidationErrors: plan.AIValidationErrors,
+
+		Groups:    planGroups(plan.Exercises),
+		CreatedAt: plan.CreatedAt,
+		UpdatedAt: plan.UpdatedAt,
 	}
 }
 
 // convertToRecordResponse 转换为记录响应
 func (s *TrainingService) convertToRecordResponse(record models.WorkoutRecord) *models.WorkoutRecordResponse {
 	return &models.WorkoutRecordResponse{
-		ID:        record.ID,
-		UserID:    record.UserID,
-		PlanID:    record.PlanID,
-		StartTime: record.StartTime,
-		EndTime:   record.EndTime,
-		Duration:  record.Duration,
-		Calories:  record.Calories,
-		Notes:     record.Notes,
-		Status:    record.Status,
-		CreatedAt: record.CreatedAt,
-		UpdatedAt: record.UpdatedAt,
-		User:      record.User,
-		Plan:      record.Plan,
+		ID:             record.ID,
+		UserID:         record.UserID,
+		PlanID:         record.PlanID,
+		StartTime:      record.StartTime,
+		EndTime:        record.EndTime,
+		Duration:       record.Duration,
+		Calories:       record.Calories,
+		CaloriesSource: record.CaloriesSource,
+		Notes:          record.Notes,
+		Status:         record.Status,
+		PausedAt:       record.PausedAt,
+		PausedSeconds:  record.PausedSeconds,
+		LastActivityAt: record.LastActivityAt,
+		AutoClosed:     record.AutoClosed,
+		Pauses:         record.Pauses,
+		AdherenceScore: record.AdherenceScore,
+		Adherence:      record.Adherence,
+		HeartRate:      record.HeartRate,
+		TrainingLoad:   record.TrainingLoad,
+		CreatedAt:      record.CreatedAt,
+		UpdatedAt:      record.UpdatedAt,
+		User:           record.User,
+		Plan:           record.Plan,
 	}
 }
 
@@ -575,26 +1029,26 @@ func (s *TrainingService) convertToFeedbackResponse(feedback models.ExerciseFeed
 	}
 }
 
-// calculateCurrentStreak 计算当前连续训练天数
-func (s *TrainingService) calculateCurrentStreak(userID string) int {
-	// 简化实现，实际应该根据训练记录计算
-	return 0
+// GetStreaks 获取连续训练天数
+func (s *TrainingService) GetStreaks(userID string) (*StreakResult, error) {
+	return s.streakService.GetStreaks(userID)
 }
 
-// calculateLongestStreak 计算最长连续训练天数
-func (s *TrainingService) calculateLongestStreak(userID string) int {
-	// 简化实现，实际应该根据训练记录计算
-	return 0
+// calculateStreaks 计算当前和最长连续训练天数
+func (s *TrainingService) calculateStreaks(userID string) (int, int) {
+	result, err := s.streakService.GetStreaks(userID)
+	if err != nil {
+		return 0, 0
+	}
+	return result.CurrentStreak, result.LongestStreak
 }
 
-// getFavoriteCategory 获取最喜欢的训练部位
-func (s *TrainingService) getFavoriteCategory(userID string) string {
-	// 简化实现，实际应该根据训练记录统计
-	return "全身"
+// getFavoriteExercise 获取最常训练的动作（最近一段时间完成组数最多的动作）
+func (s *TrainingService) getFavoriteExercise(userID string) string {
+	return s.analyticsService.FavoriteExercise(userID)
 }
 
-// getWeeklyStats 获取周统计
+// getWeeklyStats 获取最近 8 周的周统计
 func (s *TrainingService) getWeeklyStats(userID string) []models.WeeklyStat {
-	// 简化实现，实际应该根据训练记录计算
-	return []models.WeeklyStat{}
+	return s.analyticsService.WeeklyStats(userID, defaultVolumeWeeks)
 }
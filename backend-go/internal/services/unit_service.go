@@ -0,0 +1,531 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"gymates/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// cmPerInch 英寸转厘米
+	cmPerInch = 2.54
+	// gramsPerOunce 盎司转克
+	gramsPerOunce = 28.349523125
+	// maxPlateSide 配片计算每侧最多装载的重量（按 0.01 单位离散）
+	maxPlateSide = 1000
+)
+
+var (
+	// ErrPlateTargetBelowBar 目标重量小于杠铃杆重量
+	ErrPlateTargetBelowBar = errors.New("目标重量不能小于杠铃杆重量")
+	// ErrPlateTargetTooHeavy 目标重量超出配片计算范围
+	ErrPlateTargetTooHeavy = errors.New("目标重量过大")
+)
+
+// 常见的杠铃杆和杠铃片规格
+var (
+	metricBarWeight   = 20.0
+	imperialBarWeight = 45.0
+	metricPlates      = []float64{25, 20, 15, 10, 5, 2.5, 1.25}
+	imperialPlates    = []float64{45, 35, 25, 10, 5, 2.5}
+)
+
+// UnitService 单位制服务
+// 数据库中始终以公制保存，接口按用户设置的单位制接收和返回数据，换算统一在处理器中通过 UnitConverter 完成
+type UnitService struct {
+	db *gorm.DB
+}
+
+// NewUnitService 创建单位制服务
+func NewUnitService(db *gorm.DB) *UnitService {
+	return &UnitService{db: db}
+}
+
+// System 获取用户的单位制，未设置时为公制
+func (s *UnitService) System(userID string) string {
+	var settings models.UserSettings
+	if err := s.db.Select("unit_system").Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		return models.UnitSystemMetric
+	}
+	return unitSystem(settings.UnitSystem)
+}
+
+// Converter 获取用户单位制的换算器
+func (s *UnitService) Converter(userID string) UnitConverter {
+	return NewUnitConverter(s.System(userID))
+}
+
+// ConverterFor 请求指定了单位制时按请求，否则按用户的设置
+func (s *UnitService) ConverterFor(userID, system string) UnitConverter {
+	if system != "" {
+		return NewUnitConverter(system)
+	}
+	return s.Converter(userID)
+}
+
+// PlateLoading 按用户的单位制计算杠铃配片
+func (s *UnitService) PlateLoading(userID string, req models.PlateLoadingRequest) (*models.PlateLoadingResponse, error) {
+	return NewUnitConverter(s.System(userID)).PlateLoading(req)
+}
+
+// unitSystem 规范化单位制，未知值按公制处理
+func unitSystem(system string) string {
+	if system == models.UnitSystemImperial {
+		return models.UnitSystemImperial
+	}
+	return models.UnitSystemMetric
+}
+
+// UnitConverter 在公制存储值和用户单位制之间换算
+// 以 In 结尾的方法把请求中的用户单位换算为公制，其余方法把公制值换算为用户单位
+type UnitConverter struct {
+	System string
+}
+
+// NewUnitConverter 创建换算器
+func NewUnitConverter(system string) UnitConverter {
+	return UnitConverter{System: unitSystem(system)}
+}
+
+// Imperial 是否为英制
+func (u UnitConverter) Imperial() bool {
+	return u.System == models.UnitSystemImperial
+}
+
+// Labels 当前单位制下的单位名称
+func (u UnitConverter) Labels() models.UnitLabels {
+	if u.Imperial() {
+		return models.UnitLabels{System: u.System, Weight: "lb", Height: "in", Distance: "mi", Food: "oz"}
+	}
+	return models.UnitLabels{System: u.System, Weight: "kg", Height: "cm", Distance: "km", Food: "g"}
+}
+
+// Weight kg 换算为用户单位
+func (u UnitConverter) Weight(kg float64) float64 {
+	if !u.Imperial() {
+		return kg
+	}
+	return roundTo(kg/lbToKg, 1)
+}
+
+// WeightIn 用户单位换算为 kg
+func (u UnitConverter) WeightIn(v float64) float64 {
+	if !u.Imperial() {
+		return v
+	}
+	return roundTo(v*lbToKg, 2)
+}
+
+// Height cm 换算为用户单位
+func (u UnitConverter) Height(cm float64) float64 {
+	if !u.Imperial() {
+		return cm
+	}
+	return roundTo(cm/cmPerInch, 1)
+}
+
+// HeightIn 用户单位换算为 cm
+func (u UnitConverter) HeightIn(v float64) float64 {
+	if !u.Imperial() {
+		return v
+	}
+	return roundTo(v*cmPerInch, 1)
+}
+
+// Distance km 换算为用户单位
+func (u UnitConverter) Distance(km float64) float64 {
+	if !u.Imperial() {
+		return km
+	}
+	return roundTo(km/mileToKm, 2)
+}
+
+// DistanceIn 用户单位换算为 km
+func (u UnitConverter) DistanceIn(v float64) float64 {
+	if !u.Imperial() {
+		return v
+	}
+	return roundTo(v*mileToKm, 3)
+}
+
+// Food g 换算为用户的食物重量单位
+func (u UnitConverter) Food(g float64) float64 {
+	if !u.Imperial() {
+		return g
+	}
+	return roundTo(g/gramsPerOunce, 1)
+}
+
+// FoodIn 食物重量换算为 g，unit 为空时按用户单位制（g 或 oz）
+func (u UnitConverter) FoodIn(quantity float64, unit string) float64 {
+	if unit == "" {
+		unit = u.Labels().Food
+	}
+	switch unit {
+	case "kg":
+		return quantity * 1000
+	case "oz":
+		return roundTo(quantity*gramsPerOunce, 1)
+	case models.WeightUnitLb, "lbs":
+		return roundTo(quantity*lbToKg*1000, 1)
+	default:
+		return quantity
+	}
+}
+
+// PlanRequest 换算创建计划请求中各组的重量和距离
+func (u UnitConverter) PlanRequest(req *models.CreatePlanRequest) {
+	u.ExercisesRequest(req.Exercises)
+}
+
+// ExercisesRequest 换算动作列表中各组的重量和距离
+func (u UnitConverter) ExercisesRequest(exercises []models.CreateExerciseRequest) {
+	if !u.Imperial() {
+		return
+	}
+	for i := range exercises {
+		for j := range exercises[i].Sets {
+			set := &exercises[i].Sets[j]
+			set.Weight = u.WeightIn(set.Weight)
+			set.Distance = u.DistanceIn(set.Distance)
+		}
+	}
+}
+
+// ProgramRequest 换算创建训练周期请求中各训练日模板的重量和距离
+func (u UnitConverter) ProgramRequest(req *models.CreateProgramRequest) {
+	for i := range req.Phases {
+		for j := range req.Phases[i].Days {
+			u.ExercisesRequest(req.Phases[i].Days[j].Exercises)
+		}
+	}
+}
+
+// CompleteRequest 换算完成动作请求中各组的重量和距离
+func (u UnitConverter) CompleteRequest(req *models.CompleteExerciseRequest) {
+	if !u.Imperial() {
+		return
+	}
+	for i := range req.Sets {
+		req.Sets[i].Weight = u.WeightIn(req.Sets[i].Weight)
+		req.Sets[i].Distance = u.DistanceIn(req.Sets[i].Distance)
+	}
+}
+
+// ProgressionRuleRequest 换算渐进规则的加重幅度
+func (u UnitConverter) ProgressionRuleRequest(req *models.UpdateProgressionRuleRequest) {
+	req.WeightIncrement = u.WeightIn(req.WeightIncrement)
+}
+
+// ProfileRequest 换算资料中的身高和体重
+func (u UnitConverter) ProfileRequest(req *models.UpdateProfileRequest) {
+	req.Height = u.HeightIn(req.Height)
+	req.Weight = u.WeightIn(req.Weight)
+}
+
+// Plan 换算计划中各组的重量和距离
+func (u UnitConverter) Plan(plan *models.TrainingPlanResponse) {
+	if plan == nil || !u.Imperial() {
+		return
+	}
+	for i := range plan.Exercises {
+		for j := range plan.Exercises[i].Sets {
+			set := &plan.Exercises[i].Sets[j]
+			set.Weight = u.Weight(set.Weight)
+			set.Distance = u.Distance(set.Distance)
+			set.TargetWeight = u.Weight(set.TargetWeight)
+			set.TargetDistance = u.Distance(set.TargetDistance)
+		}
+	}
+}
+
+// Plans 换算计划列表
+func (u UnitConverter) Plans(plans []models.TrainingPlanResponse) {
+	for i := range plans {
+		u.Plan(&plans[i])
+	}
+}
+
+// TrainingPlan 换算直接返回的计划模型（如模板）中各组的重量和距离
+func (u UnitConverter) TrainingPlan(plan *models.TrainingPlan) {
+	if plan == nil || !u.Imperial() {
+		return
+	}
+	for i := range plan.Exercises {
+		for j := range plan.Exercises[i].Sets {
+			set := &plan.Exercises[i].Sets[j]
+			set.Weight = u.Weight(set.Weight)
+			set.Distance = u.Distance(set.Distance)
+			set.TargetWeight = u.Weight(set.TargetWeight)
+			set.TargetDistance = u.Distance(set.TargetDistance)
+		}
+	}
+}
+
+// TrainingPlans 换算计划模型列表
+func (u UnitConverter) TrainingPlans(plans []models.TrainingPlan) {
+	for i := range plans {
+		u.TrainingPlan(&plans[i])
+	}
+}
+
+// Program 换算训练周期各训练日模板中各组的重量和距离
+func (u UnitConverter) Program(program *models.TrainingProgram) {
+	if program == nil || !u.Imperial() {
+		return
+	}
+	for i := range program.Phases {
+		for j := range program.Phases[i].Days {
+			exercises := program.Phases[i].Days[j].Exercises
+			for k := range exercises {
+				for l := range exercises[k].Sets {
+					set := &exercises[k].Sets[l]
+					set.Weight = u.Weight(set.Weight)
+					set.Distance = u.Distance(set.Distance)
+				}
+			}
+		}
+	}
+}
+
+// Programs 换算训练周期列表
+func (u UnitConverter) Programs(programs []models.TrainingProgram) {
+	for i := range programs {
+		u.Program(&programs[i])
+	}
+}
+
+// Record 换算训练记录中关联计划的重量和距离
+func (u UnitConverter) Record(record *models.WorkoutRecordResponse) {
+	if record == nil || !u.Imperial() {
+		return
+	}
+	for i := range record.Plan.Exercises {
+		for j := range record.Plan.Exercises[i].Sets {
+			set := &record.Plan.Exercises[i].Sets[j]
+			set.Weight = u.Weight(set.Weight)
+			set.Distance = u.Distance(set.Distance)
+			set.TargetWeight = u.Weight(set.TargetWeight)
+			set.TargetDistance = u.Distance(set.TargetDistance)
+		}
+	}
+}
+
+// Routes 换算 GPS 轨迹的距离和平均配速，海拔和每公里分段不换算
+func (u UnitConverter) Routes(routes []models.RouteResponse) {
+	if !u.Imperial() {
+		return
+	}
+	for i := range routes {
+		routes[i].Distance = u.Distance(routes[i].Distance)
+		routes[i].Pace = int(math.Round(float64(routes[i].Pace) * mileToKm))
+	}
+}
+
+// PersonalRecords 换算个人记录，记录值按记录类型换算（次数不换算，配速换算为秒/英里）
+func (u UnitConverter) PersonalRecords(records []models.PersonalRecord) {
+	if !u.Imperial() {
+		return
+	}
+	for i := range records {
+		record := &records[i]
+		switch record.RecordType {
+		case models.RecordMaxWeight, models.RecordMaxVolume, models.RecordEstimated1RM:
+			record.Value = u.Weight(record.Value)
+			record.PreviousValue = u.Weight(record.PreviousValue)
+		case models.RecordFastestPace:
+			record.Value = roundTo(record.Value*mileToKm, 1)
+			record.PreviousValue = roundTo(record.PreviousValue*mileToKm, 1)
+		}
+		record.Weight = u.Weight(record.Weight)
+		record.Distance = u.Distance(record.Distance)
+	}
+}
+
+// Progression 换算渐进超负荷建议中的重量
+func (u UnitConverter) Progression(suggestions []models.ProgressionSuggestion) {
+	for i := range suggestions {
+		suggestions[i].LastWeight = u.Weight(suggestions[i].LastWeight)
+		suggestions[i].SuggestedWeight = u.Weight(suggestions[i].SuggestedWeight)
+	}
+}
+
+// ProgressionRule 换算渐进规则的加重幅度
+func (u UnitConverter) ProgressionRule(rule *models.ProgressionRule) {
+	if rule != nil {
+		rule.WeightIncrement = u.Weight(rule.WeightIncrement)
+	}
+}
+
+// Volume 换算训练量分析（重量 × 次数）
+func (u UnitConverter) Volume(volume *models.VolumeAnalyticsResponse) {
+	if volume == nil || !u.Imperial() {
+		return
+	}
+	for i := range volume.TotalVolume {
+		volume.TotalVolume[i] = u.Weight(volume.TotalVolume[i])
+	}
+	for _, series := range [][]models.VolumeSeries{volume.MuscleGroups, volume.Exercises} {
+		for i := range series {
+			for j := range series[i].Volume {
+				series[i].Volume[j] = u.Weight(series[i].Volume[j])
+			}
+		}
+	}
+	for _, buckets := range [][]models.VolumeBucket{volume.ThisWeek, volume.ThisWeekExercises} {
+		for i := range buckets {
+			buckets[i].Volume = u.Weight(buckets[i].Volume)
+		}
+	}
+}
+
+// User 换算用户资料中的身高和体重，BMI 与单位无关
+func (u UnitConverter) User(user *models.UserResponse) {
+	if user == nil {
+		return
+	}
+	user.Height = u.Height(user.Height)
+	user.Weight = u.Weight(user.Weight)
+}
+
+// BMIRequest 换算 BMI 请求中的身高和体重
+func (u UnitConverter) BMIRequest(req *models.BMIRequest) {
+	req.Height = u.HeightIn(req.Height)
+	req.Weight = u.WeightIn(req.Weight)
+}
+
+// BMI 换算 BMI 结果中的理想体重，BMI、体脂率和代谢与单位无关
+func (u UnitConverter) BMI(result *models.BMIResponse) {
+	if result == nil {
+		return
+	}
+	result.IdealWeight.Min = u.Weight(result.IdealWeight.Min)
+	result.IdealWeight.Max = u.Weight(result.IdealWeight.Max)
+	result.Units = u.Labels()
+}
+
+// NutritionRequest 补全营养计算请求的单位，返回换算后的克数
+func (u UnitConverter) NutritionRequest(req *models.NutritionRequest) float64 {
+	if req.Unit == "" {
+		req.Unit = u.Labels().Food
+	}
+	return u.FoodIn(req.Quantity, req.Unit)
+}
+
+// NutritionRecordRequest 饮食记录的数量换算为克
+func (u UnitConverter) NutritionRecordRequest(req *models.NutritionRecordRequest) {
+	req.Quantity = u.FoodIn(req.Quantity, req.Unit)
+	req.Unit = "g"
+}
+
+// NutritionRecords 换算饮食记录的数量，记录按克保存，英制返回盎司
+func (u UnitConverter) NutritionRecords(records []models.NutritionRecord) {
+	if !u.Imperial() {
+		return
+	}
+	for i := range records {
+		records[i].Quantity = u.Food(records[i].Quantity)
+		records[i].Unit = u.Labels().Food
+	}
+}
+
+// PlateLoading 计算每侧需要装载的杠铃片，重量均按换算器的单位制
+// 杠铃片每种规格不限数量；无法精确凑出时取不超过目标的最接近重量，相同重量下片数最少
+func (u UnitConverter) PlateLoading(req models.PlateLoadingRequest) (*models.PlateLoadingResponse, error) {
+	bar := req.BarWeight
+	if bar == 0 {
+		bar = metricBarWeight
+		if u.Imperial() {
+			bar = imperialBarWeight
+		}
+	}
+	plates := req.Plates
+	if len(plates) == 0 {
+		plates = metricPlates
+		if u.Imperial() {
+			plates = imperialPlates
+		}
+	}
+	if req.Target < bar {
+		return nil, ErrPlateTargetBelowBar
+	}
+	side := (req.Target - bar) / 2
+	if side > maxPlateSide {
+		return nil, ErrPlateTargetTooHeavy
+	}
+
+	perSide := loadPlates(side, plates)
+	loaded := 0.0
+	for _, plate := range perSide {
+		loaded += plate.Weight * float64(plate.Count)
+	}
+	total := roundTo(bar+2*loaded, 2)
+
+	return &models.PlateLoadingResponse{
+		Unit:      u.Labels().Weight,
+		Target:    req.Target,
+		BarWeight: bar,
+		PerSide:   perSide,
+		Total:     total,
+		Exact:     math.Abs(req.Target-total) < 0.005,
+		Remainder: roundTo(req.Target-total, 2),
+	}, nil
+}
+
+// loadPlates 用给定规格凑出不超过 side 的最大重量（以 0.01 为最小单位的完全背包），相同重量时片数最少
+func loadPlates(side float64, plates []float64) []models.PlateCount {
+	target := int(math.Floor(side*100 + 1e-6))
+	sizes := make([]int, 0, len(plates))
+	seen := make(map[int]bool)
+	for _, plate := range plates {
+		size := int(math.Round(plate * 100))
+		if size > 0 && !seen[size] {
+			seen[size] = true
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	// count[w] 凑出 w 的最少片数，-1 表示无法凑出；last[w] 为最后放上的规格
+	count := make([]int, target+1)
+	last := make([]int, target+1)
+	for w := 1; w <= target; w++ {
+		count[w] = -1
+		for _, size := range sizes {
+			if size > w || count[w-size] < 0 {
+				continue
+			}
+			if count[w] < 0 || count[w-size]+1 < count[w] {
+				count[w] = count[w-size] + 1
+				last[w] = size
+			}
+		}
+	}
+
+	best := target
+	for best > 0 && count[best] < 0 {
+		best--
+	}
+	used := make(map[int]int)
+	for w := best; w > 0; w -= last[w] {
+		used[last[w]]++
+	}
+
+	result := []models.PlateCount{}
+	for _, size := range sizes {
+		if used[size] > 0 {
+			result = append(result, models.PlateCount{Weight: float64(size) / 100, Count: used[size]})
+		}
+	}
+	return result
+}
+
+// roundTo 保留 digits 位小数
+func roundTo(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"testing"
+
+	"gymates/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitConverterMetricIsIdentity(t *testing.T) {
+	units := NewUnitConverter("")
+
+	assert.Equal(t, models.UnitSystemMetric, units.System)
+	assert.Equal(t, 82.5, units.Weight(82.5))
+	assert.Equal(t, 82.5, units.WeightIn(82.5))
+	assert.Equal(t, 5.0, units.Distance(5))
+	assert.Equal(t, 180.0, units.HeightIn(180))
+	assert.Equal(t, "kg", units.Labels().Weight)
+}
+
+func TestUnitConverterImperialRoundTrip(t *testing.T) {
+	units := NewUnitConverter(models.UnitSystemImperial)
+
+	// 磅换算为公斤保存后再换算回来应得到原值
+	for _, lb := range []float64{45, 135, 225, 317.5} {
+		assert.Equal(t, lb, units.Weight(units.WeightIn(lb)))
+	}
+	assert.Equal(t, 3.1, units.Distance(units.DistanceIn(3.1)))
+	assert.Equal(t, 70.0, units.Height(units.HeightIn(70)))
+	assert.Equal(t, 177.8, units.HeightIn(70))
+	assert.Equal(t, 100.0, units.Weight(45.36))
+	assert.Equal(t, 453.6, units.FoodIn(1, "lb"))
+	assert.Equal(t, 28.3, units.FoodIn(1, ""))
+	assert.Equal(t, 1.0, units.Food(28.35))
+}
+
+func TestUnitConverterConvertsPlanAndRecords(t *testing.T) {
+	units := NewUnitConverter(models.UnitSystemImperial)
+
+	req := models.CreatePlanRequest{Exercises: []models.CreateExerciseRequest{
+		{Sets: []models.CreateSetRequest{{Weight: 225, Distance: 1}}},
+	}}
+	units.PlanRequest(&req)
+	assert.Equal(t, 102.06, req.Exercises[0].Sets[0].Weight)
+	assert.Equal(t, 1.609, req.Exercises[0].Sets[0].Distance)
+
+	plan := &models.TrainingPlanResponse{Exercises: []models.TrainingExerciseResponse{
+		{Sets: []models.ExerciseSetResponse{{Weight: 102.06, TargetWeight: 100}}},
+	}}
+	units.Plan(plan)
+	assert.Equal(t, 225.0, plan.Exercises[0].Sets[0].Weight)
+	assert.Equal(t, 220.5, plan.Exercises[0].Sets[0].TargetWeight)
+
+	records := []models.PersonalRecord{
+		{RecordType: models.RecordMaxReps, Value: 12, Weight: 60},
+		{RecordType: models.RecordFastestPace, Value: 300, Distance: 5},
+	}
+	units.PersonalRecords(records)
+	assert.Equal(t, 12.0, records[0].Value)
+	assert.Equal(t, 132.3, records[0].Weight)
+	assert.Equal(t, 482.8, records[1].Value)
+	assert.Equal(t, 3.11, records[1].Distance)
+}
+
+func TestUnitConverterConvertsProgramsAndTemplates(t *testing.T) {
+	units := NewUnitConverter(models.UnitSystemImperial)
+
+	req := models.CreateProgramRequest{Phases: []models.CreatePhaseRequest{{Days: []models.CreateDayTemplateRequest{
+		{Exercises: []models.CreateExerciseRequest{{Sets: []models.CreateSetRequest{{Weight: 225}}}}},
+	}}}}
+	units.ProgramRequest(&req)
+	assert.Equal(t, 102.06, req.Phases[0].Days[0].Exercises[0].Sets[0].Weight)
+
+	program := &models.TrainingProgram{Phases: []models.ProgramPhase{{Days: []models.ProgramDayTemplate{
+		{Exercises: req.Phases[0].Days[0].Exercises},
+	}}}}
+	units.Program(program)
+	assert.Equal(t, 225.0, program.Phases[0].Days[0].Exercises[0].Sets[0].Weight)
+
+	templates := []models.TrainingPlan{{Exercises: []models.TrainingExercise{
+		{Sets: []models.ExerciseSet{{Weight: 102.06, TargetWeight: 100, Distance: 1.609}}},
+	}}}
+	units.TrainingPlans(templates)
+	assert.Equal(t, 225.0, templates[0].Exercises[0].Sets[0].Weight)
+	assert.Equal(t, 220.5, templates[0].Exercises[0].Sets[0].TargetWeight)
+	assert.Equal(t, 1.0, templates[0].Exercises[0].Sets[0].Distance)
+}
+
+func TestUnitConverterConvertsVolume(t *testing.T) {
+	volume := &models.VolumeAnalyticsResponse{
+		TotalVolume:       []float64{100},
+		ThisWeek:          []models.VolumeBucket{{Name: "胸肌", Volume: 100}},
+		ThisWeekExercises: []models.VolumeBucket{{Name: "卧推", CatalogID: 1, Volume: 100}},
+	}
+	NewUnitConverter(models.UnitSystemImperial).Volume(volume)
+	assert.Equal(t, 220.5, volume.TotalVolume[0])
+	assert.Equal(t, 220.5, volume.ThisWeek[0].Volume)
+	assert.Equal(t, 220.5, volume.ThisWeekExercises[0].Volume)
+}
+
+func TestPlateLoadingMetric(t *testing.T) {
+	result, err := NewUnitConverter(models.UnitSystemMetric).PlateLoading(models.PlateLoadingRequest{Target: 142.5})
+	require.NoError(t, err)
+
+	assert.Equal(t, "kg", result.Unit)
+	assert.Equal(t, 20.0, result.BarWeight)
+	assert.True(t, result.Exact)
+	assert.Equal(t, 142.5, result.Total)
+	assert.Equal(t, []models.PlateCount{
+		{Weight: 25, Count: 2},
+		{Weight: 10, Count: 1},
+		{Weight: 1.25, Count: 1},
+	}, result.PerSide)
+}
+
+func TestPlateLoadingImperialDefaults(t *testing.T) {
+	result, err := NewUnitConverter(models.UnitSystemImperial).PlateLoading(models.PlateLoadingRequest{Target: 315})
+	require.NoError(t, err)
+
+	assert.Equal(t, "lb", result.Unit)
+	assert.Equal(t, 45.0, result.BarWeight)
+	assert.Equal(t, []models.PlateCount{{Weight: 45, Count: 3}}, result.PerSide)
+}
+
+func TestPlateLoadingClosestBelowTarget(t *testing.T) {
+	req := models.PlateLoadingRequest{Target: 103, BarWeight: 20, Plates: []float64{20, 10, 5}}
+	result, err := NewUnitConverter(models.UnitSystemMetric).PlateLoading(req)
+	require.NoError(t, err)
+
+	assert.False(t, result.Exact)
+	assert.Equal(t, 100.0, result.Total)
+	assert.Equal(t, 3.0, result.Remainder)
+	assert.Equal(t, []models.PlateCount{{Weight: 20, Count: 2}}, result.PerSide)
+}
+
+func TestPlateLoadingPrefersFewestPlates(t *testing.T) {
+	// 每侧 30：15+15 比 20+5+5 少一片
+	req := models.PlateLoadingRequest{Target: 80, BarWeight: 20, Plates: []float64{20, 15, 5}}
+	result, err := NewUnitConverter(models.UnitSystemMetric).PlateLoading(req)
+	require.NoError(t, err)
+
+	assert.True(t, result.Exact)
+	assert.Equal(t, []models.PlateCount{{Weight: 15, Count: 2}}, result.PerSide)
+}
+
+func TestPlateLoadingBelowBar(t *testing.T) {
+	_, err := NewUnitConverter(models.UnitSystemMetric).PlateLoading(models.PlateLoadingRequest{Target: 15})
+	assert.ErrorIs(t, err, ErrPlateTargetBelowBar)
+}
@@ -261,7 +261,7 @@ func (s *UserService) GetFollowing(userID uint, page, limit int) ([]models.User,
 // 缓存相关方法
 func (s *UserService) cacheUser(user *models.User) {
 	ctx := context.Background()
-	key := fmt.Sprintf("user:%d", user.ID)
+	key := fmt.Sprintf("user:%s", user.ID)
 	keyByUsername := fmt.Sprintf("user:username:%s", user.Username)
 	keyByEmail := fmt.Sprintf("user:email:%s", user.Email)
 
@@ -14,12 +14,13 @@ import (
 
 // UserProfileService 用户资料服务
 type UserProfileService struct {
-	db *gorm.DB
+	db      *gorm.DB
+	streaks *StreakService
 }
 
 // NewUserProfileService 创建用户资料服务实例
-func NewUserProfileService(db *gorm.DB) *UserProfileService {
-	return &UserProfileService{db: db}
+func NewUserProfileService(db *gorm.DB, streakService *StreakService) *UserProfileService {
+	return &UserProfileService{db: db, streaks: streakService}
 }
 
 // Register 用户注册
@@ -77,15 +78,13 @@ func (s *UserProfileService) Register(requestData models.RegisterRequest) (*mode
 		Language:          "zh-CN",
 		Timezone:          "Asia/Shanghai",
 		Theme:             "auto",
+		UnitSystem:        models.UnitSystemMetric,
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
 	}
 
 	if err := s.db.Create(&settings).Error; err != nil {
-		logger.Error.Printf("创建用户默认设置失败", map[string]interface{}{
-			"user_id": user.ID,
-			"error":   err.Error(),
-		})
+		logger.Error.Printf("创建用户默认设置失败: user_id=%v, error=%v", user.ID, err.Error())
 	}
 
 	// 创建用户统计
@@ -107,10 +106,7 @@ func (s *UserProfileService) Register(requestData models.RegisterRequest) (*mode
 	}
 
 	if err := s.db.Create(&stats).Error; err != nil {
-		logger.Error.Printf("创建用户统计失败", map[string]interface{}{
-			"user_id": user.ID,
-			"error":   err.Error(),
-		})
+		logger.Error.Printf("创建用户统计失败: user_id=%v, error=%v", user.ID, err.Error())
 	}
 
 	response := &models.UserResponse{
@@ -304,6 +300,8 @@ func (s *UserProfileService) GetSettings(userID string) (*models.UserSettingsRes
 		Language:          settings.Language,
 		Timezone:          settings.Timezone,
 		Theme:             settings.Theme,
+		UnitSystem:        unitSystem(settings.UnitSystem),
+		MaxHeartRate:      settings.MaxHeartRate,
 		CreatedAt:         settings.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:         settings.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
@@ -329,11 +327,20 @@ func (s *UserProfileService) UpdateSettings(userID string, requestData models.Up
 		settings.Language = requestData.Language
 	}
 	if requestData.Timezone != "" {
+		if _, err := time.LoadLocation(requestData.Timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", requestData.Timezone)
+		}
 		settings.Timezone = requestData.Timezone
 	}
 	if requestData.Theme != "" {
 		settings.Theme = requestData.Theme
 	}
+	if requestData.UnitSystem != "" {
+		settings.UnitSystem = requestData.UnitSystem
+	}
+	if requestData.MaxHeartRate != nil {
+		settings.MaxHeartRate = *requestData.MaxHeartRate
+	}
 
 	settings.UpdatedAt = time.Now()
 
@@ -351,6 +358,8 @@ func (s *UserProfileService) UpdateSettings(userID string, requestData models.Up
 		Language:          settings.Language,
 		Timezone:          settings.Timezone,
 		Theme:             settings.Theme,
+		UnitSystem:        unitSystem(settings.UnitSystem),
+		MaxHeartRate:      settings.MaxHeartRate,
 		CreatedAt:         settings.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:         settings.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
@@ -399,13 +408,15 @@ func (s *UserProfileService) GetUserStats(userID string) (*models.UserStatsRespo
 		TotalWorkouts:      stats.TotalWorkouts,
 		TotalDuration:      stats.TotalDuration,
 		TotalCalories:      stats.TotalCalories,
-		CurrentStreak:      stats.CurrentStreak,
+		CurrentStreak:      s.streaks.EffectiveCurrentStreak(&stats, time.Now()),
 		LongestStreak:      stats.LongestStreak,
 		FavoriteExercise:   stats.FavoriteExercise,
 		WorkoutDays:        stats.WorkoutDays,
 		RestDays:           stats.RestDays,
 		AverageWorkoutTime: stats.AverageWorkoutTime,
 		LastWorkoutAt:      stats.LastWorkoutAt.Format("2006-01-02 15:04:05"),
+		WeeklyAdherence:    stats.WeeklyAdherence,
+		AdherenceScore:     stats.AdherenceScore,
 		CreatedAt:          stats.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:          stats.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
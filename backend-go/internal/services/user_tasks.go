@@ -0,0 +1,59 @@
+package services
+
+import (
+	"sync"
+
+	"gymates/pkg/logger"
+)
+
+// userTasks 按用户合并的后台任务
+// 事件处理中耗时的统计刷新放到后台执行，不阻塞请求；同一用户同时只运行一个任务，
+// 运行期间的新请求合并为结束后的一次重新运行
+type userTasks struct {
+	name string
+	run  func(userID string)
+
+	mu      sync.Mutex
+	running map[string]bool // 正在运行的用户
+	dirty   map[string]bool // 运行期间又有新请求的用户
+}
+
+func newUserTasks(name string, run func(userID string)) *userTasks {
+	return &userTasks{name: name, run: run, running: make(map[string]bool), dirty: make(map[string]bool)}
+}
+
+// schedule 在后台为用户运行任务
+func (t *userTasks) schedule(userID string) {
+	t.mu.Lock()
+	if t.running[userID] {
+		t.dirty[userID] = true
+		t.mu.Unlock()
+		return
+	}
+	t.running[userID] = true
+	t.mu.Unlock()
+
+	go func() {
+		for t.runOnce(userID) {
+		}
+	}()
+}
+
+// runOnce 运行一次任务，返回运行期间是否有新请求需要再运行一次
+func (t *userTasks) runOnce(userID string) (again bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error.Printf("后台任务失败: task=%v, user_id=%v, panic=%v", t.name, userID, r)
+		}
+		t.mu.Lock()
+		again = t.dirty[userID]
+		delete(t.dirty, userID)
+		if !again {
+			delete(t.running, userID)
+		}
+		t.mu.Unlock()
+	}()
+
+	t.run(userID)
+	return false
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserTasksCoalesce(t *testing.T) {
+	var mu sync.Mutex
+	runs := 0
+	release := make(chan struct{})
+	done := make(chan struct{}, 10)
+	tasks := newUserTasks("test", func(userID string) {
+		mu.Lock()
+		runs++
+		first := runs == 1
+		mu.Unlock()
+		if first {
+			<-release
+		}
+		done <- struct{}{}
+	})
+
+	tasks.schedule("u1")
+	// 第一次运行期间的多次请求合并为结束后的一次重新运行
+	tasks.schedule("u1")
+	tasks.schedule("u1")
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("任务没有运行")
+		}
+	}
+	assert.Eventually(t, func() bool {
+		tasks.mu.Lock()
+		defer tasks.mu.Unlock()
+		return !tasks.running["u1"]
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, 2, runs)
+	mu.Unlock()
+}
@@ -16,11 +16,27 @@ import (
 // WebSocketService WebSocket服务
 type WebSocketService struct {
 	upgrader websocket.Upgrader
-	clients  map[string]*websocket.Conn
-	rooms    map[string][]string // chatID -> []userID
+	clients  map[string]map[*wsClient]struct{} // userID -> 该用户所有设备上的连接
+	rooms    map[string][]string               // chatID -> []userID
+	handlers map[string]WebSocketHandler       // 其他模块注册的消息处理函数
 	mutex    sync.RWMutex
 }
 
+// WebSocketHandler 处理客户端发来的某类消息
+type WebSocketHandler func(msg *WebSocketMessage)
+
+// wsClient 一个设备上的连接，gorilla/websocket 不支持并发写，写入时加锁
+type wsClient struct {
+	conn  *websocket.Conn
+	mutex sync.Mutex
+}
+
+func (c *wsClient) write(data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
 // NewWebSocketService 创建WebSocket服务
 func NewWebSocketService() *WebSocketService {
 	return &WebSocketService{
@@ -29,11 +45,19 @@ func NewWebSocketService() *WebSocketService {
 				return true // 在生产环境中应该检查来源
 			},
 		},
-		clients: make(map[string]*websocket.Conn),
-		rooms:   make(map[string][]string),
+		clients:  make(map[string]map[*wsClient]struct{}),
+		rooms:    make(map[string][]string),
+		handlers: make(map[string]WebSocketHandler),
 	}
 }
 
+// RegisterHandler 注册某类客户端消息的处理函数，用于训练等模块接收设备发来的操作
+func (ws *WebSocketService) RegisterHandler(msgType string, handler WebSocketHandler) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.handlers[msgType] = handler
+}
+
 // WebSocketMessage WebSocket消息结构
 type WebSocketMessage struct {
 	Type      string                 `json:"type"` // message, notification, typing, online_status, video_call_invite, video_call_accept, video_call_reject, video_call_end, ice_candidate, sdp_offer, sdp_answer
@@ -53,23 +77,22 @@ func (ws *WebSocketService) HandleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// 获取用户ID（这里应该从JWT token中获取）
-	userID := c.Query("user_id")
+	// 只使用认证中间件解析出的用户，浏览器客户端通过 token 查询参数认证
+	userID := c.GetString("user_id")
 	if userID == "" {
 		log.Println("用户ID不能为空")
 		return
 	}
 
-	// 注册客户端
-	ws.mutex.Lock()
-	ws.clients[userID] = conn
-	ws.mutex.Unlock()
+	// 注册客户端，同一用户的多个设备各自保持连接
+	client := &wsClient{conn: conn}
+	if first := ws.addClient(userID, client); first {
+		// 第一个设备上线时发送在线状态
+		ws.broadcastOnlineStatus(userID, true)
+	}
 
 	log.Printf("用户 %s 已连接WebSocket", userID)
 
-	// 发送在线状态
-	ws.broadcastOnlineStatus(userID, true)
-
 	// 处理消息
 	for {
 		var msg WebSocketMessage
@@ -83,15 +106,62 @@ func (ws *WebSocketService) HandleWebSocket(c *gin.Context) {
 		ws.handleMessage(&msg)
 	}
 
-	// 清理连接
+	// 清理连接，最后一个设备断开时发送离线状态
+	if last := ws.removeClient(userID, client); last {
+		ws.broadcastOnlineStatus(userID, false)
+	}
+
+	log.Printf("用户 %s 已断开WebSocket连接", userID)
+}
+
+// addClient 注册连接，返回是否为该用户的第一个连接
+func (ws *WebSocketService) addClient(userID string, client *wsClient) bool {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	if ws.clients[userID] == nil {
+		ws.clients[userID] = make(map[*wsClient]struct{})
+	}
+	ws.clients[userID][client] = struct{}{}
+	return len(ws.clients[userID]) == 1
+}
+
+// removeClient 移除连接，返回该用户是否已没有连接
+func (ws *WebSocketService) removeClient(userID string, client *wsClient) bool {
 	ws.mutex.Lock()
-	delete(ws.clients, userID)
-	ws.mutex.Unlock()
+	defer ws.mutex.Unlock()
 
-	// 发送离线状态
-	ws.broadcastOnlineStatus(userID, false)
+	conns, exists := ws.clients[userID]
+	if !exists {
+		return false
+	}
+	if _, ok := conns[client]; !ok {
+		return false
+	}
+	delete(conns, client)
+	if len(conns) == 0 {
+		delete(ws.clients, userID)
+		return true
+	}
+	return false
+}
 
-	log.Printf("用户 %s 已断开WebSocket连接", userID)
+// writeToUser 向用户所有设备上的连接写入消息，写入失败的连接会被清理
+func (ws *WebSocketService) writeToUser(userID string, data []byte) {
+	ws.mutex.RLock()
+	clients := make([]*wsClient, 0, len(ws.clients[userID]))
+	for client := range ws.clients[userID] {
+		clients = append(clients, client)
+	}
+	ws.mutex.RUnlock()
+
+	for _, client := range clients {
+		if err := client.write(data); err != nil {
+			log.Printf("发送消息到用户 %s 失败: %v", userID, err)
+			ws.removeClient(userID, client)
+			client.conn.Close()
+		}
+	}
 }
 
 // handleMessage 处理WebSocket消息
@@ -123,6 +193,13 @@ func (ws *WebSocketService) handleMessage(msg *WebSocketMessage) {
 	case "sdp_answer":
 		ws.handleSdpAnswer(msg)
 	default:
+		ws.mutex.RLock()
+		handler, ok := ws.handlers[msg.Type]
+		ws.mutex.RUnlock()
+		if ok {
+			handler(msg)
+			return
+		}
 		log.Printf("未知的消息类型: %s", msg.Type)
 	}
 }
@@ -170,9 +247,10 @@ func (ws *WebSocketService) leaveChat(userID, chatID string) {
 // BroadcastToChat 向聊天室广播消息
 func (ws *WebSocketService) BroadcastToChat(chatID string, message interface{}) {
 	ws.mutex.RLock()
-	defer ws.mutex.RUnlock()
+	members := append([]string(nil), ws.rooms[chatID]...)
+	ws.mutex.RUnlock()
 
-	if ws.rooms[chatID] == nil {
+	if len(members) == 0 {
 		return
 	}
 
@@ -182,15 +260,8 @@ func (ws *WebSocketService) BroadcastToChat(chatID string, message interface{})
 		return
 	}
 
-	for _, userID := range ws.rooms[chatID] {
-		if conn, exists := ws.clients[userID]; exists {
-			err := conn.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				log.Printf("发送消息到用户 %s 失败: %v", userID, err)
-				// 清理无效连接
-				delete(ws.clients, userID)
-			}
-		}
+	for _, userID := range members {
+		ws.writeToUser(userID, data)
 	}
 }
 
@@ -220,52 +291,32 @@ func (ws *WebSocketService) broadcastOnlineStatus(userID string, isOnline bool)
 		},
 	}
 
-	// 向所有用户广播在线状态
-	ws.mutex.RLock()
-	defer ws.mutex.RUnlock()
-
 	data, err := json.Marshal(msg)
 	if err != nil {
 		log.Printf("序列化在线状态消息失败: %v", err)
 		return
 	}
 
-	for id, conn := range ws.clients {
+	// 向所有用户广播在线状态
+	for _, id := range ws.GetOnlineUsers() {
 		if id != userID { // 不向自己发送
-			err := conn.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				log.Printf("发送在线状态到用户 %s 失败: %v", id, err)
-			}
+			ws.writeToUser(id, data)
 		}
 	}
 }
 
 // SendNotification 发送通知
 func (ws *WebSocketService) SendNotification(userID string, notification *models.Notification) {
-	ws.mutex.RLock()
-	defer ws.mutex.RUnlock()
-
-	if conn, exists := ws.clients[userID]; exists {
-		msg := WebSocketMessage{
-			Type:      "notification",
-			UserID:    userID,
-			Timestamp: getCurrentTimestamp(),
-			Data: map[string]interface{}{
-				"notification": notification,
-			},
-		}
-
-		data, err := json.Marshal(msg)
-		if err != nil {
-			log.Printf("序列化通知消息失败: %v", err)
-			return
-		}
-
-		err = conn.WriteMessage(websocket.TextMessage, data)
-		if err != nil {
-			log.Printf("发送通知到用户 %s 失败: %v", userID, err)
-		}
+	msg := WebSocketMessage{
+		Type:      "notification",
+		UserID:    userID,
+		Timestamp: getCurrentTimestamp(),
+		Data: map[string]interface{}{
+			"notification": notification,
+		},
 	}
+
+	ws.SendToUser(userID, msg)
 }
 
 // GetOnlineUsers 获取在线用户列表
@@ -387,25 +438,19 @@ func (ws *WebSocketService) handleSdpAnswer(msg *WebSocketMessage) {
 	ws.SendToUser(otherUserID, msg)
 }
 
-// SendToUser 发送消息给指定用户
+// SendToUser 发送消息给指定用户，用户在多个设备上在线时每个设备都会收到
 func (ws *WebSocketService) SendToUser(userID string, message interface{}) {
-	ws.mutex.RLock()
-	defer ws.mutex.RUnlock()
-
-	if conn, exists := ws.clients[userID]; exists {
-		data, err := json.Marshal(message)
-		if err != nil {
-			log.Printf("序列化消息失败: %v", err)
-			return
-		}
+	if !ws.IsUserOnline(userID) {
+		return
+	}
 
-		err = conn.WriteMessage(websocket.TextMessage, data)
-		if err != nil {
-			log.Printf("发送消息到用户 %s 失败: %v", userID, err)
-			// 清理无效连接
-			delete(ws.clients, userID)
-		}
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("序列化消息失败: %v", err)
+		return
 	}
+
+	ws.writeToUser(userID, data)
 }
 
 // getCurrentTimestamp 获取当前时间戳
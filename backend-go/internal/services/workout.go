@@ -6,20 +6,28 @@ import (
 	"time"
 
 	"gymates/internal/models"
+	"gymates/pkg/logger"
 
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
 
+// ErrAlreadyCheckedIn 当天（用户时区）已经打卡
+var ErrAlreadyCheckedIn = errors.New("already checked in today")
+
 type WorkoutService struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db       *gorm.DB
+	redis    *redis.Client
+	streaks  *StreakService
+	calories *CalorieService
 }
 
-func NewWorkoutService(db *gorm.DB, redis *redis.Client) *WorkoutService {
+func NewWorkoutService(db *gorm.DB, redis *redis.Client, streakService *StreakService, calorieService *CalorieService) *WorkoutService {
 	return &WorkoutService{
-		db:    db,
-		redis: redis,
+		db:       db,
+		redis:    redis,
+		streaks:  streakService,
+		calories: calorieService,
 	}
 }
 
@@ -46,7 +54,7 @@ func (s *WorkoutService) GetWorkoutPlans(userID uint, isPublic bool, page, limit
 	query := s.db.Model(&models.TrainingPlan{})
 
 	if isPublic {
-		query = query.Where("is_public = ?", true)
+		query = query.Where("is_template = ? AND visibility = ?", true, models.PlanVisibilityPublic)
 	} else {
 		query = query.Where("user_id = ?", userID)
 	}
@@ -84,7 +92,17 @@ func (s *WorkoutService) GetWorkoutPlanByID(id uint) (*models.TrainingPlan, erro
 }
 
 // CreateWorkoutSession 创建训练会话
+// session.CaloriesSource 为 measured 时信任客户端上报的卡路里，否则按动作和时长估算
 func (s *WorkoutService) CreateWorkoutSession(session *models.WorkoutSession, exercises []ExerciseRequest) error {
+	trainingExercises := make([]models.TrainingExercise, 0, len(exercises))
+	for _, exerciseReq := range exercises {
+		trainingExercises = append(trainingExercises, models.TrainingExercise{
+			Name:     exerciseReq.Name,
+			Category: exerciseReq.Category,
+		})
+	}
+	s.calories.ApplySessionCalories(session, trainingExercises)
+
 	// 开始事务
 	tx := s.db.Begin()
 	defer func() {
@@ -100,12 +118,9 @@ func (s *WorkoutService) CreateWorkoutSession(session *models.WorkoutSession, ex
 	}
 
 	// 创建训练动作
-	for _, exerciseReq := range exercises {
-		exercise := &models.TrainingExercise{
-			PlanID:   session.ID,
-			Name:     exerciseReq.Name,
-			Category: exerciseReq.Category,
-		}
+	for i := range trainingExercises {
+		exercise := &trainingExercises[i]
+		exercise.PlanID = session.ID
 
 		if err := tx.Create(exercise).Error; err != nil {
 			tx.Rollback()
@@ -162,18 +177,24 @@ func (s *WorkoutService) GetWorkoutSessions(userID uint, planID *uint, page, lim
 
 // CreateCheckIn 创建打卡记录
 func (s *WorkoutService) CreateCheckIn(checkIn *models.CheckIn) error {
-	// 检查当天是否已经打卡
+	// 检查当天（按用户时区）是否已经打卡
+	dayStart, dayEnd := s.streaks.LocalDayRange(checkIn.UserID, checkIn.Date)
 	var existingCheckIn models.CheckIn
-	if err := s.db.Where("user_id = ? AND DATE(date) = DATE(?)", checkIn.UserID, checkIn.Date).First(&existingCheckIn).Error; err == nil {
-		return errors.New("already checked in today")
+	if err := s.db.Where("user_id = ? AND date >= ? AND date < ?", checkIn.UserID, dayStart, dayEnd).First(&existingCheckIn).Error; err == nil {
+		return ErrAlreadyCheckedIn
 	}
 
 	if err := s.db.Create(checkIn).Error; err != nil {
 		return fmt.Errorf("failed to create check-in: %w", err)
 	}
 
+	// 更新连续打卡天数；打卡已经保存，失败时只记录日志，不让客户端重试打卡
+	if _, err := s.streaks.RecordActivity(checkIn.UserID, checkIn.Date); err != nil {
+		logger.Error.Printf("更新连续打卡天数失败: user_id=%v, error=%v", checkIn.UserID, err.Error())
+	}
+
 	// 预加载用户信息
-	if err := s.db.Preload("User").First(checkIn, checkIn.ID).Error; err != nil {
+	if err := s.db.Preload("User").Where("id = ?", checkIn.ID).First(checkIn).Error; err != nil {
 		return fmt.Errorf("failed to load check-in with user: %w", err)
 	}
 
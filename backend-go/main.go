@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -48,6 +49,15 @@ func main() {
 	// 初始化所有服务
 	services := initializeServices(cfg, db, redisClient)
 
+	// 后台任务在收到关闭信号后停止
+	background, stopBackground := context.WithCancel(context.Background())
+
+	// 定期自动结束长时间未活动的训练
+	services.SessionService.StartJanitor(background, 15*time.Minute)
+
+	// 组间休息计时器每秒向用户的所有设备推送剩余时间
+	services.RestTimerService.StartTicker(background, time.Second)
+
 	// 设置Gin模式
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -66,7 +76,7 @@ func main() {
 	router.GET("/health", healthCheck)
 
 	// 设置优雅关闭
-	setupGracefulShutdown()
+	setupGracefulShutdown(stopBackground)
 
 	// 启动服务器
 	startServer(router, cfg.Server.Port)
@@ -108,14 +118,15 @@ func isServerReady() bool {
 	return resp.StatusCode == http.StatusOK
 }
 
-// setupGracefulShutdown 设置优雅关闭
-func setupGracefulShutdown() {
+// setupGracefulShutdown 设置优雅关闭，退出前先停止后台任务
+func setupGracefulShutdown(stopBackground context.CancelFunc) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-c
 		log.Println("🛑 收到关闭信号，正在优雅关闭...")
+		stopBackground()
 		os.Exit(0)
 	}()
 }
@@ -164,6 +175,22 @@ func setupAPIRoutes(router *gin.Engine, services *services.Services) {
 		services.BuddyService,
 		services.CommunityService,
 		services.UserProfileService,
+		services.ProgramService,
+		services.CatalogService,
+		services.TemplateService,
+		services.ImportService,
+		services.ExportService,
+		services.RestTimerService,
+		services.RiskService,
+		services.AdherenceService,
+		services.SyncService,
+		services.UnitService,
+		services.HeartRateService,
+		services.RouteService,
+		services.AIChatService,
+		services.HealthService,
+		services.NutritionService,
+		services.WebSocketService,
 	)
 
 	// 注册所有路由
@@ -0,0 +1,9 @@
+-- 连续训练/签到天数
+-- 描述: 记录用户最近活跃日（按用户时区），用于增量更新连续天数
+
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS current_streak INTEGER DEFAULT 0;
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS longest_streak INTEGER DEFAULT 0;
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS last_active_date VARCHAR(10);
+
+CREATE INDEX IF NOT EXISTS idx_workout_records_user_start ON workout_records(user_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_checkins_user_date ON checkins(user_id, date);
@@ -0,0 +1,18 @@
+-- 渐进超负荷规则
+-- 描述: 按用户/动作配置的加重规则（linear/double/rpe），exercise_name 为空表示用户默认规则
+
+CREATE TABLE IF NOT EXISTS progression_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    exercise_name VARCHAR(255) DEFAULT '',
+    strategy VARCHAR(20) NOT NULL DEFAULT 'double', -- linear/double/rpe
+    weight_increment DECIMAL(5,2) DEFAULT 2.5, -- kg
+    min_reps INTEGER DEFAULT 8,
+    max_reps INTEGER DEFAULT 12,
+    target_rpe DECIMAL(3,1) DEFAULT 8,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, exercise_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_feedbacks_user_exercise ON exercise_feedbacks(user_id, exercise_id);
@@ -0,0 +1,46 @@
+-- 多周期训练计划
+-- 描述: 训练周期 -> 阶段（含减载周）-> 周模板训练日，物化为每日的 training_plans
+
+CREATE TABLE IF NOT EXISTS training_programs (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    goal VARCHAR(100),
+    start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    total_weeks INTEGER DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'active', -- active/completed/archived
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS program_phases (
+    id VARCHAR(36) PRIMARY KEY,
+    program_id VARCHAR(36) NOT NULL REFERENCES training_programs(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    "order" INTEGER DEFAULT 0,
+    weeks INTEGER NOT NULL DEFAULT 1,
+    is_deload BOOLEAN DEFAULT FALSE,
+    intensity_factor DECIMAL(4,2) DEFAULT 1.0,
+    volume_factor DECIMAL(4,2) DEFAULT 1.0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS program_day_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    phase_id VARCHAR(36) NOT NULL REFERENCES program_phases(id) ON DELETE CASCADE,
+    day_offset INTEGER DEFAULT 0, -- 0-6
+    name VARCHAR(255),
+    exercises JSONB DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS program_id VARCHAR(36);
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS program_week INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_training_programs_user_id ON training_programs(user_id);
+CREATE INDEX IF NOT EXISTS idx_program_phases_program_id ON program_phases(program_id);
+CREATE INDEX IF NOT EXISTS idx_program_day_templates_phase_id ON program_day_templates(phase_id);
+CREATE INDEX IF NOT EXISTS idx_training_plans_program_id ON training_plans(program_id, date);
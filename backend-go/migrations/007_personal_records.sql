@@ -0,0 +1,22 @@
+-- 个人记录（PR）
+-- 描述: 每次刷新记录新增一行，同一动作同一类型的记录按 achieved_at 排列即为 PR 时间线
+
+CREATE TABLE IF NOT EXISTS personal_records (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    exercise_name VARCHAR(255) NOT NULL,
+    record_type VARCHAR(30) NOT NULL, -- max_weight/max_reps/max_volume/estimated_1rm/fastest_pace
+    formula VARCHAR(20) DEFAULT '', -- epley/brzycki，仅 estimated_1rm 使用
+    value DECIMAL(10,2) NOT NULL,
+    previous_value DECIMAL(10,2) DEFAULT 0,
+    weight DECIMAL(6,2) DEFAULT 0,
+    reps INTEGER DEFAULT 0,
+    duration INTEGER DEFAULT 0,
+    distance DECIMAL(8,3) DEFAULT 0,
+    exercise_id VARCHAR(36),
+    set_id VARCHAR(36),
+    achieved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_records_user_exercise ON personal_records(user_id, exercise_name, achieved_at);
@@ -0,0 +1,38 @@
+-- 标准动作库
+-- 描述: 扩展 exercises 表为标准动作库（多语言名称、别名、主/次要肌群、器械），
+--       training_exercises 通过 catalog_id 关联标准动作
+
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS name_en VARCHAR(255);
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS names JSONB DEFAULT '{}';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS aliases JSONB DEFAULT '[]';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS secondary_muscle_groups JSONB DEFAULT '[]';
+
+ALTER TABLE training_exercises ADD COLUMN IF NOT EXISTS catalog_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_exercises_name ON exercises(name);
+CREATE INDEX IF NOT EXISTS idx_training_exercises_catalog_id ON training_exercises(catalog_id);
+
+-- 常用动作（muscle_groups 为主要肌群，equipment 为 JSON 数组）
+INSERT INTO exercises (name, name_en, names, aliases, category, difficulty, muscle_groups, secondary_muscle_groups, equipment)
+SELECT v.name, v.name_en, v.names::jsonb, v.aliases::jsonb, v.category, v.difficulty, v.muscle_groups, v.secondary::jsonb, v.equipment
+FROM (VALUES
+    ('卧推', 'Bench Press', '{"zh": "卧推", "en": "Bench Press"}', '["杠铃卧推", "平板卧推", "barbell bench press"]', '胸', '中级', '["胸肌"]', '["三头肌", "肩部"]', '["杠铃", "卧推凳"]'),
+    ('上斜卧推', 'Incline Bench Press', '{"zh": "上斜卧推", "en": "Incline Bench Press"}', '["上斜杠铃卧推", "incline press"]', '胸', '中级', '["上胸"]', '["三头肌", "肩部"]', '["杠铃", "卧推凳"]'),
+    ('哑铃卧推', 'Dumbbell Bench Press', '{"zh": "哑铃卧推", "en": "Dumbbell Bench Press"}', '["db bench press"]', '胸', '初级', '["胸肌"]', '["三头肌", "肩部"]', '["哑铃", "卧推凳"]'),
+    ('俯卧撑', 'Push-up', '{"zh": "俯卧撑", "en": "Push-up"}', '["pushup", "push up"]', '胸', '初级', '["胸肌"]', '["三头肌", "肩部", "核心"]', '["无器械"]'),
+    ('深蹲', 'Squat', '{"zh": "深蹲", "en": "Squat"}', '["杠铃深蹲", "后蹲", "back squat", "barbell squat"]', '腿', '中级', '["股四头肌", "臀肌"]', '["腘绳肌", "核心"]', '["杠铃", "深蹲架"]'),
+    ('硬拉', 'Deadlift', '{"zh": "硬拉", "en": "Deadlift"}', '["传统硬拉", "杠铃硬拉", "conventional deadlift"]', '背', '高级', '["臀肌", "腘绳肌", "下背"]', '["背阔肌", "斜方肌", "前臂"]', '["杠铃"]'),
+    ('罗马尼亚硬拉', 'Romanian Deadlift', '{"zh": "罗马尼亚硬拉", "en": "Romanian Deadlift"}', '["rdl", "直腿硬拉"]', '腿', '中级', '["腘绳肌", "臀肌"]', '["下背"]', '["杠铃"]'),
+    ('引体向上', 'Pull-up', '{"zh": "引体向上", "en": "Pull-up"}', '["pullup", "pull up", "正手引体"]', '背', '中级', '["背阔肌"]', '["二头肌", "后束"]', '["单杠"]'),
+    ('杠铃划船', 'Barbell Row', '{"zh": "杠铃划船", "en": "Barbell Row"}', '["俯身划船", "bent over row"]', '背', '中级', '["背阔肌", "菱形肌"]', '["二头肌", "后束"]', '["杠铃"]'),
+    ('高位下拉', 'Lat Pulldown', '{"zh": "高位下拉", "en": "Lat Pulldown"}', '["下拉", "pulldown"]', '背', '初级', '["背阔肌"]', '["二头肌"]', '["龙门架"]'),
+    ('推举', 'Overhead Press', '{"zh": "推举", "en": "Overhead Press"}', '["站姿推举", "杠铃推举", "ohp", "military press"]', '肩', '中级', '["肩部"]', '["三头肌", "核心"]', '["杠铃"]'),
+    ('侧平举', 'Lateral Raise', '{"zh": "侧平举", "en": "Lateral Raise"}', '["哑铃侧平举", "side raise"]', '肩', '初级', '["肩部中束"]', '[]', '["哑铃"]'),
+    ('二头弯举', 'Biceps Curl', '{"zh": "二头弯举", "en": "Biceps Curl"}', '["弯举", "哑铃弯举", "curl"]', '臂', '初级', '["二头肌"]', '["前臂"]', '["哑铃"]'),
+    ('三头下压', 'Triceps Pushdown', '{"zh": "三头下压", "en": "Triceps Pushdown"}', '["绳索下压", "pushdown"]', '臂', '初级', '["三头肌"]', '[]', '["龙门架"]'),
+    ('箭步蹲', 'Lunge', '{"zh": "箭步蹲", "en": "Lunge"}', '["弓步蹲", "lunges"]', '腿', '初级', '["股四头肌", "臀肌"]', '["腘绳肌"]', '["无器械"]'),
+    ('平板支撑', 'Plank', '{"zh": "平板支撑", "en": "Plank"}', '["plank hold"]', '核心', '初级', '["核心"]', '["肩部"]', '["无器械"]'),
+    ('跑步', 'Running', '{"zh": "跑步", "en": "Running"}', '["跑步机", "慢跑", "run", "treadmill"]', '有氧', '初级', '["全身"]', '[]', '["无器械"]'),
+    ('骑行', 'Cycling', '{"zh": "骑行", "en": "Cycling"}', '["动感单车", "单车", "bike"]', '有氧', '初级', '["股四头肌"]', '["臀肌"]', '["单车"]')
+) AS v(name, name_en, names, aliases, category, difficulty, muscle_groups, secondary, equipment)
+WHERE NOT EXISTS (SELECT 1 FROM exercises e WHERE e.name = v.name);
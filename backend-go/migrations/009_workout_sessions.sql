@@ -0,0 +1,40 @@
+-- 训练会话状态机
+-- 描述: 支持暂停/恢复（暂停区间不计入时长）、每组完成时间戳、长时间未活动自动结束
+
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS paused_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS paused_seconds INTEGER DEFAULT 0;
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS auto_closed BOOLEAN DEFAULT FALSE;
+
+-- 历史记录以更新时间作为最后一次操作时间
+UPDATE workout_records SET last_activity_at = COALESCE(updated_at, start_time) WHERE last_activity_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS workout_pauses (
+    id VARCHAR(36) PRIMARY KEY,
+    record_id VARCHAR(36) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE, -- 为空表示仍在暂停中
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_pauses_record ON workout_pauses(record_id, started_at);
+
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS record_id VARCHAR(36);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_sets_record ON exercise_sets(record_id);
+
+-- 每个用户只保留最新一条未结束的训练，其余标记为自动结束；开始时间相同时按 id 取最后一条
+UPDATE workout_records r SET status = 'completed', auto_closed = TRUE, end_time = COALESCE(r.last_activity_at, r.start_time)
+WHERE r.status IN ('in_progress', 'paused')
+  AND EXISTS (
+      SELECT 1 FROM workout_records newer
+      WHERE newer.user_id = r.user_id
+        AND newer.status IN ('in_progress', 'paused')
+        AND (newer.start_time > r.start_time OR (newer.start_time = r.start_time AND newer.id > r.id))
+  );
+
+-- 每个用户同时只能有一个未结束的训练
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_records_one_active
+    ON workout_records(user_id) WHERE status IN ('in_progress', 'paused');
@@ -0,0 +1,5 @@
+-- 训练卡路里来源
+-- 描述: measured 为设备实测，estimated 为按 MET × 体重 × 实际训练时长估算
+-- 历史记录的卡路里来源未知，保持为空，统计时不计入实测部分
+
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS calories_source VARCHAR(20);
@@ -0,0 +1,38 @@
+-- 训练计划模板
+-- 描述: 模板也保存在 training_plans 中（is_template = true），可克隆到具体日期、分享给搭子或发布到模板广场
+
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS is_template BOOLEAN DEFAULT FALSE;
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS visibility VARCHAR(20); -- private/public，仅模板使用
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS source_template_id VARCHAR(36);
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS fork_count INTEGER DEFAULT 0;
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS rating_average DECIMAL(3,2) DEFAULT 0;
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS rating_count INTEGER DEFAULT 0;
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS published_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_training_plans_user_template ON training_plans(user_id, is_template);
+CREATE INDEX IF NOT EXISTS idx_training_plans_source_template ON training_plans(source_template_id);
+CREATE INDEX IF NOT EXISTS idx_training_plans_gallery ON training_plans(fork_count DESC, published_at DESC)
+    WHERE is_template = TRUE AND visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS training_plan_shares (
+    id VARCHAR(36) PRIMARY KEY,
+    template_id VARCHAR(36) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL,
+    recipient_id VARCHAR(255) NOT NULL,
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (template_id, recipient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_training_plan_shares_recipient ON training_plan_shares(recipient_id);
+
+CREATE TABLE IF NOT EXISTS training_plan_ratings (
+    id VARCHAR(36) PRIMARY KEY,
+    plan_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    review TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (plan_id, user_id)
+);
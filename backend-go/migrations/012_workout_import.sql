@@ -0,0 +1,7 @@
+-- 训练历史导入
+-- 描述: 从其他应用导入的训练记录保存去重键（开始时间 + 训练名称），重复导入时跳过
+
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS import_key VARCHAR(40);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_records_import_key
+    ON workout_records(user_id, import_key) WHERE import_key IS NOT NULL AND import_key <> '';
@@ -0,0 +1,10 @@
+-- 超级组、循环训练和组类型
+-- 描述: 相同 group_key 的动作组成超级组/巨型组/循环并按轮次交替完成；每组区分热身、正式、递减、力竭和计时
+
+ALTER TABLE training_exercises ADD COLUMN IF NOT EXISTS group_key VARCHAR(50) DEFAULT '';
+ALTER TABLE training_exercises ADD COLUMN IF NOT EXISTS group_type VARCHAR(20) DEFAULT '';
+ALTER TABLE training_exercises ADD COLUMN IF NOT EXISTS group_rounds INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_training_exercises_group ON training_exercises(plan_id, group_key) WHERE group_key <> '';
+
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS set_type VARCHAR(20) NOT NULL DEFAULT 'working';
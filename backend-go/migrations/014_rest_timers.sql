@@ -0,0 +1,21 @@
+-- 组间休息计时器
+-- 描述: 完成一组后由服务端按该组的休息时间倒计时，通过 WebSocket 推送到用户的所有设备；服务重启后恢复未结束的计时器
+
+CREATE TABLE IF NOT EXISTS rest_timers (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    record_id VARCHAR(36),
+    exercise_id VARCHAR(36),
+    set_id VARCHAR(36),
+    duration INTEGER NOT NULL, -- 秒，包含延长的时间
+    extended INTEGER DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, finished, skipped, ended
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rest_timers_user ON rest_timers(user_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_rest_timers_running ON rest_timers(status) WHERE status = 'running';
@@ -0,0 +1,14 @@
+-- 疲劳与受伤风险提醒
+-- 描述: 记录已发送的风险提醒，同一风险信号（疼痛上升、表现下降、负荷骤增、疲劳）7 天内只提醒一次
+
+CREATE TABLE IF NOT EXISTS risk_alerts (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    key VARCHAR(200) NOT NULL, -- 信号类型 + 对象，如 pain_trend:exercise:卧推
+    type VARCHAR(30) NOT NULL, -- pain_trend, performance_drop, workload_spike, fatigue
+    severity VARCHAR(20) NOT NULL, -- moderate, high
+    notification_id VARCHAR(36),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_alerts_user_key ON risk_alerts(user_id, key, created_at DESC);
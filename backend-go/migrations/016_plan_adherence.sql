@@ -0,0 +1,16 @@
+-- 计划完成度
+-- 描述: 组记录计划目标（首次完成时保存原计划值），动作记录替换动作，训练记录保存完成度评分，用户统计保存本周和最近 4 周完成度
+
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS target_reps INTEGER DEFAULT 0;
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS target_weight DECIMAL(6,2) DEFAULT 0;
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS target_duration INTEGER DEFAULT 0; -- 秒
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS target_distance DECIMAL(8,3) DEFAULT 0; -- 公里
+ALTER TABLE exercise_sets ADD COLUMN IF NOT EXISTS extra BOOLEAN DEFAULT FALSE; -- 训练时计划外追加的组
+
+ALTER TABLE training_exercises ADD COLUMN IF NOT EXISTS substituted_with VARCHAR(100);
+
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS adherence_score DECIMAL(5,1) DEFAULT 0;
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS adherence JSONB;
+
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS weekly_adherence DECIMAL(5,1) DEFAULT 0;
+ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS adherence_score DECIMAL(5,1) DEFAULT 0;
@@ -0,0 +1,32 @@
+-- 离线同步
+-- 描述: 记录已处理的客户端变更（同一客户端ID只应用一次），记录删除的实体供其他设备同步；为变更流按更新时间查询建立索引
+
+CREATE TABLE IF NOT EXISTS sync_mutation_logs (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    client_id VARCHAR(100) NOT NULL,
+    entity VARCHAR(20) NOT NULL, -- plan, set, workout, checkin
+    op VARCHAR(20) NOT NULL, -- create, update, delete, complete, start, pause, resume, end
+    entity_id VARCHAR(36),
+    status VARCHAR(20) NOT NULL, -- pending, applied, conflict, rejected
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_mutation_client ON sync_mutation_logs(user_id, client_id);
+
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    entity VARCHAR(20) NOT NULL,
+    entity_id VARCHAR(36) NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_user ON sync_tombstones(user_id, deleted_at);
+
+CREATE INDEX IF NOT EXISTS idx_training_plans_user_updated ON training_plans(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_exercise_sets_updated ON exercise_sets(updated_at);
+CREATE INDEX IF NOT EXISTS idx_workout_records_user_updated ON workout_records(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_check_ins_user_updated ON check_ins(user_id, updated_at);
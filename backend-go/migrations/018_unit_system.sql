@@ -0,0 +1,4 @@
+-- 单位制
+-- 描述: 用户设置增加单位制（metric 公制、imperial 英制），数据库中的身高、体重、重量、距离仍统一以 cm、kg、km 保存
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS unit_system VARCHAR(20) DEFAULT 'metric';
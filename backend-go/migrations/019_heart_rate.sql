@@ -0,0 +1,20 @@
+-- 训练心率
+-- 描述: 保存训练的心率采样数据（按上传分块压缩存储），训练记录增加心率分析和训练负荷，用户设置增加最大心率
+
+CREATE TABLE IF NOT EXISTS workout_heart_rate_chunks (
+    id VARCHAR(36) PRIMARY KEY,
+    record_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    sample_count INTEGER NOT NULL DEFAULT 0,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_heart_rate_chunks_record ON workout_heart_rate_chunks(record_id, created_at);
+
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS heart_rate JSONB;
+ALTER TABLE workout_records ADD COLUMN IF NOT EXISTS training_load DECIMAL(8,1) DEFAULT 0;
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS max_heart_rate INTEGER DEFAULT 0;
@@ -0,0 +1,22 @@
+-- GPS 轨迹
+-- 描述: 保存跑步、骑行等有氧训练的 GPS 轨迹（压缩编码）及距离、用时、爬升和每公里分段，每个有氧组一条轨迹
+
+CREATE TABLE IF NOT EXISTS workout_routes (
+    id VARCHAR(36) PRIMARY KEY,
+    record_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    set_id VARCHAR(36) DEFAULT '',
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    point_count INTEGER NOT NULL DEFAULT 0,
+    data BYTEA NOT NULL,
+    distance DECIMAL(10,3) DEFAULT 0,
+    duration INTEGER DEFAULT 0,
+    elevation_gain DECIMAL(8,1) DEFAULT 0,
+    elevation_loss DECIMAL(8,1) DEFAULT 0,
+    splits JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_routes_record ON workout_routes(record_id, set_id);
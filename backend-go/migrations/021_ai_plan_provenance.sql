@@ -0,0 +1,8 @@
+-- AI 训练计划来源
+-- 描述: 记录 AI 计划的来源（模型直接生成、修复后通过、模板）、提供商、模型、调用次数和最后一次未通过的校验错误
+
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS ai_source VARCHAR(20) DEFAULT '';
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS ai_provider VARCHAR(50) DEFAULT '';
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS ai_model VARCHAR(100) DEFAULT '';
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS ai_attempts INTEGER DEFAULT 0;
+ALTER TABLE training_plans ADD COLUMN IF NOT EXISTS ai_validation_errors JSONB;
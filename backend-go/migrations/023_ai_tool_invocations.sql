@@ -0,0 +1,22 @@
+-- AI 教练工具调用审计
+-- 描述: 记录 AI 教练每一次工具调用的参数、结果和状态；修改数据的调用在用户确认前为 pending
+
+CREATE TABLE IF NOT EXISTS ai_tool_invocations (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    conversation_id VARCHAR(36) DEFAULT '',
+    tool_call_id VARCHAR(100) DEFAULT '',
+    tool VARCHAR(50) NOT NULL,
+    arguments TEXT DEFAULT '',
+    mutating BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL,
+    summary VARCHAR(255) DEFAULT '',
+    result TEXT DEFAULT '',
+    error TEXT DEFAULT '',
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_tool_invocations_user ON ai_tool_invocations(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ai_tool_invocations_conversation ON ai_tool_invocations(conversation_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- 训练会话卡路里来源
+-- 描述: 与 workout_records 一致，measured 为设备实测，estimated 为按 MET × 体重 × 训练时长估算
+
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS calories_source VARCHAR(20);
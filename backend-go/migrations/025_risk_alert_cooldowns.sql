@@ -0,0 +1,13 @@
+-- 风险提醒冷却期
+-- 描述: 每个用户的每个风险信号一行，记录最近一次提醒时间；并发检查通过 (user_id, key) 主键上的条件 upsert 占用冷却期，避免重复提醒
+
+CREATE TABLE IF NOT EXISTS risk_alert_cooldowns (
+    user_id VARCHAR(36) NOT NULL,
+    key VARCHAR(200) NOT NULL,
+    alerted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+INSERT INTO risk_alert_cooldowns (user_id, key, alerted_at)
+SELECT user_id, key, MAX(created_at) FROM risk_alerts GROUP BY user_id, key
+ON CONFLICT (user_id, key) DO NOTHING;
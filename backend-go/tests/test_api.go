@@ -43,7 +43,15 @@ func TestFitTrackerAPI(t *testing.T) {
 	userService := services.NewUserService(db, redisClient)
 	authService := services.NewAuthService(cfg, userService)
 	aiService := services.NewAIService(cfg)
-	trainingService := services.NewTrainingService(db, aiService, userService)
+	catalogService := services.NewExerciseCatalogService(services.NewExerciseRepository(db))
+	events := services.NewEventBus()
+	recordService := services.NewRecordService(db, events, catalogService)
+	streakService := services.NewStreakService(db)
+	adherenceService := services.NewAdherenceService(db, streakService)
+	analyticsService := services.NewAnalyticsService(db, streakService)
+	sessionService := services.NewSessionService(db, streakService, services.NewCalorieService(db), adherenceService, analyticsService, events)
+	programService := services.NewProgramService(db, catalogService)
+	trainingService := services.NewTrainingService(db, aiService, userService, streakService, services.NewProgressionService(db, catalogService), recordService, catalogService, sessionService, analyticsService, adherenceService, programService, events)
 	communityService := services.NewCommunityService(db)
 	restService := services.NewRestService(db)
 	gymService := services.NewGymService(db)